@@ -71,6 +71,27 @@ func main() {
 			Destination: &options.RancherURL,
 			Hidden:      true,
 		},
+		cli.StringFlag{
+			Name:        "image-upload-dir",
+			EnvVar:      "HARVESTER_IMAGE_UPLOAD_DIR",
+			Usage:       "The directory staging the chunks of the chunked image uploads",
+			Value:       "/var/lib/harvester/uploads",
+			Destination: &options.ImageUploadDir,
+		},
+		cli.StringFlag{
+			Name:        "image-staging-dir",
+			EnvVar:      "HARVESTER_IMAGE_STAGING_DIR",
+			Usage:       "The directory staging the image files to convert, verify or compress",
+			Value:       "/var/lib/harvester/image-staging",
+			Destination: &options.ImageStagingDir,
+		},
+		cli.StringFlag{
+			Name:        "console-recording-dir",
+			EnvVar:      "HARVESTER_CONSOLE_RECORDING_DIR",
+			Usage:       "The directory storing the recordings of the VM serial console sessions",
+			Value:       "/var/lib/harvester/console-recordings",
+			Destination: &options.ConsoleRecordingDir,
+		},
 	}
 
 	app := cmd.NewApp("Harvester API Server", "", flags, func(commonOptions *config.CommonOptions) error {
@@ -0,0 +1,88 @@
+package backup
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"time"
+)
+
+// An export archive is a tar stream of the following files, the manifest comes first and the disks come last,
+// so the archive can be imported while it is read.
+//
+//	manifest.json
+//	virtualmachine.json
+//	volumes/<volume name>.json
+//	volumes/<volume name>.qcow2
+
+const (
+	exportArchiveVersion = "v1"
+
+	manifestFile       = "manifest.json"
+	virtualMachineFile = "virtualmachine.json"
+	volumesDir         = "volumes"
+)
+
+type ExportManifest struct {
+	Version            string         `json:"version"`
+	VirtualMachineName string         `json:"virtualMachineName"`
+	Volumes            []ExportVolume `json:"volumes"`
+}
+
+type ExportVolume struct {
+	VolumeName string `json:"volumeName"`
+	DiskFormat string `json:"diskFormat"`
+	DiskSize   int64  `json:"diskSize"`
+}
+
+func getVolumeSpecFile(volumeName string) string {
+	return path.Join(volumesDir, volumeName+".json")
+}
+
+func getVolumeDiskFile(volume ExportVolume) string {
+	return path.Join(volumesDir, fmt.Sprintf("%s.%s", volume.VolumeName, volume.DiskFormat))
+}
+
+func writeJSONFile(tw *tar.Writer, name string, obj interface{}) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(newFileHeader(name, int64(len(data)))); err != nil {
+		return err
+	}
+	_, err = tw.Write(data)
+	return err
+}
+
+func writeFile(tw *tar.Writer, name string, size int64, data io.Reader) error {
+	if err := tw.WriteHeader(newFileHeader(name, size)); err != nil {
+		return err
+	}
+	_, err := io.CopyN(tw, data, size)
+	return err
+}
+
+func newFileHeader(name string, size int64) *tar.Header {
+	return &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     size,
+		Mode:     0644,
+		ModTime:  time.Now(),
+	}
+}
+
+// readJSONFile reads the next file of the archive which is expected to be the given one
+func readJSONFile(tr *tar.Reader, name string, obj interface{}) error {
+	header, err := tr.Next()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if header.Name != name {
+		return fmt.Errorf("expect %s in the archive but got %s", name, header.Name)
+	}
+	return json.NewDecoder(tr).Decode(obj)
+}
@@ -0,0 +1,123 @@
+package backup
+
+import (
+	"archive/tar"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/config"
+	ctlbackup "github.com/harvester/harvester/pkg/controller/master/backup"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	ctllhv1beta1 "github.com/harvester/harvester/pkg/generated/controllers/longhorn.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+// ExportHandler downloads the VM spec, the PVC specs and the disk contents of a vm backup as a single archive,
+// the disk contents are served by the backing images prepared by the prepareExport action.
+type ExportHandler struct {
+	backupCache       ctlharvesterv1.VirtualMachineBackupCache
+	backingImageCache ctllhv1beta1.BackingImageCache
+
+	httpClient *http.Client
+}
+
+func NewExportHandler(scaled *config.Scaled) *ExportHandler {
+	return &ExportHandler{
+		backupCache:       scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackup().Cache(),
+		backingImageCache: scaled.LonghornFactory.Longhorn().V1beta1().BackingImage().Cache(),
+		httpClient:        &http.Client{},
+	}
+}
+
+func (h *ExportHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace, name := vars["namespace"], vars["name"]
+
+	vmBackup, err := h.backupCache.Get(namespace, name)
+	if err != nil {
+		util.ResponseError(rw, http.StatusBadRequest, errors.Wrap(err, "fail to get vm backup resource"))
+		return
+	}
+	if vmBackup.Status == nil || vmBackup.Status.ReadyToUse == nil || !*vmBackup.Status.ReadyToUse || vmBackup.Status.SourceSpec == nil {
+		util.ResponseError(rw, http.StatusBadRequest, errors.New("vm backup is not ready"))
+		return
+	}
+
+	manifest := ExportManifest{
+		Version:            exportArchiveVersion,
+		VirtualMachineName: vmBackup.Spec.Source.Name,
+	}
+	backingImageNames := map[string]string{}
+	for _, volumeBackup := range vmBackup.Status.VolumeBackups {
+		backingImageName := ctlbackup.GetExportBackingImageName(vmBackup, volumeBackup.VolumeName)
+		backingImage, err := h.backingImageCache.Get(util.LonghornSystemNamespaceName, backingImageName)
+		if err != nil || !ctlbackup.IsBackingImageReady(backingImage) {
+			util.ResponseErrorMsg(rw, http.StatusConflict, fmt.Sprintf("volume %s is not exported yet, please prepare the export first", volumeBackup.VolumeName))
+			return
+		}
+		backingImageNames[volumeBackup.VolumeName] = backingImageName
+		manifest.Volumes = append(manifest.Volumes, ExportVolume{
+			VolumeName: volumeBackup.VolumeName,
+			DiskFormat: ctlbackup.ExportDiskType,
+			DiskSize:   backingImage.Status.Size,
+		})
+	}
+
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s-%s.tar", vmBackup.Namespace, vmBackup.Name))
+	rw.Header().Set("Content-Type", "application/x-tar")
+
+	// the response is already started, the errors can only be logged and the archive is left truncated
+	tw := tar.NewWriter(rw)
+	if err := h.writeArchive(r, tw, manifest, vmBackup.Status.SourceSpec, vmBackup.Status.VolumeBackups, backingImageNames); err != nil {
+		logrus.Errorf("fail to export vm backup %s/%s: %v", namespace, name, err)
+		return
+	}
+	if err := tw.Close(); err != nil {
+		logrus.Errorf("fail to export vm backup %s/%s: %v", namespace, name, err)
+	}
+}
+
+func (h *ExportHandler) writeArchive(r *http.Request, tw *tar.Writer, manifest ExportManifest, sourceSpec *harvesterv1.VirtualMachineSourceSpec,
+	volumeBackups []harvesterv1.VolumeBackup, backingImageNames map[string]string) error {
+	if err := writeJSONFile(tw, manifestFile, manifest); err != nil {
+		return err
+	}
+	if err := writeJSONFile(tw, virtualMachineFile, sourceSpec); err != nil {
+		return err
+	}
+	for _, volumeBackup := range volumeBackups {
+		if err := writeJSONFile(tw, getVolumeSpecFile(volumeBackup.VolumeName), volumeBackup.PersistentVolumeClaim); err != nil {
+			return err
+		}
+	}
+	for _, volume := range manifest.Volumes {
+		if err := h.writeDisk(r, tw, volume, backingImageNames[volume.VolumeName]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *ExportHandler) writeDisk(r *http.Request, tw *tar.Writer, volume ExportVolume, backingImageName string) error {
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, util.GetBackingImageDownloadURL(backingImageName), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status code %d from longhorn: %s", resp.StatusCode, string(body))
+	}
+	return writeFile(tw, getVolumeDiskFile(volume), volume.DiskSize, resp.Body)
+}
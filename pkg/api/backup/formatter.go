@@ -0,0 +1,25 @@
+package backup
+
+import (
+	"github.com/rancher/apiserver/pkg/types"
+)
+
+const (
+	actionRestorePreflight = "restorePreflight"
+	actionPrepareExport    = "prepareExport"
+	actionImport           = "import"
+
+	linkExport = "export"
+)
+
+func Formatter(request *types.APIRequest, resource *types.RawResource) {
+	resource.Actions = make(map[string]string, 1)
+	if request.AccessControl.CanUpdate(request, resource.APIObject, resource.Schema) != nil {
+		return
+	}
+
+	resource.AddAction(request, actionRestorePreflight)
+	if resource.APIObject.Data().Bool("status", "readyToUse") {
+		resource.AddAction(request, actionPrepareExport)
+	}
+}
@@ -0,0 +1,63 @@
+package backup
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+
+	ctlbackup "github.com/harvester/harvester/pkg/controller/master/backup"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+)
+
+type ActionHandler struct {
+	backups     ctlharvesterv1.VirtualMachineBackupClient
+	backupCache ctlharvesterv1.VirtualMachineBackupCache
+}
+
+func (h ActionHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if err := h.do(rw, req); err != nil {
+		status := http.StatusInternalServerError
+		if e, ok := err.(*apierror.APIError); ok {
+			status = e.Code.Status
+		}
+		rw.WriteHeader(status)
+		_, _ = rw.Write([]byte(err.Error()))
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (h ActionHandler) do(rw http.ResponseWriter, req *http.Request) error {
+	vars := mux.Vars(req)
+	switch vars["action"] {
+	case actionPrepareExport:
+		return h.prepareExport(vars["namespace"], vars["name"])
+	default:
+		return apierror.NewAPIError(validation.InvalidAction, "Unsupported action")
+	}
+}
+
+// prepareExport requests the backup controller to prepare the disk contents of the vm backup for the export download,
+// the prepared disk contents are removed once the export expires and the action has to be requested again
+func (h ActionHandler) prepareExport(namespace, name string) error {
+	vmBackup, err := h.backupCache.Get(namespace, name)
+	if err != nil {
+		return err
+	}
+	if vmBackup.Status == nil || vmBackup.Status.ReadyToUse == nil || !*vmBackup.Status.ReadyToUse {
+		return apierror.NewAPIError(validation.InvalidAction, "vm backup is not ready")
+	}
+	if vmBackup.Annotations[ctlbackup.BackupExportAnnotation] == "true" {
+		return nil
+	}
+
+	toUpdate := vmBackup.DeepCopy()
+	if toUpdate.Annotations == nil {
+		toUpdate.Annotations = map[string]string{}
+	}
+	toUpdate.Annotations[ctlbackup.BackupExportAnnotation] = "true"
+	_, err = h.backups.Update(toUpdate)
+	return err
+}
@@ -0,0 +1,276 @@
+package backup
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"k8s.io/utils/pointer"
+	kv1 "kubevirt.io/client-go/api/v1"
+
+	"github.com/harvester/harvester/pkg/api/image"
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/builder"
+	"github.com/harvester/harvester/pkg/config"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+// importResources are the resources created by the import, the handler creates them with its own privileges,
+// so the user must be allowed to create all of them in the namespace.
+var importResources = []schema.GroupResource{
+	{Group: harvesterv1.SchemeGroupVersion.Group, Resource: "virtualmachineimages"},
+	{Group: corev1.SchemeGroupVersion.Group, Resource: "persistentvolumeclaims"},
+	{Group: kv1.SchemeGroupVersion.Group, Resource: "virtualmachines"},
+}
+
+// ImportHandler recreates the VM of an export archive in the given namespace, each disk of the archive is uploaded
+// to a new image, and the volumes of the VM are created from the images. The VM is created stopped.
+type ImportHandler struct {
+	images               ctlharvesterv1.VirtualMachineImageClient
+	uploader             image.UploadActionHandler
+	pvcs                 ctlcorev1.PersistentVolumeClaimClient
+	vms                  ctlkubevirtv1.VirtualMachineClient
+	subjectAccessReviews authorizationv1client.SubjectAccessReviewInterface
+}
+
+func NewImportHandler(scaled *config.Scaled, options config.Options) *ImportHandler {
+	return &ImportHandler{
+		images:               scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineImage(),
+		uploader:             image.NewUploadActionHandler(scaled, options),
+		pvcs:                 scaled.CoreFactory.Core().V1().PersistentVolumeClaim(),
+		vms:                  scaled.VirtFactory.Kubevirt().V1().VirtualMachine(),
+		subjectAccessReviews: scaled.Management.ClientSet.AuthorizationV1().SubjectAccessReviews(),
+	}
+}
+
+func (h *ImportHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+	if namespace == "" {
+		util.ResponseErrorMsg(rw, http.StatusBadRequest, "namespace is required")
+		return
+	}
+
+	if err := h.checkCreateAccess(r.Context(), namespace); err != nil {
+		status := http.StatusInternalServerError
+		if apierrors.IsForbidden(err) {
+			status = http.StatusForbidden
+		}
+		util.ResponseError(rw, status, err)
+		return
+	}
+
+	vm, err := h.importArchive(r.Context(), namespace, r.URL.Query().Get("name"), tar.NewReader(r.Body))
+	if err != nil {
+		util.ResponseError(rw, http.StatusBadRequest, errors.Wrap(err, "fail to import the vm"))
+		return
+	}
+	util.ResponseOKWithBody(rw, vm)
+}
+
+// checkCreateAccess reviews whether the user of the request can create the imported resources in the namespace
+func (h *ImportHandler) checkCreateAccess(ctx context.Context, namespace string) error {
+	user, ok := request.UserFrom(ctx)
+	if !ok {
+		return errors.New("failed to get the user of the request")
+	}
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.GetExtra()))
+	for key, value := range user.GetExtra() {
+		extra[key] = value
+	}
+
+	for _, resource := range importResources {
+		review, err := h.subjectAccessReviews.Create(ctx, &authorizationv1.SubjectAccessReview{
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace: namespace,
+					Verb:      "create",
+					Group:     resource.Group,
+					Resource:  resource.Resource,
+				},
+				User:   user.GetName(),
+				Groups: user.GetGroups(),
+				Extra:  extra,
+				UID:    user.GetUID(),
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+		if !review.Status.Allowed {
+			return apierrors.NewForbidden(resource, "", fmt.Errorf("user %s can't create %s in namespace %s", user.GetName(), resource.Resource, namespace))
+		}
+	}
+	return nil
+}
+
+// importArchive creates the resources of the archive, the created images and PVCs are removed if the import fails
+func (h *ImportHandler) importArchive(ctx context.Context, namespace, vmName string, tr *tar.Reader) (vm *kv1.VirtualMachine, err error) {
+	var manifest ExportManifest
+	if err := readJSONFile(tr, manifestFile, &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Version != exportArchiveVersion {
+		return nil, fmt.Errorf("unsupported archive version %q", manifest.Version)
+	}
+	if vmName == "" {
+		vmName = manifest.VirtualMachineName
+	}
+
+	var sourceSpec harvesterv1.VirtualMachineSourceSpec
+	if err := readJSONFile(tr, virtualMachineFile, &sourceSpec); err != nil {
+		return nil, err
+	}
+	if sourceSpec.Spec.Template == nil {
+		return nil, errors.New("the VM template is missing in the archive")
+	}
+
+	pvcSpecs := make([]harvesterv1.PersistentVolumeClaimSourceSpec, len(manifest.Volumes))
+	for i, volume := range manifest.Volumes {
+		if err := readJSONFile(tr, getVolumeSpecFile(volume.VolumeName), &pvcSpecs[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		createdImages []string
+		createdPVCs   []string
+	)
+	defer func() {
+		if err != nil {
+			h.cleanup(namespace, createdImages, createdPVCs)
+		}
+	}()
+
+	claimNames := map[string]string{}
+	for i, volume := range manifest.Volumes {
+		header, err := tr.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the disk of volume %s: %w", volume.VolumeName, err)
+		}
+		if header.Name != getVolumeDiskFile(volume) {
+			return nil, fmt.Errorf("expect %s in the archive but got %s", getVolumeDiskFile(volume), header.Name)
+		}
+
+		vmImage, err := h.importDisk(ctx, namespace, vmName, volume, header.Size, tr, &createdImages)
+		if err != nil {
+			return nil, err
+		}
+		pvc, err := h.pvcs.Create(getImportedPVC(namespace, vmName, vmImage, volume.VolumeName, pvcSpecs[i]))
+		if err != nil {
+			return nil, err
+		}
+		createdPVCs = append(createdPVCs, pvc.Name)
+		claimNames[volume.VolumeName] = pvc.Name
+	}
+
+	return h.vms.Create(getImportedVM(namespace, vmName, &sourceSpec, claimNames))
+}
+
+// cleanup removes the images and PVCs created by a failed import, the errors are only logged
+// since the import error is returned to the user.
+func (h *ImportHandler) cleanup(namespace string, images, pvcs []string) {
+	for _, name := range pvcs {
+		if err := h.pvcs.Delete(namespace, name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			logrus.Errorf("failed to remove PVC %s/%s of the failed import: %v", namespace, name, err)
+		}
+	}
+	for _, name := range images {
+		if err := h.images.Delete(namespace, name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			logrus.Errorf("failed to remove image %s/%s of the failed import: %v", namespace, name, err)
+		}
+	}
+}
+
+// importDisk uploads the disk to a new image, the image is appended to the created images as soon as it exists.
+// It doesn't wait for the storage class of the image, the PVCs of the image are provisioned once the image
+// controller creates it.
+func (h *ImportHandler) importDisk(ctx context.Context, namespace, vmName string, volume ExportVolume, size int64, tr *tar.Reader,
+	createdImages *[]string) (*harvesterv1.VirtualMachineImage, error) {
+	vmImage, err := h.images.Create(&harvesterv1.VirtualMachineImage{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "image-",
+			Namespace:    namespace,
+		},
+		Spec: harvesterv1.VirtualMachineImageSpec{
+			DisplayName: fmt.Sprintf("%s-%s.%s", vmName, volume.VolumeName, volume.DiskFormat),
+			Description: fmt.Sprintf("imported disk of volume %s of VM %s", volume.VolumeName, vmName),
+			SourceType:  harvesterv1.VirtualMachineImageSourceTypeUpload,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	*createdImages = append(*createdImages, vmImage.Name)
+
+	if err := h.uploader.UploadImageData(ctx, vmImage, tr, size); err != nil {
+		return nil, err
+	}
+	return vmImage, nil
+}
+
+func getImportedPVC(namespace, vmName string, vmImage *harvesterv1.VirtualMachineImage, volumeName string,
+	source harvesterv1.PersistentVolumeClaimSourceSpec) *corev1.PersistentVolumeClaim {
+	spec := source.Spec.DeepCopy()
+	spec.VolumeName = ""
+	spec.DataSource = nil
+	spec.StorageClassName = pointer.StringPtr(builder.BuildImageStorageClassName(vmImage.Namespace, vmImage.Name))
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s-%s", vmName, volumeName, rand.String(5)),
+			Namespace: namespace,
+			Annotations: map[string]string{
+				util.AnnotationImageID: ref.Construct(vmImage.Namespace, vmImage.Name),
+			},
+		},
+		Spec: *spec,
+	}
+}
+
+func getImportedVM(namespace, vmName string, sourceSpec *harvesterv1.VirtualMachineSourceSpec, claimNames map[string]string) *kv1.VirtualMachine {
+	spec := sourceSpec.Spec.DeepCopy()
+	spec.Running = pointer.BoolPtr(false)
+	spec.RunStrategy = nil
+	if spec.Template.ObjectMeta.Labels == nil {
+		spec.Template.ObjectMeta.Labels = map[string]string{}
+	}
+	spec.Template.ObjectMeta.Labels[builder.LabelKeyVirtualMachineName] = vmName
+	for i, volume := range spec.Template.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		if claimName, ok := claimNames[volume.Name]; ok {
+			spec.Template.Spec.Volumes[i].PersistentVolumeClaim.ClaimName = claimName
+		}
+	}
+	// the MAC addresses belong to the source VM
+	for i := range spec.Template.Spec.Domain.Devices.Interfaces {
+		spec.Template.Spec.Domain.Devices.Interfaces[i].MacAddress = ""
+	}
+
+	return &kv1.VirtualMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      vmName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				builder.LabelKeyVirtualMachineCreator: "harvester",
+			},
+		},
+		Spec: *spec,
+	}
+}
@@ -0,0 +1,168 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	corefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	kv1 "kubevirt.io/client-go/api/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/builder"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/fake"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	manifest := ExportManifest{
+		Version:            exportArchiveVersion,
+		VirtualMachineName: "vm",
+		Volumes:            []ExportVolume{{VolumeName: "disk-0", DiskFormat: "qcow2", DiskSize: 4}},
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	assert.Nil(t, writeJSONFile(tw, manifestFile, manifest))
+	assert.Nil(t, writeFile(tw, getVolumeDiskFile(manifest.Volumes[0]), 4, strings.NewReader("data")))
+	assert.Nil(t, tw.Close())
+
+	tr := tar.NewReader(&buf)
+	var actual ExportManifest
+	assert.Nil(t, readJSONFile(tr, manifestFile, &actual))
+	assert.Equal(t, manifest, actual)
+
+	header, err := tr.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, "volumes/disk-0.qcow2", header.Name)
+	data, err := ioutil.ReadAll(tr)
+	assert.Nil(t, err)
+	assert.Equal(t, "data", string(data))
+
+	// the files are read in order
+	tr = tar.NewReader(bytes.NewReader(buf.Bytes()))
+	assert.NotNil(t, readJSONFile(tr, virtualMachineFile, &harvesterv1.VirtualMachineSourceSpec{}))
+}
+
+func TestGetImportedVM(t *testing.T) {
+	sourceSpec := &harvesterv1.VirtualMachineSourceSpec{
+		Spec: kv1.VirtualMachineSpec{
+			Template: &kv1.VirtualMachineInstanceTemplateSpec{
+				Spec: kv1.VirtualMachineInstanceSpec{
+					Domain: kv1.DomainSpec{
+						Devices: kv1.Devices{
+							Interfaces: []kv1.Interface{{Name: "default", MacAddress: "52:54:00:00:00:01"}},
+						},
+					},
+					Volumes: []kv1.Volume{
+						{
+							Name: "disk-0",
+							VolumeSource: kv1.VolumeSource{
+								PersistentVolumeClaim: &kv1.PersistentVolumeClaimVolumeSource{},
+							},
+						},
+						{
+							Name: "cloudinitdisk",
+							VolumeSource: kv1.VolumeSource{
+								CloudInitNoCloud: &kv1.CloudInitNoCloudSource{},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	vm := getImportedVM("default", "imported", sourceSpec, map[string]string{"disk-0": "imported-disk-0-abcde"})
+	assert.Equal(t, "imported", vm.Name)
+	assert.False(t, *vm.Spec.Running)
+	assert.Equal(t, "imported", vm.Spec.Template.ObjectMeta.Labels[builder.LabelKeyVirtualMachineName])
+	assert.Equal(t, "imported-disk-0-abcde", vm.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName)
+	assert.Empty(t, vm.Spec.Template.Spec.Domain.Devices.Interfaces[0].MacAddress)
+	// the source spec is left alone
+	assert.Equal(t, "52:54:00:00:00:01", sourceSpec.Spec.Template.Spec.Domain.Devices.Interfaces[0].MacAddress)
+}
+
+func TestImportHandler_checkCreateAccess(t *testing.T) {
+	var testCases = []struct {
+		name          string
+		user          user.Info
+		allowed       map[string]bool
+		expectedError bool
+	}{
+		{
+			name:          "anonymous request",
+			expectedError: true,
+		},
+		{
+			name: "can create all the resources",
+			user: &user.DefaultInfo{Name: "admin"},
+			allowed: map[string]bool{
+				"virtualmachineimages":   true,
+				"persistentvolumeclaims": true,
+				"virtualmachines":        true,
+			},
+		},
+		{
+			name: "can't create images",
+			user: &user.DefaultInfo{Name: "user"},
+			allowed: map[string]bool{
+				"persistentvolumeclaims": true,
+				"virtualmachines":        true,
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		clientset := corefake.NewSimpleClientset()
+		clientset.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+			review.Status.Allowed = review.Spec.User == tc.user.GetName() && tc.allowed[review.Spec.ResourceAttributes.Resource]
+			return true, review, nil
+		})
+		h := &ImportHandler{
+			subjectAccessReviews: clientset.AuthorizationV1().SubjectAccessReviews(),
+		}
+
+		ctx := context.TODO()
+		if tc.user != nil {
+			ctx = request.WithUser(ctx, tc.user)
+		}
+		err := h.checkCreateAccess(ctx, "default")
+		assert.Equal(t, tc.expectedError, err != nil, "case %q", tc.name)
+	}
+}
+
+func TestImportHandler_cleanup(t *testing.T) {
+	coreclientset := corefake.NewSimpleClientset(&corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "imported-disk-0-abcde"},
+	})
+	clientset := fake.NewSimpleClientset(&harvesterv1.VirtualMachineImage{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "image-abcde"},
+	})
+	h := &ImportHandler{
+		images: fakeclients.VirtualMachineImageClient(clientset.HarvesterhciV1beta1().VirtualMachineImages),
+		pvcs:   fakeclients.PersistentVolumeClaimClient(coreclientset.CoreV1().PersistentVolumeClaims),
+	}
+
+	h.cleanup("default", []string{"image-abcde", "image-missing"}, []string{"imported-disk-0-abcde"})
+
+	images, err := clientset.HarvesterhciV1beta1().VirtualMachineImages("default").List(context.TODO(), metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Empty(t, images.Items)
+	pvcs, err := coreclientset.CoreV1().PersistentVolumeClaims("default").List(context.TODO(), metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Empty(t, pvcs.Items)
+}
@@ -0,0 +1,52 @@
+package backup
+
+import (
+	"net/http"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/schema"
+	"github.com/rancher/steve/pkg/server"
+	"github.com/rancher/wrangler/pkg/schemas"
+
+	"github.com/harvester/harvester/pkg/api/vm"
+	"github.com/harvester/harvester/pkg/config"
+)
+
+func RegisterSchema(scaled *config.Scaled, server *server.Server, options config.Options) error {
+	backups := scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackup()
+	actionHandler := ActionHandler{
+		backups:     backups,
+		backupCache: backups.Cache(),
+	}
+	server.BaseSchemas.MustImportAndCustomize(vm.RestoreInput{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(vm.RestorePreflightReport{}, nil)
+	t := schema.Template{
+		ID: "harvesterhci.io.virtualmachinebackup",
+		Customize: func(s *types.APISchema) {
+			s.Formatter = Formatter
+			s.ResourceActions = map[string]schemas.Action{
+				actionRestorePreflight: {
+					Input:  "restoreInput",
+					Output: "restorePreflightReport",
+				},
+				actionPrepareExport: {},
+			}
+			// the archive is imported into the namespace of the request,
+			// e.g. POST /v1/harvester/harvesterhci.io.virtualmachinebackups/{namespace}?action=import&name={vmName}
+			s.CollectionActions = map[string]schemas.Action{
+				actionImport: {},
+			}
+			s.ActionHandlers = map[string]http.Handler{
+				actionRestorePreflight: vm.NewRestorePreflightHandler(scaled, true),
+				actionPrepareExport:    actionHandler,
+				actionImport:           NewImportHandler(scaled, options),
+			}
+			// the backup is fetched with the permission of the user before the archive is downloaded
+			s.LinkHandlers = map[string]http.Handler{
+				linkExport: NewExportHandler(scaled),
+			}
+		},
+	}
+	server.SchemaFactory.AddTemplate(t)
+	return nil
+}
@@ -0,0 +1,220 @@
+package image
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apisv1beta1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	actionUploadChunk    = "uploadChunk"
+	actionUploadComplete = "uploadComplete"
+
+	reasonUploading = "Uploading"
+)
+
+// uploadChunk writes the request body to the staged file of the chunked upload at the offset of the query.
+// The chunks can be sent in any order and resent, the received ranges are tracked in the image status so that
+// the client resumes an interrupted upload by sending the missing ranges only.
+func (h UploadActionHandler) uploadChunk(req *http.Request) error {
+	vars := mux.Vars(req)
+	image, err := h.Images.Get(vars["namespace"], vars["name"], metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if err := checkChunkedUpload(image); err != nil {
+		return err
+	}
+	if image.Status.Upload != nil && apisv1beta1.ImageImported.GetReason(image) != reasonUploading {
+		return apierror.NewAPIError(validation.Conflict, "the image upload is completed")
+	}
+
+	query := req.URL.Query()
+	size, err := strconv.ParseInt(query.Get("size"), 10, 64)
+	if err != nil || size <= 0 {
+		return apierror.NewAPIError(validation.InvalidFormat, fmt.Sprintf("invalid upload size %q", query.Get("size")))
+	}
+	offset, err := strconv.ParseInt(query.Get("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return apierror.NewAPIError(validation.InvalidFormat, fmt.Sprintf("invalid chunk offset %q", query.Get("offset")))
+	}
+	if req.ContentLength <= 0 {
+		return apierror.NewAPIError(validation.MissingRequired, "the chunk length is required")
+	}
+	if offset+req.ContentLength > size {
+		return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("the chunk [%d, %d) exceeds the upload size %d", offset, offset+req.ContentLength, size))
+	}
+
+	upload := image.Status.Upload
+	if upload != nil && upload.Size != size {
+		return apierror.NewAPIError(validation.InvalidOption, fmt.Sprintf("the upload size %d differs from the size %d of the started upload", size, upload.Size))
+	}
+
+	path := util.GetImageUploadStagingPath(h.uploadDir, image)
+	if upload != nil && len(upload.ReceivedRanges) > 0 {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			// the chunks staged by another pod or before a restart without a persistent upload volume are lost
+			if err := h.updateImageOnConflict(image, func(toUpdate *apisv1beta1.VirtualMachineImage) {
+				toUpdate.Status.Upload = nil
+				toUpdate.Status.Progress = 0
+			}); err != nil {
+				return err
+			}
+			return apierror.NewAPIError(validation.Conflict, "the received chunks are lost, please restart the upload")
+		}
+	}
+
+	if err := writeChunk(path, offset, req.ContentLength, req.Body); err != nil {
+		return err
+	}
+
+	received := apisv1beta1.ByteRange{Start: offset, End: offset + req.ContentLength}
+	return h.updateImageOnConflict(image, func(toUpdate *apisv1beta1.VirtualMachineImage) {
+		if toUpdate.Status.Upload == nil {
+			toUpdate.Status.Upload = &apisv1beta1.VirtualMachineImageUpload{Size: size}
+		}
+		toUpdate.Status.Upload.ReceivedRanges = mergeRange(toUpdate.Status.Upload.ReceivedRanges, received)
+		receivedSize := rangesSize(toUpdate.Status.Upload.ReceivedRanges)
+		// the image is not imported until the upload is completed, set the progress to be at most 99
+		toUpdate.Status.Progress = int(receivedSize * 100 / size)
+		if toUpdate.Status.Progress == 100 {
+			toUpdate.Status.Progress = 99
+		}
+		apisv1beta1.ImageImported.Unknown(toUpdate)
+		apisv1beta1.ImageImported.Reason(toUpdate, reasonUploading)
+		apisv1beta1.ImageImported.Message(toUpdate, fmt.Sprintf("received %d of %d bytes", receivedSize, size))
+	})
+}
+
+// uploadComplete imports the staged file once all the chunks are received
+func (h UploadActionHandler) uploadComplete(req *http.Request) (err error) {
+	vars := mux.Vars(req)
+	image, err := h.Images.Get(vars["namespace"], vars["name"], metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if err := checkChunkedUpload(image); err != nil {
+		return err
+	}
+	upload := image.Status.Upload
+	if upload == nil {
+		return apierror.NewAPIError(validation.InvalidState, "no chunk is uploaded")
+	}
+	if missing := missingRanges(upload.ReceivedRanges, upload.Size); len(missing) > 0 {
+		return apierror.NewAPIError(validation.InvalidState, fmt.Sprintf("%d ranges are not uploaded, the first one is [%d, %d)",
+			len(missing), missing[0].Start, missing[0].End))
+	}
+
+	path := util.GetImageUploadStagingPath(h.uploadDir, image)
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if updateErr := h.updateImportedConditionOnConflict(image, "False", "UploadFailed", err.Error()); updateErr != nil {
+				logrus.Error(updateErr)
+			}
+		}
+	}()
+	if err = h.updateImportedConditionOnConflict(image, "Unknown", "Importing", ""); err != nil {
+		return err
+	}
+	if err = h.uploadFile(req.Context(), image, path); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		logrus.Errorf("failed to remove the staged upload of image %s/%s: %v", image.Namespace, image.Name, err)
+	}
+	return nil
+}
+
+func checkChunkedUpload(image *apisv1beta1.VirtualMachineImage) error {
+	if image.Spec.SourceType != apisv1beta1.VirtualMachineImageSourceTypeUpload {
+		return apierror.NewAPIError(validation.InvalidState, "the image is not of the upload source type")
+	}
+	if apisv1beta1.ImageImported.IsTrue(image) || apisv1beta1.ImageImported.IsFalse(image) {
+		return apierror.NewAPIError(validation.Conflict, "the image upload is finished")
+	}
+	if !apisv1beta1.ImageImported.IsUnknown(image) {
+		return apierror.NewAPIError(validation.Conflict, "the image is not initialized")
+	}
+	return nil
+}
+
+func writeChunk(path string, offset, length int64, data io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Seek(offset, io.SeekStart); err == nil {
+		_, err = io.CopyN(f, data, length)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to receive the chunk: %w", err)
+	}
+	return nil
+}
+
+// mergeRange adds the range to the sorted and merged ranges
+func mergeRange(ranges []apisv1beta1.ByteRange, r apisv1beta1.ByteRange) []apisv1beta1.ByteRange {
+	all := append(append([]apisv1beta1.ByteRange{}, ranges...), r)
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Start < all[j].Start
+	})
+	merged := []apisv1beta1.ByteRange{all[0]}
+	for _, current := range all[1:] {
+		last := &merged[len(merged)-1]
+		if current.Start > last.End {
+			merged = append(merged, current)
+		} else if current.End > last.End {
+			last.End = current.End
+		}
+	}
+	return merged
+}
+
+func rangesSize(ranges []apisv1beta1.ByteRange) int64 {
+	var size int64
+	for _, r := range ranges {
+		size += r.End - r.Start
+	}
+	return size
+}
+
+// missingRanges returns the ranges of [0, size) not covered by the sorted and merged ranges
+func missingRanges(ranges []apisv1beta1.ByteRange, size int64) []apisv1beta1.ByteRange {
+	var missing []apisv1beta1.ByteRange
+	var next int64
+	for _, r := range ranges {
+		if r.Start > next {
+			missing = append(missing, apisv1beta1.ByteRange{Start: next, End: r.Start})
+		}
+		if r.End > next {
+			next = r.End
+		}
+	}
+	if next < size {
+		missing = append(missing, apisv1beta1.ByteRange{Start: next, End: size})
+	}
+	return missing
+}
@@ -0,0 +1,88 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	apisv1beta1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+)
+
+func TestMergeRange(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		ranges   []apisv1beta1.ByteRange
+		r        apisv1beta1.ByteRange
+		expected []apisv1beta1.ByteRange
+	}{
+		{
+			name:     "first chunk",
+			r:        apisv1beta1.ByteRange{Start: 0, End: 10},
+			expected: []apisv1beta1.ByteRange{{Start: 0, End: 10}},
+		},
+		{
+			name:     "adjacent chunk",
+			ranges:   []apisv1beta1.ByteRange{{Start: 0, End: 10}},
+			r:        apisv1beta1.ByteRange{Start: 10, End: 20},
+			expected: []apisv1beta1.ByteRange{{Start: 0, End: 20}},
+		},
+		{
+			name:     "chunk out of order",
+			ranges:   []apisv1beta1.ByteRange{{Start: 20, End: 30}},
+			r:        apisv1beta1.ByteRange{Start: 0, End: 10},
+			expected: []apisv1beta1.ByteRange{{Start: 0, End: 10}, {Start: 20, End: 30}},
+		},
+		{
+			name:     "chunk filling the gap",
+			ranges:   []apisv1beta1.ByteRange{{Start: 0, End: 10}, {Start: 20, End: 30}},
+			r:        apisv1beta1.ByteRange{Start: 10, End: 20},
+			expected: []apisv1beta1.ByteRange{{Start: 0, End: 30}},
+		},
+		{
+			name:     "resent chunk",
+			ranges:   []apisv1beta1.ByteRange{{Start: 0, End: 30}},
+			r:        apisv1beta1.ByteRange{Start: 10, End: 20},
+			expected: []apisv1beta1.ByteRange{{Start: 0, End: 30}},
+		},
+		{
+			name:     "overlapping chunk",
+			ranges:   []apisv1beta1.ByteRange{{Start: 0, End: 15}, {Start: 25, End: 30}},
+			r:        apisv1beta1.ByteRange{Start: 10, End: 27},
+			expected: []apisv1beta1.ByteRange{{Start: 0, End: 30}},
+		},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, mergeRange(tc.ranges, tc.r), "case %q", tc.name)
+	}
+}
+
+func TestMissingRanges(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		ranges   []apisv1beta1.ByteRange
+		size     int64
+		expected []apisv1beta1.ByteRange
+	}{
+		{
+			name:     "nothing received",
+			size:     30,
+			expected: []apisv1beta1.ByteRange{{Start: 0, End: 30}},
+		},
+		{
+			name:   "all received",
+			ranges: []apisv1beta1.ByteRange{{Start: 0, End: 30}},
+			size:   30,
+		},
+		{
+			name:     "gaps",
+			ranges:   []apisv1beta1.ByteRange{{Start: 5, End: 10}, {Start: 20, End: 25}},
+			size:     30,
+			expected: []apisv1beta1.ByteRange{{Start: 0, End: 5}, {Start: 10, End: 20}, {Start: 25, End: 30}},
+		},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, missingRanges(tc.ranges, tc.size), "case %q", tc.name)
+	}
+}
@@ -0,0 +1,183 @@
+package image
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/handlers"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+
+	apisv1beta1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util"
+	"github.com/harvester/harvester/pkg/util/diskimage"
+)
+
+const (
+	downloadLink = "download"
+)
+
+// imageLinkHandler serves the download link of the images, the image file is streamed from the backing image.
+// The file is converted to a compressed qcow2 file with the compress query. The templateVersions link lists the
+// template versions compatible with the image.
+type imageLinkHandler struct {
+	httpClient           http.Client
+	imageCache           v1beta1.VirtualMachineImageCache
+	templateVersionCache v1beta1.VirtualMachineTemplateVersionCache
+	imageByID            types.RequestHandler
+	// stagingDir stages the image files to compress
+	stagingDir string
+}
+
+func (h *imageLinkHandler) byIDHandler(request *types.APIRequest) (types.APIObject, error) {
+	switch request.Link {
+	case downloadLink:
+	case templateVersionsLink:
+		return h.templateVersionsByID(request)
+	default:
+		return h.imageByID(request)
+	}
+
+	obj, err := handlers.ByIDHandler(request)
+	if err != nil {
+		return obj, err
+	}
+	if err := request.AccessControl.CanUpdate(request, obj, request.Schema); err != nil {
+		return types.APIObject{}, err
+	}
+	image, err := h.imageCache.Get(request.Namespace, request.Name)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	if !apisv1beta1.ImageImported.IsTrue(image) {
+		return types.APIObject{}, apierror.NewAPIError(validation.InvalidState, "the image is not imported")
+	}
+
+	compress := false
+	if value := request.Query.Get("compress"); value != "" {
+		if compress, err = strconv.ParseBool(value); err != nil {
+			return types.APIObject{}, apierror.NewAPIError(validation.InvalidFormat, fmt.Sprintf("invalid compress option %q", value))
+		}
+	}
+
+	if compress {
+		err = h.downloadCompressed(request, image)
+	} else {
+		err = h.download(request, image)
+	}
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	return types.APIObject{}, validation.ErrComplete
+}
+
+func (h *imageLinkHandler) download(request *types.APIRequest, image *apisv1beta1.VirtualMachineImage) error {
+	resp, err := h.getBackingImageData(request, image)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	size := resp.ContentLength
+	if size < 0 {
+		size = image.Status.Size
+	}
+	writeFileHeaders(request.Response, image.Spec.DisplayName, size)
+	if _, err := io.Copy(request.Response, resp.Body); err != nil {
+		logrus.Errorf("failed to download image %s/%s: %v", image.Namespace, image.Name, err)
+	}
+	return nil
+}
+
+// downloadCompressed saves the backing image data to a temporary file to compress it before the download
+func (h *imageLinkHandler) downloadCompressed(request *types.APIRequest, image *apisv1beta1.VirtualMachineImage) error {
+	dir, err := util.NewImageStagingDir(h.stagingDir)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "source")
+	if err := h.saveBackingImageData(request, image, source); err != nil {
+		return err
+	}
+	target, err := diskimage.Compress(request.Context(), source, dir)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(target)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	fileName := strings.TrimSuffix(image.Spec.DisplayName, filepath.Ext(image.Spec.DisplayName)) + ".qcow2"
+	writeFileHeaders(request.Response, fileName, stat.Size())
+	if _, err := io.Copy(request.Response, f); err != nil {
+		logrus.Errorf("failed to download image %s/%s: %v", image.Namespace, image.Name, err)
+	}
+	return nil
+}
+
+func (h *imageLinkHandler) saveBackingImageData(request *types.APIRequest, image *apisv1beta1.VirtualMachineImage, path string) error {
+	resp, err := h.getBackingImageData(request, image)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, resp.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download the backing image: %w", err)
+	}
+	return nil
+}
+
+func (h *imageLinkHandler) getBackingImageData(request *types.APIRequest, image *apisv1beta1.VirtualMachineImage) (*http.Response, error) {
+	backingImageName := fmt.Sprintf("%s-%s", image.Namespace, image.Name)
+	req, err := http.NewRequestWithContext(request.Context(), http.MethodGet, util.GetBackingImageDownloadURL(backingImageName), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unexpected status code %d from longhorn: %s", resp.StatusCode, string(body))
+	}
+	return resp, nil
+}
+
+func writeFileHeaders(rw http.ResponseWriter, fileName string, size int64) {
+	rw.Header().Set("Content-Type", "application/octet-stream")
+	rw.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	if size > 0 {
+		rw.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	}
+	rw.WriteHeader(http.StatusOK)
+}
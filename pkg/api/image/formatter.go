@@ -1,11 +1,19 @@
 package image
 
 import (
+	"bufio"
+	"context"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -13,6 +21,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rancher/apiserver/pkg/apierror"
 	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/pkg/data"
 	"github.com/rancher/wrangler/pkg/schemas/validation"
 	"github.com/sirupsen/logrus"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -22,6 +31,7 @@ import (
 	"github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
 	lhv1beta1 "github.com/harvester/harvester/pkg/generated/controllers/longhorn.io/v1beta1"
 	"github.com/harvester/harvester/pkg/util"
+	"github.com/harvester/harvester/pkg/util/diskimage"
 )
 
 const (
@@ -29,22 +39,42 @@ const (
 )
 
 func Formatter(request *types.APIRequest, resource *types.RawResource) {
-	resource.Actions = make(map[string]string, 1)
+	resource.Actions = make(map[string]string, 3)
+	resource.Links[templateVersionsLink] = request.URLBuilder.Link(resource.Schema, resource.ID, templateVersionsLink)
 	if request.AccessControl.CanUpdate(request, resource.APIObject, resource.Schema) != nil {
 		return
 	}
 
+	if isImported(resource.APIObject.Data()) {
+		resource.Links[downloadLink] = request.URLBuilder.Link(resource.Schema, resource.ID, downloadLink)
+	}
 	if resource.APIObject.Data().String("spec", "sourceType") == apisv1beta1.VirtualMachineImageSourceTypeUpload {
 		resource.AddAction(request, actionUpload)
+		resource.AddAction(request, actionUploadChunk)
+		resource.AddAction(request, actionUploadComplete)
 	}
 }
 
+func isImported(obj data.Object) bool {
+	for _, cond := range obj.Slice("status", "conditions") {
+		if cond.String("type") == string(apisv1beta1.ImageImported) {
+			return cond.String("status") == "True"
+		}
+	}
+	return false
+}
+
 type UploadActionHandler struct {
 	httpClient                  http.Client
 	Images                      v1beta1.VirtualMachineImageClient
 	ImageCache                  v1beta1.VirtualMachineImageCache
 	BackingImageDataSources     lhv1beta1.BackingImageDataSourceClient
 	BackingImageDataSourceCache lhv1beta1.BackingImageDataSourceCache
+
+	// uploadDir stages the chunks of the chunked uploads
+	uploadDir string
+	// stagingDir stages the uploaded files to convert
+	stagingDir string
 }
 
 func (h UploadActionHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
@@ -66,6 +96,10 @@ func (h UploadActionHandler) do(rw http.ResponseWriter, req *http.Request) error
 	switch action {
 	case actionUpload:
 		return h.uploadImage(rw, req)
+	case actionUploadChunk:
+		return h.uploadChunk(req)
+	case actionUploadComplete:
+		return h.uploadComplete(req)
 	default:
 		return apierror.NewAPIError(validation.InvalidAction, "Unsupported action")
 	}
@@ -88,41 +122,200 @@ func (h UploadActionHandler) uploadImage(rw http.ResponseWriter, req *http.Reque
 		}
 	}()
 
-	//Wait for backing image data source to be ready. Otherwise the upload request will fail.
-	dsName := fmt.Sprintf("%s-%s", namespace, name)
-	if err := h.waitForBackingImageDataSourceReady(dsName); err != nil {
+	size, err := strconv.ParseInt(req.URL.Query().Get("size"), 10, 64)
+	if err != nil {
+		err = fmt.Errorf("invalid upload size: %w", err)
 		return err
 	}
+	reader, err := req.MultipartReader()
+	if err != nil {
+		return err
+	}
+	part, err := reader.NextPart()
+	if err != nil {
+		return err
+	}
+	defer part.Close()
 
-	uploadUrl := fmt.Sprintf("http://longhorn-backend.longhorn-system:9500/v1/backingimages/%s-%s", namespace, name)
-	uploadReq, err := http.NewRequestWithContext(req.Context(), http.MethodPost, uploadUrl, req.Body)
+	// the end of the upload is not available, a fixed VHD is taken as raw which it is except for the trailing footer
+	data := bufio.NewReaderSize(part, diskimage.HeaderSize)
+	header, err := data.Peek(diskimage.HeaderSize)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	info := diskimage.Detect(header, nil, size)
+
+	if diskimage.NeedsConversion(info.Format) {
+		err = h.spoolAndUpload(req.Context(), image, data)
+		return err
+	}
+	if err = h.updateImageOnConflict(image, func(toUpdate *apisv1beta1.VirtualMachineImage) {
+		toUpdate.Status.Format = info.Format
+		toUpdate.Status.VirtualSize = info.VirtualSize
+		if toUpdate.Spec.OSInfo == nil {
+			toUpdate.Spec.OSInfo = util.GuessImageOSInfo(toUpdate, info)
+		}
+	}); err != nil {
+		return err
+	}
+	err = h.uploadData(req.Context(), image, data, size)
+	return err
+}
+
+// spoolAndUpload saves the upload to a temporary file to import it
+func (h UploadActionHandler) spoolAndUpload(ctx context.Context, image *apisv1beta1.VirtualMachineImage, data io.Reader) error {
+	dir, err := util.NewImageStagingDir(h.stagingDir)
 	if err != nil {
-		return fmt.Errorf("failed to create the upload request: %w", err)
+		return err
 	}
-	uploadReq.Header = req.Header
-	uploadReq.URL.RawQuery = req.URL.RawQuery
+	defer os.RemoveAll(dir)
 
-	var urlErr *url.Error
-	uploadResp, err := h.httpClient.Do(uploadReq)
-	if errors.As(err, &urlErr) {
-		// Trim the "POST http://xxx" implementation detail for the error
-		// set the err var and it will be recorded in image condition in the defer function
-		err = errors.Unwrap(urlErr)
+	source := filepath.Join(dir, "source")
+	f, err := os.Create(source)
+	if err != nil {
 		return err
-	} else if err != nil {
-		return fmt.Errorf("failed to send the upload request: %w", err)
 	}
-	defer uploadResp.Body.Close()
+	_, err = io.Copy(f, data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to receive the upload: %w", err)
+	}
+	return h.uploadFile(ctx, image, source)
+}
 
-	body, err := ioutil.ReadAll(uploadResp.Body)
+// uploadFile uploads the image file to the backing image, the file is converted to qcow2 first if Longhorn can't
+// import its format
+func (h UploadActionHandler) uploadFile(ctx context.Context, image *apisv1beta1.VirtualMachineImage, path string) error {
+	info, err := diskimage.DetectFile(path)
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return err
 	}
-	if uploadResp.StatusCode >= http.StatusBadRequest {
-		return fmt.Errorf("upload failed: %s", string(body))
+	if diskimage.NeedsConversion(info.Format) {
+		return h.convertAndUpload(ctx, image, info, path)
 	}
 
-	return nil
+	if err := h.updateImageOnConflict(image, func(toUpdate *apisv1beta1.VirtualMachineImage) {
+		toUpdate.Status.Format = info.Format
+		toUpdate.Status.VirtualSize = info.VirtualSize
+		if toUpdate.Spec.OSInfo == nil {
+			toUpdate.Spec.OSInfo = util.GuessImageOSInfo(toUpdate, info)
+		}
+	}); err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return h.uploadData(ctx, image, f, stat.Size())
+}
+
+// convertAndUpload converts the image file to qcow2 next to it and uploads the result to the backing image
+func (h UploadActionHandler) convertAndUpload(ctx context.Context, image *apisv1beta1.VirtualMachineImage, info diskimage.Info, source string) error {
+	if err := h.updateImageOnConflict(image, func(toUpdate *apisv1beta1.VirtualMachineImage) {
+		toUpdate.Status.Format = info.Format
+		if toUpdate.Spec.OSInfo == nil {
+			toUpdate.Spec.OSInfo = util.GuessImageOSInfo(toUpdate, info)
+		}
+		apisv1beta1.ImageImported.Unknown(toUpdate)
+		apisv1beta1.ImageImported.Reason(toUpdate, "Converting")
+		apisv1beta1.ImageImported.Message(toUpdate, fmt.Sprintf("converting the %s image to qcow2", info.Format))
+	}); err != nil {
+		return err
+	}
+
+	// the checksum is of the uploaded file, it is verified here as the converted file differs
+	var checksum string
+	if image.Spec.Checksum != "" {
+		var err error
+		if checksum, err = fileChecksum(image.Spec.Checksum, source); err != nil {
+			return err
+		}
+	}
+
+	dir, err := ioutil.TempDir(filepath.Dir(source), "vmimage-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	target, info, err := diskimage.Convert(ctx, source, dir)
+	if err != nil {
+		return err
+	}
+	converted, err := os.Open(target)
+	if err != nil {
+		return err
+	}
+	defer converted.Close()
+	stat, err := converted.Stat()
+	if err != nil {
+		return err
+	}
+
+	if err := h.updateImageOnConflict(image, func(toUpdate *apisv1beta1.VirtualMachineImage) {
+		toUpdate.Status.VirtualSize = info.VirtualSize
+		apisv1beta1.ImageImported.Reason(toUpdate, "Importing")
+		apisv1beta1.ImageImported.Message(toUpdate, "")
+		if checksum != "" {
+			setChecksumResult(toUpdate, checksum)
+		}
+	}); err != nil {
+		return err
+	}
+	return h.uploadData(ctx, image, converted, stat.Size())
+}
+
+// fileChecksum computes the checksum of the file with the hash of the expected checksum
+func fileChecksum(expected, path string) (string, error) {
+	checksum, err := util.NewChecksumHash(expected)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := io.Copy(checksum, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(checksum.Sum(nil)), nil
+}
+
+// UploadImageData streams the data of the given size to the backing image of the upload image,
+// the data is sent the same way as the browser uploads a file.
+func (h UploadActionHandler) UploadImageData(ctx context.Context, image *apisv1beta1.VirtualMachineImage, data io.Reader, size int64) (err error) {
+	defer func() {
+		if err != nil {
+			if updateErr := h.updateImportedConditionOnConflict(image, "False", "UploadFailed", err.Error()); updateErr != nil {
+				logrus.Error(updateErr)
+			}
+		}
+	}()
+	return h.uploadData(ctx, image, data, size)
+}
+
+func (h UploadActionHandler) uploadData(ctx context.Context, image *apisv1beta1.VirtualMachineImage, data io.Reader, size int64) error {
+	// Wait for backing image data source to be ready. Otherwise the upload request will fail.
+	if err := h.waitForBackingImageDataSourceReady(fmt.Sprintf("%s-%s", image.Namespace, image.Name)); err != nil {
+		return err
+	}
+
+	err := util.UploadBackingImageData(ctx, &h.httpClient, fmt.Sprintf("%s-%s", image.Namespace, image.Name), image.Spec.DisplayName, data, size)
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		// Trim the "POST http://xxx" implementation detail for the error
+		return errors.Unwrap(urlErr)
+	}
+	return err
 }
 
 func (h UploadActionHandler) waitForBackingImageDataSourceReady(name string) error {
@@ -147,6 +340,14 @@ func (h UploadActionHandler) waitForBackingImageDataSourceReady(name string) err
 
 func (h UploadActionHandler) updateImportedConditionOnConflict(image *apisv1beta1.VirtualMachineImage,
 	status, reason, message string) error {
+	return h.updateImageOnConflict(image, func(toUpdate *apisv1beta1.VirtualMachineImage) {
+		apisv1beta1.ImageImported.SetStatus(toUpdate, status)
+		apisv1beta1.ImageImported.Reason(toUpdate, reason)
+		apisv1beta1.ImageImported.Message(toUpdate, message)
+	})
+}
+
+func (h UploadActionHandler) updateImageOnConflict(image *apisv1beta1.VirtualMachineImage, update func(toUpdate *apisv1beta1.VirtualMachineImage)) error {
 	retry := 3
 	for i := 0; i < retry; i++ {
 		current, err := h.ImageCache.Get(image.Namespace, image.Name)
@@ -157,9 +358,7 @@ func (h UploadActionHandler) updateImportedConditionOnConflict(image *apisv1beta
 			return nil
 		}
 		toUpdate := current.DeepCopy()
-		apisv1beta1.ImageImported.SetStatus(toUpdate, status)
-		apisv1beta1.ImageImported.Reason(toUpdate, reason)
-		apisv1beta1.ImageImported.Message(toUpdate, message)
+		update(toUpdate)
 		if reflect.DeepEqual(current, toUpdate) {
 			return nil
 		}
@@ -169,5 +368,19 @@ func (h UploadActionHandler) updateImportedConditionOnConflict(image *apisv1beta
 		}
 		time.Sleep(2 * time.Second)
 	}
-	return errors.New("failed to update image uploaded condition, max retries exceeded")
+	return errors.New("failed to update image, max retries exceeded")
+}
+
+// setChecksumResult compares the checksum of the spec with the actual one
+func setChecksumResult(image *apisv1beta1.VirtualMachineImage, actual string) {
+	checksum := strings.ToLower(image.Spec.Checksum)
+	if actual != checksum {
+		apisv1beta1.ImageVerified.False(image)
+		apisv1beta1.ImageVerified.Reason(image, "ChecksumMismatch")
+		apisv1beta1.ImageVerified.Message(image, fmt.Sprintf("expected checksum %s but got %s", checksum, actual))
+		return
+	}
+	apisv1beta1.ImageVerified.True(image)
+	apisv1beta1.ImageVerified.Reason(image, "ChecksumMatched")
+	apisv1beta1.ImageVerified.Message(image, "")
 }
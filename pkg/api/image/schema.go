@@ -11,22 +11,45 @@ import (
 	"github.com/harvester/harvester/pkg/config"
 )
 
+func NewUploadActionHandler(scaled *config.Scaled, options config.Options) UploadActionHandler {
+	return UploadActionHandler{
+		httpClient:                  http.Client{},
+		Images:                      scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineImage(),
+		ImageCache:                  scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineImage().Cache(),
+		BackingImageDataSources:     scaled.LonghornFactory.Longhorn().V1beta1().BackingImageDataSource(),
+		BackingImageDataSourceCache: scaled.LonghornFactory.Longhorn().V1beta1().BackingImageDataSource().Cache(),
+		uploadDir:                   options.ImageUploadDir,
+		stagingDir:                  options.ImageStagingDir,
+	}
+}
+
 func RegisterSchema(scaled *config.Scaled, server *server.Server, options config.Options) error {
 	t := schema.Template{
 		ID: "harvesterhci.io.virtualmachineimage",
 		Customize: func(s *types.APISchema) {
 			s.Formatter = Formatter
+			imageCache := scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineImage().Cache()
+			sharedHandler := &sharedImageHandler{
+				imageCache: imageCache,
+			}
+			linkHandler := &imageLinkHandler{
+				imageCache:           imageCache,
+				templateVersionCache: scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineTemplateVersion().Cache(),
+				imageByID:            sharedHandler.byIDHandler,
+				stagingDir:           options.ImageStagingDir,
+			}
+			s.ByIDHandler = linkHandler.byIDHandler
+			s.ListHandler = sharedHandler.listHandler
+			uploadHandler := NewUploadActionHandler(scaled, options)
 			s.ResourceActions = map[string]schemas.Action{
-				actionUpload: {},
+				actionUpload:         {},
+				actionUploadChunk:    {},
+				actionUploadComplete: {},
 			}
 			s.ActionHandlers = map[string]http.Handler{
-				actionUpload: UploadActionHandler{
-					httpClient:                  http.Client{},
-					Images:                      scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineImage(),
-					ImageCache:                  scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineImage().Cache(),
-					BackingImageDataSources:     scaled.LonghornFactory.Longhorn().V1beta1().BackingImageDataSource(),
-					BackingImageDataSourceCache: scaled.LonghornFactory.Longhorn().V1beta1().BackingImageDataSource().Cache(),
-				},
+				actionUpload:         uploadHandler,
+				actionUploadChunk:    uploadHandler,
+				actionUploadComplete: uploadHandler,
 			}
 		},
 	}
@@ -0,0 +1,86 @@
+package image
+
+import (
+	"net/http"
+
+	"github.com/rancher/apiserver/pkg/handlers"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	apisv1beta1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+// sharedImageHandler lists the shared images to all users, the images are listed according to the RBAC of the user
+// otherwise. The shared images of the namespaces the user can't access are read-only.
+type sharedImageHandler struct {
+	imageCache v1beta1.VirtualMachineImageCache
+}
+
+// listHandler adds the shared images to the last page of the images, the images of a namespace include the images
+// shared by the other namespaces as the volumes of the namespace can be created from them
+func (h *sharedImageHandler) listHandler(request *types.APIRequest) (types.APIObjectList, error) {
+	list, err := handlers.ListHandler(request)
+	if err != nil || request.Name != "" || list.Continue != "" {
+		return list, err
+	}
+	images, err := h.imageCache.List("", labels.Everything())
+	if err != nil {
+		return list, err
+	}
+	list.Objects = appendSharedImages(request.Schema.ID, list.Objects, images)
+	return list, nil
+}
+
+// byIDHandler returns the shared image if the user can't get it, the actions and the other methods keep the RBAC
+// of the user
+func (h *sharedImageHandler) byIDHandler(request *types.APIRequest) (types.APIObject, error) {
+	obj, err := handlers.ByIDHandler(request)
+	if err == nil || request.Action != "" || request.Method != http.MethodGet {
+		return obj, err
+	}
+	image, getErr := h.imageCache.Get(request.Namespace, request.Name)
+	if getErr != nil || !util.IsImageShared(image) {
+		return obj, err
+	}
+	return toAPIObject(request.Schema.ID, image)
+}
+
+// appendSharedImages appends the shared images which are not in the objects yet
+func appendSharedImages(schemaID string, objects []types.APIObject, images []*apisv1beta1.VirtualMachineImage) []types.APIObject {
+	listed := make(map[string]bool, len(objects))
+	for _, obj := range objects {
+		listed[obj.ID] = true
+	}
+	for _, image := range images {
+		if !util.IsImageShared(image) || listed[ref.Construct(image.Namespace, image.Name)] {
+			continue
+		}
+		obj, err := toAPIObject(schemaID, image)
+		if err != nil {
+			logrus.Errorf("failed to convert shared image %s/%s: %v", image.Namespace, image.Name, err)
+			continue
+		}
+		objects = append(objects, obj)
+	}
+	return objects
+}
+
+func toAPIObject(schemaID string, image *apisv1beta1.VirtualMachineImage) (types.APIObject, error) {
+	data, err := runtime.DefaultUnstructuredConverter.ToUnstructured(image)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	obj := &unstructured.Unstructured{Object: data}
+	obj.SetGroupVersionKind(apisv1beta1.SchemeGroupVersion.WithKind("VirtualMachineImage"))
+	return types.APIObject{
+		Type:   schemaID,
+		ID:     ref.Construct(image.Namespace, image.Name),
+		Object: obj,
+	}, nil
+}
@@ -0,0 +1,44 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apisv1beta1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+func TestAppendSharedImages(t *testing.T) {
+	const schemaID = "harvesterhci.io.virtualmachineimage"
+	newImage := func(namespace, name string, shared bool) *apisv1beta1.VirtualMachineImage {
+		return &apisv1beta1.VirtualMachineImage{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec:       apisv1beta1.VirtualMachineImageSpec{Shared: shared},
+		}
+	}
+	images := []*apisv1beta1.VirtualMachineImage{
+		newImage("default", "private", false),
+		newImage("default", "listed", true),
+		newImage("team-a", "shared", true),
+		newImage("team-a", "private", false),
+		newImage(util.PublicNamespaceName, "public", false),
+	}
+	listed := []types.APIObject{
+		{Type: schemaID, ID: "default/private"},
+		{Type: schemaID, ID: "default/listed"},
+	}
+
+	actual := appendSharedImages(schemaID, listed, images)
+
+	var ids []string
+	for _, obj := range actual {
+		assert.Equal(t, schemaID, obj.Type)
+		ids = append(ids, obj.ID)
+	}
+	assert.Equal(t, []string{"default/private", "default/listed", "team-a/shared", "harvester-public/public"}, ids)
+	assert.Equal(t, "VirtualMachineImage", actual[2].Data().String("kind"))
+	assert.Equal(t, "team-a", actual[2].Data().String("metadata", "namespace"))
+}
@@ -0,0 +1,161 @@
+package image
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	kv1 "kubevirt.io/client-go/api/v1"
+
+	apisv1beta1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/util"
+	"github.com/harvester/harvester/pkg/util/diskimage"
+)
+
+const (
+	templateVersionSchemaID = "harvesterhci.io.virtualmachinetemplateversion"
+	templateVersionsLink    = "templateVersions"
+)
+
+// templateVersions writes the template versions of the image namespace and the public namespace which can boot
+// from the image, the template versions the user can't list or get are left out
+func (h *imageLinkHandler) templateVersions(request *types.APIRequest) error {
+	image, err := h.imageCache.Get(request.Namespace, request.Name)
+	if err != nil {
+		return err
+	}
+	schema := request.Schemas.LookupSchema(templateVersionSchemaID)
+	if schema == nil {
+		return apierror.NewAPIError(validation.PermissionDenied, "can not list the template versions")
+	}
+	if err := request.AccessControl.CanList(request, schema); err != nil {
+		return err
+	}
+
+	namespaces := []string{image.Namespace}
+	if image.Namespace != util.PublicNamespaceName {
+		namespaces = append(namespaces, util.PublicNamespaceName)
+	}
+	var result []types.APIObject
+	for _, namespace := range namespaces {
+		templateVersions, err := h.templateVersionCache.List(namespace, labels.Everything())
+		if err != nil {
+			return err
+		}
+		canList := request.AccessControl.CanDo(request, templateVersionSchemaID, "list", namespace, "") == nil
+		for _, templateVersion := range templateVersions {
+			if !canList && request.AccessControl.CanDo(request, templateVersionSchemaID, "get", namespace, templateVersion.Name) != nil {
+				continue
+			}
+			if !isTemplateVersionCompatible(templateVersion, image) {
+				continue
+			}
+			result = append(result, types.APIObject{
+				Type:   templateVersionSchemaID,
+				ID:     ref.Construct(templateVersion.Namespace, templateVersion.Name),
+				Object: templateVersion,
+			})
+		}
+	}
+
+	request.ResponseWriter.WriteList(request, http.StatusOK, types.APIObjectList{Objects: result})
+	return nil
+}
+
+// isTemplateVersionCompatible returns true if the template version has an image volume which can use the image.
+// An ISO image is attached as a CD-ROM, the other images as disks. The template boot mode must match the image one,
+// and the templates attaching the virtio drivers are for the Windows images only.
+func isTemplateVersionCompatible(templateVersion *apisv1beta1.VirtualMachineTemplateVersion, image *apisv1beta1.VirtualMachineImage) bool {
+	imageID := ref.Construct(image.Namespace, image.Name)
+	if templateVersion.Spec.ImageID != "" && templateVersion.Spec.ImageID != imageID {
+		return false
+	}
+
+	var pvcs []corev1.PersistentVolumeClaim
+	annotation := templateVersion.Spec.VM.ObjectMeta.Annotations[util.AnnotationVolumeClaimTemplates]
+	if annotation == "" || json.Unmarshal([]byte(annotation), &pvcs) != nil {
+		return false
+	}
+	var imageDisk *kv1.Disk
+	for _, pvc := range pvcs {
+		pvcImageID, ok := pvc.Annotations[util.AnnotationImageID]
+		if !ok || pvcImageID != "" && pvcImageID != imageID {
+			continue
+		}
+		if imageDisk = getTemplateDisk(templateVersion, pvc.Name); imageDisk != nil {
+			break
+		}
+	}
+	if imageDisk == nil {
+		return false
+	}
+	if format := image.Status.Format; format != "" && (format == diskimage.FormatISO) != (imageDisk.CDRom != nil) {
+		return false
+	}
+
+	osInfo := image.Spec.OSInfo
+	if osInfo == nil {
+		osInfo = &apisv1beta1.VirtualMachineImageOSInfo{}
+	}
+	spec := templateVersion.Spec.VM.Spec.Template
+	if spec == nil {
+		return false
+	}
+	efi := spec.Spec.Domain.Firmware != nil && spec.Spec.Domain.Firmware.Bootloader != nil &&
+		spec.Spec.Domain.Firmware.Bootloader.EFI != nil
+	switch osInfo.BootMode {
+	case apisv1beta1.VirtualMachineImageBootModeUEFI:
+		if !efi {
+			return false
+		}
+	case apisv1beta1.VirtualMachineImageBootModeBIOS:
+		if efi {
+			return false
+		}
+	}
+
+	drivers := false
+	for _, volume := range spec.Spec.Volumes {
+		if volume.ContainerDisk != nil {
+			drivers = true
+		}
+	}
+	if osInfo.VirtioDriversRequired {
+		return drivers
+	}
+	return !drivers || osInfo.Family == apisv1beta1.VirtualMachineImageOSFamilyWindows
+}
+
+// getTemplateDisk returns the disk of the template which is backed by the volume claim
+func getTemplateDisk(templateVersion *apisv1beta1.VirtualMachineTemplateVersion, claimName string) *kv1.Disk {
+	spec := templateVersion.Spec.VM.Spec.Template
+	if spec == nil {
+		return nil
+	}
+	for _, volume := range spec.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil || volume.PersistentVolumeClaim.ClaimName != claimName {
+			continue
+		}
+		for i, disk := range spec.Spec.Domain.Devices.Disks {
+			if disk.Name == volume.Name {
+				return &spec.Spec.Domain.Devices.Disks[i]
+			}
+		}
+	}
+	return nil
+}
+
+func (h *imageLinkHandler) templateVersionsByID(request *types.APIRequest) (types.APIObject, error) {
+	if _, err := h.imageByID(request); err != nil {
+		return types.APIObject{}, err
+	}
+	if err := h.templateVersions(request); err != nil {
+		return types.APIObject{}, err
+	}
+	return types.APIObject{}, validation.ErrComplete
+}
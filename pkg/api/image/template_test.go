@@ -0,0 +1,252 @@
+package image
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/server"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/pkg/schemas"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kv1 "kubevirt.io/client-go/api/v1"
+
+	apisv1beta1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/fake"
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/util"
+	"github.com/harvester/harvester/pkg/util/diskimage"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+func newTemplateVersion(imageID string, cdrom, efi, drivers bool) *apisv1beta1.VirtualMachineTemplateVersion {
+	disk := kv1.Disk{Name: "image"}
+	if cdrom {
+		disk.CDRom = &kv1.CDRomTarget{Bus: "sata"}
+	} else {
+		disk.Disk = &kv1.DiskTarget{Bus: "virtio"}
+	}
+	spec := kv1.VirtualMachineInstanceSpec{
+		Domain: kv1.DomainSpec{
+			Devices: kv1.Devices{Disks: []kv1.Disk{disk}},
+		},
+		Volumes: []kv1.Volume{
+			{
+				Name: "image",
+				VolumeSource: kv1.VolumeSource{
+					PersistentVolumeClaim: &kv1.PersistentVolumeClaimVolumeSource{},
+				},
+			},
+		},
+	}
+	spec.Volumes[0].PersistentVolumeClaim.ClaimName = "pvc-image"
+	if efi {
+		spec.Domain.Firmware = &kv1.Firmware{Bootloader: &kv1.Bootloader{EFI: &kv1.EFI{}}}
+	}
+	if drivers {
+		spec.Volumes = append(spec.Volumes, kv1.Volume{
+			Name: "virtio-container-disk",
+			VolumeSource: kv1.VolumeSource{
+				ContainerDisk: &kv1.ContainerDiskSource{Image: "registry.suse.com/harvester-beta/vmdp:latest"},
+			},
+		})
+	}
+	return &apisv1beta1.VirtualMachineTemplateVersion{
+		Spec: apisv1beta1.VirtualMachineTemplateVersionSpec{
+			VM: apisv1beta1.VirtualMachineSourceSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						util.AnnotationVolumeClaimTemplates: fmt.Sprintf(`[{"metadata":{"name":"pvc-image","annotations":{"harvesterhci.io/imageId":%q}}}]`, imageID),
+					},
+				},
+				Spec: kv1.VirtualMachineSpec{
+					Template: &kv1.VirtualMachineInstanceTemplateSpec{Spec: spec},
+				},
+			},
+		},
+	}
+}
+
+func TestIsTemplateVersionCompatible(t *testing.T) {
+	newImage := func(format string, osInfo *apisv1beta1.VirtualMachineImageOSInfo) *apisv1beta1.VirtualMachineImage {
+		return &apisv1beta1.VirtualMachineImage{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "image"},
+			Spec:       apisv1beta1.VirtualMachineImageSpec{OSInfo: osInfo},
+			Status:     apisv1beta1.VirtualMachineImageStatus{Format: format},
+		}
+	}
+	windowsISO := &apisv1beta1.VirtualMachineImageOSInfo{
+		Family:                apisv1beta1.VirtualMachineImageOSFamilyWindows,
+		VirtioDriversRequired: true,
+	}
+	uefi := &apisv1beta1.VirtualMachineImageOSInfo{
+		Family:   apisv1beta1.VirtualMachineImageOSFamilyLinux,
+		BootMode: apisv1beta1.VirtualMachineImageBootModeUEFI,
+	}
+
+	var testCases = []struct {
+		name            string
+		templateVersion *apisv1beta1.VirtualMachineTemplateVersion
+		image           *apisv1beta1.VirtualMachineImage
+		expected        bool
+	}{
+		{
+			name:            "disk image with disk template",
+			templateVersion: newTemplateVersion("", false, false, false),
+			image:           newImage(diskimage.FormatQCOW2, nil),
+			expected:        true,
+		},
+		{
+			name:            "disk image with cdrom template",
+			templateVersion: newTemplateVersion("", true, false, false),
+			image:           newImage(diskimage.FormatQCOW2, nil),
+			expected:        false,
+		},
+		{
+			name:            "iso image with cdrom template",
+			templateVersion: newTemplateVersion("", true, false, false),
+			image:           newImage(diskimage.FormatISO, nil),
+			expected:        true,
+		},
+		{
+			name:            "template of another image",
+			templateVersion: newTemplateVersion("default/other", false, false, false),
+			image:           newImage(diskimage.FormatQCOW2, nil),
+			expected:        false,
+		},
+		{
+			name:            "template of the image",
+			templateVersion: newTemplateVersion("default/image", false, false, false),
+			image:           newImage(diskimage.FormatQCOW2, nil),
+			expected:        true,
+		},
+		{
+			name:            "uefi image with bios template",
+			templateVersion: newTemplateVersion("", false, false, false),
+			image:           newImage(diskimage.FormatQCOW2, uefi),
+			expected:        false,
+		},
+		{
+			name:            "uefi image with uefi template",
+			templateVersion: newTemplateVersion("", false, true, false),
+			image:           newImage(diskimage.FormatQCOW2, uefi),
+			expected:        true,
+		},
+		{
+			name:            "windows iso with drivers template",
+			templateVersion: newTemplateVersion("", true, false, true),
+			image:           newImage(diskimage.FormatISO, windowsISO),
+			expected:        true,
+		},
+		{
+			name:            "windows iso without drivers template",
+			templateVersion: newTemplateVersion("", true, false, false),
+			image:           newImage(diskimage.FormatISO, windowsISO),
+			expected:        false,
+		},
+		{
+			name:            "linux iso with drivers template",
+			templateVersion: newTemplateVersion("", true, false, true),
+			image:           newImage(diskimage.FormatISO, nil),
+			expected:        false,
+		},
+		{
+			name:            "template without image volume",
+			templateVersion: &apisv1beta1.VirtualMachineTemplateVersion{},
+			image:           newImage(diskimage.FormatQCOW2, nil),
+			expected:        false,
+		},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, isTemplateVersionCompatible(tc.templateVersion, tc.image), "case %q", tc.name)
+	}
+}
+
+// fakeTemplateVersionAccess grants the template versions by namespace/name, or all those of a namespace by its name
+type fakeTemplateVersionAccess struct {
+	server.SchemaBasedAccess
+	granted map[string]bool
+}
+
+func (a *fakeTemplateVersionAccess) CanDo(_ *types.APIRequest, _, verb, namespace, name string) error {
+	if verb == "list" && a.granted[namespace] || verb == "get" && a.granted[ref.Construct(namespace, name)] {
+		return nil
+	}
+	return apierror.NewAPIError(validation.PermissionDenied, "denied")
+}
+
+type fakeListWriter struct {
+	list types.APIObjectList
+}
+
+func (w *fakeListWriter) Write(_ *types.APIRequest, _ int, _ types.APIObject) {}
+
+func (w *fakeListWriter) WriteList(_ *types.APIRequest, _ int, list types.APIObjectList) {
+	w.list = list
+}
+
+func TestImageLinkHandler_templateVersions(t *testing.T) {
+	image := &apisv1beta1.VirtualMachineImage{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "image"},
+	}
+	newNamedTemplateVersion := func(namespace, name string) *apisv1beta1.VirtualMachineTemplateVersion {
+		templateVersion := newTemplateVersion("", false, false, false)
+		templateVersion.Namespace = namespace
+		templateVersion.Name = name
+		return templateVersion
+	}
+
+	var testCases = []struct {
+		name     string
+		granted  map[string]bool
+		expected []string
+	}{
+		{
+			name: "no access",
+		},
+		{
+			name:     "list access of the image namespace",
+			granted:  map[string]bool{"default": true},
+			expected: []string{"default/template-1", "default/template-2"},
+		},
+		{
+			name:     "get access of a template version",
+			granted:  map[string]bool{"default/template-2": true, util.PublicNamespaceName + "/public-template": true},
+			expected: []string{"default/template-2", util.PublicNamespaceName + "/public-template"},
+		},
+	}
+
+	for _, tc := range testCases {
+		clientset := fake.NewSimpleClientset(image,
+			newNamedTemplateVersion("default", "template-1"),
+			newNamedTemplateVersion("default", "template-2"),
+			newNamedTemplateVersion(util.PublicNamespaceName, "public-template"),
+			newNamedTemplateVersion("other", "other-template"),
+		)
+		h := &imageLinkHandler{
+			imageCache:           fakeclients.VirtualMachineImageCache(clientset.HarvesterhciV1beta1().VirtualMachineImages),
+			templateVersionCache: fakeclients.VirtualMachineTemplateVersionCache(clientset.HarvesterhciV1beta1().VirtualMachineTemplateVersions),
+		}
+		writer := &fakeListWriter{}
+		request := &types.APIRequest{
+			Namespace: image.Namespace,
+			Name:      image.Name,
+			Schemas: types.EmptyAPISchemas().MustAddSchema(types.APISchema{
+				Schema: &schemas.Schema{ID: templateVersionSchemaID, CollectionMethods: []string{http.MethodGet}},
+			}),
+			AccessControl:  &fakeTemplateVersionAccess{granted: tc.granted},
+			ResponseWriter: writer,
+		}
+
+		assert.Nil(t, h.templateVersions(request), "case %q", tc.name)
+		var actual []string
+		for _, obj := range writer.list.Objects {
+			actual = append(actual, obj.ID)
+		}
+		assert.Equal(t, tc.expected, actual, "case %q", tc.name)
+	}
+}
@@ -0,0 +1,297 @@
+package vm
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/utils/pointer"
+	kv1 "kubevirt.io/client-go/api/v1"
+
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	vmNameLabel = "harvesterhci.io/vm-name"
+
+	maxHostnameLength = 63
+)
+
+var userDataHostname = regexp.MustCompile(`(?m)^hostname:.*$`)
+
+// cloneVM creates a copy of the VM whose volumes are CSI clones of the VM volumes. The clone is stopped until its
+// volumes are cloned, the VM controller tracks the progress in the clone annotations and starts it if requested.
+func (h *vmActionHandler) cloneVM(namespace, name string, input CloneInput) error {
+	vm, err := h.vmCache.Get(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	pvcs := map[string]*corev1.PersistentVolumeClaim{}
+	for _, claimName := range getClonedClaimNames(vm) {
+		pvc, err := h.pvcCache.Get(namespace, claimName)
+		if err != nil {
+			return err
+		}
+		pvcs[claimName] = pvc
+	}
+	clone, err := getClonedVM(vm, pvcs, input)
+	if err != nil {
+		return err
+	}
+
+	secrets, err := h.getClonedSecrets(vm, clone)
+	if err != nil {
+		return err
+	}
+	created, err := h.vms.Create(clone)
+	if err != nil {
+		return err
+	}
+	// the secrets are owned by the clone so that they are deleted with it, the clone is removed along with the
+	// created secrets if a secret fails as it can't boot without them
+	for _, secret := range secrets {
+		secret.OwnerReferences = []metav1.OwnerReference{
+			*metav1.NewControllerRef(created, kv1.VirtualMachineGroupVersionKind),
+		}
+		if _, err := h.secrets.Create(secret); err != nil {
+			propagation := metav1.DeletePropagationForeground
+			if deleteErr := h.vms.Delete(created.Namespace, created.Name, &metav1.DeleteOptions{PropagationPolicy: &propagation}); deleteErr != nil && !apierrors.IsNotFound(deleteErr) {
+				logrus.Errorf("failed to delete the clone %s/%s: %v", created.Namespace, created.Name, deleteErr)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// getClonedVM returns the clone of the VM. The PVCs of the VM volumes and the volumeClaimTemplates annotation are
+// replaced by new volume claim templates whose data source is the original PVC, the VM controller creates them.
+func getClonedVM(vm *kv1.VirtualMachine, pvcs map[string]*corev1.PersistentVolumeClaim, input CloneInput) (*kv1.VirtualMachine, error) {
+	if vm.Spec.Template == nil {
+		return nil, fmt.Errorf("virtual machine %s/%s has no template", vm.Namespace, vm.Name)
+	}
+
+	// removeMacAddresses copies the VM so that the cached VM isn't modified
+	source := removeMacAddresses(vm)
+	clone := &kv1.VirtualMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        input.TargetName,
+			Namespace:   vm.Namespace,
+			Labels:      source.ObjectMeta.Labels,
+			Annotations: map[string]string{},
+		},
+		Spec: source.Spec,
+	}
+	for key, value := range source.ObjectMeta.Annotations {
+		// the annotations of KubeVirt and of the operations on the source VM are not copied
+		if strings.HasPrefix(key, "kubevirt.io/") || key == util.RemovedPVCsAnnotationKey || key == util.AnnotationCloneRunning ||
+			key == util.AnnotationPendingResize {
+			continue
+		}
+		clone.Annotations[key] = value
+	}
+	clone.Annotations[util.AnnotationCloneSource] = ref.Construct(vm.Namespace, vm.Name)
+	clone.Annotations[util.AnnotationCloneProgress] = "0"
+	if input.Running {
+		clone.Annotations[util.AnnotationCloneRunning] = "true"
+	}
+	clone.Spec.Running = pointer.BoolPtr(false)
+	clone.Spec.RunStrategy = nil
+
+	template := clone.Spec.Template
+	if _, ok := template.ObjectMeta.Labels[vmNameLabel]; ok {
+		template.ObjectMeta.Labels[vmNameLabel] = input.TargetName
+	}
+	hostname := getCloneHostname(input.TargetName)
+	template.Spec.Hostname = hostname
+
+	var templates []*corev1.PersistentVolumeClaim
+	if annotation := vm.Annotations[util.AnnotationVolumeClaimTemplates]; annotation != "" {
+		if err := json.Unmarshal([]byte(annotation), &templates); err != nil {
+			return nil, err
+		}
+	}
+	claimNames := map[string]string{}
+	var cloneTemplates []*corev1.PersistentVolumeClaim
+	for _, claimName := range getClonedClaimNames(vm) {
+		pvc, ok := pvcs[claimName]
+		if !ok {
+			return nil, fmt.Errorf("volume %s/%s is not found", vm.Namespace, claimName)
+		}
+		claimNames[claimName] = fmt.Sprintf("%s-%s-%s", input.TargetName, getVolumeName(vm, claimName), rand.String(5))
+		cloneTemplates = append(cloneTemplates, getCloneVolumeClaimTemplate(pvc, findVolumeClaimTemplate(templates, claimName), claimNames[claimName]))
+	}
+	if len(cloneTemplates) > 0 {
+		data, err := json.Marshal(cloneTemplates)
+		if err != nil {
+			return nil, err
+		}
+		clone.Annotations[util.AnnotationVolumeClaimTemplates] = string(data)
+	} else {
+		delete(clone.Annotations, util.AnnotationVolumeClaimTemplates)
+	}
+
+	for i, volume := range template.Spec.Volumes {
+		switch {
+		case volume.PersistentVolumeClaim != nil:
+			template.Spec.Volumes[i].PersistentVolumeClaim.ClaimName = claimNames[volume.PersistentVolumeClaim.ClaimName]
+		case volume.CloudInitNoCloud != nil:
+			volume.CloudInitNoCloud.UserData = setUserDataHostname(volume.CloudInitNoCloud.UserData, hostname)
+		case volume.CloudInitConfigDrive != nil:
+			volume.CloudInitConfigDrive.UserData = setUserDataHostname(volume.CloudInitConfigDrive.UserData, hostname)
+		}
+	}
+	return clone, nil
+}
+
+// getClonedClaimNames returns the PVCs of the VM volumes and the PVCs of the volumeClaimTemplates annotation
+func getClonedClaimNames(vm *kv1.VirtualMachine) []string {
+	var names []string
+	seen := map[string]bool{}
+	if vm.Spec.Template != nil {
+		for _, volume := range vm.Spec.Template.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil && !seen[volume.PersistentVolumeClaim.ClaimName] {
+				seen[volume.PersistentVolumeClaim.ClaimName] = true
+				names = append(names, volume.PersistentVolumeClaim.ClaimName)
+			}
+		}
+	}
+	var templates []*corev1.PersistentVolumeClaim
+	if err := json.Unmarshal([]byte(vm.Annotations[util.AnnotationVolumeClaimTemplates]), &templates); err == nil {
+		for _, template := range templates {
+			if !seen[template.Name] {
+				seen[template.Name] = true
+				names = append(names, template.Name)
+			}
+		}
+	}
+	return names
+}
+
+func findVolumeClaimTemplate(templates []*corev1.PersistentVolumeClaim, name string) *corev1.PersistentVolumeClaim {
+	for _, template := range templates {
+		if template.Name == name {
+			return template
+		}
+	}
+	return nil
+}
+
+// getVolumeName returns the name of the VM volume of the PVC, or the PVC name if no volume uses it
+func getVolumeName(vm *kv1.VirtualMachine, claimName string) string {
+	for _, volume := range vm.Spec.Template.Spec.Volumes {
+		if volume.PersistentVolumeClaim != nil && volume.PersistentVolumeClaim.ClaimName == claimName {
+			return volume.Name
+		}
+	}
+	return claimName
+}
+
+// getCloneVolumeClaimTemplate returns the template of a CSI clone of the PVC, the clone keeps the annotations of the
+// volume claim template of the PVC such as the image ID. A clone can't be smaller than its source.
+func getCloneVolumeClaimTemplate(pvc, template *corev1.PersistentVolumeClaim, name string) *corev1.PersistentVolumeClaim {
+	clone := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      pvc.Spec.AccessModes,
+			Resources:        *pvc.Spec.Resources.DeepCopy(),
+			StorageClassName: pvc.Spec.StorageClassName,
+			VolumeMode:       pvc.Spec.VolumeMode,
+			DataSource: &corev1.TypedLocalObjectReference{
+				Kind: "PersistentVolumeClaim",
+				Name: pvc.Name,
+			},
+		},
+	}
+	if template != nil && len(template.Annotations) > 0 {
+		clone.Annotations = template.DeepCopy().Annotations
+	}
+	if capacity, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok {
+		if request := clone.Spec.Resources.Requests[corev1.ResourceStorage]; request.Cmp(capacity) < 0 {
+			if clone.Spec.Resources.Requests == nil {
+				clone.Spec.Resources.Requests = corev1.ResourceList{}
+			}
+			clone.Spec.Resources.Requests[corev1.ResourceStorage] = capacity
+		}
+	}
+	return clone
+}
+
+// getClonedSecrets returns the copies of the cloud-init secrets of the VM with the hostname of the clone, the secret
+// references of the clone are replaced by the copies
+func (h *vmActionHandler) getClonedSecrets(vm, clone *kv1.VirtualMachine) ([]*corev1.Secret, error) {
+	var secrets []*corev1.Secret
+	copySecret := func(reference *corev1.LocalObjectReference, volumeName string) error {
+		if reference == nil {
+			return nil
+		}
+		secret, err := h.secretCache.Get(vm.Namespace, reference.Name)
+		if err != nil {
+			return err
+		}
+		copied := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-%s-%s", clone.Name, volumeName, rand.String(5)),
+				Namespace: clone.Namespace,
+				Labels:    secret.Labels,
+			},
+			Type: secret.Type,
+			Data: map[string][]byte{},
+		}
+		for key, value := range secret.Data {
+			if strings.EqualFold(key, "userdata") {
+				value = []byte(setUserDataHostname(string(value), clone.Spec.Template.Spec.Hostname))
+			}
+			copied.Data[key] = value
+		}
+		reference.Name = copied.Name
+		secrets = append(secrets, copied)
+		return nil
+	}
+
+	for _, volume := range clone.Spec.Template.Spec.Volumes {
+		var err error
+		switch {
+		case volume.CloudInitNoCloud != nil:
+			if err = copySecret(volume.CloudInitNoCloud.UserDataSecretRef, volume.Name); err == nil {
+				err = copySecret(volume.CloudInitNoCloud.NetworkDataSecretRef, volume.Name)
+			}
+		case volume.CloudInitConfigDrive != nil:
+			if err = copySecret(volume.CloudInitConfigDrive.UserDataSecretRef, volume.Name); err == nil {
+				err = copySecret(volume.CloudInitConfigDrive.NetworkDataSecretRef, volume.Name)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return secrets, nil
+}
+
+// getCloneHostname returns a DNS label derived from the VM name, the VM name may contain dots
+func getCloneHostname(name string) string {
+	hostname := strings.ReplaceAll(name, ".", "-")
+	if len(hostname) > maxHostnameLength {
+		hostname = hostname[:maxHostnameLength]
+	}
+	return strings.Trim(hostname, "-")
+}
+
+// setUserDataHostname replaces the hostname of the cloud-config user data. The instance-id of the KubeVirt
+// cloud-init data sources is derived from the VM, cloud-init runs the per-instance modules again in the clone.
+func setUserDataHostname(userData, hostname string) string {
+	if userData == "" {
+		return userData
+	}
+	return userDataHostname.ReplaceAllString(userData, "hostname: "+hostname)
+}
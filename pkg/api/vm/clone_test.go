@@ -0,0 +1,122 @@
+package vm
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	kv1 "kubevirt.io/client-go/api/v1"
+
+	"github.com/harvester/harvester/pkg/util"
+)
+
+func TestGetClonedVM(t *testing.T) {
+	vm := &kv1.VirtualMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "source",
+			Annotations: map[string]string{
+				util.AnnotationVolumeClaimTemplates:       `[{"metadata":{"name":"source-rootdisk","annotations":{"harvesterhci.io/imageId":"default/image"}}}]`,
+				util.RemovedPVCsAnnotationKey:             "source-rootdisk",
+				"kubevirt.io/latest-observed-api-version": "v1",
+				"harvesterhci.io/sshNames":                `["default/key"]`,
+			},
+		},
+		Spec: kv1.VirtualMachineSpec{
+			Running: pointer.BoolPtr(true),
+			Template: &kv1.VirtualMachineInstanceTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{vmNameLabel: "source"},
+				},
+				Spec: kv1.VirtualMachineInstanceSpec{
+					Domain: kv1.DomainSpec{
+						Devices: kv1.Devices{
+							Interfaces: []kv1.Interface{{Name: "default", MacAddress: "52:54:00:00:00:01"}},
+						},
+					},
+					Volumes: []kv1.Volume{
+						{
+							Name: "rootdisk",
+							VolumeSource: kv1.VolumeSource{
+								PersistentVolumeClaim: &kv1.PersistentVolumeClaimVolumeSource{
+									PersistentVolumeClaimVolumeSource: corev1.PersistentVolumeClaimVolumeSource{ClaimName: "source-rootdisk"},
+								},
+							},
+						},
+						{
+							Name: "cloudinitdisk",
+							VolumeSource: kv1.VolumeSource{
+								CloudInitNoCloud: &kv1.CloudInitNoCloudSource{
+									UserData: "#cloud-config\nhostname: source\npassword: secret\n",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	storageClassName := "longhorn-default-image"
+	pvcs := map[string]*corev1.PersistentVolumeClaim{
+		"source-rootdisk": {
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "source-rootdisk"},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+				StorageClassName: &storageClassName,
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+				},
+				VolumeName: "pvc-1234",
+			},
+			Status: corev1.PersistentVolumeClaimStatus{
+				Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("20Gi")},
+			},
+		},
+	}
+
+	clone, err := getClonedVM(vm, pvcs, CloneInput{TargetName: "clone.1", Running: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "default", clone.Namespace)
+	assert.Equal(t, "clone.1", clone.Name)
+	assert.False(t, *clone.Spec.Running, "the clone is started once its volumes are cloned")
+	assert.Equal(t, "default/source", clone.Annotations[util.AnnotationCloneSource])
+	assert.Equal(t, "0", clone.Annotations[util.AnnotationCloneProgress])
+	assert.Equal(t, "true", clone.Annotations[util.AnnotationCloneRunning])
+	assert.Equal(t, `["default/key"]`, clone.Annotations["harvesterhci.io/sshNames"])
+	assert.NotContains(t, clone.Annotations, util.RemovedPVCsAnnotationKey)
+	assert.NotContains(t, clone.Annotations, "kubevirt.io/latest-observed-api-version")
+
+	spec := clone.Spec.Template.Spec
+	assert.Equal(t, "clone.1", clone.Spec.Template.ObjectMeta.Labels[vmNameLabel])
+	assert.Equal(t, "clone-1", spec.Hostname)
+	assert.Equal(t, "", spec.Domain.Devices.Interfaces[0].MacAddress)
+	assert.Equal(t, "#cloud-config\nhostname: clone-1\npassword: secret\n", spec.Volumes[1].CloudInitNoCloud.UserData)
+
+	var templates []*corev1.PersistentVolumeClaim
+	assert.Nil(t, json.Unmarshal([]byte(clone.Annotations[util.AnnotationVolumeClaimTemplates]), &templates))
+	assert.Len(t, templates, 1)
+	claimName := spec.Volumes[0].PersistentVolumeClaim.ClaimName
+	assert.True(t, strings.HasPrefix(claimName, "clone.1-rootdisk-"), claimName)
+	assert.Equal(t, claimName, templates[0].Name)
+	assert.Equal(t, "default/image", templates[0].Annotations[util.AnnotationImageID])
+	assert.Equal(t, &corev1.TypedLocalObjectReference{Kind: "PersistentVolumeClaim", Name: "source-rootdisk"}, templates[0].Spec.DataSource)
+	assert.Equal(t, &storageClassName, templates[0].Spec.StorageClassName)
+	assert.Equal(t, "", templates[0].Spec.VolumeName)
+	request := templates[0].Spec.Resources.Requests[corev1.ResourceStorage]
+	assert.Equal(t, "20Gi", request.String(), "the clone is as large as the source volume")
+
+	// the cached VM is not modified
+	assert.Equal(t, "source-rootdisk", vm.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName)
+	assert.Equal(t, "52:54:00:00:00:01", vm.Spec.Template.Spec.Domain.Devices.Interfaces[0].MacAddress)
+}
+
+func TestGetCloneHostname(t *testing.T) {
+	assert.Equal(t, "vm-1", getCloneHostname("vm-1"))
+	assert.Equal(t, "vm-example-com", getCloneHostname("vm.example.com"))
+	assert.Equal(t, strings.Repeat("a", 62), getCloneHostname(strings.Repeat("a", 62)+"-b"))
+}
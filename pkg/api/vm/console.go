@@ -0,0 +1,321 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/settings"
+)
+
+const (
+	consoleLink = "console"
+	vncLink     = "vnc"
+
+	// kubevirtSubprotocol is the websocket subprotocol of the KubeVirt console and vnc subresources
+	kubevirtSubprotocol = "plain.kubevirt.io"
+
+	consoleWriteTimeout = 10 * time.Second
+	// recordingPruneInterval is the interval to delete the recordings older than the retention
+	recordingPruneInterval = time.Hour
+)
+
+var unsafeFileNameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// consoleProxy proxies the websocket sessions of the console and vnc links to the KubeVirt console and vnc
+// subresources of the VMI, so that users who can update the VM reach its consoles without the KubeVirt subresource
+// permissions. The sessions are closed when the client is idle and the sessions of a VM are limited by the settings.
+// The sessions are counted by each apiserver pod, so a VM may have up to the limit times the replicas sessions.
+type consoleProxy struct {
+	restConfig                *rest.Config
+	virtSubresourceRestClient rest.Interface
+	// recordingDir stores the serial console output of the sessions if the recording is enabled
+	recordingDir string
+	upgrader     websocket.Upgrader
+
+	lock     sync.Mutex
+	sessions map[string]int
+}
+
+func newConsoleProxy(restConfig *rest.Config, virtSubresourceRestClient rest.Interface, recordingDir string) *consoleProxy {
+	return &consoleProxy{
+		restConfig:                restConfig,
+		virtSubresourceRestClient: virtSubresourceRestClient,
+		recordingDir:              recordingDir,
+		upgrader: websocket.Upgrader{
+			Subprotocols: []string{kubevirtSubprotocol, "binary"},
+		},
+		sessions: map[string]int{},
+	}
+}
+
+// serve proxies the websocket session of the request, the errors are returned before the session is established
+func (p *consoleProxy) serve(request *types.APIRequest) error {
+	vmID := ref.Construct(request.Namespace, request.Name)
+	if !p.acquire(vmID, settings.VMConsoleMaxSessions.GetInt()) {
+		return apierror.NewAPIError(validation.MaxLimitExceeded,
+			fmt.Sprintf("the VM %s has reached the limit of the concurrent console sessions", vmID))
+	}
+	defer p.release(vmID)
+
+	backend, err := p.dial(request)
+	if err != nil {
+		return err
+	}
+	defer backend.Close()
+
+	client, err := p.upgrader.Upgrade(request.Response, request.Request, nil)
+	if err != nil {
+		// the upgrader responds to the client
+		logrus.Debugf("failed to upgrade the %s session of VM %s: %v", request.Link, vmID, err)
+		return nil
+	}
+	defer client.Close()
+
+	var recording io.Writer
+	if request.Link == consoleLink && p.recordingDir != "" && settings.VMConsoleRecording.Get() == "true" {
+		file, err := p.createRecording(request.Namespace, request.Name, request.GetUser(), time.Now())
+		if err != nil {
+			logrus.Errorf("failed to record the console session of VM %s: %v", vmID, err)
+		} else {
+			defer file.Close()
+			recording = file
+		}
+	}
+
+	idleTimeout := time.Duration(settings.VMConsoleIdleTimeoutMinutes.GetInt()) * time.Minute
+	logrus.Debugf("user %s opened a %s session of VM %s", request.GetUser(), request.Link, vmID)
+	proxyConsole(client, backend, idleTimeout, recording)
+	logrus.Debugf("user %s closed a %s session of VM %s", request.GetUser(), request.Link, vmID)
+	return nil
+}
+
+// acquire counts a new session of the VM unless the VM has max sessions, max 0 means no limit
+func (p *consoleProxy) acquire(vmID string, max int) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if max > 0 && p.sessions[vmID] >= max {
+		return false
+	}
+	p.sessions[vmID]++
+	return true
+}
+
+func (p *consoleProxy) release(vmID string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.sessions[vmID]--; p.sessions[vmID] <= 0 {
+		delete(p.sessions, vmID)
+	}
+}
+
+// dial connects to the KubeVirt subresource of the VMI with the credentials of Harvester
+func (p *consoleProxy) dial(request *types.APIRequest) (*websocket.Conn, error) {
+	u := p.virtSubresourceRestClient.Get().Namespace(request.Namespace).Resource(vmiResource).
+		Name(request.Name).SubResource(request.Link).URL()
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	case "http":
+		u.Scheme = "ws"
+	}
+
+	tlsConfig, err := rest.TLSConfigFor(p.restConfig)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		TLSClientConfig:  tlsConfig,
+		Subprotocols:     []string{kubevirtSubprotocol},
+		HandshakeTimeout: 45 * time.Second,
+	}
+	dialRoundTripper := &websocketRoundTripper{dialer: dialer}
+	roundTripper, err := rest.HTTPWrappersForConfig(p.restConfig, dialRoundTripper)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(request.Context(), http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := roundTripper.RoundTrip(req)
+	if err != nil {
+		if resp != nil && resp.Body != nil {
+			defer resp.Body.Close()
+			if message, _ := ioutil.ReadAll(resp.Body); len(message) > 0 {
+				return nil, apierror.NewAPIError(validation.ErrorCode{Code: "ConsoleUnavailable", Status: resp.StatusCode},
+					fmt.Sprintf("failed to connect to the %s of VM %s/%s: %s", request.Link, request.Namespace, request.Name, message))
+			}
+		}
+		return nil, fmt.Errorf("failed to connect to the %s of VM %s/%s: %v", request.Link, request.Namespace, request.Name, err)
+	}
+	return dialRoundTripper.conn, nil
+}
+
+// websocketRoundTripper dials the websocket of the request, it's wrapped by the round trippers adding the
+// credentials of the rest config
+type websocketRoundTripper struct {
+	dialer *websocket.Dialer
+	conn   *websocket.Conn
+}
+
+func (rt *websocketRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	conn, resp, err := rt.dialer.DialContext(req.Context(), req.URL.String(), req.Header)
+	if err != nil {
+		return resp, err
+	}
+	rt.conn = conn
+	return resp, nil
+}
+
+// createRecording creates the recording file of a console session, the files are grouped by VM
+func (p *consoleProxy) createRecording(namespace, name, user string, start time.Time) (*os.File, error) {
+	dir := filepath.Join(p.recordingDir, namespace, name)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(filepath.Join(dir, getRecordingFileName(user, start)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+}
+
+// pruneRecordings deletes the recordings older than the days of the vm-console-recording-retention-days setting
+// every recordingPruneInterval until the context is done. The replicas sharing the recording volume may prune it
+// at the same time, the files deleted by the others are ignored.
+func (p *consoleProxy) pruneRecordings(ctx context.Context) {
+	if p.recordingDir == "" {
+		return
+	}
+	wait.Until(func() {
+		days := settings.VMConsoleRecordingRetention.GetInt()
+		if days <= 0 {
+			return
+		}
+		if err := pruneRecordings(p.recordingDir, time.Now().Add(-time.Duration(days)*24*time.Hour)); err != nil {
+			logrus.Errorf("failed to prune the console recordings: %v", err)
+		}
+	}, recordingPruneInterval, ctx.Done())
+}
+
+// pruneRecordings deletes the recordings last written before the time and the VM directories left empty
+func pruneRecordings(recordingDir string, before time.Time) error {
+	var dirs []string
+	err := filepath.Walk(recordingDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			if path != recordingDir {
+				dirs = append(dirs, path)
+			}
+			return nil
+		}
+		if info.ModTime().Before(before) {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// the VM directories are walked after their namespace directories, remove them first
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if entries, err := ioutil.ReadDir(dirs[i]); err == nil && len(entries) == 0 {
+			_ = os.Remove(dirs[i])
+		}
+	}
+	return nil
+}
+
+func getRecordingFileName(user string, start time.Time) string {
+	if user == "" {
+		user = "unknown"
+	}
+	return fmt.Sprintf("%s-%s.log", start.UTC().Format("20060102T150405Z"), unsafeFileNameChars.ReplaceAllString(user, "_"))
+}
+
+// proxyConsole copies the messages between the client and the KubeVirt subresource until either of them closes the
+// session or the client sends no message for the idle timeout. The output of the VM doesn't keep the session open,
+// since a VM printing to its console would keep an abandoned session forever. The output of the VM is written to the
+// recording if any.
+func proxyConsole(client, backend *websocket.Conn, idleTimeout time.Duration, recording io.Writer) {
+	var closeOnce sync.Once
+	closeSession := func(code int, reason string) {
+		closeOnce.Do(func() {
+			deadline := time.Now().Add(consoleWriteTimeout)
+			_ = client.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), deadline)
+			client.Close()
+			backend.Close()
+		})
+	}
+
+	var idleTimer *time.Timer
+	if idleTimeout > 0 {
+		idleTimer = time.AfterFunc(idleTimeout, func() {
+			closeSession(websocket.CloseNormalClosure, "the session is idle")
+		})
+		defer idleTimer.Stop()
+	}
+	touch := func() {
+		if idleTimer != nil {
+			idleTimer.Reset(idleTimeout)
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer closeSession(websocket.CloseNormalClosure, "")
+		for {
+			_, data, err := client.ReadMessage()
+			if err != nil {
+				return
+			}
+			touch()
+			if err := backend.WriteMessage(websocket.BinaryMessage, data); err != nil {
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		defer closeSession(websocket.CloseGoingAway, "the VM console is disconnected")
+		for {
+			messageType, data, err := backend.ReadMessage()
+			if err != nil {
+				return
+			}
+			if recording != nil {
+				if _, err := recording.Write(data); err != nil {
+					logrus.Errorf("failed to record the console session: %v", err)
+					recording = nil
+				}
+			}
+			if err := client.WriteMessage(messageType, data); err != nil {
+				return
+			}
+		}
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,183 @@
+package vm
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// newWebsocket returns the client side of a websocket whose server side is served by the handler
+func newWebsocket(t *testing.T, handler func(conn *websocket.Conn)) *websocket.Conn {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		handler(conn)
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	assert.Nil(t, err, "websocket should be dialed")
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// newConsoleSession returns the client side of a console session proxied to a VM console echoing the input
+func newConsoleSession(t *testing.T, idleTimeout time.Duration, recording *bytes.Buffer) (*websocket.Conn, chan struct{}) {
+	backend := newWebsocket(t, func(conn *websocket.Conn) {
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(websocket.BinaryMessage, append([]byte("echo: "), data...)); err != nil {
+				return
+			}
+		}
+	})
+	done := make(chan struct{})
+	client := newWebsocket(t, func(conn *websocket.Conn) {
+		defer close(done)
+		if recording != nil {
+			proxyConsole(conn, backend, idleTimeout, recording)
+		} else {
+			proxyConsole(conn, backend, idleTimeout, nil)
+		}
+	})
+	return client, done
+}
+
+func TestProxyConsole(t *testing.T) {
+	var recording bytes.Buffer
+	client, done := newConsoleSession(t, time.Minute, &recording)
+
+	for _, input := range []string{"ls", "uname"} {
+		assert.Nil(t, client.WriteMessage(websocket.BinaryMessage, []byte(input)))
+		_, output, err := client.ReadMessage()
+		assert.Nil(t, err)
+		assert.Equal(t, "echo: "+input, string(output))
+	}
+	assert.Nil(t, client.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")))
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the session should be closed with the client")
+	}
+	assert.Equal(t, "echo: lsecho: uname", recording.String(), "the output of the VM is recorded")
+}
+
+func TestProxyConsoleIdleTimeout(t *testing.T) {
+	client, done := newConsoleSession(t, 100*time.Millisecond, nil)
+
+	_, _, err := client.ReadMessage()
+	closeErr, ok := err.(*websocket.CloseError)
+	assert.True(t, ok, "the session should be closed, got %v", err)
+	if ok {
+		assert.Equal(t, websocket.CloseNormalClosure, closeErr.Code)
+		assert.Equal(t, "the session is idle", closeErr.Text)
+	}
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the idle session should be closed")
+	}
+}
+
+func TestProxyConsoleIdleTimeoutWithOutput(t *testing.T) {
+	// the VM keeps printing to its console, which doesn't keep the session open
+	backend := newWebsocket(t, func(conn *websocket.Conn) {
+		for {
+			if err := conn.WriteMessage(websocket.BinaryMessage, []byte("log")); err != nil {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+	done := make(chan struct{})
+	client := newWebsocket(t, func(conn *websocket.Conn) {
+		defer close(done)
+		proxyConsole(conn, backend, 100*time.Millisecond, nil)
+	})
+
+	for {
+		_, _, err := client.ReadMessage()
+		if err == nil {
+			continue
+		}
+		closeErr, ok := err.(*websocket.CloseError)
+		assert.True(t, ok, "the session should be closed, got %v", err)
+		if ok {
+			assert.Equal(t, "the session is idle", closeErr.Text)
+		}
+		break
+	}
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the idle session should be closed")
+	}
+}
+
+func TestConsoleProxySessionLimit(t *testing.T) {
+	p := newConsoleProxy(nil, nil, "")
+	assert.True(t, p.acquire("default/vm1", 2))
+	assert.True(t, p.acquire("default/vm1", 2))
+	assert.False(t, p.acquire("default/vm1", 2), "the VM has reached the limit")
+	assert.True(t, p.acquire("default/vm2", 2), "the limit is per VM")
+
+	p.release("default/vm1")
+	assert.True(t, p.acquire("default/vm1", 2), "a released session can be reused")
+	assert.True(t, p.acquire("default/vm1", 0), "0 means no limit")
+
+	for i := 0; i < 4; i++ {
+		p.release("default/vm1")
+	}
+	p.release("default/vm2")
+	assert.Empty(t, p.sessions)
+}
+
+func TestGetRecordingFileName(t *testing.T) {
+	start := time.Date(2021, 11, 2, 8, 30, 0, 0, time.UTC)
+	assert.Equal(t, "20211102T083000Z-admin.log", getRecordingFileName("admin", start))
+	assert.Equal(t, "20211102T083000Z-system_serviceaccount_default_user.log", getRecordingFileName("system:serviceaccount:default:user", start))
+	assert.Equal(t, "20211102T083000Z-_.._etc_passwd.log", getRecordingFileName("/../etc/passwd", start))
+	assert.Equal(t, "20211102T083000Z-unknown.log", getRecordingFileName("", start))
+}
+
+func TestPruneRecordings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "console-recordings-")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Now()
+	files := map[string]time.Time{
+		"default/vm1/old.log": now.Add(-48 * time.Hour),
+		"default/vm1/new.log": now,
+		"default/vm2/old.log": now.Add(-48 * time.Hour),
+	}
+	for file, modTime := range files {
+		path := filepath.Join(dir, file)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0700))
+		assert.Nil(t, ioutil.WriteFile(path, []byte("log"), 0600))
+		assert.Nil(t, os.Chtimes(path, modTime, modTime))
+	}
+
+	assert.Nil(t, pruneRecordings(dir, now.Add(-24*time.Hour)))
+	assert.FileExists(t, filepath.Join(dir, "default/vm1/new.log"))
+	assert.NoFileExists(t, filepath.Join(dir, "default/vm1/old.log"))
+	assert.NoDirExists(t, filepath.Join(dir, "default/vm2"), "the empty VM directory is removed")
+	assert.DirExists(t, dir)
+}
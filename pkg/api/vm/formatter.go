@@ -6,24 +6,32 @@ import (
 	kv1 "kubevirt.io/client-go/api/v1"
 
 	"github.com/harvester/harvester/pkg/controller/master/migration"
+	"github.com/harvester/harvester/pkg/controller/master/virtualmachine"
 	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
 	"github.com/harvester/harvester/pkg/util"
 )
 
 const (
-	startVM        = "start"
-	stopVM         = "stop"
-	restartVM      = "restart"
-	pauseVM        = "pause"
-	unpauseVM      = "unpause"
-	ejectCdRom     = "ejectCdRom"
-	migrate        = "migrate"
-	abortMigration = "abortMigration"
-	backupVM       = "backup"
-	restoreVM      = "restore"
-	createTemplate = "createTemplate"
-	addVolume      = "addVolume"
-	removeVolume   = "removeVolume"
+	startVM          = "start"
+	stopVM           = "stop"
+	restartVM        = "restart"
+	pauseVM          = "pause"
+	unpauseVM        = "unpause"
+	ejectCdRom       = "ejectCdRom"
+	migrate          = "migrate"
+	abortMigration   = "abortMigration"
+	backupVM         = "backup"
+	restoreVM        = "restore"
+	restoreVolumes   = "restoreVolumes"
+	restorePreflight = "restorePreflight"
+	snapshotVM       = "snapshot"
+	revertToSnapshot = "revertToSnapshot"
+	deleteSnapshot   = "deleteSnapshot"
+	createTemplate   = "createTemplate"
+	cloneVM          = "clone"
+	resizeVM         = "resize"
+	addVolume        = "addVolume"
+	removeVolume     = "removeVolume"
 )
 
 type vmformatter struct {
@@ -31,18 +39,35 @@ type vmformatter struct {
 }
 
 func (vf *vmformatter) formatter(request *types.APIRequest, resource *types.RawResource) {
+	resource.Links[snapshotsLink] = request.URLBuilder.Link(resource.Schema, resource.ID, snapshotsLink)
+
 	// reset resource actions, because action map already be set when add actions handler,
 	// but current framework can't support use formatter to remove key from action map
 	resource.Actions = make(map[string]string, 1)
-	if request.AccessControl.CanUpdate(request, resource.APIObject, resource.Schema) != nil {
-		return
-	}
 
 	vm := &kv1.VirtualMachine{}
 	err := convert.ToObj(resource.APIObject.Data(), vm)
 	if err != nil {
 		return
 	}
+	if virtualmachine.IsRestartRequired(vm) {
+		// the VM still runs with its former CPU and memory, it is resized when it's restarted
+		resource.APIObject.Data().SetNested(true, "status", "restartRequired")
+	}
+
+	vmi := vf.getVMI(vm)
+	if vmi != nil && vmi.IsRunning() {
+		resource.Links[guestOSInfoLink] = request.URLBuilder.Link(resource.Schema, resource.ID, guestOSInfoLink)
+		resource.Links[filesystemsLink] = request.URLBuilder.Link(resource.Schema, resource.ID, filesystemsLink)
+		resource.Links[userListLink] = request.URLBuilder.Link(resource.Schema, resource.ID, userListLink)
+		if summary, err := convert.EncodeToMap(getGuestAgentSummary(vmi)); err == nil {
+			resource.APIObject.Data().SetNested(summary, "status", "guestAgent")
+		}
+	}
+
+	if request.AccessControl.CanUpdate(request, resource.APIObject, resource.Schema) != nil {
+		return
+	}
 
 	resource.AddAction(request, addVolume)
 	resource.AddAction(request, removeVolume)
@@ -51,7 +76,11 @@ func (vf *vmformatter) formatter(request *types.APIRequest, resource *types.RawR
 		resource.AddAction(request, ejectCdRom)
 	}
 
-	vmi := vf.getVMI(vm)
+	if vmi != nil && vmi.IsRunning() {
+		resource.Links[consoleLink] = request.URLBuilder.Link(resource.Schema, resource.ID, consoleLink)
+		resource.Links[vncLink] = request.URLBuilder.Link(resource.Schema, resource.ID, vncLink)
+	}
+
 	if vf.canStart(vm, vmi) {
 		resource.AddAction(request, startVM)
 	}
@@ -88,9 +117,34 @@ func (vf *vmformatter) formatter(request *types.APIRequest, resource *types.RawR
 		resource.AddAction(request, restoreVM)
 	}
 
+	resource.AddAction(request, restoreVolumes)
+	resource.AddAction(request, restorePreflight)
+
+	if vf.canDoBackup(vm, vmi) {
+		resource.AddAction(request, snapshotVM)
+	}
+
+	if vf.canDoRestore(vm, vmi) {
+		resource.AddAction(request, revertToSnapshot)
+	}
+
+	resource.AddAction(request, deleteSnapshot)
+
 	if vf.canCreateTemplate(vmi) {
 		resource.AddAction(request, createTemplate)
 	}
+
+	if canClone(vm) {
+		resource.AddAction(request, cloneVM)
+	}
+
+	resource.AddAction(request, resizeVM)
+}
+
+// canClone returns false until the volumes of a clone are cloned, the volumes can't be cloned again before
+func canClone(vm *kv1.VirtualMachine) bool {
+	progress, ok := vm.Annotations[util.AnnotationCloneProgress]
+	return !ok || progress == "100"
 }
 
 func canEjectCdRom(vm *kv1.VirtualMachine) bool {
@@ -0,0 +1,115 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/handlers"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	kv1 "kubevirt.io/client-go/api/v1"
+)
+
+const (
+	guestOSInfoLink = "guestosinfo"
+	filesystemsLink = "filesystems"
+	userListLink    = "userlist"
+)
+
+// guestAgentSubresources are the KubeVirt subresources of the VMI returning the guest agent data of the links
+var guestAgentSubresources = map[string]string{
+	guestOSInfoLink: "guestosinfo",
+	filesystemsLink: "filesystemlist",
+	userListLink:    "userlist",
+}
+
+// guestAgentByID responds the guest agent data of the VMI to the users who can get the VM
+func (h *vmLinkHandler) guestAgentByID(request *types.APIRequest) (types.APIObject, error) {
+	if _, err := handlers.ByIDHandler(request); err != nil {
+		return types.APIObject{}, err
+	}
+	data, err := h.getGuestAgentData(request.Context(), request.Namespace, request.Name, request.Link)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+
+	request.Response.Header().Set("Content-Type", "application/json")
+	request.Response.WriteHeader(http.StatusOK)
+	_, _ = request.Response.Write(data)
+	return types.APIObject{}, validation.ErrComplete
+}
+
+func (h *vmLinkHandler) getGuestAgentData(ctx context.Context, namespace, name, link string) ([]byte, error) {
+	subresource, ok := guestAgentSubresources[link]
+	if !ok {
+		return nil, apierror.NewAPIError(validation.NotFound, fmt.Sprintf("unknown link %q", link))
+	}
+	data, err := h.virtSubresourceRestClient.Get().Namespace(namespace).Resource(vmiResource).Name(name).
+		SubResource(subresource).Do(ctx).Raw()
+	if status, ok := err.(apierrors.APIStatus); ok {
+		// e.g. KubeVirt responds a conflict if the guest agent isn't connected
+		return nil, apierror.NewAPIError(validation.ErrorCode{Code: string(status.Status().Reason), Status: int(status.Status().Code)},
+			fmt.Sprintf("failed to get the %s of VM %s/%s: %s", link, namespace, name, status.Status().Message))
+	} else if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GuestAgentSummary is the summary of the guest agent data added to the status of the formatted VMs
+type GuestAgentSummary struct {
+	Connected  bool                  `json:"connected"`
+	PrimaryIP  string                `json:"primaryIP,omitempty"`
+	OSName     string                `json:"osName,omitempty"`
+	OSVersion  string                `json:"osVersion,omitempty"`
+	Interfaces []GuestAgentInterface `json:"interfaces,omitempty"`
+}
+
+type GuestAgentInterface struct {
+	Name          string   `json:"name,omitempty"`
+	InterfaceName string   `json:"interfaceName,omitempty"`
+	MAC           string   `json:"mac,omitempty"`
+	IPs           []string `json:"ips,omitempty"`
+}
+
+// getGuestAgentSummary returns the guest agent summary of the VMI. The primary IP is the first IP of the interfaces
+// of the VM networks, the interfaces only known by the guest agent such as docker bridges come later.
+func getGuestAgentSummary(vmi *kv1.VirtualMachineInstance) GuestAgentSummary {
+	summary := GuestAgentSummary{
+		Connected: vmiAgentConnected.IsTrue(vmi),
+		OSName:    vmi.Status.GuestOSInfo.PrettyName,
+		OSVersion: vmi.Status.GuestOSInfo.VersionID,
+	}
+	if summary.OSName == "" {
+		summary.OSName = vmi.Status.GuestOSInfo.Name
+	}
+
+	var guestOnlyIP string
+	for _, iface := range vmi.Status.Interfaces {
+		ips := iface.IPs
+		if len(ips) == 0 && iface.IP != "" {
+			ips = []string{iface.IP}
+		}
+		summary.Interfaces = append(summary.Interfaces, GuestAgentInterface{
+			Name:          iface.Name,
+			InterfaceName: iface.InterfaceName,
+			MAC:           iface.MAC,
+			IPs:           ips,
+		})
+		if len(ips) == 0 {
+			continue
+		}
+		if iface.Name != "" && summary.PrimaryIP == "" {
+			summary.PrimaryIP = ips[0]
+		} else if guestOnlyIP == "" {
+			guestOnlyIP = ips[0]
+		}
+	}
+	if summary.PrimaryIP == "" {
+		summary.PrimaryIP = guestOnlyIP
+	}
+	return summary
+}
@@ -0,0 +1,157 @@
+package vm
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest/fake"
+	kv1 "kubevirt.io/client-go/api/v1"
+
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/scheme"
+)
+
+func TestGetGuestAgentSummary(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		status   kv1.VirtualMachineInstanceStatus
+		expected GuestAgentSummary
+	}{
+		{
+			name: "agent connected",
+			status: kv1.VirtualMachineInstanceStatus{
+				Conditions: []kv1.VirtualMachineInstanceCondition{
+					{Type: kv1.VirtualMachineInstanceAgentConnected, Status: corev1.ConditionTrue},
+				},
+				GuestOSInfo: kv1.VirtualMachineInstanceGuestOSInfo{Name: "Ubuntu", PrettyName: "Ubuntu 20.04.3 LTS", VersionID: "20.04"},
+				Interfaces: []kv1.VirtualMachineInstanceNetworkInterface{
+					{InterfaceName: "docker0", IP: "172.17.0.1", IPs: []string{"172.17.0.1"}},
+					{Name: "default", InterfaceName: "enp1s0", MAC: "52:54:00:00:00:01", IP: "10.0.2.2", IPs: []string{"10.0.2.2", "fe80::1"}},
+				},
+			},
+			expected: GuestAgentSummary{
+				Connected: true,
+				PrimaryIP: "10.0.2.2",
+				OSName:    "Ubuntu 20.04.3 LTS",
+				OSVersion: "20.04",
+				Interfaces: []GuestAgentInterface{
+					{InterfaceName: "docker0", IPs: []string{"172.17.0.1"}},
+					{Name: "default", InterfaceName: "enp1s0", MAC: "52:54:00:00:00:01", IPs: []string{"10.0.2.2", "fe80::1"}},
+				},
+			},
+		},
+		{
+			name: "agent not installed",
+			status: kv1.VirtualMachineInstanceStatus{
+				Interfaces: []kv1.VirtualMachineInstanceNetworkInterface{
+					{Name: "default", MAC: "52:54:00:00:00:01", IP: "10.0.2.2"},
+				},
+			},
+			expected: GuestAgentSummary{
+				PrimaryIP: "10.0.2.2",
+				Interfaces: []GuestAgentInterface{
+					{Name: "default", MAC: "52:54:00:00:00:01", IPs: []string{"10.0.2.2"}},
+				},
+			},
+		},
+		{
+			name: "only guest interfaces have IPs",
+			status: kv1.VirtualMachineInstanceStatus{
+				GuestOSInfo: kv1.VirtualMachineInstanceGuestOSInfo{Name: "Microsoft Windows"},
+				Interfaces: []kv1.VirtualMachineInstanceNetworkInterface{
+					{Name: "default", MAC: "52:54:00:00:00:01"},
+					{InterfaceName: "Ethernet 2", IPs: []string{"192.168.0.10"}},
+				},
+			},
+			expected: GuestAgentSummary{
+				PrimaryIP: "192.168.0.10",
+				OSName:    "Microsoft Windows",
+				Interfaces: []GuestAgentInterface{
+					{Name: "default", MAC: "52:54:00:00:00:01"},
+					{InterfaceName: "Ethernet 2", IPs: []string{"192.168.0.10"}},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		vmi := &kv1.VirtualMachineInstance{Status: tc.status}
+		assert.Equal(t, tc.expected, getGuestAgentSummary(vmi), "case %q", tc.name)
+	}
+}
+
+func TestGetGuestAgentData(t *testing.T) {
+	var testCases = []struct {
+		name         string
+		link         string
+		status       int
+		body         string
+		expectedPath string
+		expectError  bool
+	}{
+		{
+			name:         "guest os info",
+			link:         guestOSInfoLink,
+			status:       http.StatusOK,
+			body:         `{"hostname":"vm1","timezone":"UTC, 0"}`,
+			expectedPath: "/namespaces/default/virtualmachineinstances/vm1/guestosinfo",
+		},
+		{
+			name:         "filesystems",
+			link:         filesystemsLink,
+			status:       http.StatusOK,
+			body:         `{"items":[{"diskName":"vda1","mountPoint":"/"}]}`,
+			expectedPath: "/namespaces/default/virtualmachineinstances/vm1/filesystemlist",
+		},
+		{
+			name:         "agent not connected",
+			link:         userListLink,
+			status:       http.StatusConflict,
+			body:         `{"kind":"Status","apiVersion":"v1","status":"Failure","message":"VMI does not have guest agent connected","reason":"Conflict","code":409}`,
+			expectedPath: "/namespaces/default/virtualmachineinstances/vm1/userlist",
+			expectError:  true,
+		},
+		{
+			name:        "unknown link",
+			link:        "unknown",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		var path string
+		client := &fake.RESTClient{
+			NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+			Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+				path = req.URL.Path
+				return &http.Response{
+					StatusCode: tc.status,
+					Header:     http.Header{"Content-Type": []string{"application/json"}},
+					Body:       ioutil.NopCloser(strings.NewReader(tc.body)),
+				}, nil
+			}),
+		}
+		h := &vmLinkHandler{virtSubresourceRestClient: client}
+
+		data, err := h.getGuestAgentData(context.Background(), "default", "vm1", tc.link)
+		assert.Equal(t, tc.expectedPath, path, "case %q", tc.name)
+		if tc.expectError {
+			assert.NotNil(t, err, "case %q", tc.name)
+			if tc.status != 0 {
+				apiErr, ok := err.(*apierror.APIError)
+				assert.True(t, ok, "case %q", tc.name)
+				if ok {
+					assert.Equal(t, tc.status, apiErr.Code.Status, "case %q", tc.name)
+				}
+			}
+			continue
+		}
+		assert.Nil(t, err, "case %q", tc.name)
+		assert.Equal(t, tc.body, string(data), "case %q", tc.name)
+	}
+}
@@ -17,6 +17,8 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
 	"k8s.io/client-go/rest"
 	kv1 "kubevirt.io/client-go/api/v1"
 
@@ -25,6 +27,8 @@ import (
 	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
 	"github.com/harvester/harvester/pkg/settings"
 	"github.com/harvester/harvester/pkg/util"
+	werror "github.com/harvester/harvester/pkg/webhook/error"
+	"github.com/harvester/harvester/pkg/webhook/resources/restore"
 )
 
 const (
@@ -46,9 +50,16 @@ type vmActionHandler struct {
 	backups                   ctlharvesterv1.VirtualMachineBackupClient
 	backupCache               ctlharvesterv1.VirtualMachineBackupCache
 	restores                  ctlharvesterv1.VirtualMachineRestoreClient
+	vmSnapshots               ctlharvesterv1.VirtualMachineSnapshotClient
+	vmSnapshotCache           ctlharvesterv1.VirtualMachineSnapshotCache
 	settingCache              ctlharvesterv1.SettingCache
 	nodeCache                 ctlcorev1.NodeCache
+	podCache                  ctlcorev1.PodCache
+	pvcs                      ctlcorev1.PersistentVolumeClaimClient
 	pvcCache                  ctlcorev1.PersistentVolumeClaimCache
+	secrets                   ctlcorev1.SecretClient
+	secretCache               ctlcorev1.SecretCache
+	subjectAccessReviews      authorizationv1client.SubjectAccessReviewInterface
 	virtSubresourceRestClient rest.Interface
 	virtRestClient            rest.Interface
 }
@@ -132,10 +143,55 @@ func (h *vmActionHandler) doAction(rw http.ResponseWriter, r *http.Request) erro
 			return err
 		}
 
-		if err := h.restoreBackup(name, namespace, input); err != nil {
+		if err := h.restoreBackup(r.Context(), name, namespace, input); err != nil {
 			return err
 		}
 		return nil
+	case restoreVolumes:
+		var input RestoreVolumesInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			return apierror.NewAPIError(validation.InvalidBodyContent, "Failed to decode request body: %v "+err.Error())
+		}
+
+		if input.Name == "" || input.BackupName == "" {
+			return apierror.NewAPIError(validation.InvalidBodyContent, "Parameter name and backupName are required")
+		}
+
+		if err := h.checkBackupTargetConfigured(); err != nil {
+			return err
+		}
+
+		return h.restoreVolumes(name, namespace, input)
+	case snapshotVM:
+		var input SnapshotInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			return apierror.NewAPIError(validation.InvalidBodyContent, "Failed to decode request body: %v "+err.Error())
+		}
+
+		if input.Name == "" {
+			return apierror.NewAPIError(validation.InvalidBodyContent, "Parameter snapshot name is required")
+		}
+		return h.createVMSnapshot(name, namespace, input)
+	case revertToSnapshot:
+		var input RevertToSnapshotInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			return apierror.NewAPIError(validation.InvalidBodyContent, "Failed to decode request body: %v "+err.Error())
+		}
+
+		if input.SnapshotName == "" {
+			return apierror.NewAPIError(validation.InvalidBodyContent, "Parameter snapshotName is required")
+		}
+		return h.revertToSnapshot(name, namespace, input)
+	case deleteSnapshot:
+		var input DeleteSnapshotInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			return apierror.NewAPIError(validation.InvalidBodyContent, "Failed to decode request body: %v "+err.Error())
+		}
+
+		if input.SnapshotName == "" {
+			return apierror.NewAPIError(validation.InvalidBodyContent, "Parameter snapshotName is required")
+		}
+		return h.deleteVMSnapshot(name, namespace, input)
 	case createTemplate:
 		var input CreateTemplateInput
 		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
@@ -146,6 +202,30 @@ func (h *vmActionHandler) doAction(rw http.ResponseWriter, r *http.Request) erro
 			return apierror.NewAPIError(validation.InvalidBodyContent, "Template name is required")
 		}
 		return h.createTemplate(namespace, name, input)
+	case cloneVM:
+		var input CloneInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			return apierror.NewAPIError(validation.InvalidBodyContent, "Failed to decode request body: %v "+err.Error())
+		}
+
+		if input.TargetName == "" {
+			return apierror.NewAPIError(validation.InvalidBodyContent, "Parameter targetName is required")
+		}
+		if input.TargetNamespace != "" && input.TargetNamespace != namespace {
+			return apierror.NewAPIError(validation.InvalidBodyContent,
+				"Parameter targetNamespace must be the namespace of the VM, CSI volume clones are limited to the namespace of the source volumes")
+		}
+		return h.cloneVM(namespace, name, input)
+	case resizeVM:
+		var input ResizeInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			return apierror.NewAPIError(validation.InvalidBodyContent, "Failed to decode request body: %v "+err.Error())
+		}
+
+		if input.Cores == 0 || input.Memory == "" {
+			return apierror.NewAPIError(validation.InvalidBodyContent, "Parameter cores and memory are required")
+		}
+		return h.resizeVM(r.Context(), namespace, name, input)
 	case addVolume:
 		var input AddVolumeInput
 		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
@@ -339,32 +419,33 @@ func (h *vmActionHandler) createVMBackup(vmName, vmNamespace string, input Backu
 			},
 		},
 	}
+	if input.BackupTarget != "" {
+		backup.Spec.BackupTarget = &corev1.LocalObjectReference{Name: input.BackupTarget}
+	}
 	if _, err := h.backups.Create(backup); err != nil {
 		return fmt.Errorf("failed to create VM backup, error: %s", err.Error())
 	}
 	return nil
 }
 
-func (h *vmActionHandler) restoreBackup(vmName, vmNamespace string, input RestoreInput) error {
+func (h *vmActionHandler) restoreBackup(ctx context.Context, vmName, vmNamespace string, input RestoreInput) error {
 	if _, err := h.backupCache.Get(vmNamespace, input.BackupName); err != nil {
 		return err
 	}
-	apiGroup := kv1.SchemeGroupVersion.Group
-	backup := &harvesterv1.VirtualMachineRestore{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      input.Name,
-			Namespace: vmNamespace,
-		},
-		Spec: harvesterv1.VirtualMachineRestoreSpec{
-			Target: corev1.TypedLocalObjectReference{
-				APIGroup: &apiGroup,
-				Kind:     kv1.VirtualMachineGroupVersionKind.Kind,
-				Name:     vmName,
-			},
-			VirtualMachineBackupName: input.BackupName,
-			NewVM:                    false,
-		},
+	// the restore is created with the permissions of Harvester, so the webhook can't review the access of the user
+	if input.TargetNamespace != "" && input.TargetNamespace != vmNamespace {
+		userInfo, ok := request.UserFrom(ctx)
+		if !ok {
+			return errors.New("failed to get the user of the request")
+		}
+		if err := restore.CheckCreateAccess(ctx, h.subjectAccessReviews, input.TargetNamespace, userInfo); err != nil {
+			if _, ok := err.(werror.AdmitError); ok {
+				return apierror.NewAPIError(validation.PermissionDenied, err.Error())
+			}
+			return err
+		}
 	}
+	backup := newVMRestore(vmNamespace, vmName, input)
 	_, err := h.restores.Create(backup)
 	if err != nil {
 		return fmt.Errorf("failed to create restore, error: %s", err.Error())
@@ -373,6 +454,24 @@ func (h *vmActionHandler) restoreBackup(vmName, vmNamespace string, input Restor
 	return nil
 }
 
+// restoreVolumes restores the selected volumes of a backup into standalone PVCs and leaves the VM alone.
+func (h *vmActionHandler) restoreVolumes(vmName, vmNamespace string, input RestoreVolumesInput) error {
+	if _, err := h.backupCache.Get(vmNamespace, input.BackupName); err != nil {
+		return err
+	}
+	restore := newVMRestore(vmNamespace, vmName, RestoreInput{
+		Name:       input.Name,
+		BackupName: input.BackupName,
+		Detached:   true,
+		Volumes:    input.Volumes,
+	})
+	if _, err := h.restores.Create(restore); err != nil {
+		return fmt.Errorf("failed to create restore, error: %s", err.Error())
+	}
+
+	return nil
+}
+
 func (h *vmActionHandler) checkBackupTargetConfigured() error {
 	target, err := h.settingCache.Get(settings.BackupTargetSettingName)
 	if err == nil && harvesterv1.SettingConfigured.IsTrue(target) {
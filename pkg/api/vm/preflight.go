@@ -0,0 +1,386 @@
+package vm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	ctlstoragev1 "github.com/rancher/wrangler/pkg/generated/controllers/storage/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	kv1 "kubevirt.io/client-go/api/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/config"
+	"github.com/harvester/harvester/pkg/controller/master/backup"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	ctlcniv1 "github.com/harvester/harvester/pkg/generated/controllers/k8s.cni.cncf.io/v1"
+	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/util"
+	werror "github.com/harvester/harvester/pkg/webhook/error"
+	"github.com/harvester/harvester/pkg/webhook/resources/restore"
+)
+
+const (
+	fieldName       = "name"
+	fieldBackupName = "backupName"
+	fieldVolumes    = "volumes"
+
+	resourceRequestsStorage = corev1.ResourceName("requests.storage")
+)
+
+// RestorePreflightHandler dry-runs a restore, it reports the errors which fail the restore and the warnings
+// without creating anything. The handler serves the VM schema where the backup is given in the input,
+// and the VirtualMachineBackup schema where the backup is restored to its source VM.
+type RestorePreflightHandler struct {
+	fromBackup bool
+
+	vmCache           ctlkubevirtv1.VirtualMachineCache
+	backupCache       ctlharvesterv1.VirtualMachineBackupCache
+	restoreCache      ctlharvesterv1.VirtualMachineRestoreCache
+	imageCache        ctlharvesterv1.VirtualMachineImageCache
+	pvcCache          ctlcorev1.PersistentVolumeClaimCache
+	storageClassCache ctlstoragev1.StorageClassCache
+	nadCache          ctlcniv1.NetworkAttachmentDefinitionCache
+	resourceQuotas    typedcorev1.ResourceQuotasGetter
+	checker           *restore.Checker
+}
+
+func NewRestorePreflightHandler(scaled *config.Scaled, fromBackup bool) *RestorePreflightHandler {
+	vms := scaled.VirtFactory.Kubevirt().V1().VirtualMachine()
+	backups := scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackup()
+	storageClasses := scaled.Management.StorageFactory.Storage().V1().StorageClass()
+	nads := scaled.CniFactory.K8s().V1().NetworkAttachmentDefinition()
+	return &RestorePreflightHandler{
+		fromBackup:        fromBackup,
+		vmCache:           vms.Cache(),
+		backupCache:       backups.Cache(),
+		restoreCache:      scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineRestore().Cache(),
+		imageCache:        scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineImage().Cache(),
+		pvcCache:          scaled.CoreFactory.Core().V1().PersistentVolumeClaim().Cache(),
+		storageClassCache: storageClasses.Cache(),
+		nadCache:          nads.Cache(),
+		resourceQuotas:    scaled.Management.ClientSet.CoreV1(),
+		checker: restore.NewChecker(
+			vms.Cache(),
+			backups.Cache(),
+			scaled.HarvesterFactory.Harvesterhci().V1beta1().BackupTarget().Cache(),
+			scaled.HarvesterFactory.Harvesterhci().V1beta1().Setting().Cache(),
+			scaled.CoreFactory.Core().V1().Namespace().Cache(),
+			scaled.CoreFactory.Core().V1().PersistentVolumeClaim().Cache(),
+			storageClasses.Cache(),
+			nads.Cache(),
+			scaled.Management.ClientSet.AuthorizationV1().SubjectAccessReviews(),
+		),
+	}
+}
+
+func (h *RestorePreflightHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	namespace, name := vars["namespace"], vars["name"]
+
+	var input RestoreInput
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		util.ResponseError(rw, http.StatusBadRequest, errors.Wrap(err, "Failed to decode request body"))
+		return
+	}
+	if input.Name == "" {
+		util.ResponseErrorMsg(rw, http.StatusBadRequest, "Parameter name is required")
+		return
+	}
+
+	var (
+		vmRestore *harvesterv1.VirtualMachineRestore
+		err       error
+	)
+	if h.fromBackup {
+		vmRestore, err = h.newRestoreFromBackup(namespace, name, input)
+	} else {
+		if input.BackupName == "" {
+			util.ResponseErrorMsg(rw, http.StatusBadRequest, "Parameter backupName is required")
+			return
+		}
+		vmRestore = newVMRestore(namespace, name, input)
+	}
+	if err != nil {
+		util.ResponseError(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	userInfo, ok := request.UserFrom(req.Context())
+	if !ok {
+		util.ResponseErrorMsg(rw, http.StatusInternalServerError, "failed to get the user of the request")
+		return
+	}
+	report, err := h.preflight(req.Context(), vmRestore, userInfo)
+	if err != nil {
+		util.ResponseError(rw, http.StatusInternalServerError, err)
+		return
+	}
+	util.ResponseOKWithBody(rw, report)
+}
+
+// newRestoreFromBackup restores the backup to its source VM, or to a new VM if the source VM is gone
+func (h *RestorePreflightHandler) newRestoreFromBackup(namespace, backupName string, input RestoreInput) (*harvesterv1.VirtualMachineRestore, error) {
+	vmBackup, err := h.backupCache.Get(namespace, backupName)
+	if err != nil {
+		return nil, err
+	}
+
+	input.BackupName = backupName
+	vmRestore := newVMRestore(namespace, vmBackup.Spec.Source.Name, input)
+	if input.Detached || vmRestore.Spec.NewVM {
+		return vmRestore, nil
+	}
+	if _, err := h.vmCache.Get(namespace, vmBackup.Spec.Source.Name); apierrors.IsNotFound(err) {
+		vmRestore.Spec.NewVM = true
+	} else if err != nil {
+		return nil, err
+	}
+	return vmRestore, nil
+}
+
+func (h *RestorePreflightHandler) preflight(ctx context.Context, vmRestore *harvesterv1.VirtualMachineRestore, userInfo user.Info) (*RestorePreflightReport, error) {
+	report := &RestorePreflightReport{
+		Errors:   []RestorePreflightMessage{},
+		Warnings: []RestorePreflightMessage{},
+	}
+
+	if _, err := h.restoreCache.Get(vmRestore.Namespace, vmRestore.Name); err == nil {
+		report.addError(fieldName, fmt.Sprintf("restore %s/%s already exists", vmRestore.Namespace, vmRestore.Name))
+	} else if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	if err := h.checker.Check(ctx, vmRestore, userInfo); err != nil {
+		report.addError(getAdmitErrorField(err), err.Error())
+	}
+
+	vmBackup, err := h.backupCache.Get(vmRestore.Spec.VirtualMachineBackupNamespace, vmRestore.Spec.VirtualMachineBackupName)
+	if apierrors.IsNotFound(err) {
+		// the missing backup is already reported by the checker
+		return report, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if vmBackup.Status == nil || vmBackup.Status.ReadyToUse == nil || !*vmBackup.Status.ReadyToUse {
+		report.addError(fieldBackupName, fmt.Sprintf("backup %s/%s is not ready", vmBackup.Namespace, vmBackup.Name))
+		return report, nil
+	}
+
+	pvcs, err := backup.GetRestoredPVCs(vmRestore, vmBackup)
+	if err != nil {
+		report.addError(fieldBackupName, err.Error())
+		return report, nil
+	}
+	if err := h.checkPVCs(report, vmRestore, pvcs); err != nil {
+		return nil, err
+	}
+	if err := h.checkStorageQuota(report, backup.GetRestoreTargetNamespace(vmRestore), pvcs); err != nil {
+		return nil, err
+	}
+
+	if vmRestore.Spec.Mode != harvesterv1.VirtualMachineRestoreModeDetachedPVCs {
+		if err := h.checkVMSpec(report, vmRestore, backup.GetRestoredVMSpec(vmRestore, vmBackup)); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// checkPVCs checks the name collisions, storage classes and images of the restored PVCs. Only the PVC names given
+// in the restore can collide, the generated names contain the UID of the restore which is unknown until it is created.
+func (h *RestorePreflightHandler) checkPVCs(report *RestorePreflightReport, vmRestore *harvesterv1.VirtualMachineRestore, pvcs []*corev1.PersistentVolumeClaim) error {
+	namedPVCs := map[string]bool{}
+	for _, volume := range vmRestore.Spec.Volumes {
+		if volume.PersistentVolumeClaimName != "" {
+			namedPVCs[volume.PersistentVolumeClaimName] = true
+		}
+	}
+
+	for _, pvc := range pvcs {
+		if namedPVCs[pvc.Name] {
+			if _, err := h.pvcCache.Get(pvc.Namespace, pvc.Name); err == nil {
+				report.addError(fieldVolumes, fmt.Sprintf("PVC %s/%s already exists", pvc.Namespace, pvc.Name))
+			} else if !apierrors.IsNotFound(err) {
+				return err
+			}
+		}
+
+		if scName := pvc.Spec.StorageClassName; scName != nil && *scName != "" {
+			if _, err := h.storageClassCache.Get(*scName); apierrors.IsNotFound(err) {
+				report.addError("", fmt.Sprintf("storage class %s of PVC %s/%s is not found", *scName, pvc.Namespace, pvc.Name))
+			} else if err != nil {
+				return err
+			}
+		}
+
+		if imageID, ok := pvc.Annotations[util.AnnotationImageID]; ok && imageID != "" {
+			imageNamespace, imageName := ref.Parse(imageID)
+			if _, err := h.imageCache.Get(imageNamespace, imageName); apierrors.IsNotFound(err) {
+				report.addWarning("", fmt.Sprintf("image %s of PVC %s/%s is not found", imageID, pvc.Namespace, pvc.Name))
+			} else if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkStorageQuota checks the restored PVCs fit in the requests.storage quotas of the target namespace
+func (h *RestorePreflightHandler) checkStorageQuota(report *RestorePreflightReport, namespace string, pvcs []*corev1.PersistentVolumeClaim) error {
+	requested := resource.Quantity{}
+	for _, pvc := range pvcs {
+		if size, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+			requested.Add(size)
+		}
+	}
+	if requested.IsZero() {
+		return nil
+	}
+
+	quotas, err := h.resourceQuotas.ResourceQuotas(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, quota := range quotas.Items {
+		hard, ok := quota.Status.Hard[resourceRequestsStorage]
+		if !ok {
+			continue
+		}
+		used := quota.Status.Used[resourceRequestsStorage]
+		total := used.DeepCopy()
+		total.Add(requested)
+		if total.Cmp(hard) > 0 {
+			report.addError("", fmt.Sprintf("restored volumes request %s of storage which exceeds the quota %s/%s, %s of %s is used",
+				requested.String(), namespace, quota.Name, used.String(), hard.String()))
+		}
+	}
+	return nil
+}
+
+// checkVMSpec checks the networks and MAC addresses of the restored VM
+func (h *RestorePreflightHandler) checkVMSpec(report *RestorePreflightReport, vmRestore *harvesterv1.VirtualMachineRestore, spec *kv1.VirtualMachineSpec) error {
+	if spec == nil || spec.Template == nil {
+		return nil
+	}
+	namespace := backup.GetRestoreTargetNamespace(vmRestore)
+
+	for _, network := range spec.Template.Spec.Networks {
+		if network.Multus == nil {
+			continue
+		}
+		nadNamespace, nadName := namespace, network.Multus.NetworkName
+		if parts := strings.SplitN(network.Multus.NetworkName, "/", 2); len(parts) == 2 {
+			nadNamespace, nadName = parts[0], parts[1]
+		}
+		if _, err := h.nadCache.Get(nadNamespace, nadName); apierrors.IsNotFound(err) {
+			report.addError("", fmt.Sprintf("network %s of interface %s is not found", network.Multus.NetworkName, network.Name))
+		} else if err != nil {
+			return err
+		}
+	}
+
+	macs := map[string]string{}
+	for _, iface := range spec.Template.Spec.Domain.Devices.Interfaces {
+		if iface.MacAddress != "" {
+			macs[strings.ToLower(iface.MacAddress)] = iface.Name
+		}
+	}
+	if len(macs) == 0 {
+		return nil
+	}
+	vms, err := h.vmCache.List(metav1.NamespaceAll, labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, vm := range vms {
+		if vm.Namespace == namespace && vm.Name == vmRestore.Spec.Target.Name || vm.Spec.Template == nil {
+			continue
+		}
+		for _, iface := range vm.Spec.Template.Spec.Domain.Devices.Interfaces {
+			if ifaceName, ok := macs[strings.ToLower(iface.MacAddress)]; ok {
+				// the user may not be allowed to read the VMs of the other namespaces
+				if vm.Namespace != namespace {
+					report.addWarning("", fmt.Sprintf("MAC address %s of interface %s is used by a VM in another namespace", iface.MacAddress, ifaceName))
+					continue
+				}
+				report.addWarning("", fmt.Sprintf("MAC address %s of interface %s is used by VM %s/%s", iface.MacAddress, ifaceName, vm.Namespace, vm.Name))
+			}
+		}
+	}
+	return nil
+}
+
+func (r *RestorePreflightReport) addError(field, message string) {
+	// the first error of the restore validator may be found again by the preflight checks
+	for _, e := range r.Errors {
+		if e.Field == field && e.Message == message {
+			return
+		}
+	}
+	r.Errors = append(r.Errors, RestorePreflightMessage{Field: field, Message: message})
+}
+
+func (r *RestorePreflightReport) addWarning(field, message string) {
+	r.Warnings = append(r.Warnings, RestorePreflightMessage{Field: field, Message: message})
+}
+
+// getAdmitErrorField returns the field of the restore spec which is rejected by the restore validator
+func getAdmitErrorField(err error) string {
+	admitErr, ok := err.(werror.AdmitError)
+	if !ok {
+		return ""
+	}
+	if status := admitErr.AsResult(); status.Details != nil && len(status.Details.Causes) > 0 {
+		return status.Details.Causes[0].Field
+	}
+	return ""
+}
+
+// newVMRestore builds the restore of the backup to the existing VM, or to standalone PVCs if the input is detached
+func newVMRestore(vmNamespace, vmName string, input RestoreInput) *harvesterv1.VirtualMachineRestore {
+	apiGroup := kv1.SchemeGroupVersion.Group
+	vmRestore := &harvesterv1.VirtualMachineRestore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      input.Name,
+			Namespace: vmNamespace,
+		},
+		Spec: harvesterv1.VirtualMachineRestoreSpec{
+			Target: corev1.TypedLocalObjectReference{
+				APIGroup: &apiGroup,
+				Kind:     kv1.VirtualMachineGroupVersionKind.Kind,
+				Name:     vmName,
+			},
+			VirtualMachineBackupName:      input.BackupName,
+			VirtualMachineBackupNamespace: vmNamespace,
+			NewVM:                         false,
+			TargetNamespace:               input.TargetNamespace,
+		},
+	}
+	if input.Detached {
+		vmRestore.Spec.Mode = harvesterv1.VirtualMachineRestoreModeDetachedPVCs
+	} else if input.TargetNamespace != "" && input.TargetNamespace != vmNamespace {
+		// the VM is restored as a new VM in the other namespace
+		vmRestore.Spec.NewVM = true
+	}
+	for _, volume := range input.Volumes {
+		vmRestore.Spec.Volumes = append(vmRestore.Spec.Volumes, harvesterv1.VolumeRestoreSource{
+			VolumeBackupName:          volume.VolumeBackupName,
+			PersistentVolumeClaimName: volume.PersistentVolumeClaimName,
+		})
+	}
+	return vmRestore
+}
@@ -0,0 +1,218 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	corefake "k8s.io/client-go/kubernetes/fake"
+	kubevirtapis "kubevirt.io/client-go/api/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/fake"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+func TestRestorePreflightHandler_checkStorageQuota(t *testing.T) {
+	newPVC := func(size string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "restore-" + size},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+				},
+			},
+		}
+	}
+	quota := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "storage"},
+		Status: corev1.ResourceQuotaStatus{
+			Hard: corev1.ResourceList{resourceRequestsStorage: resource.MustParse("20Gi")},
+			Used: corev1.ResourceList{resourceRequestsStorage: resource.MustParse("10Gi")},
+		},
+	}
+
+	var testCases = []struct {
+		name           string
+		quota          *corev1.ResourceQuota
+		pvcs           []*corev1.PersistentVolumeClaim
+		expectedErrors int
+	}{
+		{
+			name: "no quota",
+			pvcs: []*corev1.PersistentVolumeClaim{newPVC("30Gi")},
+		},
+		{
+			name:  "fits in the quota",
+			quota: quota,
+			pvcs:  []*corev1.PersistentVolumeClaim{newPVC("5Gi"), newPVC("5Gi")},
+		},
+		{
+			name:           "exceeds the quota",
+			quota:          quota,
+			pvcs:           []*corev1.PersistentVolumeClaim{newPVC("5Gi"), newPVC("6Gi")},
+			expectedErrors: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		var objs []runtime.Object
+		if tc.quota != nil {
+			objs = append(objs, tc.quota)
+		}
+		var handler = &RestorePreflightHandler{
+			resourceQuotas: corefake.NewSimpleClientset(objs...).CoreV1(),
+		}
+		report := &RestorePreflightReport{}
+		err := handler.checkStorageQuota(report, "default", tc.pvcs)
+		assert.Nil(t, err, "case %q", tc.name)
+		assert.Len(t, report.Errors, tc.expectedErrors, "case %q", tc.name)
+	}
+}
+
+func TestRestorePreflightHandler_checkVMSpec(t *testing.T) {
+	newNamespacedVM := func(namespace, name, mac string) *kubevirtapis.VirtualMachine {
+		return &kubevirtapis.VirtualMachine{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec: kubevirtapis.VirtualMachineSpec{
+				Template: &kubevirtapis.VirtualMachineInstanceTemplateSpec{
+					Spec: kubevirtapis.VirtualMachineInstanceSpec{
+						Domain: kubevirtapis.DomainSpec{
+							Devices: kubevirtapis.Devices{
+								Interfaces: []kubevirtapis.Interface{{Name: "default", MacAddress: mac}},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+	newVM := func(name, mac string) *kubevirtapis.VirtualMachine {
+		return newNamespacedVM("default", name, mac)
+	}
+
+	var testCases = []struct {
+		name             string
+		vms              []*kubevirtapis.VirtualMachine
+		expectedWarnings []string
+	}{
+		{
+			name: "MAC address used by the target VM",
+			vms:  []*kubevirtapis.VirtualMachine{newVM("target", "52:54:00:00:00:01")},
+		},
+		{
+			name:             "MAC address used by another VM",
+			vms:              []*kubevirtapis.VirtualMachine{newVM("target", "52:54:00:00:00:01"), newVM("other", "52:54:00:00:00:01")},
+			expectedWarnings: []string{"MAC address 52:54:00:00:00:01 of interface default is used by VM default/other"},
+		},
+		{
+			name:             "MAC address used by a VM in another namespace",
+			vms:              []*kubevirtapis.VirtualMachine{newNamespacedVM("secret", "other", "52:54:00:00:00:01")},
+			expectedWarnings: []string{"MAC address 52:54:00:00:00:01 of interface default is used by a VM in another namespace"},
+		},
+	}
+
+	for _, tc := range testCases {
+		var clientset = fake.NewSimpleClientset()
+		for _, vm := range tc.vms {
+			err := clientset.Tracker().Add(vm)
+			assert.Nil(t, err, "Mock resource should add into fake controller tracker")
+		}
+		var handler = &RestorePreflightHandler{
+			vmCache: fakeclients.VirtualMachineCache(clientset.KubevirtV1().VirtualMachines),
+		}
+		report := &RestorePreflightReport{}
+		vmRestore := newVMRestore("default", "target", RestoreInput{Name: "restore", BackupName: "backup"})
+		err := handler.checkVMSpec(report, vmRestore, &newVM("target", "52:54:00:00:00:01").Spec)
+		assert.Nil(t, err, "case %q", tc.name)
+		var warnings []string
+		for _, warning := range report.Warnings {
+			warnings = append(warnings, warning.Message)
+		}
+		assert.Equal(t, tc.expectedWarnings, warnings, "case %q", tc.name)
+	}
+}
+
+func TestRestorePreflightHandler_checkPVCs(t *testing.T) {
+	newPVC := func(name string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		}
+	}
+
+	var testCases = []struct {
+		name           string
+		input          RestoreInput
+		pvcs           []*corev1.PersistentVolumeClaim
+		expectedErrors int
+	}{
+		{
+			name:  "generated PVC names never collide",
+			input: RestoreInput{Name: "restore", BackupName: "backup"},
+			pvcs:  []*corev1.PersistentVolumeClaim{newPVC("restore-backup--disk-0")},
+		},
+		{
+			name: "given PVC name collides",
+			input: RestoreInput{Name: "restore", BackupName: "backup", Detached: true, Volumes: []RestoreVolumeInput{
+				{VolumeBackupName: "backup-disk-0", PersistentVolumeClaimName: "existing"},
+			}},
+			pvcs:           []*corev1.PersistentVolumeClaim{newPVC("existing")},
+			expectedErrors: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		var clientset = corefake.NewSimpleClientset()
+		for _, pvc := range tc.pvcs {
+			err := clientset.Tracker().Add(pvc)
+			assert.Nil(t, err, "Mock resource should add into fake controller tracker")
+		}
+		var handler = &RestorePreflightHandler{
+			pvcCache: fakeclients.PersistentVolumeClaimCache(clientset.CoreV1().PersistentVolumeClaims),
+		}
+		report := &RestorePreflightReport{}
+		vmRestore := newVMRestore("default", "target", tc.input)
+		err := handler.checkPVCs(report, vmRestore, tc.pvcs)
+		assert.Nil(t, err, "case %q", tc.name)
+		assert.Len(t, report.Errors, tc.expectedErrors, "case %q", tc.name)
+	}
+}
+
+func TestNewVMRestore(t *testing.T) {
+	var testCases = []struct {
+		name            string
+		input           RestoreInput
+		expectedMode    harvesterv1.VirtualMachineRestoreMode
+		expectedNewVM   bool
+		expectedVolumes int
+	}{
+		{
+			name:  "restore the existing VM",
+			input: RestoreInput{Name: "restore", BackupName: "backup"},
+		},
+		{
+			name:          "restore a new VM into another namespace",
+			input:         RestoreInput{Name: "restore", BackupName: "backup", TargetNamespace: "other"},
+			expectedNewVM: true,
+		},
+		{
+			name: "restore detached PVCs into another namespace",
+			input: RestoreInput{Name: "restore", BackupName: "backup", Detached: true, TargetNamespace: "other", Volumes: []RestoreVolumeInput{
+				{VolumeBackupName: "backup-disk-0"},
+			}},
+			expectedMode:    harvesterv1.VirtualMachineRestoreModeDetachedPVCs,
+			expectedVolumes: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		vmRestore := newVMRestore("default", "vm", tc.input)
+		assert.Equal(t, tc.expectedMode, vmRestore.Spec.Mode, "case %q", tc.name)
+		assert.Equal(t, tc.expectedNewVM, vmRestore.Spec.NewVM, "case %q", tc.name)
+		assert.Equal(t, tc.input.TargetNamespace, vmRestore.Spec.TargetNamespace, "case %q", tc.name)
+		assert.Len(t, vmRestore.Spec.Volumes, tc.expectedVolumes, "case %q", tc.name)
+	}
+}
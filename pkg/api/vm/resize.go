@@ -0,0 +1,195 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	kv1 "kubevirt.io/client-go/api/v1"
+
+	"github.com/harvester/harvester/pkg/controller/master/virtualmachine"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+var (
+	// the memory overhead of the virt-launcher pods, see GetMemoryOverhead of KubeVirt
+	virtLauncherStaticMemoryOverhead   = resource.MustParse("180Mi")
+	virtLauncherMemoryOverheadPerVCPU  = resource.MustParse("8Mi")
+	virtLauncherGraphicsMemoryOverhead = resource.MustParse("16Mi")
+)
+
+// resizeVM changes the CPU cores and memory of the VM. KubeVirt applies the change when the VMI is created, so a
+// running VM gets the pending resize annotation until it is restarted, the VM is restarted now if requested.
+func (h *vmActionHandler) resizeVM(ctx context.Context, namespace, name string, input ResizeInput) error {
+	memory, err := resource.ParseQuantity(input.Memory)
+	if err != nil || memory.Sign() <= 0 {
+		return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("Parameter memory %q is invalid", input.Memory))
+	}
+
+	vm, err := h.vmCache.Get(namespace, name)
+	if err != nil {
+		return err
+	}
+	if vm.Spec.Template == nil {
+		return fmt.Errorf("virtual machine %s/%s has no template", namespace, name)
+	}
+	vmi, err := h.vmiCache.Get(namespace, name)
+	if apierrors.IsNotFound(err) {
+		vmi = nil
+	} else if err != nil {
+		return err
+	}
+
+	toUpdate := vm.DeepCopy()
+	setVMResources(toUpdate, input.Cores, memory)
+	if err := h.checkNodeAllocatable(vmi, &toUpdate.Spec.Template.Spec); err != nil {
+		return err
+	}
+	resize := &virtualmachine.PendingResize{Cores: input.Cores, Memory: memory.String()}
+	pending := vmi != nil && !vmi.IsFinal() && !virtualmachine.IsResizeApplied(resize, vmi)
+	if pending {
+		if err := virtualmachine.SetPendingResize(toUpdate, resize); err != nil {
+			return err
+		}
+	} else {
+		delete(toUpdate.Annotations, util.AnnotationPendingResize)
+	}
+	if !reflect.DeepEqual(vm.Spec, toUpdate.Spec) || !reflect.DeepEqual(vm.Annotations, toUpdate.Annotations) {
+		if _, err := h.vms.Update(toUpdate); err != nil {
+			return err
+		}
+	}
+
+	if pending && input.Restart {
+		return h.subresourceOperate(ctx, vmResource, namespace, name, restartVM)
+	}
+	return nil
+}
+
+// checkNodeAllocatable returns an error if no schedulable node has the CPU and memory requested by the resized VM
+// left. The requests of the pods on a node are subtracted from its allocatable resources except those of the
+// virt-launcher pod of the VM, which is replaced on the restart, and the memory overhead of virt-launcher is added
+// to the VM memory.
+func (h *vmActionHandler) checkNodeAllocatable(vmi *kv1.VirtualMachineInstance, spec *kv1.VirtualMachineInstanceSpec) error {
+	var cores uint32
+	if spec.Domain.CPU != nil {
+		cores = spec.Domain.CPU.Cores
+	}
+	cpu, ok := spec.Domain.Resources.Requests[corev1.ResourceCPU]
+	if !ok {
+		cpu = *resource.NewQuantity(int64(cores), resource.DecimalSI)
+	}
+	vmMemory := spec.Domain.Resources.Requests[corev1.ResourceMemory]
+	memory := vmMemory.DeepCopy()
+	memory.Add(getVirtLauncherMemoryOverhead(vmMemory, cores, spec.Domain.Devices.AutoattachGraphicsDevice))
+
+	nodes, err := h.nodeCache.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	pods, err := h.podCache.List(corev1.NamespaceAll, labels.Everything())
+	if err != nil {
+		return err
+	}
+	requested := map[string]corev1.ResourceList{}
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if vmi != nil && pod.Labels[kv1.CreatedByLabel] == string(vmi.UID) {
+			continue
+		}
+		if requested[pod.Spec.NodeName] == nil {
+			requested[pod.Spec.NodeName] = corev1.ResourceList{}
+		}
+		addPodRequests(requested[pod.Spec.NodeName], pod)
+	}
+
+	for _, node := range nodes {
+		if node.Spec.Unschedulable {
+			continue
+		}
+		allocatableCPU := node.Status.Allocatable[corev1.ResourceCPU]
+		allocatableCPU.Sub(requested[node.Name][corev1.ResourceCPU])
+		allocatableMemory := node.Status.Allocatable[corev1.ResourceMemory]
+		allocatableMemory.Sub(requested[node.Name][corev1.ResourceMemory])
+		if allocatableCPU.Cmp(cpu) >= 0 && allocatableMemory.Cmp(memory) >= 0 {
+			return nil
+		}
+	}
+	return apierror.NewAPIError(validation.InvalidBodyContent,
+		fmt.Sprintf("No schedulable node has %s CPU and %s memory left for the VM with %d CPU cores and %s memory", cpu.String(), memory.String(), cores, vmMemory.String()))
+}
+
+// addPodRequests adds the CPU and memory requests of the pod to the resource list. A pod requests the sum of its
+// containers or the most of an init container, as the init containers run one at a time before the containers.
+func addPodRequests(list corev1.ResourceList, pod *corev1.Pod) {
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		var podRequest resource.Quantity
+		for _, container := range pod.Spec.Containers {
+			podRequest.Add(container.Resources.Requests[name])
+		}
+		for _, container := range pod.Spec.InitContainers {
+			if request := container.Resources.Requests[name]; request.Cmp(podRequest) > 0 {
+				podRequest = request.DeepCopy()
+			}
+		}
+		if overhead, ok := pod.Spec.Overhead[name]; ok {
+			podRequest.Add(overhead)
+		}
+		total := list[name]
+		total.Add(podRequest)
+		list[name] = total
+	}
+}
+
+// getVirtLauncherMemoryOverhead returns the memory KubeVirt adds to the request of the virt-launcher pod for the
+// page tables of the guest memory, the virt-launcher, libvirt and QEMU processes, the vCPUs and the graphics device
+func getVirtLauncherMemoryOverhead(memory resource.Quantity, cores uint32, autoattachGraphicsDevice *bool) resource.Quantity {
+	overhead := *resource.NewScaledQuantity(memory.ScaledValue(resource.Kilo)/512, resource.Kilo)
+	overhead.Add(virtLauncherStaticMemoryOverhead)
+	vcpus := int64(cores)
+	if vcpus < 1 {
+		vcpus = 1
+	}
+	overhead.Add(*resource.NewQuantity(vcpus*virtLauncherMemoryOverheadPerVCPU.Value(), resource.BinarySI))
+	if autoattachGraphicsDevice == nil || *autoattachGraphicsDevice {
+		overhead.Add(virtLauncherGraphicsMemoryOverhead)
+	}
+	return overhead
+}
+
+// setVMResources sets the CPU cores and memory of the VM template, the CPU and memory limits and the guest memory
+// are changed too if they are set. The CPU request is kept unless it's more than the cores.
+func setVMResources(vm *kv1.VirtualMachine, cores uint32, memory resource.Quantity) {
+	domain := &vm.Spec.Template.Spec.Domain
+	if domain.CPU == nil {
+		domain.CPU = &kv1.CPU{}
+	}
+	domain.CPU.Cores = cores
+
+	cpu := *resource.NewQuantity(int64(cores), resource.DecimalSI)
+	if domain.Resources.Requests == nil {
+		domain.Resources.Requests = corev1.ResourceList{}
+	}
+	domain.Resources.Requests[corev1.ResourceMemory] = memory
+	if request, ok := domain.Resources.Requests[corev1.ResourceCPU]; ok && request.Cmp(cpu) > 0 {
+		domain.Resources.Requests[corev1.ResourceCPU] = cpu
+	}
+	if _, ok := domain.Resources.Limits[corev1.ResourceCPU]; ok {
+		domain.Resources.Limits[corev1.ResourceCPU] = cpu
+	}
+	if _, ok := domain.Resources.Limits[corev1.ResourceMemory]; ok {
+		domain.Resources.Limits[corev1.ResourceMemory] = memory
+	}
+	if domain.Memory != nil && domain.Memory.Guest != nil {
+		guest := memory.DeepCopy()
+		domain.Memory.Guest = &guest
+	}
+}
@@ -0,0 +1,197 @@
+package vm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corefake "k8s.io/client-go/kubernetes/fake"
+	kv1 "kubevirt.io/client-go/api/v1"
+
+	"github.com/harvester/harvester/pkg/controller/master/virtualmachine"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/fake"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+func newResizeNode(name, cpu, memory string, unschedulable bool) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       corev1.NodeSpec{Unschedulable: unschedulable},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			},
+		},
+	}
+}
+
+func newResizePod(name, nodeName, cpu, memory string, labels map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: labels},
+		Spec: corev1.PodSpec{
+			NodeName: nodeName,
+			Containers: []corev1.Container{
+				{
+					Name: "compute",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse(cpu),
+							corev1.ResourceMemory: resource.MustParse(memory),
+						},
+					},
+				},
+			},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func newResizeVMISpec(cores uint32, memory string) kv1.VirtualMachineInstanceSpec {
+	return kv1.VirtualMachineInstanceSpec{
+		Domain: kv1.DomainSpec{
+			CPU: &kv1.CPU{Cores: cores},
+			Resources: kv1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(memory)},
+				Limits: corev1.ResourceList{
+					corev1.ResourceCPU:    *resource.NewQuantity(int64(cores), resource.DecimalSI),
+					corev1.ResourceMemory: resource.MustParse(memory),
+				},
+			},
+		},
+	}
+}
+
+func TestResizeVM(t *testing.T) {
+	runningVMI := &kv1.VirtualMachineInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "vmi-uid"},
+		Spec:       newResizeVMISpec(2, "4Gi"),
+		Status:     kv1.VirtualMachineInstanceStatus{Phase: kv1.Running},
+	}
+	var testCases = []struct {
+		name            string
+		vmi             *kv1.VirtualMachineInstance
+		input           ResizeInput
+		expectError     bool
+		restartRequired bool
+	}{
+		{
+			name:            "stopped VM is resized on the next start",
+			input:           ResizeInput{Cores: 4, Memory: "8Gi"},
+			restartRequired: false,
+		},
+		{
+			name:            "running VM requires a restart",
+			vmi:             runningVMI,
+			input:           ResizeInput{Cores: 4, Memory: "8Gi"},
+			restartRequired: true,
+		},
+		{
+			name:            "running VM resized to its resources",
+			vmi:             runningVMI,
+			input:           ResizeInput{Cores: 2, Memory: "4Gi"},
+			restartRequired: false,
+		},
+		{
+			name:            "the virt-launcher pod of the running VM is replaced",
+			vmi:             runningVMI,
+			input:           ResizeInput{Cores: 6, Memory: "8Gi"},
+			restartRequired: true,
+		},
+		{
+			name:        "the other pods leave too few cores",
+			input:       ResizeInput{Cores: 7, Memory: "8Gi"},
+			expectError: true,
+		},
+		{
+			name:        "the other pods and the virt-launcher overhead leave too little memory",
+			input:       ResizeInput{Cores: 4, Memory: "16Gi"},
+			expectError: true,
+		},
+		{
+			name:        "more cores than any node",
+			input:       ResizeInput{Cores: 16, Memory: "8Gi"},
+			expectError: true,
+		},
+		{
+			name:        "more memory than any node",
+			input:       ResizeInput{Cores: 4, Memory: "64Gi"},
+			expectError: true,
+		},
+		{
+			name:        "fits an unschedulable node only",
+			input:       ResizeInput{Cores: 12, Memory: "8Gi"},
+			expectError: true,
+		},
+		{
+			name:        "invalid memory",
+			input:       ResizeInput{Cores: 4, Memory: "8 GB"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		vm := &kv1.VirtualMachine{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+			Spec: kv1.VirtualMachineSpec{
+				Template: &kv1.VirtualMachineInstanceTemplateSpec{Spec: newResizeVMISpec(2, "4Gi")},
+			},
+		}
+		var clientset = fake.NewSimpleClientset(vm)
+		if tc.vmi != nil {
+			assert.Nil(t, clientset.Tracker().Add(tc.vmi), "mock resource should add into fake controller tracker")
+		}
+		var coreclientset = corefake.NewSimpleClientset(
+			newResizeNode("node1", "8", "32Gi", false),
+			newResizeNode("node2", "12", "16Gi", true),
+			newResizePod("workload", "node1", "2", "16Gi", nil),
+			newResizePod("virt-launcher-test", "node1", "2", "5Gi", map[string]string{kv1.CreatedByLabel: "vmi-uid"}),
+			newResizePod("pending", "", "8", "32Gi", nil),
+		)
+		var handler = &vmActionHandler{
+			vms:       fakeclients.VirtualMachineClient(clientset.KubevirtV1().VirtualMachines),
+			vmCache:   fakeclients.VirtualMachineCache(clientset.KubevirtV1().VirtualMachines),
+			vmiCache:  fakeclients.VirtualMachineInstanceCache(clientset.KubevirtV1().VirtualMachineInstances),
+			nodeCache: fakeclients.NodeCache(coreclientset.CoreV1().Nodes),
+			podCache:  fakeclients.PodCache(coreclientset.CoreV1().Pods),
+		}
+
+		err := handler.resizeVM(context.Background(), "default", "test", tc.input)
+		if tc.expectError {
+			assert.NotNil(t, err, "case %q", tc.name)
+			continue
+		}
+		assert.Nil(t, err, "case %q", tc.name)
+
+		actual, err := clientset.KubevirtV1().VirtualMachines("default").Get(context.TODO(), "test", metav1.GetOptions{})
+		assert.Nil(t, err, "case %q", tc.name)
+		assert.Equal(t, tc.input.Cores, actual.Spec.Template.Spec.Domain.CPU.Cores, "case %q", tc.name)
+		memory := actual.Spec.Template.Spec.Domain.Resources.Requests[corev1.ResourceMemory]
+		assert.Equal(t, tc.input.Memory, memory.String(), "case %q", tc.name)
+		assert.Equal(t, tc.restartRequired, virtualmachine.IsRestartRequired(actual), "case %q", tc.name)
+	}
+}
+
+func TestSetVMResources(t *testing.T) {
+	vm := &kv1.VirtualMachine{
+		Spec: kv1.VirtualMachineSpec{
+			Template: &kv1.VirtualMachineInstanceTemplateSpec{Spec: newResizeVMISpec(2, "4Gi")},
+		},
+	}
+	guest := resource.MustParse("4Gi")
+	domain := &vm.Spec.Template.Spec.Domain
+	domain.Memory = &kv1.Memory{Guest: &guest}
+	domain.Resources.Requests[corev1.ResourceCPU] = resource.MustParse("3")
+
+	setVMResources(vm, 1, resource.MustParse("2Gi"))
+	assert.Equal(t, uint32(1), domain.CPU.Cores)
+	assert.Equal(t, "1", domain.Resources.Limits.Cpu().String())
+	assert.Equal(t, "1", domain.Resources.Requests.Cpu().String(), "the CPU request can't be more than the cores")
+	assert.Equal(t, "2Gi", domain.Resources.Requests.Memory().String())
+	assert.Equal(t, "2Gi", domain.Resources.Limits.Memory().String())
+	assert.Equal(t, "2Gi", domain.Memory.Guest.String())
+	assert.Equal(t, "4Gi", guest.String(), "the former guest memory isn't modified")
+}
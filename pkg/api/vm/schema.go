@@ -31,8 +31,15 @@ func RegisterSchema(scaled *config.Scaled, server *server.Server, options config
 	server.BaseSchemas.MustImportAndCustomize(EjectCdRomActionInput{}, nil)
 	server.BaseSchemas.MustImportAndCustomize(BackupInput{}, nil)
 	server.BaseSchemas.MustImportAndCustomize(RestoreInput{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(RestoreVolumesInput{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(RestorePreflightReport{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(SnapshotInput{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(RevertToSnapshotInput{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(DeleteSnapshotInput{}, nil)
 	server.BaseSchemas.MustImportAndCustomize(MigrateInput{}, nil)
 	server.BaseSchemas.MustImportAndCustomize(CreateTemplateInput{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(CloneInput{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(ResizeInput{}, nil)
 	server.BaseSchemas.MustImportAndCustomize(AddVolumeInput{}, nil)
 	server.BaseSchemas.MustImportAndCustomize(RemoveVolumeInput{}, nil)
 
@@ -41,9 +48,11 @@ func RegisterSchema(scaled *config.Scaled, server *server.Server, options config
 	vmims := scaled.VirtFactory.Kubevirt().V1().VirtualMachineInstanceMigration()
 	backups := scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackup()
 	restores := scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineRestore()
+	vmSnapshots := scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineSnapshot()
 	settings := scaled.HarvesterFactory.Harvesterhci().V1beta1().Setting()
 	nodes := scaled.CoreFactory.Core().V1().Node()
 	pvcs := scaled.CoreFactory.Core().V1().PersistentVolumeClaim()
+	secrets := scaled.CoreFactory.Core().V1().Secret()
 	vmt := scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineTemplate()
 	vmtv := scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineTemplateVersion()
 
@@ -59,6 +68,8 @@ func RegisterSchema(scaled *config.Scaled, server *server.Server, options config
 	if err != nil {
 		return err
 	}
+	restorePreflightHandler := NewRestorePreflightHandler(scaled, false)
+
 	actionHandler := vmActionHandler{
 		namespace:                 options.Namespace,
 		vms:                       vms,
@@ -72,9 +83,16 @@ func RegisterSchema(scaled *config.Scaled, server *server.Server, options config
 		backups:                   backups,
 		backupCache:               backups.Cache(),
 		restores:                  restores,
+		vmSnapshots:               vmSnapshots,
+		vmSnapshotCache:           vmSnapshots.Cache(),
 		settingCache:              settings.Cache(),
 		nodeCache:                 nodes.Cache(),
+		podCache:                  scaled.CoreFactory.Core().V1().Pod().Cache(),
+		pvcs:                      pvcs,
 		pvcCache:                  pvcs.Cache(),
+		secrets:                   secrets,
+		secretCache:               secrets.Cache(),
+		subjectAccessReviews:      scaled.Management.ClientSet.AuthorizationV1().SubjectAccessReviews(),
 		virtSubresourceRestClient: virtSubresourceClient,
 		virtRestClient:            virtv1Client.RESTClient(),
 	}
@@ -83,6 +101,15 @@ func RegisterSchema(scaled *config.Scaled, server *server.Server, options config
 		vmiCache: vmis.Cache(),
 	}
 
+	console := newConsoleProxy(copyConfig, virtSubresourceClient, options.ConsoleRecordingDir)
+	go console.pruneRecordings(scaled.Ctx)
+
+	linkHandler := &vmLinkHandler{
+		vmSnapshotCache:           vmSnapshots.Cache(),
+		console:                   console,
+		virtSubresourceRestClient: virtSubresourceClient,
+	}
+
 	vmStore := &vmStore{
 		Store:    proxy.NewProxyStore(server.ClientFactory, nil, server.AccessSetLookup),
 		vms:      scaled.VirtFactory.Kubevirt().V1().VirtualMachine(),
@@ -95,20 +122,28 @@ func RegisterSchema(scaled *config.Scaled, server *server.Server, options config
 		ID: vmSchemaID,
 		Customize: func(apiSchema *types.APISchema) {
 			apiSchema.ActionHandlers = map[string]http.Handler{
-				startVM:        &actionHandler,
-				stopVM:         &actionHandler,
-				restartVM:      &actionHandler,
-				ejectCdRom:     &actionHandler,
-				pauseVM:        &actionHandler,
-				unpauseVM:      &actionHandler,
-				migrate:        &actionHandler,
-				abortMigration: &actionHandler,
-				backupVM:       &actionHandler,
-				restoreVM:      &actionHandler,
-				createTemplate: &actionHandler,
-				addVolume:      &actionHandler,
-				removeVolume:   &actionHandler,
+				startVM:          &actionHandler,
+				stopVM:           &actionHandler,
+				restartVM:        &actionHandler,
+				ejectCdRom:       &actionHandler,
+				pauseVM:          &actionHandler,
+				unpauseVM:        &actionHandler,
+				migrate:          &actionHandler,
+				abortMigration:   &actionHandler,
+				backupVM:         &actionHandler,
+				restoreVM:        &actionHandler,
+				restoreVolumes:   &actionHandler,
+				restorePreflight: restorePreflightHandler,
+				snapshotVM:       &actionHandler,
+				revertToSnapshot: &actionHandler,
+				deleteSnapshot:   &actionHandler,
+				createTemplate:   &actionHandler,
+				cloneVM:          &actionHandler,
+				resizeVM:         &actionHandler,
+				addVolume:        &actionHandler,
+				removeVolume:     &actionHandler,
 			}
+			apiSchema.ByIDHandler = linkHandler.byIDHandler
 			apiSchema.ResourceActions = map[string]schemas.Action{
 				startVM:   {},
 				stopVM:    {},
@@ -128,9 +163,31 @@ func RegisterSchema(scaled *config.Scaled, server *server.Server, options config
 				restoreVM: {
 					Input: "restoreInput",
 				},
+				restoreVolumes: {
+					Input: "restoreVolumesInput",
+				},
+				restorePreflight: {
+					Input:  "restoreInput",
+					Output: "restorePreflightReport",
+				},
+				snapshotVM: {
+					Input: "snapshotInput",
+				},
+				revertToSnapshot: {
+					Input: "revertToSnapshotInput",
+				},
+				deleteSnapshot: {
+					Input: "deleteSnapshotInput",
+				},
 				createTemplate: {
 					Input: "createTemplateInput",
 				},
+				cloneVM: {
+					Input: "cloneInput",
+				},
+				resizeVM: {
+					Input: "resizeInput",
+				},
 				addVolume: {
 					Input: "addVolumeInput",
 				},
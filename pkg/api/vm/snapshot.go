@@ -0,0 +1,206 @@
+package vm
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/handlers"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/rest"
+	kv1 "kubevirt.io/client-go/api/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	vmSnapshotSchemaID = "harvesterhci.io.virtualmachinesnapshot"
+	snapshotsLink      = "snapshots"
+)
+
+type vmLinkHandler struct {
+	vmSnapshotCache           ctlharvesterv1.VirtualMachineSnapshotCache
+	console                   *consoleProxy
+	virtSubresourceRestClient rest.Interface
+}
+
+func (h *vmLinkHandler) byIDHandler(request *types.APIRequest) (types.APIObject, error) {
+	switch request.Link {
+	case snapshotsLink:
+		snapshots, err := h.getSnapshots(request.Namespace, request.Name)
+		if err != nil {
+			return types.APIObject{}, err
+		}
+
+		request.ResponseWriter.WriteList(request, http.StatusOK, snapshots)
+		return types.APIObject{}, validation.ErrComplete
+	case consoleLink, vncLink:
+		// the consoles are available to the users who can update the VM
+		obj, err := handlers.ByIDHandler(request)
+		if err != nil {
+			return obj, err
+		}
+		if err := request.AccessControl.CanUpdate(request, obj, request.Schema); err != nil {
+			return types.APIObject{}, err
+		}
+		if err := h.console.serve(request); err != nil {
+			return types.APIObject{}, err
+		}
+		return types.APIObject{}, validation.ErrComplete
+	case guestOSInfoLink, filesystemsLink, userListLink:
+		return h.guestAgentByID(request)
+	}
+
+	return handlers.ByIDHandler(request)
+}
+
+func (h *vmLinkHandler) getSnapshots(vmNamespace, vmName string) (types.APIObjectList, error) {
+	vmSnapshots, err := listVMSnapshots(h.vmSnapshotCache, vmNamespace, vmName)
+	if err != nil {
+		return types.APIObjectList{}, apierror.NewAPIError(validation.ServerError, err.Error())
+	}
+
+	result := make([]types.APIObject, 0, len(vmSnapshots))
+	for _, vmSnapshot := range vmSnapshots {
+		result = append(result, types.APIObject{
+			Type:   vmSnapshotSchemaID,
+			ID:     fmt.Sprintf("%s/%s", vmSnapshot.Namespace, vmSnapshot.Name),
+			Object: vmSnapshot,
+		})
+	}
+
+	return types.APIObjectList{
+		Objects: result,
+	}, nil
+}
+
+// listVMSnapshots returns the snapshots of the VM, or the snapshots of all VMs in the namespace if vmName is empty.
+func listVMSnapshots(vmSnapshotCache ctlharvesterv1.VirtualMachineSnapshotCache, vmNamespace, vmName string) ([]*harvesterv1.VirtualMachineSnapshot, error) {
+	vmSnapshots, err := vmSnapshotCache.List(vmNamespace, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*harvesterv1.VirtualMachineSnapshot, 0, len(vmSnapshots))
+	for _, vmSnapshot := range vmSnapshots {
+		if vmSnapshot.Spec.Source.Kind != kv1.VirtualMachineGroupVersionKind.Kind {
+			continue
+		}
+		if vmName == "" || vmSnapshot.Spec.Source.Name == vmName {
+			result = append(result, vmSnapshot)
+		}
+	}
+	return result, nil
+}
+
+func (h *vmActionHandler) createVMSnapshot(vmName, vmNamespace string, input SnapshotInput) error {
+	apiGroup := kv1.SchemeGroupVersion.Group
+	vmSnapshot := &harvesterv1.VirtualMachineSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      input.Name,
+			Namespace: vmNamespace,
+		},
+		Spec: harvesterv1.VirtualMachineSnapshotSpec{
+			Source: corev1.TypedLocalObjectReference{
+				APIGroup: &apiGroup,
+				Kind:     kv1.VirtualMachineGroupVersionKind.Kind,
+				Name:     vmName,
+			},
+		},
+	}
+	if _, err := h.vmSnapshots.Create(vmSnapshot); err != nil {
+		return fmt.Errorf("failed to create VM snapshot, error: %s", err.Error())
+	}
+	return nil
+}
+
+func (h *vmActionHandler) deleteVMSnapshot(vmName, vmNamespace string, input DeleteSnapshotInput) error {
+	if _, err := h.getVMSnapshot(vmName, vmNamespace, input.SnapshotName); err != nil {
+		return err
+	}
+	if err := h.vmSnapshots.Delete(vmNamespace, input.SnapshotName, &metav1.DeleteOptions{}); err != nil {
+		return fmt.Errorf("failed to delete VM snapshot, error: %s", err.Error())
+	}
+	return nil
+}
+
+// revertToSnapshot restores the VM spec stored in the snapshot and marks the VM to be reverted, the backup
+// controller reverts the volumes to their longhorn snapshots in place and removes the mark. The VM must be stopped.
+func (h *vmActionHandler) revertToSnapshot(vmName, vmNamespace string, input RevertToSnapshotInput) error {
+	vm, err := h.vmCache.Get(vmNamespace, vmName)
+	if err != nil {
+		return err
+	}
+
+	// the same checks as restoring a backup to the existing VM
+	stoppedErr := apierror.NewAPIError(validation.Conflict, fmt.Sprintf("please stop the VM %q before reverting it to a snapshot", vm.Name))
+	if vm.Status.Ready || vm.Status.SnapshotInProgress != nil {
+		return stoppedErr
+	}
+	if _, err := h.vmiCache.Get(vmNamespace, vmName); err == nil {
+		return stoppedErr
+	} else if !apierrors.IsNotFound(err) {
+		return err
+	}
+	if snapshotName, ok := vm.Annotations[util.AnnotationRevertToSnapshot]; ok {
+		return apierror.NewAPIError(validation.Conflict, fmt.Sprintf("VM %q is being reverted to snapshot %s", vm.Name, snapshotName))
+	}
+
+	vmSnapshot, err := h.getVMSnapshot(vmName, vmNamespace, input.SnapshotName)
+	if err != nil {
+		return err
+	}
+	if vmSnapshot.Status == nil || vmSnapshot.Status.SourceUID == nil || *vmSnapshot.Status.SourceUID != vm.UID {
+		return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("snapshot %s is not taken from the VM %s", vmSnapshot.Name, vm.Name))
+	}
+	if vmSnapshot.Status.ReadyToUse == nil || !*vmSnapshot.Status.ReadyToUse || vmSnapshot.Status.SourceSpec == nil {
+		return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("snapshot %s is not ready to use", vmSnapshot.Name))
+	}
+
+	// the snapshots are kept in the volumes, so the volumes of the snapshot must still be bound to the PVCs
+	for _, volumeSnapshot := range vmSnapshot.Status.VolumeSnapshots {
+		claim := volumeSnapshot.PersistentVolumeClaim
+		pvc, err := h.pvcCache.Get(vmNamespace, claim.ObjectMeta.Name)
+		if apierrors.IsNotFound(err) {
+			return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("PVC %s of snapshot %s is not found", claim.ObjectMeta.Name, vmSnapshot.Name))
+		} else if err != nil {
+			return err
+		}
+		if pvc.Spec.VolumeName != claim.Spec.VolumeName {
+			return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("volume of PVC %s is changed since snapshot %s is taken", pvc.Name, vmSnapshot.Name))
+		}
+	}
+
+	vmCpy := vm.DeepCopy()
+	vmCpy.Spec = *vmSnapshot.Status.SourceSpec.Spec.DeepCopy()
+	// keep the VM stopped after reverting it
+	vmCpy.Spec.Running = vm.Spec.Running
+	vmCpy.Spec.RunStrategy = vm.Spec.RunStrategy
+	if vmCpy.Annotations == nil {
+		vmCpy.Annotations = map[string]string{}
+	}
+	if volumeClaimTemplates, ok := vmSnapshot.Status.SourceSpec.ObjectMeta.Annotations[util.AnnotationVolumeClaimTemplates]; ok {
+		vmCpy.Annotations[util.AnnotationVolumeClaimTemplates] = volumeClaimTemplates
+	}
+	vmCpy.Annotations[util.AnnotationRevertToSnapshot] = vmSnapshot.Name
+	_, err = h.vms.Update(vmCpy)
+	return err
+}
+
+func (h *vmActionHandler) getVMSnapshot(vmName, vmNamespace, snapshotName string) (*harvesterv1.VirtualMachineSnapshot, error) {
+	vmSnapshot, err := h.vmSnapshotCache.Get(vmNamespace, snapshotName)
+	if err != nil {
+		return nil, err
+	}
+	if vmSnapshot.Spec.Source.Kind != kv1.VirtualMachineGroupVersionKind.Kind || vmSnapshot.Spec.Source.Name != vmName {
+		return nil, apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("snapshot %s is not taken from the VM %s", snapshotName, vmName))
+	}
+	return vmSnapshot, nil
+}
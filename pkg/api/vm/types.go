@@ -3,8 +3,9 @@ package vm
 import "github.com/rancher/wrangler/pkg/condition"
 
 var (
-	vmReady   condition.Cond = "Ready"
-	vmiPaused condition.Cond = "Paused"
+	vmReady           condition.Cond = "Ready"
+	vmiPaused         condition.Cond = "Paused"
+	vmiAgentConnected condition.Cond = "AgentConnected"
 )
 
 type EjectCdRomActionInput struct {
@@ -12,12 +13,40 @@ type EjectCdRomActionInput struct {
 }
 
 type BackupInput struct {
-	Name string `json:"name"`
+	Name         string `json:"name"`
+	BackupTarget string `json:"backupTarget,omitempty"`
 }
 
 type RestoreInput struct {
 	Name       string `json:"name"`
 	BackupName string `json:"backupName"`
+	// Detached restores the volumes into standalone PVCs and leaves the VM alone
+	Detached        bool                 `json:"detached,omitempty"`
+	Volumes         []RestoreVolumeInput `json:"volumes,omitempty"`
+	TargetNamespace string               `json:"targetNamespace,omitempty"`
+}
+
+type RestoreVolumesInput struct {
+	Name       string               `json:"name"`
+	BackupName string               `json:"backupName"`
+	Volumes    []RestoreVolumeInput `json:"volumes,omitempty"`
+}
+
+type RestoreVolumeInput struct {
+	VolumeBackupName          string `json:"volumeBackupName"`
+	PersistentVolumeClaimName string `json:"persistentVolumeClaimName,omitempty"`
+}
+
+type SnapshotInput struct {
+	Name string `json:"name"`
+}
+
+type RevertToSnapshotInput struct {
+	SnapshotName string `json:"snapshotName"`
+}
+
+type DeleteSnapshotInput struct {
+	SnapshotName string `json:"snapshotName"`
 }
 
 type MigrateInput struct {
@@ -29,6 +58,22 @@ type CreateTemplateInput struct {
 	Description string `json:"description,omitempty"`
 }
 
+// CloneInput is the input of the clone action, the VM is cloned to its namespace as the CSI volume clones are created
+// in the namespace of the source volumes. The target namespace is optional and must be the namespace of the VM.
+type CloneInput struct {
+	TargetName      string `json:"targetName"`
+	TargetNamespace string `json:"targetNamespace,omitempty"`
+	Running         bool   `json:"running,omitempty"`
+}
+
+// ResizeInput is the input of the resize action, the change is applied when the VM is restarted unless a restart is
+// requested
+type ResizeInput struct {
+	Cores   uint32 `json:"cores"`
+	Memory  string `json:"memory"`
+	Restart bool   `json:"restart,omitempty"`
+}
+
 type AddVolumeInput struct {
 	DiskName         string `json:"diskName"`
 	VolumeSourceName string `json:"volumeSourceName"`
@@ -42,3 +87,15 @@ type ExportVolumeInput struct {
 	DisplayName string `json:"displayName"`
 	Namespace   string `json:"namespace"`
 }
+
+// RestorePreflightReport is the result of the restorePreflight action, the restore is blocked by any of the errors,
+// and the warnings point out what might go wrong after the restore.
+type RestorePreflightReport struct {
+	Errors   []RestorePreflightMessage `json:"errors"`
+	Warnings []RestorePreflightMessage `json:"warnings"`
+}
+
+type RestorePreflightMessage struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
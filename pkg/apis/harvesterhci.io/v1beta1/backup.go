@@ -17,6 +17,12 @@ const (
 
 	// ConditionProgressing is the "progressing" condition type
 	BackupConditionProgressing condition.Cond = "InProgress"
+
+	// BackupConditionMetadataReady is the condition type of the VM metadata being stored in the backup target
+	BackupConditionMetadataReady condition.Cond = "MetadataReady"
+
+	// BackupConditionApplicationConsistent is the condition type of the guest filesystems being frozen during the volume snapshots
+	BackupConditionApplicationConsistent condition.Cond = "ApplicationConsistent"
 )
 
 // DeletionPolicy defines that to do with resources when VirtualMachineRestore is deleted
@@ -31,12 +37,25 @@ const (
 	VirtualMachineRestoreRetain DeletionPolicy = "retain"
 )
 
+// VirtualMachineRestoreMode defines what a VirtualMachineRestore restores the volume backups into
+type VirtualMachineRestoreMode string
+
+const (
+	// VirtualMachineRestoreModeVM is the default and restores the whole VM, either in place or as a new VM
+	VirtualMachineRestoreModeVM VirtualMachineRestoreMode = "vm"
+
+	// VirtualMachineRestoreModeDetachedPVCs restores the selected volumes into standalone PVCs
+	// and leaves the source VM alone
+	VirtualMachineRestoreModeDetachedPVCs VirtualMachineRestoreMode = "detachedPVCs"
+)
+
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +kubebuilder:resource:shortName=vmbackup;vmbackups,scope=Namespaced
 // +kubebuilder:printcolumn:name="SOURCE_KIND",type=string,JSONPath=`.spec.source.kind`
 // +kubebuilder:printcolumn:name="SOURCE_NAME",type=string,JSONPath=`.spec.source.name`
 // +kubebuilder:printcolumn:name="READY_TO_USE",type=boolean,JSONPath=`.status.readyToUse`
+// +kubebuilder:printcolumn:name="PROGRESS",type=integer,JSONPath=`.status.progress`
 // +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=`.metadata.creationTimestamp`
 // +kubebuilder:printcolumn:name="ERROR",type=date,JSONPath=`.status.error.message`
 
@@ -52,6 +71,25 @@ type VirtualMachineBackup struct {
 
 type VirtualMachineBackupSpec struct {
 	Source corev1.TypedLocalObjectReference `json:"source"`
+
+	// BackupTarget refers to the backup target storing the backup, the default backup target is used if it is empty
+	// +optional
+	BackupTarget *corev1.LocalObjectReference `json:"backupTarget,omitempty"`
+
+	// ApplicationConsistent freezes the guest filesystems through the guest agent while taking the volume snapshots
+	// +optional
+	ApplicationConsistent *ApplicationConsistentSpec `json:"applicationConsistent,omitempty"`
+}
+
+type ApplicationConsistentSpec struct {
+	// FreezeTimeout is the maximum duration of the guest filesystems being frozen, defaults to 5 minutes
+	// +optional
+	FreezeTimeout *metav1.Duration `json:"freezeTimeout,omitempty"`
+
+	// FallbackToCrashConsistent continues the backup without freezing the guest filesystems if the guest agent
+	// is not connected or the freeze fails, otherwise the backup fails
+	// +optional
+	FallbackToCrashConsistent bool `json:"fallbackToCrashConsistent,omitempty"`
 }
 
 // VirtualMachineBackupStatus is the status for a VirtualMachineBackup resource
@@ -77,6 +115,10 @@ type VirtualMachineBackupStatus struct {
 
 	// +optional
 	Conditions []Condition `json:"conditions,omitempty"`
+
+	// +optional
+	// Progress is the aggregated percentage of the volume data transferred
+	Progress int `json:"progress,omitempty"`
 }
 
 // Error is the last error encountered during the snapshot/restore
@@ -107,6 +149,32 @@ type VolumeBackup struct {
 
 	// +optional
 	Error *Error `json:"error,omitempty"`
+
+	// +optional
+	// LonghornBackupName is the name of the longhorn backup stored in the backup target
+	LonghornBackupName *string `json:"longhornBackupName,omitempty"`
+
+	// +optional
+	// Progress is the percentage of the volume data transferred
+	Progress int `json:"progress,omitempty"`
+
+	// +optional
+	// BytesDone is the size of the volume data transferred in bytes, estimated from the progress and the PVC size
+	BytesDone int64 `json:"bytesDone,omitempty"`
+
+	// +optional
+	// BytesTotal is the size of the volume data to transfer in bytes
+	BytesTotal int64 `json:"bytesTotal,omitempty"`
+
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// +optional
+	EndTime *metav1.Time `json:"endTime,omitempty"`
+
+	// +optional
+	// EstimatedEndTime is the end time estimated from the progress rate since the start time
+	EstimatedEndTime *metav1.Time `json:"estimatedEndTime,omitempty"`
 }
 
 type PersistentVolumeClaimSourceSpec struct {
@@ -125,6 +193,7 @@ type PersistentVolumeClaimSourceSpec struct {
 // +kubebuilder:printcolumn:name="TARGET_KIND",type=string,JSONPath=`.spec.target.kind`
 // +kubebuilder:printcolumn:name="TARGET_NAME",type=string,JSONPath=`.spec.target.name`
 // +kubebuilder:printcolumn:name="COMPLETE",type=boolean,JSONPath=`.status.complete`
+// +kubebuilder:printcolumn:name="PROGRESS",type=integer,JSONPath=`.status.progress`
 // +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=`.metadata.creationTimestamp`
 // +kubebuilder:printcolumn:name="ERROR",type=date,JSONPath=`.status.error.message`
 
@@ -154,6 +223,42 @@ type VirtualMachineRestoreSpec struct {
 
 	// +optional
 	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// Mode is the restore mode, defaults to vm
+	// +optional
+	// +kubebuilder:validation:Enum=vm;detachedPVCs
+	Mode VirtualMachineRestoreMode `json:"mode,omitempty"`
+
+	// Volumes selects the volume backups to restore in the detachedPVCs mode, all volumes are restored if it is empty
+	// +optional
+	Volumes []VolumeRestoreSource `json:"volumes,omitempty"`
+
+	// TargetNamespace is the namespace to restore the VM and its volumes into,
+	// defaults to the namespace of the VirtualMachineRestore
+	// +optional
+	TargetNamespace string `json:"targetNamespace,omitempty"`
+
+	// StorageClassMappings maps the storage class names of the backed-up volumes to the storage classes of the restored volumes
+	// +optional
+	StorageClassMappings map[string]string `json:"storageClassMappings,omitempty"`
+
+	// NetworkMappings maps the Multus network names of the backed-up VM to the networks of the restored VM
+	// +optional
+	NetworkMappings map[string]string `json:"networkMappings,omitempty"`
+
+	// RegenerateMACAddresses clears the MAC addresses of the restored VM interfaces so that new ones are generated
+	// +optional
+	RegenerateMACAddresses bool `json:"regenerateMACAddresses,omitempty"`
+}
+
+// VolumeRestoreSource selects a volume backup to restore and the name of the restored PVC
+type VolumeRestoreSource struct {
+	// +kubebuilder:validation:Required
+	VolumeBackupName string `json:"volumeBackupName"`
+
+	// PersistentVolumeClaimName is the name of the restored PVC, a name is generated if it is empty
+	// +optional
+	PersistentVolumeClaimName string `json:"persistentVolumeClaimName,omitempty"`
 }
 
 // VirtualMachineRestoreStatus is the spec for a VirtualMachineRestore resource
@@ -174,6 +279,10 @@ type VirtualMachineRestoreStatus struct {
 	Conditions []Condition `json:"conditions,omitempty"`
 
 	TargetUID *types.UID `json:"targetUID,omitempty"`
+
+	// +optional
+	// Progress is the aggregated percentage of the volume data transferred
+	Progress int `json:"progress,omitempty"`
 }
 
 // VolumeRestore contains the volume data need to restore a PVC
@@ -183,4 +292,115 @@ type VolumeRestore struct {
 	PersistentVolumeClaim PersistentVolumeClaimSourceSpec `json:"persistentVolumeClaimSpec,omitempty"`
 
 	VolumeBackupName string `json:"volumeBackupName,omitempty"`
+
+	// +optional
+	// Progress is the percentage of the volume data transferred
+	Progress int `json:"progress,omitempty"`
+
+	// +optional
+	// BytesDone is the size of the volume data transferred in bytes, estimated from the progress and the PVC size
+	BytesDone int64 `json:"bytesDone,omitempty"`
+
+	// +optional
+	// BytesTotal is the size of the volume data to transfer in bytes
+	BytesTotal int64 `json:"bytesTotal,omitempty"`
+
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// +optional
+	EndTime *metav1.Time `json:"endTime,omitempty"`
+
+	// +optional
+	// EstimatedEndTime is the end time estimated from the progress rate since the start time
+	EstimatedEndTime *metav1.Time `json:"estimatedEndTime,omitempty"`
+}
+
+// VirtualMachineBackupSchedule defines a cron schedule which periodically backs up the selected VMs
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:shortName=vmbackupschedule;vmbackupschedules,scope=Namespaced
+// +kubebuilder:printcolumn:name="CRON",type=string,JSONPath=`.spec.cron`
+// +kubebuilder:printcolumn:name="SUSPEND",type=boolean,JSONPath=`.spec.suspend`
+// +kubebuilder:printcolumn:name="LAST_RUN",type=date,JSONPath=`.status.lastRunTime`
+// +kubebuilder:printcolumn:name="NEXT_RUN",type=date,JSONPath=`.status.nextRunTime`
+// +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="ERROR",type=string,JSONPath=`.status.lastError.message`
+
+type VirtualMachineBackupSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VirtualMachineBackupScheduleSpec `json:"spec"`
+
+	// +optional
+	Status VirtualMachineBackupScheduleStatus `json:"status,omitempty"`
+}
+
+// VirtualMachineBackupScheduleSpec is the spec for a VirtualMachineBackupSchedule resource
+type VirtualMachineBackupScheduleSpec struct {
+	// +kubebuilder:validation:Required
+	// Cron is a standard five-field cron expression, e.g. "0 2 * * *"
+	Cron string `json:"cron"`
+
+	// +kubebuilder:validation:Required
+	// VMSelector selects the VMs to back up in the namespace of the schedule
+	VMSelector VirtualMachineSelector `json:"vmSelector"`
+
+	// +optional
+	// Suspend stops the schedule from creating new backups
+	Suspend bool `json:"suspend,omitempty"`
+
+	// +optional
+	// Retention prunes the backups created by the schedule, overrides the VM and cluster default policies
+	Retention *BackupRetentionPolicy `json:"retention,omitempty"`
+}
+
+// BackupRetentionPolicy defines which ready VirtualMachineBackups of a VM are kept,
+// a backup is kept if any of the rules keeps it and all backups are kept if no rule is set
+type BackupRetentionPolicy struct {
+	// +optional
+	// KeepLast keeps the latest N backups
+	KeepLast *int `json:"keepLast,omitempty"`
+
+	// +optional
+	// KeepWithin keeps the backups created within the duration, e.g. "168h"
+	KeepWithin *metav1.Duration `json:"keepWithin,omitempty"`
+
+	// +optional
+	// KeepDaily keeps the latest backup of each of the last N days which have backups
+	KeepDaily *int `json:"keepDaily,omitempty"`
+
+	// +optional
+	// KeepWeekly keeps the latest backup of each of the last N weeks which have backups
+	KeepWeekly *int `json:"keepWeekly,omitempty"`
+
+	// +optional
+	// KeepMonthly keeps the latest backup of each of the last N months which have backups
+	KeepMonthly *int `json:"keepMonthly,omitempty"`
+}
+
+// VirtualMachineSelector selects VMs by names and/or labels, the result is the union of both
+type VirtualMachineSelector struct {
+	// +optional
+	Names []string `json:"names,omitempty"`
+
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+}
+
+// VirtualMachineBackupScheduleStatus is the status for a VirtualMachineBackupSchedule resource
+type VirtualMachineBackupScheduleStatus struct {
+	// +optional
+	LastRunTime *metav1.Time `json:"lastRunTime,omitempty"`
+
+	// +optional
+	NextRunTime *metav1.Time `json:"nextRunTime,omitempty"`
+
+	// +optional
+	// LastBackups are the names of VirtualMachineBackups created by the last run
+	LastBackups []string `json:"lastBackups,omitempty"`
+
+	// +optional
+	LastError *Error `json:"lastError,omitempty"`
 }
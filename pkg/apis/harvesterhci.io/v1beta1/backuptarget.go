@@ -0,0 +1,65 @@
+package v1beta1
+
+import (
+	"github.com/rancher/wrangler/pkg/condition"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultBackupTargetName is the name of the backup target mapped from the backup-target setting,
+	// it is used by the VM backups without a backup target reference. Longhorn has a single backup target,
+	// so the other backup targets must point to the same location until Longhorn supports multiple targets.
+	DefaultBackupTargetName = "default"
+)
+
+var (
+	BackupTargetValidated condition.Cond = "validated"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="TYPE",type="string",JSONPath=`.spec.type`
+// +kubebuilder:printcolumn:name="ENDPOINT",type="string",JSONPath=`.spec.endpoint`
+// +kubebuilder:printcolumn:name="VALIDATED",type="string",JSONPath=`.status.conditions[?(@.type=="validated")].status`
+// +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=`.metadata.creationTimestamp`
+
+type BackupTarget struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BackupTargetSpec   `json:"spec"`
+	Status BackupTargetStatus `json:"status,omitempty"`
+}
+
+type BackupTargetSpec struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=nfs;s3
+	Type string `json:"type"`
+
+	// +kubebuilder:validation:Required
+	Endpoint string `json:"endpoint"`
+
+	// +optional
+	BucketName string `json:"bucketName,omitempty"`
+
+	// +optional
+	BucketRegion string `json:"bucketRegion,omitempty"`
+
+	// +optional
+	Cert string `json:"cert,omitempty"`
+
+	// +optional
+	VirtualHostedStyle bool `json:"virtualHostedStyle,omitempty"`
+
+	// CredentialSecret refers to the secret holding the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY of the s3 target
+	// +optional
+	CredentialSecret *corev1.SecretReference `json:"credentialSecret,omitempty"`
+}
+
+type BackupTargetStatus struct {
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+}
@@ -9,12 +9,28 @@ import (
 var (
 	ImageInitialized condition.Cond = "Initialized"
 	ImageImported    condition.Cond = "Imported"
+	ImageVerified    condition.Cond = "Verified"
 )
 
 const (
 	VirtualMachineImageSourceTypeDownload     = "download"
 	VirtualMachineImageSourceTypeUpload       = "upload"
 	VirtualMachineImageSourceTypeExportVolume = "export-from-volume"
+
+	// the keys of the source secret of a download image, the basic auth credentials are used unless a token is given
+	VirtualMachineImageSourceSecretUsername = "username"
+	VirtualMachineImageSourceSecretPassword = "password"
+	VirtualMachineImageSourceSecretToken    = "token"
+	VirtualMachineImageSourceSecretCABundle = "ca.crt"
+
+	VirtualMachineImageOSFamilyLinux   = "linux"
+	VirtualMachineImageOSFamilyWindows = "windows"
+
+	VirtualMachineImageArchitectureAMD64 = "amd64"
+	VirtualMachineImageArchitectureARM64 = "arm64"
+
+	VirtualMachineImageBootModeBIOS = "bios"
+	VirtualMachineImageBootModeUEFI = "uefi"
 )
 
 // +genclient
@@ -51,6 +67,53 @@ type VirtualMachineImageSpec struct {
 
 	// +optional
 	URL string `json:"url"`
+
+	// SourceSecretName is the name of the secret in the image namespace holding the credentials and the CA bundle
+	// to download the image
+	// +optional
+	SourceSecretName string `json:"sourceSecretName,omitempty"`
+
+	// Checksum is the sha256 or sha512 hex digest of the image file, the image is verified against it once it is imported
+	// +optional
+	Checksum string `json:"checksum,omitempty"`
+
+	// StorageClassParameters override the Longhorn parameters of the image StorageClass, which default to
+	// the image-storage-class-parameters setting
+	// +optional
+	StorageClassParameters map[string]string `json:"storageClassParameters,omitempty"`
+
+	// Shared publishes the image to all namespaces, the images in the harvester-public namespace are always shared
+	// +optional
+	Shared bool `json:"shared,omitempty"`
+
+	// OSInfo describes the operating system of the image, it is filled from the image catalog or the detected
+	// image file if it is not given
+	// +optional
+	OSInfo *VirtualMachineImageOSInfo `json:"osInfo,omitempty"`
+}
+
+// VirtualMachineImageOSInfo describes the operating system of an image to recommend the compatible templates
+type VirtualMachineImageOSInfo struct {
+	// +optional
+	// +kubebuilder:validation:Enum=linux;windows
+	Family string `json:"family,omitempty"`
+
+	// Version is the OS version, e.g. 20.04 or 2019
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Enum=amd64;arm64
+	Architecture string `json:"architecture,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Enum=bios;uefi
+	BootMode string `json:"bootMode,omitempty"`
+
+	// VirtioDriversRequired is true if the guest needs the virtio drivers to be installed, which is the case of
+	// the Windows installation media
+	// +optional
+	VirtioDriversRequired bool `json:"virtioDriversRequired,omitempty"`
 }
 
 type VirtualMachineImageStatus struct {
@@ -63,13 +126,73 @@ type VirtualMachineImageStatus struct {
 	// +optional
 	Size int64 `json:"size,omitempty"`
 
+	// Format is the detected format of the source file, the formats other than raw, qcow2 and iso are converted to qcow2
+	// +optional
+	Format string `json:"format,omitempty"`
+
+	// VirtualSize is the size of the disk presented to the guest
+	// +optional
+	VirtualSize int64 `json:"virtualSize,omitempty"`
+
 	// +optional
 	StorageClassName string `json:"storageClassName,omitempty"`
 
+	// Upload tracks the chunks of a chunked upload
+	// +optional
+	Upload *VirtualMachineImageUpload `json:"upload,omitempty"`
+
+	// Usage lists the volumes created from the image and the VMs using them
+	// +optional
+	Usage *VirtualMachineImageUsage `json:"usage,omitempty"`
+
 	// +optional
 	Conditions []Condition `json:"conditions,omitempty"`
 }
 
+// VirtualMachineImageUpload tracks the chunks of a chunked upload, the chunks are staged by the Harvester API server
+// until the upload is completed
+type VirtualMachineImageUpload struct {
+	// Size is the size of the file to upload
+	Size int64 `json:"size"`
+
+	// ReceivedRanges are the sorted and merged byte ranges of the file received so far
+	// +optional
+	ReceivedRanges []ByteRange `json:"receivedRanges,omitempty"`
+}
+
+// VirtualMachineImageUsage lists the users of an image
+type VirtualMachineImageUsage struct {
+	// Volumes are the namespace/name of the PVCs created from the image
+	// +optional
+	Volumes []string `json:"volumes,omitempty"`
+
+	// VirtualMachines are the namespace/name of the VMs using the volumes
+	// +optional
+	VirtualMachines []string `json:"virtualMachines,omitempty"`
+
+	// TemplateVersions are the namespace/name of the VM template versions creating volumes from the image
+	// +optional
+	TemplateVersions []string `json:"templateVersions,omitempty"`
+
+	// VirtualMachineBackups are the namespace/name of the VM backups restoring volumes of the image storage class
+	// +optional
+	VirtualMachineBackups []string `json:"virtualMachineBackups,omitempty"`
+
+	// VirtualMachineSnapshots are the namespace/name of the VM snapshots restoring volumes of the image storage class
+	// +optional
+	VirtualMachineSnapshots []string `json:"virtualMachineSnapshots,omitempty"`
+
+	// LastUsedTime is the time the last user of the image was removed
+	// +optional
+	LastUsedTime *metav1.Time `json:"lastUsedTime,omitempty"`
+}
+
+// ByteRange is the byte range [Start, End) of a file
+type ByteRange struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
 type Condition struct {
 	// Type of the condition.
 	Type condition.Cond `json:"type"`
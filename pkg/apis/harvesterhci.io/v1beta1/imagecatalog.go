@@ -0,0 +1,83 @@
+package v1beta1
+
+import (
+	"github.com/rancher/wrangler/pkg/condition"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	ImageCatalogSynced condition.Cond = "Synced"
+)
+
+const (
+	ImageCatalogFormatSimpleStreams = "simplestreams"
+	ImageCatalogFormatManifest      = "manifest"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:shortName=imgcatalog;imgcatalogs,scope=Namespaced
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.spec.url`
+// +kubebuilder:printcolumn:name="FORMAT",type=string,JSONPath=`.spec.format`
+// +kubebuilder:printcolumn:name="LAST_SYNC",type=date,JSONPath=`.status.lastSyncTime`
+// +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ImageCatalog subscribes to an image index feed, the VirtualMachineImages of the builds matching the filters
+// are created in the catalog namespace
+type ImageCatalog struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ImageCatalogSpec `json:"spec"`
+
+	// +optional
+	Status ImageCatalogStatus `json:"status,omitempty"`
+}
+
+type ImageCatalogSpec struct {
+	// URL is the index feed, the streams/v1/index.json of a simplestreams mirror or a YAML manifest
+	// +kubebuilder:validation:Required
+	URL string `json:"url"`
+
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=simplestreams;manifest
+	Format string `json:"format"`
+
+	// OS filters the builds by OS, e.g. ubuntu, all OSes are matched if it is empty
+	// +optional
+	OS []string `json:"os,omitempty"`
+
+	// Releases filters the builds by release, e.g. focal or 15.3, all releases are matched if it is empty
+	// +optional
+	Releases []string `json:"releases,omitempty"`
+
+	// Arches filters the builds by architecture, e.g. amd64, all architectures are matched if it is empty
+	// +optional
+	Arches []string `json:"arches,omitempty"`
+
+	// KeepLatest is the number of the latest builds kept for each OS release and architecture,
+	// the older unused images are deleted. All builds are kept if it is 0.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	KeepLatest int `json:"keepLatest,omitempty"`
+
+	// SyncInterval is the interval to sync the feed, it defaults to 24h
+	// +optional
+	SyncInterval *metav1.Duration `json:"syncInterval,omitempty"`
+}
+
+type ImageCatalogStatus struct {
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// AppliedSpec is the spec of the last sync, the feed is synced again once the spec changes
+	// +optional
+	AppliedSpec *ImageCatalogSpec `json:"appliedSpec,omitempty"`
+
+	// Images are the names of the VirtualMachineImages created from the feed
+	// +optional
+	Images []string `json:"images,omitempty"`
+
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+}
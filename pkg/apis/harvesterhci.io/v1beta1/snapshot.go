@@ -0,0 +1,58 @@
+package v1beta1
+
+// NOTES: Harvester VM snapshot takes longhorn snapshots of the VM volumes and keeps them in the volume replicas,
+// unlike the VM backup, it doesn't require a backup target, and a stopped VM can be reverted to it.
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:shortName=vmsnapshot;vmsnapshots,scope=Namespaced
+// +kubebuilder:printcolumn:name="SOURCE_KIND",type=string,JSONPath=`.spec.source.kind`
+// +kubebuilder:printcolumn:name="SOURCE_NAME",type=string,JSONPath=`.spec.source.name`
+// +kubebuilder:printcolumn:name="READY_TO_USE",type=boolean,JSONPath=`.status.readyToUse`
+// +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="ERROR",type=date,JSONPath=`.status.error.message`
+
+type VirtualMachineSnapshot struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VirtualMachineSnapshotSpec `json:"spec"`
+
+	// +optional
+	Status *VirtualMachineSnapshotStatus `json:"status,omitempty"`
+}
+
+type VirtualMachineSnapshotSpec struct {
+	Source corev1.TypedLocalObjectReference `json:"source"`
+}
+
+// VirtualMachineSnapshotStatus is the status for a VirtualMachineSnapshot resource
+type VirtualMachineSnapshotStatus struct {
+	// +optional
+	SourceUID *types.UID `json:"sourceUID,omitempty"`
+
+	// +optional
+	CreationTime *metav1.Time `json:"creationTime,omitempty"`
+
+	// SourceSpec contains the vm spec source of the snapshot
+	// +optional
+	SourceSpec *VirtualMachineSourceSpec `json:"source,omitempty"`
+
+	// VolumeSnapshots contains the longhorn snapshots of the VM volumes
+	// +optional
+	VolumeSnapshots []VolumeBackup `json:"volumeSnapshots,omitempty"`
+
+	// +optional
+	ReadyToUse *bool `json:"readyToUse,omitempty"`
+
+	// +optional
+	Error *Error `json:"error,omitempty"`
+
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+}
@@ -26,6 +26,40 @@ import (
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
+// BackupTargetList is a list of BackupTarget resources
+type BackupTargetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []BackupTarget `json:"items"`
+}
+
+func NewBackupTarget(namespace, name string, obj BackupTarget) *BackupTarget {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("BackupTarget").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ImageCatalogList is a list of ImageCatalog resources
+type ImageCatalogList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []ImageCatalog `json:"items"`
+}
+
+func NewImageCatalog(namespace, name string, obj ImageCatalog) *ImageCatalog {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("ImageCatalog").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
 // KeyPairList is a list of KeyPair resources
 type KeyPairList struct {
 	metav1.TypeMeta `json:",inline"`
@@ -111,6 +145,23 @@ func NewVirtualMachineBackup(namespace, name string, obj VirtualMachineBackup) *
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
+// VirtualMachineBackupScheduleList is a list of VirtualMachineBackupSchedule resources
+type VirtualMachineBackupScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []VirtualMachineBackupSchedule `json:"items"`
+}
+
+func NewVirtualMachineBackupSchedule(namespace, name string, obj VirtualMachineBackupSchedule) *VirtualMachineBackupSchedule {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("VirtualMachineBackupSchedule").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
 // VirtualMachineRestoreList is a list of VirtualMachineRestore resources
 type VirtualMachineRestoreList struct {
 	metav1.TypeMeta `json:",inline"`
@@ -128,6 +179,23 @@ func NewVirtualMachineRestore(namespace, name string, obj VirtualMachineRestore)
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
+// VirtualMachineSnapshotList is a list of VirtualMachineSnapshot resources
+type VirtualMachineSnapshotList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []VirtualMachineSnapshot `json:"items"`
+}
+
+func NewVirtualMachineSnapshot(namespace, name string, obj VirtualMachineSnapshot) *VirtualMachineSnapshot {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("VirtualMachineSnapshot").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
 // VirtualMachineImageList is a list of VirtualMachineImage resources
 type VirtualMachineImageList struct {
 	metav1.TypeMeta `json:",inline"`
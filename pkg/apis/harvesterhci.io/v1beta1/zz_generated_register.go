@@ -28,14 +28,18 @@ import (
 )
 
 var (
+	BackupTargetResourceName                  = "backuptargets"
+	ImageCatalogResourceName                  = "imagecatalogs"
 	KeyPairResourceName                       = "keypairs"
 	PreferenceResourceName                    = "preferences"
 	SettingResourceName                       = "settings"
 	SupportBundleResourceName                 = "supportbundles"
 	UpgradeResourceName                       = "upgrades"
 	VirtualMachineBackupResourceName          = "virtualmachinebackups"
+	VirtualMachineBackupScheduleResourceName  = "virtualmachinebackupschedules"
 	VirtualMachineImageResourceName           = "virtualmachineimages"
 	VirtualMachineRestoreResourceName         = "virtualmachinerestores"
+	VirtualMachineSnapshotResourceName        = "virtualmachinesnapshots"
 	VirtualMachineTemplateResourceName        = "virtualmachinetemplates"
 	VirtualMachineTemplateVersionResourceName = "virtualmachinetemplateversions"
 )
@@ -61,6 +65,10 @@ var (
 // Adds the list of known types to Scheme.
 func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
+		&BackupTarget{},
+		&BackupTargetList{},
+		&ImageCatalog{},
+		&ImageCatalogList{},
 		&KeyPair{},
 		&KeyPairList{},
 		&Preference{},
@@ -73,10 +81,14 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&UpgradeList{},
 		&VirtualMachineBackup{},
 		&VirtualMachineBackupList{},
+		&VirtualMachineBackupSchedule{},
+		&VirtualMachineBackupScheduleList{},
 		&VirtualMachineImage{},
 		&VirtualMachineImageList{},
 		&VirtualMachineRestore{},
 		&VirtualMachineRestoreList{},
+		&VirtualMachineSnapshot{},
+		&VirtualMachineSnapshotList{},
 		&VirtualMachineTemplate{},
 		&VirtualMachineTemplateList{},
 		&VirtualMachineTemplateVersion{},
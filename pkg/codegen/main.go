@@ -26,12 +26,16 @@ func main() {
 		Groups: map[string]args.Group{
 			"harvesterhci.io": {
 				Types: []interface{}{
+					harvesterv1.BackupTarget{},
+					harvesterv1.ImageCatalog{},
 					harvesterv1.KeyPair{},
 					harvesterv1.Preference{},
 					harvesterv1.Setting{},
 					harvesterv1.Upgrade{},
 					harvesterv1.VirtualMachineBackup{},
+					harvesterv1.VirtualMachineBackupSchedule{},
 					harvesterv1.VirtualMachineRestore{},
+					harvesterv1.VirtualMachineSnapshot{},
 					harvesterv1.VirtualMachineImage{},
 					harvesterv1.VirtualMachineTemplate{},
 					harvesterv1.VirtualMachineTemplateVersion{},
@@ -67,9 +71,11 @@ func main() {
 			},
 			longhornv1.SchemeGroupVersion.Group: {
 				Types: []interface{}{
+					longhornv1.Backup{},
 					longhornv1.BackingImage{},
 					longhornv1.BackingImageDataSource{},
 					longhornv1.Volume{},
+					longhornv1.Engine{},
 					longhornv1.Setting{},
 				},
 			},
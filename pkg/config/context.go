@@ -43,6 +43,10 @@ type Options struct {
 	RancherEmbedded bool
 	RancherURL      string
 	HCIMode         bool
+
+	ImageUploadDir      string
+	ImageStagingDir     string
+	ConsoleRecordingDir string
 }
 
 type Scaled struct {
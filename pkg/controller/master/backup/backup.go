@@ -13,12 +13,15 @@ import (
 	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
 	kv1 "kubevirt.io/client-go/api/v1"
 
 	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
 	"github.com/harvester/harvester/pkg/config"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/scheme"
 	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
 	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
 	ctllonghornv1 "github.com/harvester/harvester/pkg/generated/controllers/longhorn.io/v1beta1"
@@ -34,11 +37,17 @@ const (
 	BackupBucketNameAnnotation   = "backup.harvesterhci.io/bucket-name"
 	BackupBucketRegionAnnotation = "backup.harvesterhci.io/bucket-region"
 
+	longhornBackupHandlePrefix = "bs://"
+
 	volumeSnapshotMissingEvent = "VolumeSnapshotMissing"
 	volumeSnapshotCreateEvent  = "VolumeSnapshotCreated"
+	volumeSnapshotDeleteEvent  = "VolumeSnapshotDeleted"
 )
 
-var vmBackupKind = harvesterv1.SchemeGroupVersion.WithKind(vmBackupKindName)
+var (
+	vmBackupKind                    = harvesterv1.SchemeGroupVersion.WithKind(vmBackupKindName)
+	kubevirtSubresourceGroupVersion = schema.GroupVersion{Group: "subresources.kubevirt.io", Version: "v1"}
+)
 
 // RegisterBackup register the vmBackup and volumeSnapshot controller
 func RegisterBackup(ctx context.Context, management *config.Management, opts config.Options) error {
@@ -46,42 +55,89 @@ func RegisterBackup(ctx context.Context, management *config.Management, opts con
 	pvc := management.CoreFactory.Core().V1().PersistentVolumeClaim()
 	vms := management.VirtFactory.Kubevirt().V1().VirtualMachine()
 	volumes := management.LonghornFactory.Longhorn().V1beta1().Volume()
+	engines := management.LonghornFactory.Longhorn().V1beta1().Engine()
 	snapshots := management.SnapshotFactory.Snapshot().V1beta1().VolumeSnapshot()
 	snapshotClass := management.SnapshotFactory.Snapshot().V1beta1().VolumeSnapshotClass()
+	snapshotContents := management.SnapshotFactory.Snapshot().V1beta1().VolumeSnapshotContent()
+	longhornBackups := management.LonghornFactory.Longhorn().V1beta1().Backup()
+	secrets := management.CoreFactory.Core().V1().Secret()
+	backupTargets := management.HarvesterFactory.Harvesterhci().V1beta1().BackupTarget()
+	vmis := management.VirtFactory.Kubevirt().V1().VirtualMachineInstance()
+	backingImages := management.LonghornFactory.Longhorn().V1beta1().BackingImage()
+
+	copyConfig := rest.CopyConfig(management.RestConfig)
+	copyConfig.GroupVersion = &kubevirtSubresourceGroupVersion
+	copyConfig.APIPath = "/apis"
+	copyConfig.NegotiatedSerializer = scheme.Codecs.WithoutConversion()
+	virtSubresourceClient, err := rest.RESTClientFor(copyConfig)
+	if err != nil {
+		return err
+	}
 
 	vmBackupController := &Handler{
-		vmBackups:          vmBackups,
-		vmBackupController: vmBackups,
-		vmBackupCache:      vmBackups.Cache(),
-		pvcCache:           pvc.Cache(),
-		vms:                vms,
-		vmsCache:           vms.Cache(),
-		volumeCache:        volumes.Cache(),
-		volumes:            volumes,
-		snapshots:          snapshots,
-		snapshotCache:      snapshots.Cache(),
-		snapshotClassCache: snapshotClass.Cache(),
-		recorder:           management.NewRecorder(backupControllerName, "", ""),
+		ctx:                  ctx,
+		vmBackups:            vmBackups,
+		vmBackupController:   vmBackups,
+		vmBackupCache:        vmBackups.Cache(),
+		pvcs:                 pvc,
+		pvcCache:             pvc.Cache(),
+		vms:                  vms,
+		vmsCache:             vms.Cache(),
+		volumeCache:          volumes.Cache(),
+		volumes:              volumes,
+		engineCache:          engines.Cache(),
+		snapshots:            snapshots,
+		snapshotCache:        snapshots.Cache(),
+		snapshotClassCache:   snapshotClass.Cache(),
+		snapshotContents:     snapshotContents,
+		snapshotContentCache: snapshotContents.Cache(),
+		longhornBackups:      longhornBackups,
+		secretCache:          secrets.Cache(),
+		backupTargetCache:    backupTargets.Cache(),
+		vmiCache:             vmis.Cache(),
+		backingImages:        backingImages,
+		backingImageCache:    backingImages.Cache(),
+		guestFreezer:         &virtGuestFreezer{ctx: ctx, client: virtSubresourceClient},
+		recorder:             management.NewRecorder(backupControllerName, "", ""),
 	}
 
 	vmBackups.OnChange(ctx, backupControllerName, vmBackupController.OnBackupChange)
+	vmBackups.OnRemove(ctx, backupControllerName, vmBackupController.OnBackupRemove)
 	snapshots.OnChange(ctx, backupControllerName, vmBackupController.updateVolumeSnapshotChanged)
+	engines.OnChange(ctx, backupControllerName, vmBackupController.updateEngineChanged)
 	return nil
 }
 
 type Handler struct {
-	vmBackups          ctlharvesterv1.VirtualMachineBackupClient
-	vmBackupCache      ctlharvesterv1.VirtualMachineBackupCache
-	vmBackupController ctlharvesterv1.VirtualMachineBackupController
-	vms                ctlkubevirtv1.VirtualMachineClient
-	vmsCache           ctlkubevirtv1.VirtualMachineCache
-	pvcCache           ctlcorev1.PersistentVolumeClaimCache
-	volumeCache        ctllonghornv1.VolumeCache
-	volumes            ctllonghornv1.VolumeClient
-	snapshots          ctlsnapshotv1.VolumeSnapshotClient
-	snapshotCache      ctlsnapshotv1.VolumeSnapshotCache
-	snapshotClassCache ctlsnapshotv1.VolumeSnapshotClassCache
-	recorder           record.EventRecorder
+	ctx                  context.Context
+	vmBackups            ctlharvesterv1.VirtualMachineBackupClient
+	vmBackupCache        ctlharvesterv1.VirtualMachineBackupCache
+	vmBackupController   ctlharvesterv1.VirtualMachineBackupController
+	vmSnapshots          ctlharvesterv1.VirtualMachineSnapshotClient
+	vmSnapshotCache      ctlharvesterv1.VirtualMachineSnapshotCache
+	vmSnapshotController ctlharvesterv1.VirtualMachineSnapshotController
+	vms                  ctlkubevirtv1.VirtualMachineClient
+	vmsCache             ctlkubevirtv1.VirtualMachineCache
+	vmController         ctlkubevirtv1.VirtualMachineController
+	pvcs                 ctlcorev1.PersistentVolumeClaimClient
+	pvcCache             ctlcorev1.PersistentVolumeClaimCache
+	volumeCache          ctllonghornv1.VolumeCache
+	volumes              ctllonghornv1.VolumeClient
+	engineCache          ctllonghornv1.EngineCache
+	snapshots            ctlsnapshotv1.VolumeSnapshotClient
+	snapshotCache        ctlsnapshotv1.VolumeSnapshotCache
+	snapshotClassCache   ctlsnapshotv1.VolumeSnapshotClassCache
+	snapshotContents     ctlsnapshotv1.VolumeSnapshotContentClient
+	snapshotContentCache ctlsnapshotv1.VolumeSnapshotContentCache
+	longhornBackups      ctllonghornv1.BackupClient
+	secretCache          ctlcorev1.SecretCache
+	backupTargetCache    ctlharvesterv1.BackupTargetCache
+	vmiCache             ctlkubevirtv1.VirtualMachineInstanceCache
+	backingImages        ctllonghornv1.BackingImageClient
+	backingImageCache    ctllonghornv1.BackingImageCache
+	guestFreezer         guestFreezer
+	snapshotter          volumeSnapshotter
+	recorder             record.EventRecorder
 }
 
 // OnBackupChange handles vm backup object on change and reconcile vm backup status
@@ -90,8 +146,24 @@ func (h *Handler) OnBackupChange(key string, vmBackup *harvesterv1.VirtualMachin
 		return nil, nil
 	}
 
+	// make sure the guest filesystems are not left frozen once the backup is finished
+	if isGuestFrozen(vmBackup) && !isBackupProgressing(vmBackup) {
+		if isBackupReady(vmBackup) {
+			return nil, h.thawGuest(vmBackup, newApplicationConsistentCondition(corev1.ConditionTrue, guestThawedReason,
+				"The guest filesystems were frozen while taking the volume snapshots"))
+		}
+		return nil, h.thawGuest(vmBackup, newApplicationConsistentCondition(corev1.ConditionFalse, freezeFailedReason,
+			"The backup failed while the guest filesystems were frozen"))
+	}
+
 	if isBackupReady(vmBackup) {
-		return nil, nil
+		if isBackupReadOnly(vmBackup) {
+			return nil, h.reconcileImportedVolumeSnapshots(vmBackup)
+		}
+		if err := h.reconcileExport(vmBackup); err != nil {
+			return nil, err
+		}
+		return nil, h.uploadBackupMetadata(vmBackup)
 	}
 
 	// set vmBackup init status
@@ -99,6 +171,13 @@ func (h *Handler) OnBackupChange(key string, vmBackup *harvesterv1.VirtualMachin
 		return nil, h.updateStatus(vmBackup, nil)
 	}
 
+	// check the backup target before creating the volume snapshots
+	if isBackupProgressing(vmBackup) && vmBackup.Status.VolumeBackups == nil {
+		if err := h.checkBackupTarget(vmBackup); err != nil {
+			return nil, h.setBackupError(vmBackup, err)
+		}
+	}
+
 	// get vmBackup source
 	sourceVM, err := h.getBackupSource(vmBackup)
 	if err != nil {
@@ -122,6 +201,14 @@ func (h *Handler) OnBackupChange(key string, vmBackup *harvesterv1.VirtualMachin
 			}
 		}
 
+		// freeze the guest filesystems before creating the volume snapshots of an application-consistent backup
+		if vmBackup.Spec.ApplicationConsistent != nil {
+			proceed, err := h.reconcileGuestFreeze(vmBackup, sourceVM)
+			if err != nil || !proceed {
+				return nil, err
+			}
+		}
+
 		// reconcile backup status of volume backups, validate if those volumeSnapshots are ready to use
 		if err = h.reconcileBackupStatus(vmBackup); err != nil {
 			return nil, err
@@ -259,7 +346,7 @@ func (h *Handler) updateStatus(vmBackup *harvesterv1.VirtualMachineBackup, sourc
 	}
 
 	if vmBackupCpy.Annotations[BackupTargetAnnotation] == "" {
-		target, err := decodeTarget(settings.BackupTargetSet.Get())
+		target, err := h.getBackupTarget(vmBackupCpy)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,207 @@
+package backup
+
+import (
+	"fmt"
+	"time"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/v2/pkg/apis/volumesnapshot/v1beta1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/settings"
+)
+
+const (
+	// BackupReadOnlyAnnotation marks the VM backups imported from the backup target,
+	// they are only used to restore and never write to the backup target.
+	BackupReadOnlyAnnotation = "backup.harvesterhci.io/read-only"
+
+	longhornDriverName = "driver.longhorn.io"
+
+	backupMetadataUploadedEvent = "BackupMetadataUploaded"
+)
+
+func isBackupReadOnly(backup *harvesterv1.VirtualMachineBackup) bool {
+	return backup.Annotations[BackupReadOnlyAnnotation] == "true"
+}
+
+func isBackupMetadataReady(backup *harvesterv1.VirtualMachineBackup) bool {
+	if backup.Status == nil {
+		return false
+	}
+	for _, c := range backup.Status.Conditions {
+		if c.Type == harvesterv1.BackupConditionMetadataReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// isBackupInCurrentTarget checks whether the backup is stored in the given backup target
+func isBackupInCurrentTarget(backup *harvesterv1.VirtualMachineBackup, target *settings.BackupTarget) bool {
+	return target.Endpoint != "" &&
+		backup.Annotations[BackupTargetAnnotation] == target.Endpoint &&
+		backup.Annotations[BackupBucketNameAnnotation] == target.BucketName &&
+		backup.Annotations[BackupBucketRegionAnnotation] == target.BucketRegion
+}
+
+// uploadBackupMetadata writes the VM metadata of a ready backup to the backup target,
+// so that the backup can be imported in another cluster.
+func (h *Handler) uploadBackupMetadata(vmBackup *harvesterv1.VirtualMachineBackup) error {
+	if isBackupMetadataReady(vmBackup) {
+		return nil
+	}
+
+	target, err := h.getBackupTarget(vmBackup)
+	if err != nil {
+		return err
+	}
+	if !isBackupInCurrentTarget(vmBackup, target) {
+		logrus.Debugf("skip uploading metadata of VM backup %s/%s, it is not in the current backup target", vmBackup.Namespace, vmBackup.Name)
+		return nil
+	}
+
+	data, err := encodeVMBackupMetadata(vmBackup)
+	if err != nil {
+		return err
+	}
+
+	store, err := newBackupStore(h.ctx, target, h.secretCache)
+	if err != nil {
+		return err
+	}
+	defer closeBackupStore(store)
+
+	if err := store.Write(getVMBackupMetadataPath(vmBackup.Namespace, vmBackup.Name, vmBackup.UID), data); err != nil {
+		return fmt.Errorf("failed to upload metadata of VM backup %s/%s, error: %w", vmBackup.Namespace, vmBackup.Name, err)
+	}
+
+	h.recorder.Eventf(
+		vmBackup,
+		corev1.EventTypeNormal,
+		backupMetadataUploadedEvent,
+		"Successfully uploaded the VM metadata to the backup target",
+	)
+
+	vmBackupCpy := vmBackup.DeepCopy()
+	vmBackupCpy.Status.Conditions = updateCondition(vmBackupCpy.Status.Conditions, harvesterv1.Condition{
+		Type:               harvesterv1.BackupConditionMetadataReady,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: currentTime().Format(time.RFC3339),
+	}, false)
+	_, err = h.vmBackups.Update(vmBackupCpy)
+	return err
+}
+
+// deleteBackupMetadata removes the VM metadata of the backup from the backup target
+func (h *Handler) deleteBackupMetadata(vmBackup *harvesterv1.VirtualMachineBackup) error {
+	if !isBackupMetadataReady(vmBackup) {
+		return nil
+	}
+
+	target, err := h.getBackupTarget(vmBackup)
+	if err != nil {
+		return err
+	}
+	if !isBackupInCurrentTarget(vmBackup, target) {
+		return nil
+	}
+
+	store, err := newBackupStore(h.ctx, target, h.secretCache)
+	if err != nil {
+		return err
+	}
+	defer closeBackupStore(store)
+
+	return store.Delete(getVMBackupMetadataPath(vmBackup.Namespace, vmBackup.Name, vmBackup.UID))
+}
+
+// reconcileImportedVolumeSnapshots creates the pre-provisioned volume snapshot contents and volume snapshots of
+// an imported backup, so that the restore controller can create PVCs from the longhorn backups.
+func (h *Handler) reconcileImportedVolumeSnapshots(vmBackup *harvesterv1.VirtualMachineBackup) error {
+	for _, volumeBackup := range vmBackup.Status.VolumeBackups {
+		if volumeBackup.Name == nil || volumeBackup.LonghornBackupName == nil {
+			continue
+		}
+
+		volumeSnapshot, err := h.getVolumeSnapshot(vmBackup.Namespace, *volumeBackup.Name)
+		if err != nil {
+			return err
+		}
+		if volumeSnapshot != nil {
+			continue
+		}
+
+		contentName := getImportedSnapshotContentName(vmBackup.Namespace, *volumeBackup.Name)
+		if _, err := h.snapshotContentCache.Get(contentName); apierrors.IsNotFound(err) {
+			snapshotHandle := fmt.Sprintf("%s%s/%s", longhornBackupHandlePrefix,
+				volumeBackup.PersistentVolumeClaim.Spec.VolumeName, *volumeBackup.LonghornBackupName)
+			content := &snapshotv1.VolumeSnapshotContent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: contentName,
+				},
+				Spec: snapshotv1.VolumeSnapshotContentSpec{
+					VolumeSnapshotRef: corev1.ObjectReference{
+						Name:      *volumeBackup.Name,
+						Namespace: vmBackup.Namespace,
+					},
+					// the backup data is not owned by this cluster
+					DeletionPolicy:          snapshotv1.VolumeSnapshotContentRetain,
+					Driver:                  longhornDriverName,
+					VolumeSnapshotClassName: pointer.StringPtr(settings.VolumeSnapshotClass.Get()),
+					Source: snapshotv1.VolumeSnapshotContentSource{
+						SnapshotHandle: &snapshotHandle,
+					},
+				},
+			}
+			if _, err := h.snapshotContents.Create(content); err != nil && !apierrors.IsAlreadyExists(err) {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+
+		snapshot := &snapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      *volumeBackup.Name,
+				Namespace: vmBackup.Namespace,
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion:         harvesterv1.SchemeGroupVersion.String(),
+						Kind:               vmBackupKind.Kind,
+						Name:               vmBackup.Name,
+						UID:                vmBackup.UID,
+						Controller:         pointer.BoolPtr(true),
+						BlockOwnerDeletion: pointer.BoolPtr(true),
+					},
+				},
+			},
+			Spec: snapshotv1.VolumeSnapshotSpec{
+				Source: snapshotv1.VolumeSnapshotSource{
+					VolumeSnapshotContentName: pointer.StringPtr(contentName),
+				},
+				VolumeSnapshotClassName: pointer.StringPtr(settings.VolumeSnapshotClass.Get()),
+			},
+		}
+		if _, err := h.snapshots.Create(snapshot); err != nil && !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+
+		h.recorder.Eventf(
+			vmBackup,
+			corev1.EventTypeNormal,
+			volumeSnapshotCreateEvent,
+			"Successfully created VolumeSnapshot %s from the backup target",
+			snapshot.Name,
+		)
+	}
+	return nil
+}
+
+func getImportedSnapshotContentName(namespace, name string) string {
+	return fmt.Sprintf("imported-%s-%s", namespace, name)
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/v2/pkg/apis/volumesnapshot/v1beta1"
+	lhv1beta1 "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
 	"github.com/longhorn/longhorn-manager/types"
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
@@ -70,8 +71,20 @@ func (h *Handler) reconcileBackupStatus(vmBackup *harvesterv1.VirtualMachineBack
 			vmBackup.Status.VolumeBackups[i].ReadyToUse = volumeSnapshot.Status.ReadyToUse
 			vmBackup.Status.VolumeBackups[i].CreationTime = volumeSnapshot.Status.CreationTime
 			vmBackup.Status.VolumeBackups[i].Error = translateError(volumeSnapshot.Status.Error)
+
+			// record the longhorn backup to be able to restore the volume from the backup target in another cluster
+			if volumeBackup.LonghornBackupName == nil && volumeSnapshot.Status.BoundVolumeSnapshotContentName != nil {
+				longhornBackupName, err := h.getContentLonghornBackupName(*volumeSnapshot.Status.BoundVolumeSnapshotContentName)
+				if err != nil {
+					return err
+				}
+				vmBackup.Status.VolumeBackups[i].LonghornBackupName = longhornBackupName
+			}
 		}
 
+		if err := h.updateVolumeBackupProgress(&vmBackup.Status.VolumeBackups[i], volumeSnapshot); err != nil {
+			return err
+		}
 	}
 
 	var ready = true
@@ -111,6 +124,7 @@ func (h *Handler) reconcileBackupStatus(vmBackup *harvesterv1.VirtualMachineBack
 	}
 
 	backupCpy.Status.ReadyToUse = &ready
+	backupCpy.Status.Progress = getBackupProgress(backupCpy.Status.VolumeBackups)
 
 	if !reflect.DeepEqual(vmBackup.Status, backupCpy.Status) {
 		if _, err := h.vmBackups.Update(backupCpy); err != nil {
@@ -180,6 +194,137 @@ func (h *Handler) createVolumeSnapshot(backup *harvesterv1.VirtualMachineBackup,
 	return volumeSnapshot, nil
 }
 
+// OnBackupRemove deletes the volume snapshots of the removed vm backup together with their snapshot contents
+// and the backups stored in the backup target
+func (h *Handler) OnBackupRemove(key string, vmBackup *harvesterv1.VirtualMachineBackup) (*harvesterv1.VirtualMachineBackup, error) {
+	if vmBackup == nil || vmBackup.Status == nil {
+		return nil, nil
+	}
+
+	if isGuestFrozen(vmBackup) {
+		if err := h.guestFreezer.Unfreeze(vmBackup.Namespace, vmBackup.Spec.Source.Name); err != nil && !apierrors.IsNotFound(err) {
+			return vmBackup, err
+		}
+	}
+
+	if err := h.deleteExport(vmBackup); err != nil {
+		return vmBackup, err
+	}
+
+	// the data of imported backups is owned by the cluster which created them
+	deleteRemote := !isBackupReadOnly(vmBackup)
+	for _, volumeBackup := range vmBackup.Status.VolumeBackups {
+		if volumeBackup.Name == nil {
+			continue
+		}
+		if err := h.deleteVolumeSnapshot(vmBackup, *volumeBackup.Name, deleteRemote); err != nil {
+			return vmBackup, err
+		}
+	}
+
+	if deleteRemote {
+		if err := h.deleteBackupMetadata(vmBackup); err != nil {
+			return vmBackup, err
+		}
+	}
+	return vmBackup, nil
+}
+
+func (h *Handler) deleteVolumeSnapshot(backup *harvesterv1.VirtualMachineBackup, name string, deleteRemote bool) error {
+	volumeSnapshot, err := h.getVolumeSnapshot(backup.Namespace, name)
+	if err != nil || volumeSnapshot == nil {
+		return err
+	}
+
+	if volumeSnapshot.Status != nil && volumeSnapshot.Status.BoundVolumeSnapshotContentName != nil {
+		if err := h.deleteVolumeSnapshotContent(*volumeSnapshot.Status.BoundVolumeSnapshotContentName, deleteRemote); err != nil {
+			return err
+		}
+	}
+
+	if volumeSnapshot.DeletionTimestamp != nil {
+		return nil
+	}
+	if err := h.snapshots.Delete(backup.Namespace, name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	h.recorder.Eventf(
+		backup,
+		corev1.EventTypeNormal,
+		volumeSnapshotDeleteEvent,
+		"Successfully deleted VolumeSnapshot %s",
+		name,
+	)
+	return nil
+}
+
+// deleteVolumeSnapshotContent deletes the snapshot content, if deleteRemote is set, it makes sure the CSI driver
+// deletes the backup data by switching it to the delete policy, and removes the longhorn backup from the backup target
+func (h *Handler) deleteVolumeSnapshotContent(name string, deleteRemote bool) error {
+	content, err := h.snapshotContentCache.Get(name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if deleteRemote && content.Status != nil && content.Status.SnapshotHandle != nil {
+		if backupName := getLonghornBackupName(*content.Status.SnapshotHandle); backupName != "" {
+			err := h.longhornBackups.Delete(util.LonghornSystemNamespaceName, backupName, &metav1.DeleteOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+
+	if content.DeletionTimestamp != nil {
+		return nil
+	}
+
+	if deleteRemote && content.Spec.DeletionPolicy != snapshotv1.VolumeSnapshotContentDelete {
+		contentCpy := content.DeepCopy()
+		contentCpy.Spec.DeletionPolicy = snapshotv1.VolumeSnapshotContentDelete
+		if _, err := h.snapshotContents.Update(contentCpy); err != nil {
+			return err
+		}
+	}
+
+	if err := h.snapshotContents.Delete(name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func (h *Handler) getContentLonghornBackupName(contentName string) (*string, error) {
+	content, err := h.snapshotContentCache.Get(contentName)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if content.Status == nil || content.Status.SnapshotHandle == nil {
+		return nil, nil
+	}
+	if backupName := getLonghornBackupName(*content.Status.SnapshotHandle); backupName != "" {
+		return &backupName, nil
+	}
+	return nil, nil
+}
+
+// getLonghornBackupName returns the longhorn backup name of a snapshot handle in the form of "bs://<volume>/<backup>"
+func getLonghornBackupName(snapshotHandle string) string {
+	if !strings.HasPrefix(snapshotHandle, longhornBackupHandlePrefix) {
+		return ""
+	}
+	parts := strings.Split(strings.TrimPrefix(snapshotHandle, longhornBackupHandlePrefix), "/")
+	if len(parts) != 2 {
+		return ""
+	}
+	return parts[1]
+}
+
 func (h *Handler) updateVolumeSnapshotChanged(key string, snapshot *snapshotv1.VolumeSnapshot) (*snapshotv1.VolumeSnapshot, error) {
 	if snapshot == nil || snapshot.DeletionTimestamp != nil {
 		return nil, nil
@@ -237,16 +382,24 @@ func (h *Handler) reconcileLonghornVolumes(vm *kubevirtv1.VirtualMachine) error
 			return fmt.Errorf("failed to get volume %s/%s, error: %s", name, vm.Namespace, err.Error())
 		}
 
-		volCpy := volume.DeepCopy()
-		if volume.Status.State == types.VolumeStateDetached || volume.Status.State == types.VolumeStateDetaching {
-			volCpy.Spec.NodeID = volume.Status.OwnerID
+		if err := h.attachLonghornVolume(volume); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		if !reflect.DeepEqual(volCpy, volume) {
-			logrus.Infof("mount detached volume %s to the node %s", volCpy.Name, volCpy.Spec.NodeID)
-			if _, err = h.volumes.Update(volCpy); err != nil {
-				return err
-			}
+// attachLonghornVolume mounts the detached volume to the node managing it
+func (h *Handler) attachLonghornVolume(volume *lhv1beta1.Volume) error {
+	volCpy := volume.DeepCopy()
+	if volume.Status.State == types.VolumeStateDetached || volume.Status.State == types.VolumeStateDetaching {
+		volCpy.Spec.NodeID = volume.Status.OwnerID
+	}
+
+	if !reflect.DeepEqual(volCpy, volume) {
+		logrus.Infof("mount detached volume %s to the node %s", volCpy.Name, volCpy.Spec.NodeID)
+		if _, err := h.volumes.Update(volCpy); err != nil {
+			return err
 		}
 	}
 	return nil
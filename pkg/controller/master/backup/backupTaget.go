@@ -47,29 +47,45 @@ func RegisterBackupTarget(ctx context.Context, management *config.Management, op
 	secrets := management.CoreFactory.Core().V1().Secret()
 	longhornSettings := management.LonghornFactory.Longhorn().V1beta1().Setting()
 	vms := management.VirtFactory.Kubevirt().V1().VirtualMachine()
+	vmBackups := management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackup()
+	backupTargets := management.HarvesterFactory.Harvesterhci().V1beta1().BackupTarget()
 
 	backupTargetController := &TargetHandler{
-		ctx:                  ctx,
-		longhornSettings:     longhornSettings,
-		longhornSettingCache: longhornSettings.Cache(),
-		secrets:              secrets,
-		secretCache:          secrets.Cache(),
-		vms:                  vms,
-		settings:             settings,
+		ctx:                    ctx,
+		longhornSettings:       longhornSettings,
+		longhornSettingCache:   longhornSettings.Cache(),
+		secrets:                secrets,
+		secretCache:            secrets.Cache(),
+		vms:                    vms,
+		settings:               settings,
+		settingController:      settings,
+		vmBackups:              vmBackups,
+		vmBackupCache:          vmBackups.Cache(),
+		backupTargets:          backupTargets,
+		backupTargetCache:      backupTargets.Cache(),
+		backupTargetController: backupTargets,
 	}
 
 	settings.OnChange(ctx, backupTargetControllerName, backupTargetController.OnBackupTargetChange)
+	settings.OnChange(ctx, backupTargetSyncControllerName, backupTargetController.OnBackupTargetSync)
+	backupTargets.OnChange(ctx, backupTargetResourceControllerName, backupTargetController.OnBackupTargetResourceChange)
 	return nil
 }
 
 type TargetHandler struct {
-	ctx                  context.Context
-	longhornSettings     ctllonghornv1.SettingClient
-	longhornSettingCache ctllonghornv1.SettingCache
-	secrets              ctlcorev1.SecretClient
-	secretCache          ctlcorev1.SecretCache
-	vms                  ctlkubevirtv1.VirtualMachineController
-	settings             ctlharvesterv1.SettingClient
+	ctx                    context.Context
+	longhornSettings       ctllonghornv1.SettingClient
+	longhornSettingCache   ctllonghornv1.SettingCache
+	secrets                ctlcorev1.SecretClient
+	secretCache            ctlcorev1.SecretCache
+	vms                    ctlkubevirtv1.VirtualMachineController
+	settings               ctlharvesterv1.SettingClient
+	settingController      ctlharvesterv1.SettingController
+	vmBackups              ctlharvesterv1.VirtualMachineBackupClient
+	vmBackupCache          ctlharvesterv1.VirtualMachineBackupCache
+	backupTargets          ctlharvesterv1.BackupTargetClient
+	backupTargetCache      ctlharvesterv1.BackupTargetCache
+	backupTargetController ctlharvesterv1.BackupTargetController
 }
 
 // OnBackupTargetChange handles backupTarget setting object on change
@@ -86,7 +102,8 @@ func (h *TargetHandler) OnBackupTargetChange(key string, setting *harvesterv1.Se
 
 	settingCpy := setting.DeepCopy()
 	if target.Type == settings.S3BackupType && (target.SecretAccessKey == "" || target.AccessKeyID == "") {
-		return nil, nil
+		// the credentials are already moved to the backup target secret
+		return nil, h.reconcileDefaultBackupTarget(target)
 	}
 
 	target, err = h.validateTargetEndpoint(target)
@@ -105,6 +122,10 @@ func (h *TargetHandler) OnBackupTargetChange(key string, setting *harvesterv1.Se
 		}
 	}
 
+	if err = h.reconcileDefaultBackupTarget(target); err != nil {
+		return nil, err
+	}
+
 	return h.updateBackupTargetSetting(settingCpy, target, err)
 }
 
@@ -213,7 +234,7 @@ func (h *TargetHandler) validateTargetEndpoint(target *settings.BackupTarget) (*
 
 	switch target.Type {
 	case settings.NFSBackupType:
-		target.Endpoint = fmt.Sprintf("nfs://%s", strings.TrimPrefix(target.Endpoint, "nfs://"))
+		target.Endpoint = normalizeNFSEndpoint(target.Endpoint)
 		return target, validateNFSBackupTarget(target.Endpoint)
 	case settings.S3BackupType:
 		err := h.validateS3BackupTarget(target)
@@ -223,41 +244,76 @@ func (h *TargetHandler) validateTargetEndpoint(target *settings.BackupTarget) (*
 	}
 }
 
+func normalizeNFSEndpoint(endpoint string) string {
+	return fmt.Sprintf("nfs://%s", strings.TrimPrefix(endpoint, "nfs://"))
+}
+
 func validateNFSBackupTarget(destURL string) error {
+	b, err := newNFSStoreDriver(destURL)
+	if err != nil {
+		return err
+	}
+	return b.unmount()
+}
+
+// newNFSStoreDriver mounts the NFS backup target, the caller is responsible to unmount it
+func newNFSStoreDriver(destURL string) (*StoreDriver, error) {
 	b := &StoreDriver{}
 	b.FileSystemOperator = fsops.NewFileSystemOperator(b)
 
 	u, err := url.Parse(destURL)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if u.Host == "" {
-		return fmt.Errorf("NFS path must follow: nfs://server:/path/ format")
+		return nil, fmt.Errorf("NFS path must follow: nfs://server:/path/ format")
 	}
 	if u.Path == "" {
-		return fmt.Errorf("cannot find nfs path")
+		return nil, fmt.Errorf("cannot find nfs path")
 	}
 
 	b.serverPath = u.Host + u.Path
 	b.mountDir = filepath.Join(MountDir, strings.TrimRight(strings.Replace(u.Host, ".", "_", -1), ":"), u.Path)
 	if err := os.MkdirAll(b.mountDir, os.ModeDir|0700); err != nil {
-		return fmt.Errorf("cannot create mount directory %v for NFS server", b.mountDir)
+		return nil, fmt.Errorf("cannot create mount directory %v for NFS server", b.mountDir)
 	}
 
 	if err := b.mount(); err != nil {
-		return fmt.Errorf("cannot mount nfs %v: %v", b.serverPath, err)
+		return nil, fmt.Errorf("cannot mount nfs %v: %v", b.serverPath, err)
 	}
 	if _, err := b.List(""); err != nil {
-		return fmt.Errorf("NFS path %v doesn't exist or is not a directory", b.serverPath)
+		if err := b.unmount(); err != nil {
+			logrus.Errorf("failed to unmount nfs %v: %v", b.serverPath, err)
+		}
+		return nil, fmt.Errorf("NFS path %v doesn't exist or is not a directory", b.serverPath)
 	}
 
 	b.destURL = KIND + "://" + b.serverPath
 	logrus.Debugf("Loaded driver for %v", b.destURL)
-	return b.unmount()
+	return b, nil
 }
 
 func (h *TargetHandler) validateS3BackupTarget(target *settings.BackupTarget) error {
+	client, err := newS3Client(h.ctx, target)
+	if err != nil {
+		return err
+	}
+
+	output, err := client.ListBuckets(h.ctx, &s3.ListBucketsInput{})
+	if err != nil {
+		return err
+	}
+
+	for _, b := range output.Buckets {
+		if *b.Name == target.BucketName {
+			return nil
+		}
+	}
+	return fmt.Errorf("bucket %s does not exist", target.BucketName)
+}
+
+func newS3Client(ctx context.Context, target *settings.BackupTarget) (*s3.Client, error) {
 	credentials := credentials.StaticCredentialsProvider{
 		Value: aws.Credentials{
 			AccessKeyID:     target.AccessKeyID,
@@ -281,27 +337,18 @@ func (h *TargetHandler) validateS3BackupTarget(target *settings.BackupTarget) er
 	if target.Cert != "" {
 		ca = strings.NewReader(target.Cert)
 	}
-	cfg, err := awsconfig.LoadDefaultConfig(h.ctx, awsconfig.WithCredentialsProvider(credentials),
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithCredentialsProvider(credentials),
 		awsconfig.WithEndpointResolver(endpointResolver),
 		awsconfig.WithCustomCABundle(ca),
 		awsconfig.WithDefaultRegion(target.BucketRegion))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// create a s3 service client
-	client := s3.NewFromConfig(cfg)
-	output, err := client.ListBuckets(h.ctx, &s3.ListBucketsInput{})
-	if err != nil {
-		return err
-	}
-
-	for _, b := range output.Buckets {
-		if *b.Name == target.BucketName {
-			return nil
-		}
-	}
-	return fmt.Errorf("bucket %s does not exist", target.BucketName)
+	return s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.UsePathStyle = !target.VirtualHostedStyle
+	}), nil
 }
 
 func decodeTarget(value string) (*settings.BackupTarget, error) {
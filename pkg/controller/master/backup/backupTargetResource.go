@@ -0,0 +1,226 @@
+package backup
+
+import (
+	"fmt"
+	"reflect"
+
+	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/settings"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	backupTargetResourceControllerName = "harvester-backup-target-resource-controller"
+)
+
+// OnBackupTargetResourceChange validates the backup target resource and records the result in its status,
+// the invalid backup targets are validated again periodically.
+func (h *TargetHandler) OnBackupTargetResourceChange(key string, backupTarget *harvesterv1.BackupTarget) (*harvesterv1.BackupTarget, error) {
+	if backupTarget == nil || backupTarget.DeletionTimestamp != nil {
+		return nil, nil
+	}
+
+	target, err := getBackupTargetSettings(backupTarget, h.secretCache)
+	if err == nil {
+		_, err = h.validateTargetEndpoint(target)
+	}
+	if err != nil {
+		logrus.Errorf("invalid backup target %s, error: %s", backupTarget.Name, err.Error())
+		h.backupTargetController.EnqueueAfter(backupTarget.Name, backupTargetSyncInterval)
+	}
+
+	backupTargetCpy := backupTarget.DeepCopy()
+	harvesterv1.BackupTargetValidated.SetError(backupTargetCpy, "", err)
+	if reflect.DeepEqual(backupTarget.Status, backupTargetCpy.Status) {
+		return backupTarget, nil
+	}
+	return h.backupTargets.Update(backupTargetCpy)
+}
+
+// reconcileDefaultBackupTarget maps the backup-target setting to the default backup target resource
+func (h *TargetHandler) reconcileDefaultBackupTarget(target *settings.BackupTarget) error {
+	backupTarget, err := h.backupTargetCache.Get(harvesterv1.DefaultBackupTargetName)
+	if apierrors.IsNotFound(err) {
+		backupTarget = nil
+	} else if err != nil {
+		return err
+	}
+
+	if target.Endpoint == "" {
+		if backupTarget == nil {
+			return nil
+		}
+		return h.backupTargets.Delete(backupTarget.Name, &metav1.DeleteOptions{})
+	}
+
+	spec := harvesterv1.BackupTargetSpec{
+		Type:               string(target.Type),
+		Endpoint:           target.Endpoint,
+		BucketName:         target.BucketName,
+		BucketRegion:       target.BucketRegion,
+		Cert:               target.Cert,
+		VirtualHostedStyle: target.VirtualHostedStyle,
+	}
+	if target.Type == settings.S3BackupType {
+		spec.CredentialSecret = &corev1.SecretReference{
+			Name:      backupTargetSecretName,
+			Namespace: util.LonghornSystemNamespaceName,
+		}
+	}
+
+	if backupTarget == nil {
+		_, err := h.backupTargets.Create(&harvesterv1.BackupTarget{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: harvesterv1.DefaultBackupTargetName,
+			},
+			Spec: spec,
+		})
+		return err
+	}
+
+	if !reflect.DeepEqual(backupTarget.Spec, spec) {
+		backupTargetCpy := backupTarget.DeepCopy()
+		backupTargetCpy.Spec = spec
+		_, err := h.backupTargets.Update(backupTargetCpy)
+		return err
+	}
+	return nil
+}
+
+// getBackupTargetSettings converts the backup target resource to the backup target settings with the credentials
+func getBackupTargetSettings(backupTarget *harvesterv1.BackupTarget, secretCache ctlcorev1.SecretCache) (*settings.BackupTarget, error) {
+	target := &settings.BackupTarget{
+		Type:               settings.TargetType(backupTarget.Spec.Type),
+		Endpoint:           normalizeEndpoint(backupTarget),
+		BucketName:         backupTarget.Spec.BucketName,
+		BucketRegion:       backupTarget.Spec.BucketRegion,
+		Cert:               backupTarget.Spec.Cert,
+		VirtualHostedStyle: backupTarget.Spec.VirtualHostedStyle,
+	}
+
+	if target.Type != settings.S3BackupType {
+		return target, nil
+	}
+	secretRef := backupTarget.Spec.CredentialSecret
+	if secretRef == nil || secretRef.Name == "" {
+		return nil, fmt.Errorf("credential secret of the s3 backup target %s is empty", backupTarget.Name)
+	}
+	secret, err := secretCache.Get(secretRef.Namespace, secretRef.Name)
+	if err != nil {
+		return nil, fmt.Errorf("can't get credential secret %s/%s, error: %w", secretRef.Namespace, secretRef.Name, err)
+	}
+	target.AccessKeyID = string(secret.Data[awsAccessKeyID])
+	target.SecretAccessKey = string(secret.Data[awsSecretAccessKey])
+	return target, nil
+}
+
+// GetBackupTargetName returns the name of the backup target storing the VM backup
+func GetBackupTargetName(vmBackup *harvesterv1.VirtualMachineBackup) string {
+	if vmBackup.Spec.BackupTarget == nil || vmBackup.Spec.BackupTarget.Name == "" {
+		return harvesterv1.DefaultBackupTargetName
+	}
+	return vmBackup.Spec.BackupTarget.Name
+}
+
+// IsSameBackupTarget checks whether the backup targets point to the same storage location
+func IsSameBackupTarget(a, b *harvesterv1.BackupTarget) bool {
+	return a.Spec.Type == b.Spec.Type &&
+		normalizeEndpoint(a) == normalizeEndpoint(b) &&
+		a.Spec.BucketName == b.Spec.BucketName &&
+		a.Spec.BucketRegion == b.Spec.BucketRegion
+}
+
+// IsBackupInTarget checks whether the VM backup is stored in the backup target
+func IsBackupInTarget(vmBackup *harvesterv1.VirtualMachineBackup, backupTarget *harvesterv1.BackupTarget) bool {
+	return vmBackup.Annotations[BackupTargetAnnotation] == normalizeEndpoint(backupTarget) &&
+		vmBackup.Annotations[BackupBucketNameAnnotation] == backupTarget.Spec.BucketName &&
+		vmBackup.Annotations[BackupBucketRegionAnnotation] == backupTarget.Spec.BucketRegion
+}
+
+// IsBackupInTargetSetting checks whether the VM backup is stored in the target of the backup-target setting value
+func IsBackupInTargetSetting(vmBackup *harvesterv1.VirtualMachineBackup, value string) (bool, error) {
+	target, err := decodeTarget(value)
+	if err != nil {
+		return false, err
+	}
+	return target.Endpoint != "" &&
+		vmBackup.Annotations[BackupTargetAnnotation] == target.Endpoint &&
+		vmBackup.Annotations[BackupBucketNameAnnotation] == target.BucketName &&
+		vmBackup.Annotations[BackupBucketRegionAnnotation] == target.BucketRegion, nil
+}
+
+// normalizeEndpoint returns the endpoint in the form of the validated backup-target setting
+func normalizeEndpoint(backupTarget *harvesterv1.BackupTarget) string {
+	if settings.TargetType(backupTarget.Spec.Type) == settings.NFSBackupType {
+		return normalizeNFSEndpoint(backupTarget.Spec.Endpoint)
+	}
+	return backupTarget.Spec.Endpoint
+}
+
+// getBackupTarget returns the backup target of the VM backup, it falls back to the backup-target setting
+// until the default backup target is created.
+func (h *Handler) getBackupTarget(vmBackup *harvesterv1.VirtualMachineBackup) (*settings.BackupTarget, error) {
+	name := GetBackupTargetName(vmBackup)
+	backupTarget, err := h.backupTargetCache.Get(name)
+	if apierrors.IsNotFound(err) && name == harvesterv1.DefaultBackupTargetName {
+		return decodeTarget(settings.BackupTargetSet.Get())
+	} else if err != nil {
+		return nil, err
+	}
+	return getBackupTargetSettings(backupTarget, h.secretCache)
+}
+
+// checkBackupTarget makes sure the backup target of the VM backup is validated and points to the default
+// backup target, since longhorn stores the volume backups in its only backup target.
+func (h *Handler) checkBackupTarget(vmBackup *harvesterv1.VirtualMachineBackup) error {
+	name := GetBackupTargetName(vmBackup)
+	if name == harvesterv1.DefaultBackupTargetName {
+		return nil
+	}
+
+	backupTarget, err := h.backupTargetCache.Get(name)
+	if err != nil {
+		return fmt.Errorf("can't get backup target %s, error: %w", name, err)
+	}
+	if !harvesterv1.BackupTargetValidated.IsTrue(backupTarget) {
+		return fmt.Errorf("backup target %s is not validated", name)
+	}
+	return CheckBackupTargetLocation(h.backupTargetCache, backupTarget)
+}
+
+// CheckBackupTargetLocation rejects the named backup targets pointing to a location other than the default backup
+// target. Longhorn has a single backup target, so the volume data of every VM backup is stored in the default
+// backup target and the other backup targets only name the same location.
+func CheckBackupTargetLocation(backupTargetCache ctlharvesterv1.BackupTargetCache, backupTarget *harvesterv1.BackupTarget) error {
+	if backupTarget.Name == harvesterv1.DefaultBackupTargetName {
+		return nil
+	}
+	defaultTarget, err := backupTargetCache.Get(harvesterv1.DefaultBackupTargetName)
+	if err != nil {
+		return fmt.Errorf("can't get the default backup target, error: %w", err)
+	}
+	if !IsSameBackupTarget(backupTarget, defaultTarget) {
+		return fmt.Errorf("backup target %s is not the same as the default backup target, the volume data can only be stored in the default backup target", backupTarget.Name)
+	}
+	return nil
+}
+
+func (h *Handler) setBackupError(vmBackup *harvesterv1.VirtualMachineBackup, err error) error {
+	vmBackupCpy := vmBackup.DeepCopy()
+	message := err.Error()
+	vmBackupCpy.Status.Error = &harvesterv1.Error{
+		Time:    currentTime(),
+		Message: &message,
+	}
+	updateBackupCondition(vmBackupCpy, newProgressingCondition(corev1.ConditionFalse, "In error state"))
+	updateBackupCondition(vmBackupCpy, newReadyCondition(corev1.ConditionFalse, "Error"))
+	_, err = h.vmBackups.Update(vmBackupCpy)
+	return err
+}
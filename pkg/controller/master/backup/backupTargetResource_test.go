@@ -0,0 +1,231 @@
+package backup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/fake"
+	"github.com/harvester/harvester/pkg/settings"
+	"github.com/harvester/harvester/pkg/util"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+func newTestBackupTarget(name, endpoint string, validated bool) *harvesterv1.BackupTarget {
+	backupTarget := &harvesterv1.BackupTarget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: harvesterv1.BackupTargetSpec{
+			Type:     string(settings.NFSBackupType),
+			Endpoint: endpoint,
+		},
+	}
+	if validated {
+		harvesterv1.BackupTargetValidated.True(backupTarget)
+	} else {
+		harvesterv1.BackupTargetValidated.False(backupTarget)
+	}
+	return backupTarget
+}
+
+func TestTargetHandler_reconcileDefaultBackupTarget(t *testing.T) {
+	type input struct {
+		target  *settings.BackupTarget
+		objects []runtime.Object
+	}
+	var testCases = []struct {
+		name     string
+		given    input
+		expected *harvesterv1.BackupTargetSpec
+	}{
+		{
+			name: "create the default backup target",
+			given: input{
+				target: &settings.BackupTarget{
+					Type:     settings.NFSBackupType,
+					Endpoint: testNFSEndpoint,
+				},
+			},
+			expected: &harvesterv1.BackupTargetSpec{
+				Type:     string(settings.NFSBackupType),
+				Endpoint: testNFSEndpoint,
+			},
+		},
+		{
+			name: "update the default backup target to s3",
+			given: input{
+				target: &settings.BackupTarget{
+					Type:         settings.S3BackupType,
+					Endpoint:     "https://s3.example.com",
+					BucketName:   "backups",
+					BucketRegion: "us-east-1",
+				},
+				objects: []runtime.Object{
+					newTestBackupTarget(harvesterv1.DefaultBackupTargetName, testNFSEndpoint, true),
+				},
+			},
+			expected: &harvesterv1.BackupTargetSpec{
+				Type:         string(settings.S3BackupType),
+				Endpoint:     "https://s3.example.com",
+				BucketName:   "backups",
+				BucketRegion: "us-east-1",
+				CredentialSecret: &corev1.SecretReference{
+					Name:      backupTargetSecretName,
+					Namespace: util.LonghornSystemNamespaceName,
+				},
+			},
+		},
+		{
+			name: "delete the default backup target if the setting is reset",
+			given: input{
+				target: &settings.BackupTarget{},
+				objects: []runtime.Object{
+					newTestBackupTarget(harvesterv1.DefaultBackupTargetName, testNFSEndpoint, true),
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		var clientset = fake.NewSimpleClientset(tc.given.objects...)
+		var handler = &TargetHandler{
+			backupTargets:     fakeclients.BackupTargetClient(clientset.HarvesterhciV1beta1().BackupTargets),
+			backupTargetCache: fakeclients.BackupTargetCache(clientset.HarvesterhciV1beta1().BackupTargets),
+		}
+
+		err := handler.reconcileDefaultBackupTarget(tc.given.target)
+		assert.Nil(t, err, "case %q", tc.name)
+
+		backupTarget, err := handler.backupTargetCache.Get(harvesterv1.DefaultBackupTargetName)
+		if tc.expected == nil {
+			assert.NotNil(t, err, "case %q", tc.name)
+			continue
+		}
+		assert.Nil(t, err, "case %q", tc.name)
+		assert.Equal(t, *tc.expected, backupTarget.Spec, "case %q", tc.name)
+	}
+}
+
+func TestHandler_checkBackupTarget(t *testing.T) {
+	newTestTargetBackup := func(targetName string) *harvesterv1.VirtualMachineBackup {
+		backup := newTestInProgressBackup("vm")
+		if targetName != "" {
+			backup.Spec.BackupTarget = &corev1.LocalObjectReference{Name: targetName}
+		}
+		return backup
+	}
+	defaultTarget := newTestBackupTarget(harvesterv1.DefaultBackupTargetName, testNFSEndpoint, true)
+
+	type input struct {
+		backup  *harvesterv1.VirtualMachineBackup
+		objects []runtime.Object
+	}
+	var testCases = []struct {
+		name        string
+		given       input
+		expectedErr bool
+	}{
+		{
+			name: "backup without a backup target uses the default one",
+			given: input{
+				backup: newTestTargetBackup(""),
+			},
+		},
+		{
+			name: "backup target is not found",
+			given: input{
+				backup:  newTestTargetBackup("lab"),
+				objects: []runtime.Object{defaultTarget},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "backup target is not validated",
+			given: input{
+				backup: newTestTargetBackup("lab"),
+				objects: []runtime.Object{
+					defaultTarget,
+					newTestBackupTarget("lab", testNFSEndpoint, false),
+				},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "backup target is different from the default one",
+			given: input{
+				backup: newTestTargetBackup("lab"),
+				objects: []runtime.Object{
+					defaultTarget,
+					newTestBackupTarget("lab", "nfs://lab.example.com:/backups", true),
+				},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "backup target is the same as the default one",
+			given: input{
+				backup: newTestTargetBackup("lab"),
+				objects: []runtime.Object{
+					defaultTarget,
+					newTestBackupTarget("lab", "nfs.example.com:/backups", true),
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		var clientset = fake.NewSimpleClientset(tc.given.objects...)
+		var handler = &Handler{
+			backupTargetCache: fakeclients.BackupTargetCache(clientset.HarvesterhciV1beta1().BackupTargets),
+		}
+
+		err := handler.checkBackupTarget(tc.given.backup)
+		assert.Equal(t, tc.expectedErr, err != nil, "case %q", tc.name)
+	}
+}
+
+func TestIsBackupInTargetSetting(t *testing.T) {
+	vmBackup := newTestInProgressBackup("vm")
+	vmBackup.Annotations = map[string]string{
+		BackupTargetAnnotation:       "nfs.example.com:/backups",
+		BackupBucketNameAnnotation:   "",
+		BackupBucketRegionAnnotation: "",
+	}
+
+	var testCases = []struct {
+		name        string
+		given       string
+		expected    bool
+		expectedErr bool
+	}{
+		{
+			name:     "backup is stored in the target of the setting",
+			given:    `{"type":"nfs","endpoint":"nfs.example.com:/backups"}`,
+			expected: true,
+		},
+		{
+			name:  "backup is stored in another target",
+			given: `{"type":"nfs","endpoint":"nfs.example.com:/others"}`,
+		},
+		{
+			name:  "backup target setting is empty",
+			given: `{}`,
+		},
+		{
+			name:        "backup target setting is invalid",
+			given:       `nfs.example.com:/backups`,
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		actual, err := IsBackupInTargetSetting(vmBackup, tc.given)
+		assert.Equal(t, tc.expectedErr, err != nil, "case %q", tc.name)
+		assert.Equal(t, tc.expected, actual, "case %q", tc.name)
+	}
+}
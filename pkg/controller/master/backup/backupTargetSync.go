@@ -0,0 +1,163 @@
+package backup
+
+import (
+	"path"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/settings"
+)
+
+const (
+	backupTargetSyncControllerName = "harvester-backup-target-sync-controller"
+
+	backupTargetSyncInterval = 5 * time.Minute
+)
+
+// OnBackupTargetSync scans the VM backup metadata in the configured backup target and imports
+// the backups which do not exist in the cluster as read-only VM backups.
+func (h *TargetHandler) OnBackupTargetSync(key string, setting *harvesterv1.Setting) (*harvesterv1.Setting, error) {
+	if setting == nil || setting.DeletionTimestamp != nil ||
+		setting.Name != settings.BackupTargetSettingName || setting.Value == "" ||
+		!harvesterv1.SettingConfigured.IsTrue(setting) {
+		return nil, nil
+	}
+
+	target, err := decodeTarget(setting.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.syncBackupTarget(target); err != nil {
+		return nil, err
+	}
+
+	h.settingController.EnqueueAfter(setting.Name, backupTargetSyncInterval)
+	return nil, nil
+}
+
+func (h *TargetHandler) syncBackupTarget(target *settings.BackupTarget) error {
+	store, err := newBackupStore(h.ctx, target, h.secretCache)
+	if err != nil {
+		return err
+	}
+	defer closeBackupStore(store)
+
+	namespaces, err := store.List(vmBackupMetadataDir)
+	if err != nil {
+		return err
+	}
+	for _, namespace := range namespaces {
+		names, err := store.List(path.Join(vmBackupMetadataDir, namespace))
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if err := h.importVMBackup(store, target, namespace, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// importVMBackup imports the latest backup of the name from the backup target, the clusters sharing the
+// backup target may each have a backup of the name, which are stored by their UIDs.
+func (h *TargetHandler) importVMBackup(store backupStore, target *settings.BackupTarget, namespace, name string) error {
+	if _, err := h.vmBackupCache.Get(namespace, name); err == nil {
+		return nil
+	} else if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	files, err := store.List(path.Join(vmBackupMetadataDir, namespace, name))
+	if err != nil {
+		return err
+	}
+	var metadata *harvesterv1.VirtualMachineBackup
+	for _, file := range files {
+		if !strings.HasSuffix(file, vmBackupMetadataExtension) {
+			continue
+		}
+		uid := types.UID(strings.TrimSuffix(file, vmBackupMetadataExtension))
+		data, err := store.Read(getVMBackupMetadataPath(namespace, name, uid))
+		if err != nil {
+			return err
+		}
+		m, err := decodeVMBackupMetadata(data)
+		if err != nil {
+			logrus.Warnf("skip importing VM backup %s/%s(%s) from the backup target, error: %s", namespace, name, uid, err.Error())
+			continue
+		}
+		if m.Namespace != namespace || m.Name != name {
+			logrus.Warnf("skip importing VM backup %s/%s(%s) from the backup target, the metadata belongs to %s/%s",
+				namespace, name, uid, m.Namespace, m.Name)
+			continue
+		}
+		if metadata == nil || isCreatedBefore(metadata, m) {
+			metadata = m
+		}
+	}
+	if metadata == nil {
+		return nil
+	}
+
+	vmBackup := &harvesterv1.VirtualMachineBackup{
+		ObjectMeta: metadata.ObjectMeta,
+		Spec:       metadata.Spec,
+		Status: &harvesterv1.VirtualMachineBackupStatus{
+			CreationTime:  metadata.Status.CreationTime,
+			SourceSpec:    metadata.Status.SourceSpec,
+			VolumeBackups: metadata.Status.VolumeBackups,
+			ReadyToUse:    pointer.BoolPtr(true),
+			Conditions: []harvesterv1.Condition{
+				{
+					Type:               harvesterv1.BackupConditionMetadataReady,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: currentTime().Format(time.RFC3339),
+				},
+			},
+		},
+	}
+	// the imported backups are stored in the default backup target of this cluster
+	vmBackup.Spec.BackupTarget = nil
+	if vmBackup.Annotations == nil {
+		vmBackup.Annotations = map[string]string{}
+	}
+	vmBackup.Annotations[BackupReadOnlyAnnotation] = "true"
+	vmBackup.Annotations[BackupTargetAnnotation] = target.Endpoint
+	delete(vmBackup.Annotations, BackupBucketNameAnnotation)
+	delete(vmBackup.Annotations, BackupBucketRegionAnnotation)
+	if target.Type == settings.S3BackupType {
+		vmBackup.Annotations[BackupBucketNameAnnotation] = target.BucketName
+		vmBackup.Annotations[BackupBucketRegionAnnotation] = target.BucketRegion
+	}
+
+	if _, err := h.vmBackups.Create(vmBackup); err != nil {
+		// the namespace of the backup may not exist in this cluster
+		if apierrors.IsNotFound(err) {
+			logrus.Warnf("skip importing VM backup %s/%s from the backup target, error: %s", namespace, name, err.Error())
+			return nil
+		}
+		if !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+		return nil
+	}
+	logrus.Infof("imported VM backup %s/%s from the backup target", namespace, name)
+	return nil
+}
+
+func isCreatedBefore(a, b *harvesterv1.VirtualMachineBackup) bool {
+	if a.Status.CreationTime == nil || b.Status.CreationTime == nil {
+		return b.Status.CreationTime != nil
+	}
+	return a.Status.CreationTime.Before(b.Status.CreationTime)
+}
@@ -0,0 +1,193 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	kv1 "kubevirt.io/client-go/api/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/fake"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/settings"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+const testNFSEndpoint = "nfs://nfs.example.com:/backups"
+
+type fakeSettingController struct {
+	ctlharvesterv1.SettingController
+	enqueueAfter time.Duration
+}
+
+func (c *fakeSettingController) EnqueueAfter(name string, duration time.Duration) {
+	c.enqueueAfter = duration
+}
+
+// memoryBackupStore keeps the files in memory
+type memoryBackupStore map[string][]byte
+
+func (s memoryBackupStore) List(dir string) ([]string, error) {
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+	var names []string
+	found := map[string]bool{}
+	for filePath := range s {
+		if !strings.HasPrefix(filePath, prefix) {
+			continue
+		}
+		name := strings.SplitN(strings.TrimPrefix(filePath, prefix), "/", 2)[0]
+		if !found[name] {
+			found[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s memoryBackupStore) Read(filePath string) ([]byte, error) {
+	data, ok := s[filePath]
+	if !ok {
+		return nil, fmt.Errorf("file %s is not found", filePath)
+	}
+	return data, nil
+}
+
+func (s memoryBackupStore) Write(filePath string, data []byte) error {
+	s[filePath] = data
+	return nil
+}
+
+func (s memoryBackupStore) Delete(filePath string) error {
+	delete(s, filePath)
+	return nil
+}
+
+func (s memoryBackupStore) Close() error {
+	return nil
+}
+
+func newTestMetadataBackup(name string) *harvesterv1.VirtualMachineBackup {
+	return &harvesterv1.VirtualMachineBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      name,
+			UID:       "backup-uid",
+			Annotations: map[string]string{
+				BackupTargetAnnotation: testNFSEndpoint,
+			},
+		},
+		Spec: harvesterv1.VirtualMachineBackupSpec{
+			Source: corev1.TypedLocalObjectReference{
+				Kind: kv1.VirtualMachineGroupVersionKind.Kind,
+				Name: "vm",
+			},
+		},
+		Status: &harvesterv1.VirtualMachineBackupStatus{
+			CreationTime: &metav1.Time{Time: time.Date(2021, 7, 1, 2, 0, 0, 0, time.UTC)},
+			ReadyToUse:   pointer.BoolPtr(true),
+			SourceSpec: &harvesterv1.VirtualMachineSourceSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: testNamespace,
+					Name:      "vm",
+				},
+			},
+			VolumeBackups: []harvesterv1.VolumeBackup{
+				{
+					Name:               pointer.StringPtr(name + "-disk-0"),
+					VolumeName:         "disk-0",
+					LonghornBackupName: pointer.StringPtr("backup-0123456789"),
+					PersistentVolumeClaim: harvesterv1.PersistentVolumeClaimSourceSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Namespace: testNamespace,
+							Name:      "vm-disk-0",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestEncodeVMBackupMetadata(t *testing.T) {
+	backup := newTestMetadataBackup("backup")
+	data, err := encodeVMBackupMetadata(backup)
+	assert.Nil(t, err)
+
+	metadata, err := decodeVMBackupMetadata(data)
+	assert.Nil(t, err)
+	assert.Empty(t, metadata.UID)
+	assert.Nil(t, metadata.Status.ReadyToUse)
+	assert.Equal(t, backup.Annotations, metadata.Annotations)
+	assert.Equal(t, backup.Spec, metadata.Spec)
+	assert.Equal(t, backup.Status.SourceSpec, metadata.Status.SourceSpec)
+	assert.Equal(t, backup.Status.VolumeBackups, metadata.Status.VolumeBackups)
+
+	_, err = decodeVMBackupMetadata([]byte(`{"metadata":{"name":"backup","namespace":"default"}}`))
+	assert.NotNil(t, err)
+}
+
+func TestTargetHandler_OnBackupTargetSync(t *testing.T) {
+	store := memoryBackupStore{}
+	defer func(f func(context.Context, *settings.BackupTarget, ctlcorev1.SecretCache) (backupStore, error)) {
+		newBackupStore = f
+	}(newBackupStore)
+	newBackupStore = func(ctx context.Context, target *settings.BackupTarget, secretCache ctlcorev1.SecretCache) (backupStore, error) {
+		return store, nil
+	}
+	for _, name := range []string{"imported", "existing"} {
+		data, err := encodeVMBackupMetadata(newTestMetadataBackup(name))
+		assert.Nil(t, err)
+		assert.Nil(t, store.Write(getVMBackupMetadataPath(testNamespace, name, "backup-uid"), data))
+	}
+	// an older backup of the same name written by another cluster sharing the backup target
+	older := newTestMetadataBackup("imported")
+	older.Status.CreationTime = &metav1.Time{Time: time.Date(2021, 6, 1, 2, 0, 0, 0, time.UTC)}
+	older.Status.VolumeBackups[0].LonghornBackupName = pointer.StringPtr("backup-older")
+	data, err := encodeVMBackupMetadata(older)
+	assert.Nil(t, err)
+	assert.Nil(t, store.Write(getVMBackupMetadataPath(testNamespace, "imported", "other-cluster-uid"), data))
+	assert.Nil(t, store.Write(getVMBackupMetadataPath(testNamespace, "broken", "backup-uid"), []byte("{}")))
+	assert.Nil(t, store.Write(path.Join(vmBackupMetadataDir, testNamespace, "imported", "backup-uid.cfg.tmp.1"), []byte("{}")))
+
+	existing := newTestMetadataBackup("existing")
+	var clientset = fake.NewSimpleClientset(existing)
+	var controller = &fakeSettingController{}
+	var handler = &TargetHandler{
+		ctx:               context.Background(),
+		settingController: controller,
+		vmBackups:         fakeclients.VirtualMachineBackupClient(clientset.HarvesterhciV1beta1().VirtualMachineBackups),
+		vmBackupCache:     fakeclients.VirtualMachineBackupCache(clientset.HarvesterhciV1beta1().VirtualMachineBackups),
+	}
+
+	setting := newTestBackupTargetSetting(true)
+	setting.Value = fmt.Sprintf(`{"type":"nfs","endpoint":"%s"}`, testNFSEndpoint)
+	_, err = handler.OnBackupTargetSync(setting.Name, setting)
+	assert.Nil(t, err)
+	assert.Equal(t, backupTargetSyncInterval, controller.enqueueAfter)
+
+	imported, err := handler.vmBackupCache.Get(testNamespace, "imported")
+	assert.Nil(t, err)
+	assert.True(t, isBackupReadOnly(imported))
+	assert.True(t, isBackupReady(imported))
+	assert.True(t, isBackupMetadataReady(imported))
+	assert.Equal(t, testNFSEndpoint, imported.Annotations[BackupTargetAnnotation])
+	assert.Equal(t, "backup-0123456789", *imported.Status.VolumeBackups[0].LonghornBackupName, "the latest backup of the name is imported")
+
+	existing, err = handler.vmBackupCache.Get(testNamespace, "existing")
+	assert.Nil(t, err)
+	assert.False(t, isBackupReadOnly(existing))
+
+	_, err = handler.vmBackupCache.Get(testNamespace, "broken")
+	assert.NotNil(t, err)
+}
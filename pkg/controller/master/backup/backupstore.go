@@ -0,0 +1,209 @@
+package backup
+
+// backupStore helps to read and write the VM backup metadata files next to the longhorn backups in the
+// configured backup target, the files are stored as:
+// <backup target>/harvester/vmbackups/<namespace>/<name>/<uid>.cfg
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/settings"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	vmBackupMetadataDir       = "harvester/vmbackups"
+	vmBackupMetadataExtension = ".cfg"
+
+	awsAccessKeyID     = "AWS_ACCESS_KEY_ID"
+	awsSecretAccessKey = "AWS_SECRET_ACCESS_KEY"
+)
+
+type backupStore interface {
+	// List returns the names of the entries in the directory
+	List(dir string) ([]string, error)
+	Read(filePath string) ([]byte, error)
+	Write(filePath string, data []byte) error
+	Delete(filePath string) error
+	Close() error
+}
+
+// variable so can be overridden in tests
+var newBackupStore = func(ctx context.Context, target *settings.BackupTarget, secretCache ctlcorev1.SecretCache) (backupStore, error) {
+	switch target.Type {
+	case settings.NFSBackupType:
+		driver, err := newNFSStoreDriver(target.Endpoint)
+		if err != nil {
+			return nil, err
+		}
+		return &nfsBackupStore{driver: driver}, nil
+	case settings.S3BackupType:
+		// the s3 credentials are removed from the setting once it is configured, read them from the backup target secret
+		targetCpy := *target
+		if targetCpy.AccessKeyID == "" || targetCpy.SecretAccessKey == "" {
+			secret, err := secretCache.Get(util.LonghornSystemNamespaceName, backupTargetSecretName)
+			if err != nil {
+				return nil, err
+			}
+			targetCpy.AccessKeyID = string(secret.Data[awsAccessKeyID])
+			targetCpy.SecretAccessKey = string(secret.Data[awsSecretAccessKey])
+		}
+		client, err := newS3Client(ctx, &targetCpy)
+		if err != nil {
+			return nil, err
+		}
+		return &s3BackupStore{ctx: ctx, client: client, bucket: target.BucketName}, nil
+	}
+	return nil, fmt.Errorf("unknown type of the backup target %q", target.Type)
+}
+
+type nfsBackupStore struct {
+	driver *StoreDriver
+}
+
+func (s *nfsBackupStore) List(dir string) ([]string, error) {
+	return s.driver.List(dir)
+}
+
+func (s *nfsBackupStore) Read(filePath string) ([]byte, error) {
+	rc, err := s.driver.Read(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ioutil.ReadAll(rc)
+}
+
+func (s *nfsBackupStore) Write(filePath string, data []byte) error {
+	return s.driver.Write(filePath, bytes.NewReader(data))
+}
+
+func (s *nfsBackupStore) Delete(filePath string) error {
+	return s.driver.Remove(filePath)
+}
+
+func (s *nfsBackupStore) Close() error {
+	return s.driver.unmount()
+}
+
+type s3BackupStore struct {
+	ctx    context.Context
+	client *s3.Client
+	bucket string
+}
+
+func (s *s3BackupStore) List(dir string) ([]string, error) {
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+	var names []string
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket:    aws.String(s.bucket),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String("/"),
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(s.ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range output.CommonPrefixes {
+			names = append(names, strings.TrimSuffix(strings.TrimPrefix(aws.ToString(p.Prefix), prefix), "/"))
+		}
+		for _, object := range output.Contents {
+			names = append(names, strings.TrimPrefix(aws.ToString(object.Key), prefix))
+		}
+	}
+	return names, nil
+}
+
+func (s *s3BackupStore) Read(filePath string) ([]byte, error) {
+	output, err := s.client.GetObject(s.ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(filePath),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer output.Body.Close()
+	return ioutil.ReadAll(output.Body)
+}
+
+func (s *s3BackupStore) Write(filePath string, data []byte) error {
+	_, err := s.client.PutObject(s.ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(filePath),
+		Body:   bytes.NewReader(data),
+	})
+	return err
+}
+
+func (s *s3BackupStore) Delete(filePath string) error {
+	_, err := s.client.DeleteObject(s.ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(filePath),
+	})
+	return err
+}
+
+func (s *s3BackupStore) Close() error {
+	return nil
+}
+
+func closeBackupStore(store backupStore) {
+	if err := store.Close(); err != nil {
+		logrus.Errorf("failed to close the backup store: %s", err.Error())
+	}
+}
+
+// getVMBackupMetadataPath returns the metadata path of the backup, which is keyed by the backup UID to keep the
+// metadata of the backups with the same name in the clusters sharing the backup target
+func getVMBackupMetadataPath(namespace, name string, uid types.UID) string {
+	return path.Join(vmBackupMetadataDir, namespace, name, string(uid)+vmBackupMetadataExtension)
+}
+
+// encodeVMBackupMetadata keeps the content required to restore the backup in a new cluster
+func encodeVMBackupMetadata(vmBackup *harvesterv1.VirtualMachineBackup) ([]byte, error) {
+	metadata := &harvesterv1.VirtualMachineBackup{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: harvesterv1.SchemeGroupVersion.String(),
+			Kind:       vmBackupKindName,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        vmBackup.Name,
+			Namespace:   vmBackup.Namespace,
+			Labels:      vmBackup.Labels,
+			Annotations: vmBackup.Annotations,
+		},
+		Spec: vmBackup.Spec,
+		Status: &harvesterv1.VirtualMachineBackupStatus{
+			CreationTime:  vmBackup.Status.CreationTime,
+			SourceSpec:    vmBackup.Status.SourceSpec,
+			VolumeBackups: vmBackup.Status.VolumeBackups,
+		},
+	}
+	return json.Marshal(metadata)
+}
+
+func decodeVMBackupMetadata(data []byte) (*harvesterv1.VirtualMachineBackup, error) {
+	vmBackup := &harvesterv1.VirtualMachineBackup{}
+	if err := json.Unmarshal(data, vmBackup); err != nil {
+		return nil, err
+	}
+	if vmBackup.Name == "" || vmBackup.Namespace == "" || vmBackup.Status == nil ||
+		vmBackup.Status.SourceSpec == nil || len(vmBackup.Status.VolumeBackups) == 0 {
+		return nil, fmt.Errorf("incomplete VM backup metadata")
+	}
+	return vmBackup, nil
+}
@@ -0,0 +1,230 @@
+package backup
+
+import (
+	"fmt"
+	"time"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/v2/pkg/apis/volumesnapshot/v1beta1"
+	lhv1beta1 "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
+	"github.com/longhorn/longhorn-manager/types"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+// The export of a vm backup makes the disk contents of its volume backups downloadable. The data of a volume backup
+// only lives in the backup target, so each volume backup is restored into a temporary PVC first, then the longhorn volume
+// of the PVC is exported to a longhorn backing image which serves the data. The temporary PVC is removed once the
+// backing image is ready. The backing images are removed once the export expires, or together with the vm backup.
+//
+// The archive is downloaded from the export link of the vm backup, i.e.
+// GET /v1/harvester/harvesterhci.io.virtualmachinebackups/{namespace}/{name}?link=export
+
+const (
+	// BackupExportAnnotation requests the export of the vm backup
+	BackupExportAnnotation = "backup.harvesterhci.io/export"
+	// exportFromAnnotation records the vm backup of the export PVCs and backing images
+	exportFromAnnotation = "backup.harvesterhci.io/export-from"
+	// exportExpireAnnotation records the time after which the backing images of the export are removed
+	exportExpireAnnotation = "backup.harvesterhci.io/export-expire-at"
+
+	// exportTTL is how long the backing images of a prepared export are kept
+	exportTTL = 24 * time.Hour
+
+	ExportDiskType = types.DataSourceTypeExportFromVolumeParameterExportTypeQCOW2
+)
+
+// GetExportBackingImageName returns the name of the longhorn backing image which serves the disk contents of the volume backup
+func GetExportBackingImageName(vmBackup *harvesterv1.VirtualMachineBackup, volumeName string) string {
+	return fmt.Sprintf("vmbackup-%s-%s", vmBackup.UID, volumeName)
+}
+
+// IsBackingImageReady returns true if the backing image file is ready on any disk
+func IsBackingImageReady(backingImage *lhv1beta1.BackingImage) bool {
+	for _, status := range backingImage.Status.DiskFileStatusMap {
+		if status != nil && status.State == types.BackingImageStateReady {
+			return true
+		}
+	}
+	return false
+}
+
+func isExportRequested(vmBackup *harvesterv1.VirtualMachineBackup) bool {
+	return vmBackup.Annotations[BackupExportAnnotation] == "true"
+}
+
+func getExportPVCName(vmBackup *harvesterv1.VirtualMachineBackup, volumeName string) string {
+	return fmt.Sprintf("export-%s-%s", vmBackup.Name, volumeName)
+}
+
+// reconcileExport prepares the backing images of the volume backups of the requested export
+func (h *Handler) reconcileExport(vmBackup *harvesterv1.VirtualMachineBackup) error {
+	if !isExportRequested(vmBackup) || !isBackupReady(vmBackup) {
+		return nil
+	}
+
+	exported := true
+	for _, volumeBackup := range vmBackup.Status.VolumeBackups {
+		ready, err := h.reconcileVolumeExport(vmBackup, volumeBackup)
+		if err != nil {
+			return err
+		}
+		exported = exported && ready
+	}
+	if !exported {
+		h.vmBackupController.EnqueueAfter(vmBackup.Namespace, vmBackup.Name, 5*time.Second)
+		return nil
+	}
+	return h.reconcileExportExpiry(vmBackup)
+}
+
+// reconcileExportExpiry sets the expiry of the prepared export, and removes the backing images once the export expires
+func (h *Handler) reconcileExportExpiry(vmBackup *harvesterv1.VirtualMachineBackup) error {
+	now := currentTime().Time
+	expireAt, err := time.Parse(time.RFC3339, vmBackup.Annotations[exportExpireAnnotation])
+	if err != nil {
+		toUpdate := vmBackup.DeepCopy()
+		toUpdate.Annotations[exportExpireAnnotation] = now.Add(exportTTL).Format(time.RFC3339)
+		_, err = h.vmBackups.Update(toUpdate)
+		return err
+	}
+	if now.Before(expireAt) {
+		h.vmBackupController.EnqueueAfter(vmBackup.Namespace, vmBackup.Name, expireAt.Sub(now))
+		return nil
+	}
+
+	logrus.Infof("the export of the backup %s/%s expired, removing the exported backing images", vmBackup.Namespace, vmBackup.Name)
+	if err := h.deleteExport(vmBackup); err != nil {
+		return err
+	}
+	toUpdate := vmBackup.DeepCopy()
+	delete(toUpdate.Annotations, BackupExportAnnotation)
+	delete(toUpdate.Annotations, exportExpireAnnotation)
+	_, err = h.vmBackups.Update(toUpdate)
+	return err
+}
+
+// reconcileVolumeExport returns true if the backing image of the volume backup is ready
+func (h *Handler) reconcileVolumeExport(vmBackup *harvesterv1.VirtualMachineBackup, volumeBackup harvesterv1.VolumeBackup) (bool, error) {
+	if volumeBackup.Name == nil {
+		return false, fmt.Errorf("missing VolumeSnapshot name")
+	}
+
+	pvcName := getExportPVCName(vmBackup, volumeBackup.VolumeName)
+	backingImage, err := h.backingImageCache.Get(util.LonghornSystemNamespaceName, GetExportBackingImageName(vmBackup, volumeBackup.VolumeName))
+	if err == nil {
+		if !IsBackingImageReady(backingImage) {
+			return false, nil
+		}
+		// the backing image keeps its own copy of the data
+		if err := h.pvcs.Delete(vmBackup.Namespace, pvcName, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return false, err
+		}
+		return true, nil
+	} else if !apierrors.IsNotFound(err) {
+		return false, err
+	}
+
+	pvc, err := h.pvcCache.Get(vmBackup.Namespace, pvcName)
+	if apierrors.IsNotFound(err) {
+		_, err = h.pvcs.Create(getExportPVC(vmBackup, volumeBackup, pvcName))
+		return false, err
+	} else if err != nil {
+		return false, err
+	}
+	if pvc.Spec.VolumeName == "" {
+		return false, nil
+	}
+
+	volume, err := h.volumeCache.Get(util.LonghornSystemNamespaceName, pvc.Spec.VolumeName)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	// wait for longhorn to restore the volume data from the backup target
+	if volume.Status.RestoreRequired || volume.Status.State == types.VolumeStateCreating {
+		return false, nil
+	}
+
+	// the volume is exported by its engine, make sure it is attached
+	if volume.Status.State != types.VolumeStateAttached {
+		if volume.Spec.NodeID == "" && volume.Status.State == types.VolumeStateDetached {
+			volCpy := volume.DeepCopy()
+			volCpy.Spec.NodeID = volume.Status.OwnerID
+			logrus.Infof("attach the volume %s to the node %s to export the backup %s/%s", volCpy.Name, volCpy.Spec.NodeID, vmBackup.Namespace, vmBackup.Name)
+			if _, err := h.volumes.Update(volCpy); err != nil {
+				return false, err
+			}
+		}
+		return false, nil
+	}
+
+	_, err = h.backingImages.Create(&lhv1beta1.BackingImage{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetExportBackingImageName(vmBackup, volumeBackup.VolumeName),
+			Namespace: util.LonghornSystemNamespaceName,
+			Annotations: map[string]string{
+				exportFromAnnotation: ref.Construct(vmBackup.Namespace, vmBackup.Name),
+			},
+		},
+		Spec: types.BackingImageSpec{
+			SourceType: types.BackingImageDataSourceTypeExportFromVolume,
+			SourceParameters: map[string]string{
+				types.DataSourceTypeExportFromVolumeParameterVolumeName: volume.Name,
+				types.DataSourceTypeExportFromVolumeParameterExportType: ExportDiskType,
+			},
+		},
+	})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return false, err
+	}
+	return false, nil
+}
+
+// getExportPVC returns the temporary PVC which restores the volume backup to be exported
+func getExportPVC(vmBackup *harvesterv1.VirtualMachineBackup, volumeBackup harvesterv1.VolumeBackup, pvcName string) *corev1.PersistentVolumeClaim {
+	spec := volumeBackup.PersistentVolumeClaim.Spec.DeepCopy()
+	spec.VolumeName = ""
+	spec.DataSource = &corev1.TypedLocalObjectReference{
+		APIGroup: pointer.StringPtr(snapshotv1.SchemeGroupVersion.Group),
+		Kind:     volumeSnapshotKindName,
+		Name:     *volumeBackup.Name,
+	}
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pvcName,
+			Namespace: vmBackup.Namespace,
+			Annotations: map[string]string{
+				exportFromAnnotation: ref.Construct(vmBackup.Namespace, vmBackup.Name),
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         vmBackupKind.GroupVersion().String(),
+					Kind:               vmBackupKind.Kind,
+					Name:               vmBackup.Name,
+					UID:                vmBackup.UID,
+					BlockOwnerDeletion: pointer.BoolPtr(true),
+				},
+			},
+		},
+		Spec: *spec,
+	}
+}
+
+// deleteExport deletes the backing images of the exported vm backup
+func (h *Handler) deleteExport(vmBackup *harvesterv1.VirtualMachineBackup) error {
+	for _, volumeBackup := range vmBackup.Status.VolumeBackups {
+		name := GetExportBackingImageName(vmBackup, volumeBackup.VolumeName)
+		if err := h.backingImages.Delete(util.LonghornSystemNamespaceName, name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package backup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/fake"
+	ctllonghornv1 "github.com/harvester/harvester/pkg/generated/controllers/longhorn.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+type fakeBackingImageClient struct {
+	ctllonghornv1.BackingImageClient
+	deleted []string
+}
+
+func (c *fakeBackingImageClient) Delete(namespace, name string, options *metav1.DeleteOptions) error {
+	c.deleted = append(c.deleted, name)
+	return nil
+}
+
+func TestReconcileExportExpiry(t *testing.T) {
+	now := time.Date(2021, 7, 31, 12, 0, 0, 0, time.UTC)
+	originalCurrentTime := currentTime
+	t.Cleanup(func() { currentTime = originalCurrentTime })
+	currentTime = func() *metav1.Time {
+		return &metav1.Time{Time: now}
+	}
+
+	newExportedBackup := func(expireAt string) *harvesterv1.VirtualMachineBackup {
+		vmBackup := newTestReadyBackup("backup", "vm", now.Add(-time.Hour))
+		vmBackup.UID = "backup-uid"
+		vmBackup.Annotations = map[string]string{BackupExportAnnotation: "true"}
+		if expireAt != "" {
+			vmBackup.Annotations[exportExpireAnnotation] = expireAt
+		}
+		vmBackup.Status.VolumeBackups = []harvesterv1.VolumeBackup{newTestVolumeBackup("disk")}
+		return vmBackup
+	}
+
+	var testCases = []struct {
+		name                string
+		vmBackup            *harvesterv1.VirtualMachineBackup
+		expectedAnnotations map[string]string
+		expectedDeleted     []string
+	}{
+		{
+			name:     "set the expiry of the prepared export",
+			vmBackup: newExportedBackup(""),
+			expectedAnnotations: map[string]string{
+				BackupExportAnnotation: "true",
+				exportExpireAnnotation: now.Add(exportTTL).Format(time.RFC3339),
+			},
+		},
+		{
+			name:     "keep the export before the expiry",
+			vmBackup: newExportedBackup(now.Add(time.Hour).Format(time.RFC3339)),
+			expectedAnnotations: map[string]string{
+				BackupExportAnnotation: "true",
+				exportExpireAnnotation: now.Add(time.Hour).Format(time.RFC3339),
+			},
+		},
+		{
+			name:                "remove the expired export",
+			vmBackup:            newExportedBackup(now.Add(-time.Hour).Format(time.RFC3339)),
+			expectedAnnotations: map[string]string{},
+			expectedDeleted:     []string{"vmbackup-backup-uid-disk"},
+		},
+	}
+
+	for _, tc := range testCases {
+		var clientset = fake.NewSimpleClientset(tc.vmBackup)
+		var backingImages = &fakeBackingImageClient{}
+		var handler = &Handler{
+			vmBackups:          fakeclients.VirtualMachineBackupClient(clientset.HarvesterhciV1beta1().VirtualMachineBackups),
+			vmBackupController: &fakeBackupController{},
+			backingImages:      backingImages,
+		}
+
+		err := handler.reconcileExportExpiry(tc.vmBackup)
+		assert.Nil(t, err, "case %q", tc.name)
+
+		vmBackup, err := clientset.HarvesterhciV1beta1().VirtualMachineBackups(testNamespace).Get(context.TODO(), tc.vmBackup.Name, metav1.GetOptions{})
+		assert.Nil(t, err, "case %q", tc.name)
+		assert.Equal(t, tc.expectedAnnotations, vmBackup.Annotations, "case %q", tc.name)
+		assert.Equal(t, tc.expectedDeleted, backingImages.deleted, "case %q", tc.name)
+	}
+}
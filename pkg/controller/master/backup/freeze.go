@@ -0,0 +1,211 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/rest"
+	kv1 "kubevirt.io/client-go/api/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+)
+
+const (
+	vmiResource         = "virtualmachineinstances"
+	freezeSubresource   = "freeze"
+	unfreezeSubresource = "unfreeze"
+
+	defaultFreezeTimeout = 5 * time.Minute
+
+	// reasons of the ApplicationConsistent condition
+	guestFrozenReason       = "Frozen"
+	guestThawedReason       = "Thawed"
+	vmNotRunningReason      = "VMNotRunning"
+	agentNotConnectedReason = "AgentNotConnected"
+	freezeFailedReason      = "FreezeFailed"
+	freezeTimeoutReason     = "FreezeTimeout"
+
+	guestFrozenEvent = "GuestFilesystemsFrozen"
+	guestThawedEvent = "GuestFilesystemsThawed"
+	guestFreezeEvent = "GuestFilesystemsFreezeFailed"
+)
+
+// guestFreezer freezes and thaws the guest filesystems of a running VM through the guest agent
+type guestFreezer interface {
+	Freeze(namespace, name string) error
+	Unfreeze(namespace, name string) error
+}
+
+// virtGuestFreezer calls the freeze and unfreeze subresources of the KubeVirt VMI
+type virtGuestFreezer struct {
+	ctx    context.Context
+	client rest.Interface
+}
+
+func (f *virtGuestFreezer) Freeze(namespace, name string) error {
+	return f.client.Put().Namespace(namespace).Resource(vmiResource).Name(name).SubResource(freezeSubresource).Do(f.ctx).Error()
+}
+
+func (f *virtGuestFreezer) Unfreeze(namespace, name string) error {
+	return f.client.Put().Namespace(namespace).Resource(vmiResource).Name(name).SubResource(unfreezeSubresource).Do(f.ctx).Error()
+}
+
+func getApplicationConsistentCondition(vmBackup *harvesterv1.VirtualMachineBackup) *harvesterv1.Condition {
+	if vmBackup.Status == nil {
+		return nil
+	}
+	for i, c := range vmBackup.Status.Conditions {
+		if c.Type == harvesterv1.BackupConditionApplicationConsistent {
+			return &vmBackup.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func isGuestFrozen(vmBackup *harvesterv1.VirtualMachineBackup) bool {
+	c := getApplicationConsistentCondition(vmBackup)
+	return c != nil && c.Reason == guestFrozenReason
+}
+
+func getFreezeTimeout(vmBackup *harvesterv1.VirtualMachineBackup) time.Duration {
+	if spec := vmBackup.Spec.ApplicationConsistent; spec != nil && spec.FreezeTimeout != nil && spec.FreezeTimeout.Duration > 0 {
+		return spec.FreezeTimeout.Duration
+	}
+	return defaultFreezeTimeout
+}
+
+// areVolumeSnapshotsTaken checks whether the point-in-time snapshots of all volumes are taken
+func areVolumeSnapshotsTaken(vmBackup *harvesterv1.VirtualMachineBackup) bool {
+	for _, vb := range vmBackup.Status.VolumeBackups {
+		if vb.CreationTime == nil && (vb.ReadyToUse == nil || !*vb.ReadyToUse) {
+			return false
+		}
+	}
+	return true
+}
+
+func newApplicationConsistentCondition(status corev1.ConditionStatus, reason, message string) harvesterv1.Condition {
+	return harvesterv1.Condition{
+		Type:               harvesterv1.BackupConditionApplicationConsistent,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: currentTime().Format(time.RFC3339),
+	}
+}
+
+// reconcileGuestFreeze freezes the guest filesystems before the volume snapshots are created and thaws them
+// once all snapshots are taken or the freeze timeout fires, it returns true if the volume snapshots can be reconciled.
+func (h *Handler) reconcileGuestFreeze(vmBackup *harvesterv1.VirtualMachineBackup, vm *kv1.VirtualMachine) (bool, error) {
+	c := getApplicationConsistentCondition(vmBackup)
+	if c == nil {
+		return false, h.freezeGuest(vmBackup, vm)
+	}
+	if c.Reason != guestFrozenReason {
+		return true, nil
+	}
+
+	if areVolumeSnapshotsTaken(vmBackup) {
+		return false, h.thawGuest(vmBackup, newApplicationConsistentCondition(corev1.ConditionTrue, guestThawedReason,
+			"The guest filesystems were frozen while taking the volume snapshots"))
+	}
+
+	frozenAt, err := time.Parse(time.RFC3339, c.LastTransitionTime)
+	if err != nil {
+		return false, err
+	}
+	if remaining := frozenAt.Add(getFreezeTimeout(vmBackup)).Sub(currentTime().Time); remaining > 0 {
+		h.vmBackupController.EnqueueAfter(vmBackup.Namespace, vmBackup.Name, remaining)
+		return true, nil
+	}
+
+	message := fmt.Sprintf("The volume snapshots were not taken within the freeze timeout %s", getFreezeTimeout(vmBackup))
+	if !vmBackup.Spec.ApplicationConsistent.FallbackToCrashConsistent {
+		return false, h.thawGuest(vmBackup, newApplicationConsistentCondition(corev1.ConditionFalse, freezeTimeoutReason, message))
+	}
+	return false, h.thawGuest(vmBackup, newApplicationConsistentCondition(corev1.ConditionFalse, freezeTimeoutReason,
+		message+", fall back to a crash-consistent backup"))
+}
+
+func (h *Handler) freezeGuest(vmBackup *harvesterv1.VirtualMachineBackup, vm *kv1.VirtualMachine) error {
+	vmBackupCpy := vmBackup.DeepCopy()
+
+	vmi, err := h.vmiCache.Get(vm.Namespace, vm.Name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if vmi == nil || apierrors.IsNotFound(err) || vmi.Status.Phase != kv1.Running {
+		// nothing writes to the volumes of a stopped VM
+		updateBackupCondition(vmBackupCpy, newApplicationConsistentCondition(corev1.ConditionTrue, vmNotRunningReason,
+			"The VM is not running"))
+		_, err := h.vmBackups.Update(vmBackupCpy)
+		return err
+	}
+
+	if !isGuestAgentConnected(vmi) {
+		return h.onGuestFreezeFailure(vmBackupCpy, agentNotConnectedReason, "The guest agent is not connected")
+	}
+
+	if err := h.guestFreezer.Freeze(vmi.Namespace, vmi.Name); err != nil {
+		logrus.Errorf("failed to freeze the guest filesystems of VM %s/%s: %s", vmi.Namespace, vmi.Name, err.Error())
+		return h.onGuestFreezeFailure(vmBackupCpy, freezeFailedReason, fmt.Sprintf("Failed to freeze the guest filesystems: %s", err.Error()))
+	}
+	h.recorder.Eventf(vmBackup, corev1.EventTypeNormal, guestFrozenEvent, "Froze the guest filesystems of VM %s", vmi.Name)
+
+	updateBackupCondition(vmBackupCpy, newApplicationConsistentCondition(corev1.ConditionUnknown, guestFrozenReason,
+		"The guest filesystems are frozen"))
+	if _, err := h.vmBackups.Update(vmBackupCpy); err != nil {
+		// do not keep the guest frozen if the state is not recorded
+		if unfreezeErr := h.guestFreezer.Unfreeze(vmi.Namespace, vmi.Name); unfreezeErr != nil {
+			logrus.Errorf("failed to thaw the guest filesystems of VM %s/%s: %s", vmi.Namespace, vmi.Name, unfreezeErr.Error())
+		}
+		return err
+	}
+	h.vmBackupController.EnqueueAfter(vmBackup.Namespace, vmBackup.Name, getFreezeTimeout(vmBackup))
+	return nil
+}
+
+// onGuestFreezeFailure falls back to a crash-consistent backup if the policy allows it, otherwise fails the backup
+func (h *Handler) onGuestFreezeFailure(vmBackupCpy *harvesterv1.VirtualMachineBackup, reason, message string) error {
+	h.recorder.Event(vmBackupCpy, corev1.EventTypeWarning, guestFreezeEvent, message)
+	if vmBackupCpy.Spec.ApplicationConsistent.FallbackToCrashConsistent {
+		updateBackupCondition(vmBackupCpy, newApplicationConsistentCondition(corev1.ConditionFalse, reason,
+			message+", fall back to a crash-consistent backup"))
+		_, err := h.vmBackups.Update(vmBackupCpy)
+		return err
+	}
+
+	updateBackupCondition(vmBackupCpy, newApplicationConsistentCondition(corev1.ConditionFalse, reason, message))
+	return h.setBackupError(vmBackupCpy, fmt.Errorf("failed to take an application-consistent backup: %s", message))
+}
+
+// thawGuest thaws the guest filesystems and records the outcome in the ApplicationConsistent condition,
+// the backup fails if the condition is false and the fallback is not allowed.
+func (h *Handler) thawGuest(vmBackup *harvesterv1.VirtualMachineBackup, c harvesterv1.Condition) error {
+	sourceName := vmBackup.Spec.Source.Name
+	if err := h.guestFreezer.Unfreeze(vmBackup.Namespace, sourceName); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to thaw the guest filesystems of VM %s/%s: %w", vmBackup.Namespace, sourceName, err)
+	}
+	h.recorder.Eventf(vmBackup, corev1.EventTypeNormal, guestThawedEvent, "Thawed the guest filesystems of VM %s", sourceName)
+
+	vmBackupCpy := vmBackup.DeepCopy()
+	vmBackupCpy.Status.Conditions = updateCondition(vmBackupCpy.Status.Conditions, c, true)
+	if c.Status == corev1.ConditionFalse && !vmBackup.Spec.ApplicationConsistent.FallbackToCrashConsistent && isBackupProgressing(vmBackup) {
+		return h.setBackupError(vmBackupCpy, fmt.Errorf("failed to take an application-consistent backup: %s", c.Message))
+	}
+	_, err := h.vmBackups.Update(vmBackupCpy)
+	return err
+}
+
+func isGuestAgentConnected(vmi *kv1.VirtualMachineInstance) bool {
+	for _, c := range vmi.Status.Conditions {
+		if c.Type == kv1.VirtualMachineInstanceAgentConnected {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,226 @@
+package backup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	kv1 "kubevirt.io/client-go/api/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/fake"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+type fakeGuestFreezer struct {
+	frozen   int
+	unfrozen int
+}
+
+func (f *fakeGuestFreezer) Freeze(namespace, name string) error {
+	f.frozen++
+	return nil
+}
+
+func (f *fakeGuestFreezer) Unfreeze(namespace, name string) error {
+	f.unfrozen++
+	return nil
+}
+
+func TestHandler_reconcileGuestFreeze(t *testing.T) {
+	originalCurrentTime := currentTime
+	t.Cleanup(func() { currentTime = originalCurrentTime })
+
+	now := time.Date(2021, 7, 1, 2, 0, 0, 0, time.UTC)
+	newTestConsistentBackup := func(fallback bool, conditions ...harvesterv1.Condition) *harvesterv1.VirtualMachineBackup {
+		backup := newTestInProgressBackup("vm")
+		backup.Spec.ApplicationConsistent = &harvesterv1.ApplicationConsistentSpec{
+			FreezeTimeout:             &metav1.Duration{Duration: time.Minute},
+			FallbackToCrashConsistent: fallback,
+		}
+		backup.Status.VolumeBackups = []harvesterv1.VolumeBackup{{VolumeName: "disk-0"}}
+		backup.Status.Conditions = conditions
+		return backup
+	}
+	newTestVMI := func(agentConnected bool) *kv1.VirtualMachineInstance {
+		vmi := &kv1.VirtualMachineInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testNamespace,
+				Name:      "vm",
+			},
+			Status: kv1.VirtualMachineInstanceStatus{
+				Phase: kv1.Running,
+			},
+		}
+		if agentConnected {
+			vmi.Status.Conditions = []kv1.VirtualMachineInstanceCondition{
+				{Type: kv1.VirtualMachineInstanceAgentConnected, Status: corev1.ConditionTrue},
+			}
+		}
+		return vmi
+	}
+	frozenSince := func(d time.Duration) harvesterv1.Condition {
+		return harvesterv1.Condition{
+			Type:               harvesterv1.BackupConditionApplicationConsistent,
+			Status:             corev1.ConditionUnknown,
+			Reason:             guestFrozenReason,
+			LastTransitionTime: now.Add(-d).Format(time.RFC3339),
+		}
+	}
+
+	type input struct {
+		backup  *harvesterv1.VirtualMachineBackup
+		objects []runtime.Object
+	}
+	type output struct {
+		proceed  bool
+		frozen   int
+		unfrozen int
+		status   corev1.ConditionStatus
+		reason   string
+		failed   bool
+	}
+	var testCases = []struct {
+		name     string
+		given    input
+		expected output
+	}{
+		{
+			name: "VM is not running",
+			given: input{
+				backup: newTestConsistentBackup(false),
+			},
+			expected: output{
+				status: corev1.ConditionTrue,
+				reason: vmNotRunningReason,
+			},
+		},
+		{
+			name: "fall back to crash-consistent if the guest agent is not connected",
+			given: input{
+				backup:  newTestConsistentBackup(true),
+				objects: []runtime.Object{newTestVMI(false)},
+			},
+			expected: output{
+				status: corev1.ConditionFalse,
+				reason: agentNotConnectedReason,
+			},
+		},
+		{
+			name: "fail the backup if the guest agent is not connected",
+			given: input{
+				backup:  newTestConsistentBackup(false),
+				objects: []runtime.Object{newTestVMI(false)},
+			},
+			expected: output{
+				status: corev1.ConditionFalse,
+				reason: agentNotConnectedReason,
+				failed: true,
+			},
+		},
+		{
+			name: "freeze the guest filesystems",
+			given: input{
+				backup:  newTestConsistentBackup(false),
+				objects: []runtime.Object{newTestVMI(true)},
+			},
+			expected: output{
+				frozen: 1,
+				status: corev1.ConditionUnknown,
+				reason: guestFrozenReason,
+			},
+		},
+		{
+			name: "create the volume snapshots while frozen",
+			given: input{
+				backup:  newTestConsistentBackup(false, frozenSince(30*time.Second)),
+				objects: []runtime.Object{newTestVMI(true)},
+			},
+			expected: output{
+				proceed: true,
+				status:  corev1.ConditionUnknown,
+				reason:  guestFrozenReason,
+			},
+		},
+		{
+			name: "thaw the guest filesystems once the volume snapshots are taken",
+			given: input{
+				backup: func() *harvesterv1.VirtualMachineBackup {
+					backup := newTestConsistentBackup(false, frozenSince(30*time.Second))
+					backup.Status.VolumeBackups[0].CreationTime = &metav1.Time{Time: now}
+					return backup
+				}(),
+				objects: []runtime.Object{newTestVMI(true)},
+			},
+			expected: output{
+				unfrozen: 1,
+				status:   corev1.ConditionTrue,
+				reason:   guestThawedReason,
+			},
+		},
+		{
+			name: "thaw the guest filesystems and fail the backup after the freeze timeout",
+			given: input{
+				backup:  newTestConsistentBackup(false, frozenSince(2*time.Minute)),
+				objects: []runtime.Object{newTestVMI(true)},
+			},
+			expected: output{
+				unfrozen: 1,
+				status:   corev1.ConditionFalse,
+				reason:   freezeTimeoutReason,
+				failed:   true,
+			},
+		},
+		{
+			name: "thaw the guest filesystems and fall back to crash-consistent after the freeze timeout",
+			given: input{
+				backup:  newTestConsistentBackup(true, frozenSince(2*time.Minute)),
+				objects: []runtime.Object{newTestVMI(true)},
+			},
+			expected: output{
+				unfrozen: 1,
+				status:   corev1.ConditionFalse,
+				reason:   freezeTimeoutReason,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		currentTime = func() *metav1.Time {
+			t := metav1.NewTime(now)
+			return &t
+		}
+
+		var clientset = fake.NewSimpleClientset(append(tc.given.objects, tc.given.backup)...)
+		var freezer = &fakeGuestFreezer{}
+		var handler = &Handler{
+			vmBackups:          fakeclients.VirtualMachineBackupClient(clientset.HarvesterhciV1beta1().VirtualMachineBackups),
+			vmBackupCache:      fakeclients.VirtualMachineBackupCache(clientset.HarvesterhciV1beta1().VirtualMachineBackups),
+			vmBackupController: &fakeBackupController{},
+			vmiCache:           fakeclients.VirtualMachineInstanceCache(clientset.KubevirtV1().VirtualMachineInstances),
+			guestFreezer:       freezer,
+			recorder:           record.NewFakeRecorder(10),
+		}
+
+		proceed, err := handler.reconcileGuestFreeze(tc.given.backup, newTestVM("vm", nil))
+		assert.Nil(t, err, "case %q", tc.name)
+
+		backup, err := handler.vmBackupCache.Get(testNamespace, tc.given.backup.Name)
+		assert.Nil(t, err, "case %q", tc.name)
+		var actual = output{
+			proceed:  proceed,
+			frozen:   freezer.frozen,
+			unfrozen: freezer.unfrozen,
+			failed:   isBackupError(backup),
+		}
+		if c := getApplicationConsistentCondition(backup); c != nil {
+			actual.status = c.Status
+			actual.reason = c.Reason
+		}
+		assert.Equal(t, tc.expected, actual, "case %q", tc.name)
+	}
+}
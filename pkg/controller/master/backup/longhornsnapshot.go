@@ -0,0 +1,118 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	longhornSnapshotTimeout = time.Minute
+)
+
+// volumeSnapshotter manages the snapshots of the longhorn volumes. The snapshots are kept in the volume replicas, so
+// they are taken and reverted in place without a backup target. The volumes must be attached, and reverting a
+// volume requires it to be attached with the frontend disabled.
+type volumeSnapshotter interface {
+	// Get returns the snapshot of the volume, or nil if it is not found or removed
+	Get(volumeName, snapshotName string) (*longhornSnapshot, error)
+	Create(volumeName, snapshotName string) error
+	Delete(volumeName, snapshotName string) error
+	Revert(volumeName, snapshotName string) error
+}
+
+// longhornSnapshot is the snapshot of a longhorn volume in the responses of the longhorn manager API
+type longhornSnapshot struct {
+	Name    string `json:"name"`
+	Created string `json:"created"`
+	Removed bool   `json:"removed"`
+}
+
+// longhornVolumeSnapshotter calls the snapshot actions of the volumes in the longhorn manager API,
+// the CSI VolumeSnapshots of longhorn v1.2 are always backups stored in the backup target.
+type longhornVolumeSnapshotter struct {
+	ctx        context.Context
+	httpClient *http.Client
+	endpoint   string
+}
+
+func newLonghornVolumeSnapshotter(ctx context.Context) *longhornVolumeSnapshotter {
+	return &longhornVolumeSnapshotter{
+		ctx:        ctx,
+		httpClient: &http.Client{Timeout: longhornSnapshotTimeout},
+		endpoint:   util.LonghornManagerEndpoint,
+	}
+}
+
+func (s *longhornVolumeSnapshotter) Get(volumeName, snapshotName string) (*longhornSnapshot, error) {
+	var snapshots struct {
+		Data []longhornSnapshot `json:"data"`
+	}
+	if err := s.doAction(volumeName, "snapshotList", nil, &snapshots); err != nil {
+		return nil, err
+	}
+	for i, snapshot := range snapshots.Data {
+		if snapshot.Name == snapshotName && !snapshot.Removed {
+			return &snapshots.Data[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *longhornVolumeSnapshotter) Create(volumeName, snapshotName string) error {
+	return s.doAction(volumeName, "snapshotCreate", map[string]string{"name": snapshotName}, nil)
+}
+
+// Delete marks the snapshot as removed and purges the removed snapshots to release their space
+func (s *longhornVolumeSnapshotter) Delete(volumeName, snapshotName string) error {
+	if err := s.doAction(volumeName, "snapshotDelete", map[string]string{"name": snapshotName}, nil); err != nil {
+		return err
+	}
+	return s.doAction(volumeName, "snapshotPurge", nil, nil)
+}
+
+func (s *longhornVolumeSnapshotter) Revert(volumeName, snapshotName string) error {
+	return s.doAction(volumeName, "snapshotRevert", map[string]string{"name": snapshotName}, nil)
+}
+
+func (s *longhornVolumeSnapshotter) doAction(volumeName, action string, input interface{}, output interface{}) error {
+	body := []byte("{}")
+	if input != nil {
+		var err error
+		if body, err = json.Marshal(input); err != nil {
+			return err
+		}
+	}
+
+	actionURL := fmt.Sprintf("%s/v1/volumes/%s?%s", s.endpoint, url.PathEscape(volumeName), url.Values{"action": []string{action}}.Encode())
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, actionURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to %s of volume %s: %w", action, volumeName, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("failed to %s of volume %s: %s", action, volumeName, string(respBody))
+	}
+	if output == nil {
+		return nil
+	}
+	return json.Unmarshal(respBody, output)
+}
@@ -0,0 +1,59 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLonghornVolumeSnapshotter(t *testing.T) {
+	var actions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		action := r.URL.Query().Get("action")
+		actions = append(actions, r.URL.Path+" "+action+" "+string(body))
+		switch action {
+		case "snapshotList":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": []longhornSnapshot{
+					{Name: "volume-head"},
+					{Name: "snap-1", Removed: true},
+					{Name: "snap-2"},
+				},
+			})
+		case "snapshotRevert":
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("volume is not in maintenance mode"))
+		}
+	}))
+	defer server.Close()
+
+	s := newLonghornVolumeSnapshotter(context.TODO())
+	s.endpoint = server.URL
+
+	snapshot, err := s.Get("pvc-1", "snap-2")
+	assert.Nil(t, err)
+	assert.Equal(t, &longhornSnapshot{Name: "snap-2"}, snapshot)
+
+	snapshot, err = s.Get("pvc-1", "snap-1")
+	assert.Nil(t, err, "removed snapshot is not found")
+	assert.Nil(t, snapshot, "removed snapshot is not found")
+
+	assert.Nil(t, s.Create("pvc-1", "snap-3"))
+	assert.Nil(t, s.Delete("pvc-1", "snap-2"))
+	assert.EqualError(t, s.Revert("pvc-1", "snap-2"), "failed to snapshotRevert of volume pvc-1: volume is not in maintenance mode")
+
+	assert.Equal(t, []string{
+		"/v1/volumes/pvc-1 snapshotList {}",
+		"/v1/volumes/pvc-1 snapshotList {}",
+		`/v1/volumes/pvc-1 snapshotCreate {"name":"snap-3"}`,
+		`/v1/volumes/pvc-1 snapshotDelete {"name":"snap-2"}`,
+		"/v1/volumes/pvc-1 snapshotPurge {}",
+		`/v1/volumes/pvc-1 snapshotRevert {"name":"snap-2"}`,
+	}, actions)
+}
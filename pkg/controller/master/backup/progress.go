@@ -0,0 +1,320 @@
+package backup
+
+import (
+	"time"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/v2/pkg/apis/volumesnapshot/v1beta1"
+	lhv1beta1 "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
+	"github.com/longhorn/longhorn-manager/types"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	ctllonghornv1 "github.com/harvester/harvester/pkg/generated/controllers/longhorn.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+// The progress of the volume backups and restores is reported by the longhorn engine of each volume,
+// the engine status keeps the progress of the backups in the backupStatus map keyed by the longhorn backup name,
+// and the progress of the restore in the restoreStatus map keyed by the replica address.
+
+const progressComplete = 100
+
+// getVolumeEngine returns the engine of the longhorn volume, or nil if it doesn't exist
+func getVolumeEngine(engineCache ctllonghornv1.EngineCache, volumeName string) (*lhv1beta1.Engine, error) {
+	engines, err := engineCache.List(util.LonghornSystemNamespaceName, labels.SelectorFromSet(types.GetVolumeLabels(volumeName)))
+	if err != nil || len(engines) == 0 {
+		return nil, err
+	}
+	return engines[0], nil
+}
+
+// getEngineBackupProgress returns the progress of the longhorn backup of the volume snapshot in the engine status,
+// the backup is looked up by the longhorn backup name or by the longhorn snapshot named after the volume snapshot UID.
+func getEngineBackupProgress(engine *lhv1beta1.Engine, longhornBackupName *string, snapshotName string) (int, bool) {
+	if engine == nil {
+		return 0, false
+	}
+	if longhornBackupName != nil {
+		if status, ok := engine.Status.BackupStatus[*longhornBackupName]; ok && status != nil {
+			return status.Progress, true
+		}
+	}
+	for _, status := range engine.Status.BackupStatus {
+		if status != nil && status.SnapshotName == snapshotName {
+			return status.Progress, true
+		}
+	}
+	return 0, false
+}
+
+// getEngineRestoreProgress returns the average restore progress of the engine replicas
+func getEngineRestoreProgress(engine *lhv1beta1.Engine) int {
+	if engine == nil || len(engine.Status.RestoreStatus) == 0 {
+		return 0
+	}
+	var sum, count int
+	for _, status := range engine.Status.RestoreStatus {
+		if status == nil {
+			continue
+		}
+		sum += status.Progress
+		count++
+	}
+	if count == 0 {
+		return 0
+	}
+	return sum / count
+}
+
+// getVolumeRestoreProgress returns the restore progress of the longhorn volume created from a backup,
+// longhorn keeps the volume in the restore required state until all the backup data is restored.
+func getVolumeRestoreProgress(volume *lhv1beta1.Volume, engine *lhv1beta1.Engine) int {
+	if volume == nil || volume.Status.State == "" || volume.Status.State == types.VolumeStateCreating {
+		return 0
+	}
+	if !volume.Status.RestoreRequired {
+		return progressComplete
+	}
+	return getEngineRestoreProgress(engine)
+}
+
+// getPVCStorageSize returns the requested storage size of the PVC in bytes
+func getPVCStorageSize(spec corev1.PersistentVolumeClaimSpec) int64 {
+	if size, ok := spec.Resources.Requests[corev1.ResourceStorage]; ok {
+		return size.Value()
+	}
+	return 0
+}
+
+// getBytesDone estimates the transferred bytes from the progress, the engines only report the percentage
+func getBytesDone(bytesTotal int64, progress int) int64 {
+	return bytesTotal * int64(progress) / progressComplete
+}
+
+// getEstimatedEndTime estimates the end time of a transfer at the progress rate since its start time,
+// the end time is unknown before any progress is made.
+func getEstimatedEndTime(startTime *metav1.Time, progress int, now time.Time) *metav1.Time {
+	if startTime == nil || progress <= 0 || progress >= progressComplete {
+		return nil
+	}
+	elapsed := now.Sub(startTime.Time)
+	if elapsed <= 0 {
+		return nil
+	}
+	return &metav1.Time{Time: startTime.Add(elapsed * progressComplete / time.Duration(progress))}
+}
+
+// aggregateProgress returns the overall progress weighted by the size of the volumes,
+// it falls back to the average progress if the volume sizes are unknown.
+func aggregateProgress(progresses []int, bytesDone, bytesTotal int64) int {
+	if len(progresses) == 0 {
+		return 0
+	}
+	if bytesTotal > 0 {
+		return int(bytesDone * progressComplete / bytesTotal)
+	}
+	var sum int
+	for _, progress := range progresses {
+		sum += progress
+	}
+	return sum / len(progresses)
+}
+
+// updateVolumeBackupProgress updates the progress of the volume backup upon the CSI volume snapshot and
+// the longhorn engine of the source volume.
+func (h *Handler) updateVolumeBackupProgress(volumeBackup *harvesterv1.VolumeBackup, volumeSnapshot *snapshotv1.VolumeSnapshot) error {
+	if volumeBackup.StartTime == nil {
+		startTime := volumeSnapshot.CreationTimestamp
+		volumeBackup.StartTime = &startTime
+	}
+	volumeBackup.BytesTotal = getPVCStorageSize(volumeBackup.PersistentVolumeClaim.Spec)
+
+	progress := volumeBackup.Progress
+	if volumeBackup.ReadyToUse != nil && *volumeBackup.ReadyToUse {
+		progress = progressComplete
+	} else if volumeName := volumeBackup.PersistentVolumeClaim.Spec.VolumeName; volumeName != "" {
+		engine, err := getVolumeEngine(h.engineCache, volumeName)
+		if err != nil {
+			return err
+		}
+		if engineProgress, ok := getEngineBackupProgress(engine, volumeBackup.LonghornBackupName, "snapshot-"+string(volumeSnapshot.UID)); ok {
+			progress = engineProgress
+		}
+	}
+
+	volumeBackup.Progress = progress
+	volumeBackup.BytesDone = getBytesDone(volumeBackup.BytesTotal, progress)
+	volumeBackup.EstimatedEndTime = getEstimatedEndTime(volumeBackup.StartTime, progress, currentTime().Time)
+	if progress == progressComplete && volumeBackup.EndTime == nil {
+		volumeBackup.EndTime = currentTime()
+	}
+	return nil
+}
+
+func getBackupProgress(volumeBackups []harvesterv1.VolumeBackup) int {
+	var progresses = make([]int, 0, len(volumeBackups))
+	var bytesDone, bytesTotal int64
+	for _, vb := range volumeBackups {
+		progresses = append(progresses, vb.Progress)
+		bytesDone += vb.BytesDone
+		bytesTotal += vb.BytesTotal
+	}
+	return aggregateProgress(progresses, bytesDone, bytesTotal)
+}
+
+// updateEngineChanged enqueues the in-progress vm backups of the volume to refresh their progress
+func (h *Handler) updateEngineChanged(key string, engine *lhv1beta1.Engine) (*lhv1beta1.Engine, error) {
+	if engine == nil || engine.DeletionTimestamp != nil || len(engine.Status.BackupStatus) == 0 {
+		return nil, nil
+	}
+
+	volume, err := h.volumeCache.Get(util.LonghornSystemNamespaceName, engine.Spec.VolumeName)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	pvcNamespace, pvcName := volume.Status.KubernetesStatus.Namespace, volume.Status.KubernetesStatus.PVCName
+	if pvcName == "" {
+		return nil, nil
+	}
+
+	vmBackups, err := h.vmBackupCache.List(pvcNamespace, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, vmBackup := range vmBackups {
+		if vmBackup.Status == nil || !isBackupProgressing(vmBackup) {
+			continue
+		}
+		for _, vb := range vmBackup.Status.VolumeBackups {
+			if vb.PersistentVolumeClaim.ObjectMeta.Name == pvcName {
+				h.vmBackupController.Enqueue(vmBackup.Namespace, vmBackup.Name)
+				break
+			}
+		}
+	}
+	return nil, nil
+}
+
+// updateVolumeRestoreProgress updates the progress of the volume restores upon the longhorn volumes of the restored PVCs,
+// the volumes removed after the vmRestore is complete have nothing left to restore, so they are counted as done.
+func (h *RestoreHandler) updateVolumeRestoreProgress(vmRestore *harvesterv1.VirtualMachineRestore) error {
+	var progresses = make([]int, 0, len(vmRestore.Status.VolumeRestores))
+	var bytesDone, bytesTotal int64
+	for i := range vmRestore.Status.VolumeRestores {
+		vr := &vmRestore.Status.VolumeRestores[i]
+		if vr.Progress != progressComplete {
+			if err := h.updateVolumeRestore(vr, !vmRestoreProgressing(vmRestore)); err != nil {
+				return err
+			}
+		}
+		progresses = append(progresses, vr.Progress)
+		bytesDone += vr.BytesDone
+		bytesTotal += vr.BytesTotal
+	}
+	vmRestore.Status.Progress = aggregateProgress(progresses, bytesDone, bytesTotal)
+	return nil
+}
+
+func (h *RestoreHandler) updateVolumeRestore(volumeRestore *harvesterv1.VolumeRestore, restoreComplete bool) error {
+	pvc, err := h.pvcCache.Get(volumeRestore.PersistentVolumeClaim.ObjectMeta.Namespace, volumeRestore.PersistentVolumeClaim.ObjectMeta.Name)
+	if apierrors.IsNotFound(err) {
+		if restoreComplete {
+			setVolumeRestoreDone(volumeRestore)
+		}
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if volumeRestore.StartTime == nil {
+		startTime := pvc.CreationTimestamp
+		volumeRestore.StartTime = &startTime
+	}
+	volumeRestore.BytesTotal = getPVCStorageSize(pvc.Spec)
+	if pvc.Spec.VolumeName == "" {
+		return nil
+	}
+
+	volume, err := h.volumeCache.Get(util.LonghornSystemNamespaceName, pvc.Spec.VolumeName)
+	if apierrors.IsNotFound(err) {
+		if restoreComplete {
+			setVolumeRestoreDone(volumeRestore)
+		}
+		return nil
+	} else if err != nil {
+		return err
+	}
+	engine, err := getVolumeEngine(h.engineCache, volume.Name)
+	if err != nil {
+		return err
+	}
+
+	volumeRestore.Progress = getVolumeRestoreProgress(volume, engine)
+	volumeRestore.BytesDone = getBytesDone(volumeRestore.BytesTotal, volumeRestore.Progress)
+	volumeRestore.EstimatedEndTime = getEstimatedEndTime(volumeRestore.StartTime, volumeRestore.Progress, currentTime().Time)
+	if volumeRestore.Progress == progressComplete && volumeRestore.EndTime == nil {
+		volumeRestore.EndTime = currentTime()
+	}
+	return nil
+}
+
+func setVolumeRestoreDone(volumeRestore *harvesterv1.VolumeRestore) {
+	volumeRestore.Progress = progressComplete
+	volumeRestore.BytesDone = volumeRestore.BytesTotal
+	volumeRestore.EstimatedEndTime = nil
+	if volumeRestore.EndTime == nil {
+		volumeRestore.EndTime = currentTime()
+	}
+}
+
+// EngineOnChange enqueues the vmRestore of the restoring volume to refresh its progress
+func (h *RestoreHandler) EngineOnChange(key string, engine *lhv1beta1.Engine) (*lhv1beta1.Engine, error) {
+	if engine == nil || engine.DeletionTimestamp != nil || len(engine.Status.RestoreStatus) == 0 {
+		return nil, nil
+	}
+
+	volume, err := h.volumeCache.Get(util.LonghornSystemNamespaceName, engine.Spec.VolumeName)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	kubernetesStatus := volume.Status.KubernetesStatus
+	if kubernetesStatus.PVCName == "" {
+		return nil, nil
+	}
+	pvc, err := h.pvcCache.Get(kubernetesStatus.Namespace, kubernetesStatus.PVCName)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if restoreName, ok := pvc.Annotations[restoreNameAnnotation]; ok {
+		h.restoreController.Enqueue(getRestoreNamespace(pvc.ObjectMeta), restoreName)
+	}
+	return nil, nil
+}
+
+// reconcileRestoreProgress keeps updating the progress of the completed vmRestore until all the volume data is restored
+func (h *RestoreHandler) reconcileRestoreProgress(vmRestore *harvesterv1.VirtualMachineRestore) error {
+	if vmRestore.Status.Progress == progressComplete {
+		return nil
+	}
+
+	restoreCpy := vmRestore.DeepCopy()
+	if err := h.updateVolumeRestoreProgress(restoreCpy); err != nil {
+		return err
+	}
+	if restoreCpy.Status.Progress != progressComplete {
+		h.restoreController.EnqueueAfter(restoreCpy.Namespace, restoreCpy.Name, 5*time.Second)
+	}
+	return h.doUpdate(vmRestore, restoreCpy)
+}
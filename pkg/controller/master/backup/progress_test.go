@@ -0,0 +1,220 @@
+package backup
+
+import (
+	"testing"
+	"time"
+
+	lhv1beta1 "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
+	"github.com/longhorn/longhorn-manager/types"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/pointer"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+func TestGetBackupProgress(t *testing.T) {
+	var testCases = []struct {
+		name          string
+		volumeBackups []harvesterv1.VolumeBackup
+		expected      int
+	}{
+		{
+			name:     "no volumes",
+			expected: 0,
+		},
+		{
+			name: "weighted by the volume size",
+			volumeBackups: []harvesterv1.VolumeBackup{
+				{Progress: 100, BytesDone: 1 << 30, BytesTotal: 1 << 30},
+				{Progress: 0, BytesDone: 0, BytesTotal: 3 << 30},
+			},
+			expected: 25,
+		},
+		{
+			name: "average of unknown volume sizes",
+			volumeBackups: []harvesterv1.VolumeBackup{
+				{Progress: 100},
+				{Progress: 50},
+			},
+			expected: 75,
+		},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, getBackupProgress(tc.volumeBackups), "case %q", tc.name)
+	}
+}
+
+func TestGetEstimatedEndTime(t *testing.T) {
+	start := &metav1.Time{Time: time.Date(2021, 7, 1, 2, 0, 0, 0, time.UTC)}
+	now := start.Add(10 * time.Minute)
+
+	assert.Nil(t, getEstimatedEndTime(nil, 50, now), "the start time is unknown")
+	assert.Nil(t, getEstimatedEndTime(start, 0, now), "no progress is made")
+	assert.Nil(t, getEstimatedEndTime(start, progressComplete, now), "the transfer is complete")
+	assert.Equal(t, start.Add(20*time.Minute), getEstimatedEndTime(start, 50, now).Time)
+	assert.Equal(t, start.Add(40*time.Minute), getEstimatedEndTime(start, 25, now).Time)
+}
+
+func TestGetEngineBackupProgress(t *testing.T) {
+	engine := &lhv1beta1.Engine{
+		Status: types.EngineStatus{
+			BackupStatus: map[string]*types.BackupStatus{
+				"backup-1": {Progress: 30, SnapshotName: "snapshot-1"},
+				"backup-2": {Progress: 60, SnapshotName: "snapshot-2"},
+			},
+		},
+	}
+
+	var testCases = []struct {
+		name               string
+		engine             *lhv1beta1.Engine
+		longhornBackupName *string
+		snapshotName       string
+		expectedProgress   int
+		expectedFound      bool
+	}{
+		{
+			name:               "by longhorn backup name",
+			engine:             engine,
+			longhornBackupName: pointer.StringPtr("backup-1"),
+			snapshotName:       "snapshot-2",
+			expectedProgress:   30,
+			expectedFound:      true,
+		},
+		{
+			name:             "by snapshot name",
+			engine:           engine,
+			snapshotName:     "snapshot-2",
+			expectedProgress: 60,
+			expectedFound:    true,
+		},
+		{
+			name:         "backup not started",
+			engine:       engine,
+			snapshotName: "snapshot-3",
+		},
+		{
+			name:         "no engine",
+			snapshotName: "snapshot-1",
+		},
+	}
+
+	for _, tc := range testCases {
+		progress, found := getEngineBackupProgress(tc.engine, tc.longhornBackupName, tc.snapshotName)
+		assert.Equal(t, tc.expectedProgress, progress, "case %q", tc.name)
+		assert.Equal(t, tc.expectedFound, found, "case %q", tc.name)
+	}
+}
+
+func TestGetVolumeRestoreProgress(t *testing.T) {
+	engine := &lhv1beta1.Engine{
+		Status: types.EngineStatus{
+			RestoreStatus: map[string]*types.RestoreStatus{
+				"replica-1": {Progress: 40},
+				"replica-2": {Progress: 60},
+			},
+		},
+	}
+	newVolume := func(state types.VolumeState, restoreRequired bool) *lhv1beta1.Volume {
+		return &lhv1beta1.Volume{
+			Status: types.VolumeStatus{
+				State:           state,
+				RestoreRequired: restoreRequired,
+			},
+		}
+	}
+
+	var testCases = []struct {
+		name     string
+		volume   *lhv1beta1.Volume
+		engine   *lhv1beta1.Engine
+		expected int
+	}{
+		{
+			name:     "volume not created",
+			volume:   newVolume(types.VolumeStateCreating, false),
+			expected: 0,
+		},
+		{
+			name:     "restoring",
+			volume:   newVolume(types.VolumeStateAttached, true),
+			engine:   engine,
+			expected: 50,
+		},
+		{
+			name:     "restoring without engine",
+			volume:   newVolume(types.VolumeStateAttaching, true),
+			expected: 0,
+		},
+		{
+			name:     "restored",
+			volume:   newVolume(types.VolumeStateDetached, false),
+			engine:   engine,
+			expected: 100,
+		},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, getVolumeRestoreProgress(tc.volume, tc.engine), "case %q", tc.name)
+	}
+}
+
+func TestRestoreHandler_updateVolumeRestoreProgress(t *testing.T) {
+	originalCurrentTime := currentTime
+	t.Cleanup(func() { currentTime = originalCurrentTime })
+	endTime := &metav1.Time{Time: time.Date(2021, 7, 1, 2, 0, 0, 0, time.UTC)}
+	currentTime = func() *metav1.Time {
+		return endTime
+	}
+
+	newVMRestore := func(complete bool) *harvesterv1.VirtualMachineRestore {
+		return &harvesterv1.VirtualMachineRestore{
+			Status: &harvesterv1.VirtualMachineRestoreStatus{
+				Complete: pointer.BoolPtr(complete),
+				VolumeRestores: []harvesterv1.VolumeRestore{
+					{
+						PersistentVolumeClaim: harvesterv1.PersistentVolumeClaimSourceSpec{
+							ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: "removed"},
+						},
+						BytesTotal: 1024,
+					},
+				},
+			},
+		}
+	}
+
+	var testCases = []struct {
+		name            string
+		vmRestore       *harvesterv1.VirtualMachineRestore
+		expected        int
+		expectedEndTime *metav1.Time
+	}{
+		{
+			name:      "PVC is not created yet",
+			vmRestore: newVMRestore(false),
+			expected:  0,
+		},
+		{
+			name:            "PVC is removed after the restore is complete",
+			vmRestore:       newVMRestore(true),
+			expected:        progressComplete,
+			expectedEndTime: endTime,
+		},
+	}
+
+	for _, tc := range testCases {
+		clientset := corefake.NewSimpleClientset()
+		h := &RestoreHandler{
+			pvcCache: fakeclients.PersistentVolumeClaimCache(clientset.CoreV1().PersistentVolumeClaims),
+		}
+
+		err := h.updateVolumeRestoreProgress(tc.vmRestore)
+		assert.Nil(t, err, "case %q", tc.name)
+		assert.Equal(t, tc.expected, tc.vmRestore.Status.Progress, "case %q", tc.name)
+		assert.Equal(t, tc.expectedEndTime, tc.vmRestore.Status.VolumeRestores[0].EndTime, "case %q", tc.name)
+	}
+}
@@ -5,6 +5,7 @@ package backup
 // Currently, the following features are supported:
 // 1. support VM live & offline backup to the supported backupTarget(i.e, nfs_v4 or s3 storage server).
 // 2. restore a backup to a new VM or replacing it with the existing VM is supported.
+// 3. restore selected volumes of a backup into standalone PVCs without touching the VM.
 import (
 	"context"
 	"fmt"
@@ -28,7 +29,10 @@ import (
 	"github.com/harvester/harvester/pkg/config"
 	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
 	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
+	ctllonghornv1 "github.com/harvester/harvester/pkg/generated/controllers/longhorn.io/v1beta1"
+	ctlsnapshotv1 "github.com/harvester/harvester/pkg/generated/controllers/snapshot.storage.k8s.io/v1beta1"
 	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/settings"
 	"github.com/harvester/harvester/pkg/util"
 )
 
@@ -46,8 +50,9 @@ const (
 	volumeSnapshotKindName = "VolumeSnapshot"
 	vmRestoreKindName      = "VirtualMachineRestore"
 
-	restoreNameAnnotation = "restore.harvesterhci.io/name"
-	lastRestoreAnnotation = "restore.harvesterhci.io/last-restore-uid"
+	restoreNameAnnotation      = "restore.harvesterhci.io/name"
+	restoreNamespaceAnnotation = "restore.harvesterhci.io/namespace"
+	lastRestoreAnnotation      = "restore.harvesterhci.io/last-restore-uid"
 
 	vmCreatorLabel = "harvesterhci.io/creator"
 	vmNameLabel    = "harvesterhci.io/vm-name"
@@ -73,6 +78,13 @@ type RestoreHandler struct {
 	vmCache           ctlkubevirtv1.VirtualMachineCache
 	pvcClient         ctlcorev1.PersistentVolumeClaimClient
 	pvcCache          ctlcorev1.PersistentVolumeClaimCache
+	volumeCache       ctllonghornv1.VolumeCache
+	engineCache       ctllonghornv1.EngineCache
+
+	snapshots            ctlsnapshotv1.VolumeSnapshotClient
+	snapshotCache        ctlsnapshotv1.VolumeSnapshotCache
+	snapshotContents     ctlsnapshotv1.VolumeSnapshotContentClient
+	snapshotContentCache ctlsnapshotv1.VolumeSnapshotContentCache
 
 	recorder   record.EventRecorder
 	restClient *rest.RESTClient
@@ -83,22 +95,34 @@ func RegisterRestore(ctx context.Context, management *config.Management, opts co
 	backups := management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackup()
 	vms := management.VirtFactory.Kubevirt().V1().VirtualMachine()
 	pvcs := management.CoreFactory.Core().V1().PersistentVolumeClaim()
+	volumes := management.LonghornFactory.Longhorn().V1beta1().Volume()
+	engines := management.LonghornFactory.Longhorn().V1beta1().Engine()
+	snapshots := management.SnapshotFactory.Snapshot().V1beta1().VolumeSnapshot()
+	snapshotContents := management.SnapshotFactory.Snapshot().V1beta1().VolumeSnapshotContent()
 
 	handler := &RestoreHandler{
-		context:           ctx,
-		restores:          restores,
-		restoreController: restores,
-		backupCache:       backups.Cache(),
-		vms:               vms,
-		vmCache:           vms.Cache(),
-		pvcClient:         pvcs,
-		pvcCache:          pvcs.Cache(),
-		recorder:          management.NewRecorder(restoreControllerName, "", ""),
+		context:              ctx,
+		restores:             restores,
+		restoreController:    restores,
+		backupCache:          backups.Cache(),
+		vms:                  vms,
+		vmCache:              vms.Cache(),
+		pvcClient:            pvcs,
+		pvcCache:             pvcs.Cache(),
+		volumeCache:          volumes.Cache(),
+		engineCache:          engines.Cache(),
+		snapshots:            snapshots,
+		snapshotCache:        snapshots.Cache(),
+		snapshotContents:     snapshotContents,
+		snapshotContentCache: snapshotContents.Cache(),
+		recorder:             management.NewRecorder(restoreControllerName, "", ""),
 	}
 
 	restores.OnChange(ctx, restoreControllerName, handler.RestoreOnChanged)
+	restores.OnRemove(ctx, restoreControllerName, handler.RestoreOnRemove)
 	pvcs.OnChange(ctx, restoreControllerName, handler.PersistentVolumeClaimOnChange)
 	vms.OnChange(ctx, restoreControllerName, handler.VMOnChange)
+	engines.OnChange(ctx, restoreControllerName, handler.EngineOnChange)
 	return nil
 }
 
@@ -109,8 +133,12 @@ func (h *RestoreHandler) RestoreOnChanged(key string, restore *harvesterv1.Virtu
 		return nil, nil
 	}
 
+	// longhorn keeps restoring the volume data after the restored PVCs are bound
 	if !vmRestoreProgressing(restore) {
-		return nil, nil
+		if err := h.deleteCopiedVolumeSnapshots(restore, false); err != nil {
+			return nil, err
+		}
+		return nil, h.reconcileRestoreProgress(restore)
 	}
 
 	restoreCpy := restore.DeepCopy()
@@ -132,12 +160,19 @@ func (h *RestoreHandler) RestoreOnChanged(key string, restore *harvesterv1.Virtu
 		return nil, h.doUpdate(restore, restoreCpy)
 	}
 
-	backup, err := h.getVMBackup(restoreCpy, target.UID(), restoreCpy.Spec.NewVM)
+	// the detached PVCs are restored aside and the source VM is left alone
+	detached := isDetachedPVCsRestore(restoreCpy)
+
+	backup, err := h.getVMBackup(restoreCpy, target.UID(), restoreCpy.Spec.NewVM || detached)
 	if err != nil {
 		updateRestoreCondition(restoreCpy, newReadyCondition(corev1.ConditionFalse, err.Error()))
 		return nil, h.doUpdate(restore, restoreCpy)
 	}
 
+	if detached {
+		return nil, h.reconcileDetachedPVCs(restore, restoreCpy, backup)
+	}
+
 	// set vmRestore owner reference to the target VM
 	if len(restoreCpy.OwnerReferences) == 0 && !target.newVM {
 		restoreCpy.SetOwnerReferences(configVMOwner(target.vm))
@@ -150,7 +185,7 @@ func (h *RestoreHandler) RestoreOnChanged(key string, restore *harvesterv1.Virtu
 	var updated bool
 	restoreCpy, updated, err = h.reconcileVolumeRestores(restoreCpy, backup)
 	if err != nil {
-		return nil, h.doUpdateError(restore, restoreCpy, fmt.Errorf("error reconciling VolumeRestores"), true)
+		return nil, h.doUpdateError(restore, restoreCpy, fmt.Errorf("error reconciling VolumeRestores, err:%s", err.Error()), true)
 	}
 
 	// create target vm after restore PVC volumes
@@ -226,6 +261,14 @@ func (h *RestoreHandler) RestoreOnChanged(key string, restore *harvesterv1.Virtu
 	return nil, h.doUpdate(restore, restoreCpy)
 }
 
+// RestoreOnRemove deletes the VolumeSnapshots copied for the restore into another namespace
+func (h *RestoreHandler) RestoreOnRemove(key string, restore *harvesterv1.VirtualMachineRestore) (*harvesterv1.VirtualMachineRestore, error) {
+	if restore == nil {
+		return nil, nil
+	}
+	return restore, h.deleteCopiedVolumeSnapshots(restore, true)
+}
+
 // PersistentVolumeClaimOnChange watching the PVCs on change and enqueue the vmRestore if it has the restore annotation
 func (h *RestoreHandler) PersistentVolumeClaimOnChange(key string, pvc *corev1.PersistentVolumeClaim) (*corev1.PersistentVolumeClaim, error) {
 	if pvc == nil || pvc.DeletionTimestamp != nil {
@@ -238,7 +281,7 @@ func (h *RestoreHandler) PersistentVolumeClaimOnChange(key string, pvc *corev1.P
 	}
 
 	logrus.Debugf("handling PVC updating %s/%s", pvc.Namespace, pvc.Name)
-	h.restoreController.EnqueueAfter(pvc.Namespace, restoreName, 5*time.Second)
+	h.restoreController.EnqueueAfter(getRestoreNamespace(pvc.ObjectMeta), restoreName, 5*time.Second)
 	return nil, nil
 }
 
@@ -254,7 +297,7 @@ func (h *RestoreHandler) VMOnChange(key string, vm *kv1.VirtualMachine) (*kv1.Vi
 	}
 
 	logrus.Debugf("handling VM updating %s/%s", vm.Namespace, vm.Name)
-	h.restoreController.EnqueueAfter(vm.Namespace, restoreName, 5*time.Second)
+	h.restoreController.EnqueueAfter(getRestoreNamespace(vm.ObjectMeta), restoreName, 5*time.Second)
 	return nil, nil
 }
 
@@ -264,7 +307,7 @@ func (h *RestoreHandler) getTarget(vmRestore *harvesterv1.VirtualMachineRestore)
 	isNewVM := false
 	switch vmRestore.Spec.Target.Kind {
 	case kv1.VirtualMachineGroupVersionKind.Kind:
-		vm, err := h.vmCache.Get(vmRestore.Namespace, vmRestore.Spec.Target.Name)
+		vm, err := h.vmCache.Get(GetRestoreTargetNamespace(vmRestore), vmRestore.Spec.Target.Name)
 		if err != nil {
 			if !apierrors.IsNotFound(err) && !vmRestore.Spec.NewVM {
 				return nil, err
@@ -283,8 +326,43 @@ func (h *RestoreHandler) getTarget(vmRestore *harvesterv1.VirtualMachineRestore)
 	return nil, fmt.Errorf("unknown source %+v", vmRestore.Spec.Target)
 }
 
+// reconcileDetachedPVCs creates the selected volumes as standalone PVCs and completes the vmRestore
+// once all of them are bound, the VM spec and its existing PVCs are not changed.
+func (h *RestoreHandler) reconcileDetachedPVCs(original, vmRestore *harvesterv1.VirtualMachineRestore,
+	backup *harvesterv1.VirtualMachineBackup) error {
+	vmRestore, updated, err := h.reconcileVolumeRestores(vmRestore, backup)
+	if err != nil {
+		return h.doUpdateError(original, vmRestore, fmt.Errorf("error reconciling VolumeRestores, err:%s", err.Error()), true)
+	}
+
+	if updated {
+		updateRestoreCondition(vmRestore, newProgressingCondition(corev1.ConditionTrue, "Creating new PVCs"))
+		updateRestoreCondition(vmRestore, newReadyCondition(corev1.ConditionFalse, "Waiting for new PVCs"))
+		return h.doUpdate(original, vmRestore)
+	}
+
+	h.recorder.Eventf(
+		vmRestore,
+		corev1.EventTypeNormal,
+		restoreCompleteEvent,
+		"Successfully restored %d volumes of VirtualMachineRestore %s",
+		len(vmRestore.Status.VolumeRestores),
+		vmRestore.Name,
+	)
+
+	vmRestore.Status.RestoreTime = currentTime()
+	vmRestore.Status.Complete = pointer.BoolPtr(true)
+	updateRestoreCondition(vmRestore, newProgressingCondition(corev1.ConditionFalse, "Operation complete"))
+	updateRestoreCondition(vmRestore, newReadyCondition(corev1.ConditionTrue, "Operation complete"))
+	return h.doUpdate(original, vmRestore)
+}
+
 // getVolumeRestores helps to create an array of new restored volumes
 func getVolumeRestores(vmRestore *harvesterv1.VirtualMachineRestore, backup *harvesterv1.VirtualMachineBackup) ([]harvesterv1.VolumeRestore, error) {
+	if isDetachedPVCsRestore(vmRestore) {
+		return getDetachedVolumeRestores(vmRestore, backup)
+	}
+
 	restores := make([]harvesterv1.VolumeRestore, 0, len(backup.Status.VolumeBackups))
 	for _, vb := range backup.Status.VolumeBackups {
 		found := false
@@ -306,7 +384,7 @@ func getVolumeRestores(vmRestore *harvesterv1.VirtualMachineRestore, backup *har
 				PersistentVolumeClaim: harvesterv1.PersistentVolumeClaimSourceSpec{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      restorePVCName(vmRestore, vb.VolumeName),
-						Namespace: vmRestore.Namespace,
+						Namespace: GetRestoreTargetNamespace(vmRestore),
 					},
 					Spec: vb.PersistentVolumeClaim.Spec,
 				},
@@ -318,6 +396,56 @@ func getVolumeRestores(vmRestore *harvesterv1.VirtualMachineRestore, backup *har
 	return restores, nil
 }
 
+// getDetachedVolumeRestores helps to create an array of restored volumes selected by vmRestore.spec.volumes,
+// all volumes of the backup are selected if the list is empty.
+func getDetachedVolumeRestores(vmRestore *harvesterv1.VirtualMachineRestore, backup *harvesterv1.VirtualMachineBackup) ([]harvesterv1.VolumeRestore, error) {
+	sources := vmRestore.Spec.Volumes
+	if len(sources) == 0 {
+		for _, vb := range backup.Status.VolumeBackups {
+			if vb.Name == nil {
+				return nil, fmt.Errorf("VolumeSnapshotName missing %+v", vb)
+			}
+			sources = append(sources, harvesterv1.VolumeRestoreSource{VolumeBackupName: *vb.Name})
+		}
+	}
+
+	restores := make([]harvesterv1.VolumeRestore, 0, len(sources))
+	for _, source := range sources {
+		vb := getVolumeBackup(backup, source.VolumeBackupName)
+		if vb == nil {
+			return nil, fmt.Errorf("volume backup %s is not found in VMBackup %s", source.VolumeBackupName, backup.Name)
+		}
+
+		pvcName := source.PersistentVolumeClaimName
+		if pvcName == "" {
+			pvcName = restorePVCName(vmRestore, vb.VolumeName)
+		}
+
+		restores = append(restores, harvesterv1.VolumeRestore{
+			VolumeName: vb.VolumeName,
+			PersistentVolumeClaim: harvesterv1.PersistentVolumeClaimSourceSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      pvcName,
+					Namespace: GetRestoreTargetNamespace(vmRestore),
+				},
+				Spec: vb.PersistentVolumeClaim.Spec,
+			},
+			VolumeBackupName: source.VolumeBackupName,
+		})
+	}
+	return restores, nil
+}
+
+// getVolumeBackup returns the volume backup of the given name in the backup status, or nil if it is not found
+func getVolumeBackup(backup *harvesterv1.VirtualMachineBackup, volumeBackupName string) *harvesterv1.VolumeBackup {
+	for i, vb := range backup.Status.VolumeBackups {
+		if vb.Name != nil && *vb.Name == volumeBackupName {
+			return &backup.Status.VolumeBackups[i]
+		}
+	}
+	return nil
+}
+
 func (h *RestoreHandler) reconcileVolumeRestores(vmRestore *harvesterv1.VirtualMachineRestore,
 	backup *harvesterv1.VirtualMachineBackup) (*harvesterv1.VirtualMachineRestore, bool, error) {
 
@@ -331,11 +459,14 @@ func (h *RestoreHandler) reconcileVolumeRestores(vmRestore *harvesterv1.VirtualM
 
 	createdPVC := false
 	waitingPVC := false
-	for i, restore := range vmRestore.Status.VolumeRestores {
+	for _, restore := range vmRestore.Status.VolumeRestores {
 		pvc, err := h.pvcCache.Get(restore.PersistentVolumeClaim.ObjectMeta.Namespace, restore.PersistentVolumeClaim.ObjectMeta.Name)
 		if apierrors.IsNotFound(err) {
-			backup := backup.Status.VolumeBackups[i]
-			if err = h.createRestoredPVC(vmRestore, backup, restore); err != nil {
+			volumeBackup := getVolumeBackup(backup, restore.VolumeBackupName)
+			if volumeBackup == nil {
+				return vmRestore, false, fmt.Errorf("volume backup %s is not found in VMBackup %s", restore.VolumeBackupName, backup.Name)
+			}
+			if err = h.createRestoredPVC(vmRestore, *volumeBackup, restore); err != nil {
 				return vmRestore, false, err
 			}
 			createdPVC = true
@@ -344,6 +475,10 @@ func (h *RestoreHandler) reconcileVolumeRestores(vmRestore *harvesterv1.VirtualM
 		if err != nil {
 			return vmRestore, false, err
 		}
+		if !isPVCCreatedByRestore(pvc, vmRestore) {
+			return vmRestore, false, fmt.Errorf("PVC %s/%s already exists and is not created by VMRestore %s/%s",
+				pvc.Namespace, pvc.Name, vmRestore.Namespace, vmRestore.Name)
+		}
 
 		if pvc.Status.Phase == corev1.ClaimPending {
 			waitingPVC = true
@@ -352,7 +487,7 @@ func (h *RestoreHandler) reconcileVolumeRestores(vmRestore *harvesterv1.VirtualM
 		}
 	}
 
-	if vmRestore.Status.DeletedVolumes == nil {
+	if vmRestore.Status.DeletedVolumes == nil && !isDetachedPVCsRestore(vmRestore) {
 		var deletedVolumes []string
 		for _, vol := range backup.Status.VolumeBackups {
 			deletedVolumes = append(deletedVolumes, vol.PersistentVolumeClaim.ObjectMeta.Name)
@@ -360,6 +495,10 @@ func (h *RestoreHandler) reconcileVolumeRestores(vmRestore *harvesterv1.VirtualM
 		vmRestore.Status.DeletedVolumes = deletedVolumes
 	}
 
+	if err := h.updateVolumeRestoreProgress(vmRestore); err != nil {
+		return vmRestore, false, err
+	}
+
 	return vmRestore, createdPVC || waitingPVC, nil
 }
 
@@ -393,7 +532,7 @@ func (t *vmRestoreTarget) Cleanup() error {
 
 	// clean up existing pvc
 	for _, volName := range t.vmRestore.Status.DeletedVolumes {
-		vol, err := t.handler.pvcCache.Get(t.vmRestore.Namespace, volName)
+		vol, err := t.handler.pvcCache.Get(t.vm.Namespace, volName)
 		if err != nil && !apierrors.IsNotFound(err) {
 			return err
 		}
@@ -478,8 +617,9 @@ func (t *vmRestoreTarget) Reconcile() (bool, error) {
 	}
 
 	vmCpy := t.vm.DeepCopy()
-	vmCpy.Spec = backup.Status.SourceSpec.Spec
+	vmCpy.Spec = *backup.Status.SourceSpec.Spec.DeepCopy()
 	vmCpy.Spec.Template.Spec.Volumes = newVolumes
+	mapVMSpec(t.vmRestore, &vmCpy.Spec)
 	if vmCpy.Annotations == nil {
 		vmCpy.Annotations = make(map[string]string)
 	}
@@ -507,10 +647,11 @@ func (h *RestoreHandler) createNewVM(restore *harvesterv1.VirtualMachineRestore,
 	vm := &kv1.VirtualMachine{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      vmName,
-			Namespace: restore.Namespace,
+			Namespace: GetRestoreTargetNamespace(restore),
 			Annotations: map[string]string{
-				lastRestoreAnnotation: restoreID,
-				restoreNameAnnotation: restore.Name,
+				lastRestoreAnnotation:      restoreID,
+				restoreNameAnnotation:      restore.Name,
+				restoreNamespaceAnnotation: restore.Namespace,
 			},
 		},
 		Spec: kv1.VirtualMachineSpec{
@@ -538,6 +679,7 @@ func (h *RestoreHandler) createNewVM(restore *harvesterv1.VirtualMachineRestore,
 		// remove the copied mac address of the new VM
 		vm.Spec.Template.Spec.Domain.Devices.Interfaces[i].MacAddress = ""
 	}
+	mapVMSpec(restore, &vm.Spec)
 
 	newVM, err := h.vms.Create(vm)
 	if err != nil {
@@ -558,29 +700,190 @@ func (h *RestoreHandler) createNewVM(restore *harvesterv1.VirtualMachineRestore,
 // createRestoredPVC helps to create new PVC from CSI volumeSnapshot
 func (h *RestoreHandler) createRestoredPVC(vmRestore *harvesterv1.VirtualMachineRestore,
 	volumeBackup harvesterv1.VolumeBackup, volumeRestore harvesterv1.VolumeRestore) error {
-	sourcePVC := volumeBackup.PersistentVolumeClaim
+	pvc, err := getRestoredPVC(vmRestore, volumeBackup, volumeRestore)
+	if err != nil {
+		return err
+	}
+	if err := h.copyVolumeSnapshot(vmRestore, volumeBackup, pvc.Namespace); err != nil {
+		return err
+	}
+
+	_, err = h.pvcClient.Create(pvc)
+	return err
+}
+
+// copyVolumeSnapshot copies the VolumeSnapshot of the volume backup into the namespace of the restored PVC, since a PVC
+// can only be created from a VolumeSnapshot of its own namespace. The copy is pre-provisioned from the snapshot handle
+// of the source VolumeSnapshotContent and retains the volume data when it is deleted.
+func (h *RestoreHandler) copyVolumeSnapshot(vmRestore *harvesterv1.VirtualMachineRestore,
+	volumeBackup harvesterv1.VolumeBackup, namespace string) error {
+	sourceNamespace := vmRestore.Spec.VirtualMachineBackupNamespace
+	if namespace == sourceNamespace {
+		return nil
+	}
+	snapshotName := getRestoredSnapshotName(vmRestore, volumeBackup, namespace)
+	if _, err := h.snapshotCache.Get(namespace, snapshotName); err == nil {
+		return nil
+	} else if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	source, err := h.snapshotCache.Get(sourceNamespace, *volumeBackup.Name)
+	if err != nil {
+		return fmt.Errorf("can't get VolumeSnapshot %s/%s, error: %w", sourceNamespace, *volumeBackup.Name, err)
+	}
+	if source.Status == nil || source.Status.BoundVolumeSnapshotContentName == nil {
+		return fmt.Errorf("VolumeSnapshot %s/%s is not bound to a VolumeSnapshotContent", source.Namespace, source.Name)
+	}
+	sourceContent, err := h.snapshotContentCache.Get(*source.Status.BoundVolumeSnapshotContentName)
+	if err != nil {
+		return err
+	}
+	if sourceContent.Status == nil || sourceContent.Status.SnapshotHandle == nil {
+		return fmt.Errorf("VolumeSnapshotContent %s has no snapshot handle", sourceContent.Name)
+	}
+
+	snapshotClassName := sourceContent.Spec.VolumeSnapshotClassName
+	if snapshotClassName == nil {
+		snapshotClassName = pointer.StringPtr(settings.VolumeSnapshotClass.Get())
+	}
+	annotations := map[string]string{
+		restoreNameAnnotation:      vmRestore.Name,
+		restoreNamespaceAnnotation: vmRestore.Namespace,
+	}
+	contentName := getRestoredSnapshotContentName(namespace, snapshotName)
+	content := &snapshotv1.VolumeSnapshotContent{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        contentName,
+			Annotations: annotations,
+		},
+		Spec: snapshotv1.VolumeSnapshotContentSpec{
+			VolumeSnapshotRef: corev1.ObjectReference{
+				Name:      snapshotName,
+				Namespace: namespace,
+			},
+			// the volume data is still owned by the source VolumeSnapshotContent
+			DeletionPolicy:          snapshotv1.VolumeSnapshotContentRetain,
+			Driver:                  sourceContent.Spec.Driver,
+			VolumeSnapshotClassName: snapshotClassName,
+			Source: snapshotv1.VolumeSnapshotContentSource{
+				SnapshotHandle: pointer.StringPtr(*sourceContent.Status.SnapshotHandle),
+			},
+		},
+	}
+	if _, err := h.snapshotContents.Create(content); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	snapshot := &snapshotv1.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        snapshotName,
+			Namespace:   namespace,
+			Annotations: annotations,
+		},
+		Spec: snapshotv1.VolumeSnapshotSpec{
+			Source: snapshotv1.VolumeSnapshotSource{
+				VolumeSnapshotContentName: pointer.StringPtr(contentName),
+			},
+			VolumeSnapshotClassName: snapshotClassName,
+		},
+	}
+	if _, err := h.snapshots.Create(snapshot); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+// deleteCopiedVolumeSnapshots deletes the VolumeSnapshots copied into the target namespace of the restore and their
+// VolumeSnapshotContents, the copy of a PVC is kept until the PVC is bound unless the restore is removed.
+func (h *RestoreHandler) deleteCopiedVolumeSnapshots(vmRestore *harvesterv1.VirtualMachineRestore, removed bool) error {
+	if vmRestore.Status == nil {
+		return nil
+	}
+	for _, volumeRestore := range vmRestore.Status.VolumeRestores {
+		namespace := volumeRestore.PersistentVolumeClaim.ObjectMeta.Namespace
+		if namespace == "" || namespace == vmRestore.Spec.VirtualMachineBackupNamespace {
+			continue
+		}
+		if !removed {
+			pvc, err := h.pvcCache.Get(namespace, volumeRestore.PersistentVolumeClaim.ObjectMeta.Name)
+			if apierrors.IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			if pvc.Status.Phase != corev1.ClaimBound {
+				continue
+			}
+		}
+
+		snapshotName := getCopiedSnapshotName(vmRestore, volumeRestore.VolumeBackupName)
+		if _, err := h.snapshotCache.Get(namespace, snapshotName); err == nil {
+			if err := h.snapshots.Delete(namespace, snapshotName, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+		} else if !apierrors.IsNotFound(err) {
+			return err
+		}
+		// the content retains the volume data of the source VolumeSnapshotContent
+		contentName := getRestoredSnapshotContentName(namespace, snapshotName)
+		if _, err := h.snapshotContentCache.Get(contentName); err == nil {
+			if err := h.snapshotContents.Delete(contentName, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+		} else if !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// getRestoredSnapshotName returns the name of the VolumeSnapshot which the restored PVC in the namespace is created from
+func getRestoredSnapshotName(vmRestore *harvesterv1.VirtualMachineRestore, volumeBackup harvesterv1.VolumeBackup, namespace string) string {
+	if namespace == vmRestore.Spec.VirtualMachineBackupNamespace {
+		return *volumeBackup.Name
+	}
+	return getCopiedSnapshotName(vmRestore, *volumeBackup.Name)
+}
+
+// getCopiedSnapshotName returns the name of the VolumeSnapshot copied into the target namespace from the volume backup
+func getCopiedSnapshotName(vmRestore *harvesterv1.VirtualMachineRestore, volumeBackupName string) string {
+	return fmt.Sprintf("%s-%s", vmRestore.Spec.VirtualMachineBackupNamespace, volumeBackupName)
+}
+
+func getRestoredSnapshotContentName(namespace, name string) string {
+	return fmt.Sprintf("restored-%s-%s", namespace, name)
+}
+
+func getRestoredPVC(vmRestore *harvesterv1.VirtualMachineRestore,
+	volumeBackup harvesterv1.VolumeBackup, volumeRestore harvesterv1.VolumeRestore) (*corev1.PersistentVolumeClaim, error) {
+	// copy the source PVC to not modify the annotations of the cached backup
+	sourcePVC := volumeBackup.PersistentVolumeClaim.DeepCopy()
 	pvc := &corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        volumeRestore.PersistentVolumeClaim.ObjectMeta.Name,
-			Namespace:   vmRestore.Namespace,
+			Namespace:   volumeRestore.PersistentVolumeClaim.ObjectMeta.Namespace,
 			Labels:      sourcePVC.ObjectMeta.Labels,
 			Annotations: sourcePVC.ObjectMeta.Annotations,
 		},
-		Spec: *sourcePVC.Spec.DeepCopy(),
+		Spec: sourcePVC.Spec,
 	}
 
-	pvc.SetOwnerReferences([]metav1.OwnerReference{
-		{
-			APIVersion:         harvesterv1.SchemeGroupVersion.String(),
-			Kind:               vmRestoreKindName,
-			Name:               vmRestore.Name,
-			UID:                vmRestore.UID,
-			Controller:         pointer.BoolPtr(true),
-			BlockOwnerDeletion: pointer.BoolPtr(true),
-		},
-	})
+	// the detached PVCs are standalone and outlive the vmRestore, and the owner reference can't cross namespaces
+	if !isDetachedPVCsRestore(vmRestore) && !isCrossNamespaceRestore(vmRestore) {
+		pvc.SetOwnerReferences([]metav1.OwnerReference{
+			{
+				APIVersion:         harvesterv1.SchemeGroupVersion.String(),
+				Kind:               vmRestoreKindName,
+				Name:               vmRestore.Name,
+				UID:                vmRestore.UID,
+				Controller:         pointer.BoolPtr(true),
+				BlockOwnerDeletion: pointer.BoolPtr(true),
+			},
+		})
+	}
 	if volumeBackup.Name == nil {
-		return fmt.Errorf("missing VolumeSnapshot name")
+		return nil, fmt.Errorf("missing VolumeSnapshot name")
 	}
 
 	if pvc.Annotations == nil {
@@ -595,15 +898,72 @@ func (h *RestoreHandler) createRestoredPVC(vmRestore *harvesterv1.VirtualMachine
 		}
 	}
 	pvc.Annotations[restoreNameAnnotation] = vmRestore.Name
+	pvc.Annotations[restoreNamespaceAnnotation] = vmRestore.Namespace
+	mapStorageClass(vmRestore, &pvc.Spec)
 	pvc.Spec.DataSource = &corev1.TypedLocalObjectReference{
 		APIGroup: pointer.StringPtr(snapshotv1.SchemeGroupVersion.Group),
 		Kind:     volumeSnapshotKindName,
-		Name:     *volumeBackup.Name,
+		Name:     getRestoredSnapshotName(vmRestore, volumeBackup, pvc.Namespace),
 	}
 	pvc.Spec.VolumeName = ""
+	return pvc, nil
+}
 
-	_, err := h.pvcClient.Create(pvc)
-	return err
+// GetRestoredPVCs returns the PVCs which the vmRestore creates from the volume backups, without creating them
+func GetRestoredPVCs(vmRestore *harvesterv1.VirtualMachineRestore, backup *harvesterv1.VirtualMachineBackup) ([]*corev1.PersistentVolumeClaim, error) {
+	volumeRestores, err := getVolumeRestores(vmRestore, backup)
+	if err != nil {
+		return nil, err
+	}
+
+	pvcs := make([]*corev1.PersistentVolumeClaim, 0, len(volumeRestores))
+	for _, volumeRestore := range volumeRestores {
+		volumeBackup := getVolumeBackup(backup, volumeRestore.VolumeBackupName)
+		if volumeBackup == nil {
+			return nil, fmt.Errorf("volume backup %s is not found in VMBackup %s", volumeRestore.VolumeBackupName, backup.Name)
+		}
+		pvc, err := getRestoredPVC(vmRestore, *volumeBackup, volumeRestore)
+		if err != nil {
+			return nil, err
+		}
+		pvcs = append(pvcs, pvc)
+	}
+	return pvcs, nil
+}
+
+// GetRestoredVMSpec returns the VM spec which the vmRestore applies to the target VM, the volumes are not replaced
+func GetRestoredVMSpec(vmRestore *harvesterv1.VirtualMachineRestore, backup *harvesterv1.VirtualMachineBackup) *kv1.VirtualMachineSpec {
+	if backup.Status == nil || backup.Status.SourceSpec == nil {
+		return nil
+	}
+
+	spec := backup.Status.SourceSpec.Spec.DeepCopy()
+	if vmRestore.Spec.NewVM && spec.Template != nil {
+		for i := range spec.Template.Spec.Domain.Devices.Interfaces {
+			spec.Template.Spec.Domain.Devices.Interfaces[i].MacAddress = ""
+		}
+	}
+	mapVMSpec(vmRestore, spec)
+	return spec
+}
+
+// getRestoreNamespace returns the namespace of the vmRestore that restored the object
+// isPVCCreatedByRestore checks whether the PVC is created by the vmRestore, an existing PVC of the same name must
+// not be taken as a restored volume.
+func isPVCCreatedByRestore(pvc *corev1.PersistentVolumeClaim, vmRestore *harvesterv1.VirtualMachineRestore) bool {
+	for _, owner := range pvc.OwnerReferences {
+		if owner.UID == vmRestore.UID {
+			return true
+		}
+	}
+	return pvc.Annotations[restoreNameAnnotation] == vmRestore.Name && getRestoreNamespace(pvc.ObjectMeta) == vmRestore.Namespace
+}
+
+func getRestoreNamespace(obj metav1.ObjectMeta) string {
+	if namespace, ok := obj.Annotations[restoreNamespaceAnnotation]; ok {
+		return namespace
+	}
+	return obj.Namespace
 }
 
 func getRestoreID(vmRestore *harvesterv1.VirtualMachineRestore) string {
@@ -654,6 +1014,10 @@ func restorePVCName(vmRestore *harvesterv1.VirtualMachineRestore, name string) s
 	return s
 }
 
+func isDetachedPVCsRestore(vmRestore *harvesterv1.VirtualMachineRestore) bool {
+	return vmRestore.Spec.Mode == harvesterv1.VirtualMachineRestoreModeDetachedPVCs
+}
+
 func vmRestoreProgressing(vmRestore *harvesterv1.VirtualMachineRestore) bool {
 	return vmRestore.Status == nil || vmRestore.Status.Complete == nil || !*vmRestore.Status.Complete
 }
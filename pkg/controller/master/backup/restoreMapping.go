@@ -0,0 +1,55 @@
+package backup
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	kv1 "kubevirt.io/client-go/api/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+)
+
+// GetRestoreTargetNamespace returns the namespace to restore the VM and volumes into
+func GetRestoreTargetNamespace(vmRestore *harvesterv1.VirtualMachineRestore) string {
+	if vmRestore.Spec.TargetNamespace != "" {
+		return vmRestore.Spec.TargetNamespace
+	}
+	return vmRestore.Namespace
+}
+
+// isCrossNamespaceRestore returns true if the VM and volumes are restored into another namespace,
+// the restored resources can't be owned by the vmRestore in this case.
+func isCrossNamespaceRestore(vmRestore *harvesterv1.VirtualMachineRestore) bool {
+	return GetRestoreTargetNamespace(vmRestore) != vmRestore.Namespace
+}
+
+// mapStorageClass replaces the storage class of the restored PVC spec upon vmRestore.spec.storageClassMappings
+func mapStorageClass(vmRestore *harvesterv1.VirtualMachineRestore, spec *corev1.PersistentVolumeClaimSpec) {
+	if spec.StorageClassName == nil {
+		return
+	}
+	if storageClassName, ok := vmRestore.Spec.StorageClassMappings[*spec.StorageClassName]; ok {
+		spec.StorageClassName = &storageClassName
+	}
+}
+
+// mapVMSpec replaces the Multus networks of the restored VM spec upon vmRestore.spec.networkMappings,
+// and clears the interface MAC addresses if vmRestore.spec.regenerateMACAddresses is set.
+func mapVMSpec(vmRestore *harvesterv1.VirtualMachineRestore, spec *kv1.VirtualMachineSpec) {
+	if spec.Template == nil {
+		return
+	}
+
+	for i, network := range spec.Template.Spec.Networks {
+		if network.Multus == nil {
+			continue
+		}
+		if networkName, ok := vmRestore.Spec.NetworkMappings[network.Multus.NetworkName]; ok {
+			spec.Template.Spec.Networks[i].Multus.NetworkName = networkName
+		}
+	}
+
+	if vmRestore.Spec.RegenerateMACAddresses {
+		for i := range spec.Template.Spec.Domain.Devices.Interfaces {
+			spec.Template.Spec.Domain.Devices.Interfaces[i].MacAddress = ""
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package backup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	kv1 "kubevirt.io/client-go/api/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+)
+
+func newTestMappingRestore(targetNamespace string, regenerateMACAddresses bool) *harvesterv1.VirtualMachineRestore {
+	return &harvesterv1.VirtualMachineRestore{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      "restore",
+		},
+		Spec: harvesterv1.VirtualMachineRestoreSpec{
+			TargetNamespace: targetNamespace,
+			StorageClassMappings: map[string]string{
+				"longhorn": "longhorn-dr",
+			},
+			NetworkMappings: map[string]string{
+				"default/vlan1": "dr/vlan100",
+			},
+			RegenerateMACAddresses: regenerateMACAddresses,
+		},
+	}
+}
+
+func newTestMappingVMSpec() *kv1.VirtualMachineSpec {
+	return &kv1.VirtualMachineSpec{
+		Template: &kv1.VirtualMachineInstanceTemplateSpec{
+			Spec: kv1.VirtualMachineInstanceSpec{
+				Domain: kv1.DomainSpec{
+					Devices: kv1.Devices{
+						Interfaces: []kv1.Interface{
+							{Name: "default", MacAddress: "52:54:00:00:00:01"},
+							{Name: "nic-1", MacAddress: "52:54:00:00:00:02"},
+						},
+					},
+				},
+				Networks: []kv1.Network{
+					{
+						Name:          "default",
+						NetworkSource: kv1.NetworkSource{Pod: &kv1.PodNetwork{}},
+					},
+					{
+						Name:          "nic-1",
+						NetworkSource: kv1.NetworkSource{Multus: &kv1.MultusNetwork{NetworkName: "default/vlan1"}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestGetRestoreTargetNamespace(t *testing.T) {
+	assert.Equal(t, testNamespace, GetRestoreTargetNamespace(newTestMappingRestore("", false)))
+	assert.False(t, isCrossNamespaceRestore(newTestMappingRestore("", false)))
+	assert.Equal(t, "dr", GetRestoreTargetNamespace(newTestMappingRestore("dr", false)))
+	assert.True(t, isCrossNamespaceRestore(newTestMappingRestore("dr", false)))
+}
+
+func TestMapStorageClass(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		given    *string
+		expected *string
+	}{
+		{
+			name:     "mapped storage class",
+			given:    pointer.StringPtr("longhorn"),
+			expected: pointer.StringPtr("longhorn-dr"),
+		},
+		{
+			name:     "unmapped storage class",
+			given:    pointer.StringPtr("local-path"),
+			expected: pointer.StringPtr("local-path"),
+		},
+		{
+			name:     "default storage class",
+			given:    nil,
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		spec := &corev1.PersistentVolumeClaimSpec{StorageClassName: tc.given}
+		mapStorageClass(newTestMappingRestore("", false), spec)
+		assert.Equal(t, tc.expected, spec.StorageClassName, "case %q", tc.name)
+	}
+}
+
+func TestMapVMSpec(t *testing.T) {
+	spec := newTestMappingVMSpec()
+	mapVMSpec(newTestMappingRestore("", false), spec)
+	assert.Nil(t, spec.Template.Spec.Networks[0].Multus)
+	assert.Equal(t, "dr/vlan100", spec.Template.Spec.Networks[1].Multus.NetworkName)
+	assert.Equal(t, "52:54:00:00:00:01", spec.Template.Spec.Domain.Devices.Interfaces[0].MacAddress)
+
+	spec = newTestMappingVMSpec()
+	mapVMSpec(newTestMappingRestore("", true), spec)
+	for _, iface := range spec.Template.Spec.Domain.Devices.Interfaces {
+		assert.Empty(t, iface.MacAddress)
+	}
+}
@@ -0,0 +1,309 @@
+package backup
+
+import (
+	"context"
+	"testing"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/v2/pkg/apis/volumesnapshot/v1beta1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/pointer"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/fake"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+func newTestVolumeBackup(volumeName string) harvesterv1.VolumeBackup {
+	return harvesterv1.VolumeBackup{
+		Name:       pointer.StringPtr("vmbackup-" + volumeName),
+		VolumeName: volumeName,
+		PersistentVolumeClaim: harvesterv1.PersistentVolumeClaimSourceSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testNamespace,
+				Name:      "vm-" + volumeName,
+			},
+		},
+	}
+}
+
+func TestGetVolumeRestores(t *testing.T) {
+	backup := &harvesterv1.VirtualMachineBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      "vmbackup",
+		},
+		Status: &harvesterv1.VirtualMachineBackupStatus{
+			VolumeBackups: []harvesterv1.VolumeBackup{
+				newTestVolumeBackup("rootdisk"),
+				newTestVolumeBackup("datadisk"),
+			},
+		},
+	}
+
+	newRestore := func(mode harvesterv1.VirtualMachineRestoreMode, volumes ...harvesterv1.VolumeRestoreSource) *harvesterv1.VirtualMachineRestore {
+		return &harvesterv1.VirtualMachineRestore{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testNamespace,
+				Name:      "restore",
+				UID:       "uid",
+			},
+			Spec: harvesterv1.VirtualMachineRestoreSpec{
+				Target: corev1.TypedLocalObjectReference{
+					Name: "vm",
+				},
+				VirtualMachineBackupName: backup.Name,
+				Mode:                     mode,
+				Volumes:                  volumes,
+			},
+			Status: &harvesterv1.VirtualMachineRestoreStatus{},
+		}
+	}
+
+	type output struct {
+		volumeBackupNames []string
+		pvcNames          []string
+		err               bool
+	}
+	var testCases = []struct {
+		name     string
+		given    *harvesterv1.VirtualMachineRestore
+		expected output
+	}{
+		{
+			name:  "vm mode restores all volumes",
+			given: newRestore(""),
+			expected: output{
+				volumeBackupNames: []string{"vmbackup-rootdisk", "vmbackup-datadisk"},
+				pvcNames:          []string{"restore-vmbackup-uid-rootdisk", "restore-vmbackup-uid-datadisk"},
+			},
+		},
+		{
+			name:  "detached mode without selected volumes restores all volumes",
+			given: newRestore(harvesterv1.VirtualMachineRestoreModeDetachedPVCs),
+			expected: output{
+				volumeBackupNames: []string{"vmbackup-rootdisk", "vmbackup-datadisk"},
+				pvcNames:          []string{"restore-vmbackup-uid-rootdisk", "restore-vmbackup-uid-datadisk"},
+			},
+		},
+		{
+			name: "detached mode restores the selected volumes",
+			given: newRestore(harvesterv1.VirtualMachineRestoreModeDetachedPVCs,
+				harvesterv1.VolumeRestoreSource{VolumeBackupName: "vmbackup-datadisk", PersistentVolumeClaimName: "datadisk-copy"}),
+			expected: output{
+				volumeBackupNames: []string{"vmbackup-datadisk"},
+				pvcNames:          []string{"datadisk-copy"},
+			},
+		},
+		{
+			name: "detached mode generates the PVC name if it is empty",
+			given: newRestore(harvesterv1.VirtualMachineRestoreModeDetachedPVCs,
+				harvesterv1.VolumeRestoreSource{VolumeBackupName: "vmbackup-rootdisk"}),
+			expected: output{
+				volumeBackupNames: []string{"vmbackup-rootdisk"},
+				pvcNames:          []string{"restore-vmbackup-uid-rootdisk"},
+			},
+		},
+		{
+			name: "detached mode with an unknown volume backup",
+			given: newRestore(harvesterv1.VirtualMachineRestoreModeDetachedPVCs,
+				harvesterv1.VolumeRestoreSource{VolumeBackupName: "vmbackup-unknown"}),
+			expected: output{
+				err: true,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		restores, err := getVolumeRestores(tc.given, backup)
+		if tc.expected.err {
+			assert.NotNil(t, err, "case %q", tc.name)
+			continue
+		}
+		assert.Nil(t, err, "case %q", tc.name)
+
+		var volumeBackupNames, pvcNames []string
+		for _, restore := range restores {
+			volumeBackupNames = append(volumeBackupNames, restore.VolumeBackupName)
+			pvcNames = append(pvcNames, restore.PersistentVolumeClaim.ObjectMeta.Name)
+			assert.Equal(t, testNamespace, restore.PersistentVolumeClaim.ObjectMeta.Namespace, "case %q", tc.name)
+		}
+		assert.Equal(t, tc.expected.volumeBackupNames, volumeBackupNames, "case %q", tc.name)
+		assert.Equal(t, tc.expected.pvcNames, pvcNames, "case %q", tc.name)
+	}
+}
+
+func TestIsPVCCreatedByRestore(t *testing.T) {
+	vmRestore := &harvesterv1.VirtualMachineRestore{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      "restore",
+			UID:       "uid",
+		},
+	}
+	newPVC := func(annotations map[string]string, owners ...metav1.OwnerReference) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       "target",
+				Name:            "disk",
+				Annotations:     annotations,
+				OwnerReferences: owners,
+			},
+		}
+	}
+
+	var testCases = []struct {
+		name     string
+		given    *corev1.PersistentVolumeClaim
+		expected bool
+	}{
+		{
+			name:  "existing PVC",
+			given: newPVC(nil),
+		},
+		{
+			name:     "PVC owned by the restore",
+			given:    newPVC(nil, metav1.OwnerReference{Name: "restore", UID: "uid"}),
+			expected: true,
+		},
+		{
+			name: "PVC annotated with the restore",
+			given: newPVC(map[string]string{
+				restoreNameAnnotation:      "restore",
+				restoreNamespaceAnnotation: testNamespace,
+			}),
+			expected: true,
+		},
+		{
+			name: "PVC annotated with a restore of the same name in another namespace",
+			given: newPVC(map[string]string{
+				restoreNameAnnotation:      "restore",
+				restoreNamespaceAnnotation: "other",
+			}),
+		},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, isPVCCreatedByRestore(tc.given, vmRestore), "case %q", tc.name)
+	}
+}
+
+func TestRestoreHandler_createRestoredPVC(t *testing.T) {
+	volumeBackup := newTestVolumeBackup("rootdisk")
+	sourceSnapshot := &snapshotv1.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      *volumeBackup.Name,
+		},
+		Status: &snapshotv1.VolumeSnapshotStatus{
+			BoundVolumeSnapshotContentName: pointer.StringPtr("snapcontent-rootdisk"),
+		},
+	}
+	sourceContent := &snapshotv1.VolumeSnapshotContent{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "snapcontent-rootdisk",
+		},
+		Spec: snapshotv1.VolumeSnapshotContentSpec{
+			Driver:                  longhornDriverName,
+			VolumeSnapshotClassName: pointer.StringPtr("longhorn"),
+		},
+		Status: &snapshotv1.VolumeSnapshotContentStatus{
+			SnapshotHandle: pointer.StringPtr("bs://pvc-rootdisk/backup-rootdisk"),
+		},
+	}
+	newRestore := func(targetNamespace string) *harvesterv1.VirtualMachineRestore {
+		return &harvesterv1.VirtualMachineRestore{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testNamespace,
+				Name:      "restore",
+				UID:       "uid",
+			},
+			Spec: harvesterv1.VirtualMachineRestoreSpec{
+				VirtualMachineBackupNamespace: testNamespace,
+				VirtualMachineBackupName:      "vmbackup",
+				TargetNamespace:               targetNamespace,
+				NewVM:                         true,
+			},
+			Status: &harvesterv1.VirtualMachineRestoreStatus{},
+		}
+	}
+
+	var testCases = []struct {
+		name             string
+		given            *harvesterv1.VirtualMachineRestore
+		expectedSnapshot string
+		expectedCopy     bool
+	}{
+		{
+			name:             "restore into the backup namespace",
+			given:            newRestore(""),
+			expectedSnapshot: *volumeBackup.Name,
+		},
+		{
+			name:             "restore into another namespace",
+			given:            newRestore("target"),
+			expectedSnapshot: testNamespace + "-" + *volumeBackup.Name,
+			expectedCopy:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		var clientset = fake.NewSimpleClientset(sourceSnapshot, sourceContent)
+		var coreclientset = corefake.NewSimpleClientset()
+		var handler = &RestoreHandler{
+			pvcClient:            fakeclients.PersistentVolumeClaimClient(coreclientset.CoreV1().PersistentVolumeClaims),
+			pvcCache:             fakeclients.PersistentVolumeClaimCache(coreclientset.CoreV1().PersistentVolumeClaims),
+			snapshots:            fakeclients.VolumeSnapshotClient(clientset.SnapshotV1beta1().VolumeSnapshots),
+			snapshotCache:        fakeclients.VolumeSnapshotCache(clientset.SnapshotV1beta1().VolumeSnapshots),
+			snapshotContents:     fakeclients.VolumeSnapshotContentClient(clientset.SnapshotV1beta1().VolumeSnapshotContents),
+			snapshotContentCache: fakeclients.VolumeSnapshotContentCache(clientset.SnapshotV1beta1().VolumeSnapshotContents),
+		}
+		volumeRestores, err := getVolumeRestores(tc.given, &harvesterv1.VirtualMachineBackup{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: "vmbackup"},
+			Status: &harvesterv1.VirtualMachineBackupStatus{
+				VolumeBackups: []harvesterv1.VolumeBackup{volumeBackup},
+			},
+		})
+		assert.Nil(t, err, "case %q", tc.name)
+
+		err = handler.createRestoredPVC(tc.given, volumeBackup, volumeRestores[0])
+		assert.Nil(t, err, "case %q", tc.name)
+
+		targetNamespace := GetRestoreTargetNamespace(tc.given)
+		pvc, err := coreclientset.CoreV1().PersistentVolumeClaims(targetNamespace).Get(context.TODO(), volumeRestores[0].PersistentVolumeClaim.ObjectMeta.Name, metav1.GetOptions{})
+		assert.Nil(t, err, "case %q", tc.name)
+		assert.Equal(t, tc.expectedSnapshot, pvc.Spec.DataSource.Name, "case %q", tc.name)
+
+		// the PVC data source refers to a VolumeSnapshot in the namespace of the PVC
+		snapshot, err := clientset.SnapshotV1beta1().VolumeSnapshots(pvc.Namespace).Get(context.TODO(), pvc.Spec.DataSource.Name, metav1.GetOptions{})
+		assert.Nil(t, err, "case %q", tc.name)
+		if !tc.expectedCopy {
+			continue
+		}
+		content, err := clientset.SnapshotV1beta1().VolumeSnapshotContents().Get(context.TODO(), *snapshot.Spec.Source.VolumeSnapshotContentName, metav1.GetOptions{})
+		assert.Nil(t, err, "case %q", tc.name)
+		assert.Equal(t, sourceContent.Status.SnapshotHandle, content.Spec.Source.SnapshotHandle, "case %q", tc.name)
+		assert.Equal(t, snapshotv1.VolumeSnapshotContentRetain, content.Spec.DeletionPolicy, "case %q", tc.name)
+		assert.Equal(t, corev1.ObjectReference{Namespace: targetNamespace, Name: snapshot.Name}, content.Spec.VolumeSnapshotRef, "case %q", tc.name)
+
+		// the copy is kept until the PVC is bound
+		tc.given.Status.VolumeRestores = volumeRestores
+		assert.Nil(t, handler.deleteCopiedVolumeSnapshots(tc.given, false), "case %q", tc.name)
+		_, err = clientset.SnapshotV1beta1().VolumeSnapshots(pvc.Namespace).Get(context.TODO(), snapshot.Name, metav1.GetOptions{})
+		assert.Nil(t, err, "case %q", tc.name)
+
+		pvc.Status.Phase = corev1.ClaimBound
+		_, err = coreclientset.CoreV1().PersistentVolumeClaims(pvc.Namespace).UpdateStatus(context.TODO(), pvc, metav1.UpdateOptions{})
+		assert.Nil(t, err, "case %q", tc.name)
+		assert.Nil(t, handler.deleteCopiedVolumeSnapshots(tc.given, false), "case %q", tc.name)
+		_, err = clientset.SnapshotV1beta1().VolumeSnapshots(pvc.Namespace).Get(context.TODO(), snapshot.Name, metav1.GetOptions{})
+		assert.True(t, apierrors.IsNotFound(err), "case %q", tc.name)
+		_, err = clientset.SnapshotV1beta1().VolumeSnapshotContents().Get(context.TODO(), content.Name, metav1.GetOptions{})
+		assert.True(t, apierrors.IsNotFound(err), "case %q", tc.name)
+		_, err = clientset.SnapshotV1beta1().VolumeSnapshots(testNamespace).Get(context.TODO(), sourceSnapshot.Name, metav1.GetOptions{})
+		assert.Nil(t, err, "the source VolumeSnapshot is kept, case %q", tc.name)
+	}
+}
@@ -0,0 +1,348 @@
+package backup
+
+// Harvester VM backup retention controller prunes the ready VM backups which are no longer kept by the
+// retention policy. The policy of a backup is resolved in the following order:
+// 1. the retention of the VirtualMachineBackupSchedule which created the backup.
+// 2. the retention annotation of the source VM.
+// 3. the cluster-wide backup-retention setting.
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	kv1 "kubevirt.io/client-go/api/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/config"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
+	"github.com/harvester/harvester/pkg/settings"
+)
+
+const (
+	backupRetentionControllerName = "harvester-vm-backup-retention-controller"
+
+	// BackupRetentionAnnotation is the VM annotation of its backup retention policy in JSON, e.g. {"keepLast":7}
+	BackupRetentionAnnotation = "backup.harvesterhci.io/retention"
+
+	backupPrunedEvent       = "BackupPruned"
+	backupPruneSkippedEvent = "BackupPruneSkipped"
+)
+
+// RegisterBackupRetention register the vmBackup retention controller
+func RegisterBackupRetention(ctx context.Context, management *config.Management, opts config.Options) error {
+	vmBackups := management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackup()
+	restores := management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineRestore()
+	schedules := management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackupSchedule()
+	vms := management.VirtFactory.Kubevirt().V1().VirtualMachine()
+
+	retentionHandler := &RetentionHandler{
+		vmBackups:          vmBackups,
+		vmBackupCache:      vmBackups.Cache(),
+		vmBackupController: vmBackups,
+		restoreCache:       restores.Cache(),
+		scheduleCache:      schedules.Cache(),
+		vmsCache:           vms.Cache(),
+		recorder:           management.NewRecorder(backupRetentionControllerName, "", ""),
+	}
+
+	vmBackups.OnChange(ctx, backupRetentionControllerName, retentionHandler.OnBackupChange)
+	return nil
+}
+
+type RetentionHandler struct {
+	vmBackups          ctlharvesterv1.VirtualMachineBackupClient
+	vmBackupCache      ctlharvesterv1.VirtualMachineBackupCache
+	vmBackupController ctlharvesterv1.VirtualMachineBackupController
+	restoreCache       ctlharvesterv1.VirtualMachineRestoreCache
+	scheduleCache      ctlharvesterv1.VirtualMachineBackupScheduleCache
+	vmsCache           ctlkubevirtv1.VirtualMachineCache
+	recorder           record.EventRecorder
+}
+
+// OnBackupChange prunes the backups in the same retention group once a backup becomes ready
+func (h *RetentionHandler) OnBackupChange(key string, vmBackup *harvesterv1.VirtualMachineBackup) (*harvesterv1.VirtualMachineBackup, error) {
+	// the backups imported from the backup target are owned by another cluster
+	if vmBackup == nil || vmBackup.DeletionTimestamp != nil || !isBackupReady(vmBackup) || isBackupReadOnly(vmBackup) {
+		return nil, nil
+	}
+
+	policy, group, err := h.getRetentionPolicy(vmBackup)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return nil, nil
+	}
+
+	backups, err := h.getRetentionGroupBackups(vmBackup, group)
+	if err != nil {
+		return nil, err
+	}
+
+	now := currentTime().Time
+	toPrune, expiring := getBackupsToPrune(backups, policy, now)
+	for _, backup := range toPrune {
+		if err := h.pruneBackup(backup); err != nil {
+			return nil, err
+		}
+	}
+
+	// the group is re-evaluated when the first backup kept only by its age expires
+	if expiring != nil {
+		expiry := getBackupTime(expiring).Add(policy.KeepWithin.Duration)
+		h.vmBackupController.EnqueueAfter(expiring.Namespace, expiring.Name, expiry.Sub(now))
+	}
+	return nil, nil
+}
+
+// getRetentionPolicy returns the retention policy of the backup and its retention group,
+// the group is the schedule name if the schedule defines the policy, otherwise it is empty.
+func (h *RetentionHandler) getRetentionPolicy(vmBackup *harvesterv1.VirtualMachineBackup) (*harvesterv1.BackupRetentionPolicy, string, error) {
+	schedulePolicy, err := h.getSchedulePolicy(vmBackup)
+	if err != nil {
+		return nil, "", err
+	}
+	if schedulePolicy != nil {
+		return schedulePolicy, vmBackup.Labels[BackupScheduleLabel], nil
+	}
+
+	vm, err := h.vmsCache.Get(vmBackup.Namespace, vmBackup.Spec.Source.Name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, "", err
+	}
+	if vm != nil && vm.Annotations[BackupRetentionAnnotation] != "" {
+		policy, err := decodeRetentionPolicy(vm.Annotations[BackupRetentionAnnotation])
+		if err != nil {
+			logrus.Errorf("invalid backup retention annotation of VM %s/%s: %s", vm.Namespace, vm.Name, err.Error())
+			return nil, "", nil
+		}
+		return policy, "", nil
+	}
+
+	if value := settings.BackupRetention.Get(); value != "" {
+		policy, err := decodeRetentionPolicy(value)
+		if err != nil {
+			logrus.Errorf("invalid %s setting: %s", settings.BackupRetention.Name, err.Error())
+			return nil, "", nil
+		}
+		return policy, "", nil
+	}
+	return nil, "", nil
+}
+
+func (h *RetentionHandler) getSchedulePolicy(vmBackup *harvesterv1.VirtualMachineBackup) (*harvesterv1.BackupRetentionPolicy, error) {
+	scheduleName := vmBackup.Labels[BackupScheduleLabel]
+	if scheduleName == "" {
+		return nil, nil
+	}
+	schedule, err := h.scheduleCache.Get(vmBackup.Namespace, scheduleName)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return schedule.Spec.Retention, nil
+}
+
+// getRetentionGroupBackups returns the ready backups of the same source VM within the retention group
+func (h *RetentionHandler) getRetentionGroupBackups(vmBackup *harvesterv1.VirtualMachineBackup, group string) ([]*harvesterv1.VirtualMachineBackup, error) {
+	backups, err := h.vmBackupCache.List(vmBackup.Namespace, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*harvesterv1.VirtualMachineBackup
+	for _, backup := range backups {
+		if backup.DeletionTimestamp != nil || !isBackupReady(backup) || isBackupReadOnly(backup) ||
+			backup.Spec.Source.Kind != vmBackup.Spec.Source.Kind ||
+			backup.Spec.Source.Name != vmBackup.Spec.Source.Name {
+			continue
+		}
+		schedulePolicy, err := h.getSchedulePolicy(backup)
+		if err != nil {
+			return nil, err
+		}
+		backupGroup := ""
+		if schedulePolicy != nil {
+			backupGroup = backup.Labels[BackupScheduleLabel]
+		}
+		if backupGroup == group {
+			result = append(result, backup)
+		}
+	}
+	return result, nil
+}
+
+func (h *RetentionHandler) pruneBackup(backup *harvesterv1.VirtualMachineBackup) error {
+	eventObj := h.getEventObject(backup)
+
+	restore, err := h.getInProgressRestore(backup)
+	if err != nil {
+		return err
+	}
+	if restore != nil {
+		h.recorder.Eventf(eventObj, corev1.EventTypeNormal, backupPruneSkippedEvent,
+			"Skipped pruning backup %s, it is used by the in-progress restore %s", backup.Name, restore.Name)
+		return nil
+	}
+
+	if err := h.vmBackups.Delete(backup.Namespace, backup.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	h.recorder.Eventf(eventObj, corev1.EventTypeNormal, backupPrunedEvent, "Pruned backup %s by the retention policy", backup.Name)
+	return nil
+}
+
+// getEventObject returns the source VM of the backup, or the backup itself if the VM no longer exists
+func (h *RetentionHandler) getEventObject(backup *harvesterv1.VirtualMachineBackup) runtime.Object {
+	if backup.Spec.Source.Kind == kv1.VirtualMachineGroupVersionKind.Kind {
+		if vm, err := h.vmsCache.Get(backup.Namespace, backup.Spec.Source.Name); err == nil {
+			return vm
+		}
+	}
+	return backup
+}
+
+func (h *RetentionHandler) getInProgressRestore(backup *harvesterv1.VirtualMachineBackup) (*harvesterv1.VirtualMachineRestore, error) {
+	restores, err := h.restoreCache.List(corev1.NamespaceAll, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, restore := range restores {
+		if restore.Spec.VirtualMachineBackupNamespace != backup.Namespace ||
+			restore.Spec.VirtualMachineBackupName != backup.Name {
+			continue
+		}
+		if restore.Status == nil || restore.Status.Complete == nil || !*restore.Status.Complete {
+			return restore, nil
+		}
+	}
+	return nil, nil
+}
+
+func decodeRetentionPolicy(value string) (*harvesterv1.BackupRetentionPolicy, error) {
+	policy := &harvesterv1.BackupRetentionPolicy{}
+	if err := json.Unmarshal([]byte(value), policy); err != nil {
+		return nil, err
+	}
+	if err := ValidateRetentionPolicy(policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// ValidateRetentionPolicy checks that all the rules of the retention policy are not negative
+func ValidateRetentionPolicy(policy *harvesterv1.BackupRetentionPolicy) error {
+	counts := map[string]*int{
+		"keepLast":    policy.KeepLast,
+		"keepDaily":   policy.KeepDaily,
+		"keepWeekly":  policy.KeepWeekly,
+		"keepMonthly": policy.KeepMonthly,
+	}
+	for name, count := range counts {
+		if count != nil && *count < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+	}
+	if policy.KeepWithin != nil && policy.KeepWithin.Duration < 0 {
+		return fmt.Errorf("keepWithin must not be negative")
+	}
+	return nil
+}
+
+func isRetentionPolicyEmpty(policy *harvesterv1.BackupRetentionPolicy) bool {
+	return policy.KeepLast == nil && policy.KeepWithin == nil &&
+		policy.KeepDaily == nil && policy.KeepWeekly == nil && policy.KeepMonthly == nil
+}
+
+func getBackupTime(backup *harvesterv1.VirtualMachineBackup) time.Time {
+	if backup.Status != nil && backup.Status.CreationTime != nil {
+		return backup.Status.CreationTime.Time
+	}
+	return backup.CreationTimestamp.Time
+}
+
+// getBackupsToPrune returns the backups which are not kept by any rule of the policy, and the backup
+// kept only by the keepWithin rule which expires first.
+func getBackupsToPrune(backups []*harvesterv1.VirtualMachineBackup, policy *harvesterv1.BackupRetentionPolicy, now time.Time) ([]*harvesterv1.VirtualMachineBackup, *harvesterv1.VirtualMachineBackup) {
+	if isRetentionPolicyEmpty(policy) {
+		return nil, nil
+	}
+
+	sorted := make([]*harvesterv1.VirtualMachineBackup, len(backups))
+	copy(sorted, backups)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ti, tj := getBackupTime(sorted[i]), getBackupTime(sorted[j])
+		if ti.Equal(tj) {
+			return sorted[i].Name > sorted[j].Name
+		}
+		return ti.After(tj)
+	})
+
+	keep := make([]bool, len(sorted))
+	if policy.KeepLast != nil {
+		for i := 0; i < len(sorted) && i < *policy.KeepLast; i++ {
+			keep[i] = true
+		}
+	}
+
+	bucketRules := []struct {
+		count  *int
+		bucket func(t time.Time) string
+	}{
+		{policy.KeepDaily, func(t time.Time) string { return t.UTC().Format("2006-01-02") }},
+		{policy.KeepWeekly, func(t time.Time) string {
+			year, week := t.UTC().ISOWeek()
+			return fmt.Sprintf("%d-%d", year, week)
+		}},
+		{policy.KeepMonthly, func(t time.Time) string { return t.UTC().Format("2006-01") }},
+	}
+	for _, rule := range bucketRules {
+		if rule.count == nil {
+			continue
+		}
+		buckets := map[string]bool{}
+		for i, backup := range sorted {
+			if len(buckets) >= *rule.count {
+				break
+			}
+			bucket := rule.bucket(getBackupTime(backup))
+			if !buckets[bucket] {
+				buckets[bucket] = true
+				keep[i] = true
+			}
+		}
+	}
+
+	var expiring *harvesterv1.VirtualMachineBackup
+	if policy.KeepWithin != nil {
+		for i, backup := range sorted {
+			if !getBackupTime(backup).Add(policy.KeepWithin.Duration).After(now) {
+				continue
+			}
+			// the backups are sorted from the newest, the last one kept only by age expires first
+			if !keep[i] {
+				expiring = backup
+			}
+			keep[i] = true
+		}
+	}
+
+	var toPrune []*harvesterv1.VirtualMachineBackup
+	for i, backup := range sorted {
+		if !keep[i] {
+			toPrune = append(toPrune, backup)
+		}
+	}
+	return toPrune, expiring
+}
@@ -0,0 +1,278 @@
+package backup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	kv1 "kubevirt.io/client-go/api/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/fake"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+type fakeBackupController struct {
+	ctlharvesterv1.VirtualMachineBackupController
+}
+
+func (c *fakeBackupController) EnqueueAfter(namespace, name string, duration time.Duration) {}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func newTestReadyBackup(name, vmName string, created time.Time) *harvesterv1.VirtualMachineBackup {
+	return &harvesterv1.VirtualMachineBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      name,
+		},
+		Spec: harvesterv1.VirtualMachineBackupSpec{
+			Source: corev1.TypedLocalObjectReference{
+				Kind: kv1.VirtualMachineGroupVersionKind.Kind,
+				Name: vmName,
+			},
+		},
+		Status: &harvesterv1.VirtualMachineBackupStatus{
+			CreationTime: &metav1.Time{Time: created},
+			ReadyToUse:   pointer.BoolPtr(true),
+		},
+	}
+}
+
+func TestGetBackupsToPrune(t *testing.T) {
+	now := time.Date(2021, 7, 31, 12, 0, 0, 0, time.UTC)
+	// one backup every day at 02:00 in July 2021, and two backups on June 30
+	var backups []*harvesterv1.VirtualMachineBackup
+	for day := 31; day >= 1; day-- {
+		created := time.Date(2021, 7, day, 2, 0, 0, 0, time.UTC)
+		backups = append(backups, newTestReadyBackup(getScheduledBackupName("vm", created), "vm", created))
+	}
+	for _, hour := range []int{1, 2} {
+		created := time.Date(2021, 6, 30, hour, 0, 0, 0, time.UTC)
+		backups = append(backups, newTestReadyBackup(getScheduledBackupName("vm", created), "vm", created))
+	}
+
+	type output struct {
+		kept     []string
+		expiring string
+	}
+	var testCases = []struct {
+		name     string
+		given    *harvesterv1.BackupRetentionPolicy
+		expected output
+	}{
+		{
+			name:  "empty policy keeps all",
+			given: &harvesterv1.BackupRetentionPolicy{},
+			expected: output{
+				kept: func() []string {
+					var names []string
+					for _, backup := range backups {
+						names = append(names, backup.Name)
+					}
+					return names
+				}(),
+			},
+		},
+		{
+			name:  "keep last",
+			given: &harvesterv1.BackupRetentionPolicy{KeepLast: intPtr(2)},
+			expected: output{
+				kept: []string{"vm-20210731-020000", "vm-20210730-020000"},
+			},
+		},
+		{
+			name:  "keep within",
+			given: &harvesterv1.BackupRetentionPolicy{KeepWithin: &metav1.Duration{Duration: 48 * time.Hour}},
+			expected: output{
+				kept:     []string{"vm-20210731-020000", "vm-20210730-020000"},
+				expiring: "vm-20210730-020000",
+			},
+		},
+		{
+			name: "keep within does not report backups kept by other rules",
+			given: &harvesterv1.BackupRetentionPolicy{
+				KeepLast:   intPtr(1),
+				KeepWithin: &metav1.Duration{Duration: 48 * time.Hour},
+			},
+			expected: output{
+				kept:     []string{"vm-20210731-020000", "vm-20210730-020000"},
+				expiring: "vm-20210730-020000",
+			},
+		},
+		{
+			name: "grandfather-father-son",
+			given: &harvesterv1.BackupRetentionPolicy{
+				KeepDaily:   intPtr(3),
+				KeepWeekly:  intPtr(2),
+				KeepMonthly: intPtr(2),
+			},
+			expected: output{
+				// 2021-07-25 is the last backup of the ISO week before the one of 2021-07-31
+				kept: []string{
+					"vm-20210731-020000", "vm-20210730-020000", "vm-20210729-020000",
+					"vm-20210725-020000", "vm-20210630-020000",
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		toPrune, expiring := getBackupsToPrune(backups, tc.given, now)
+		pruned := map[string]bool{}
+		for _, backup := range toPrune {
+			pruned[backup.Name] = true
+		}
+		var actual output
+		for _, backup := range backups {
+			if !pruned[backup.Name] {
+				actual.kept = append(actual.kept, backup.Name)
+			}
+		}
+		if expiring != nil {
+			actual.expiring = expiring.Name
+		}
+		assert.Equal(t, tc.expected, actual, "case %q", tc.name)
+	}
+}
+
+func TestRetentionHandler_OnBackupChange(t *testing.T) {
+	originalCurrentTime := currentTime
+	t.Cleanup(func() { currentTime = originalCurrentTime })
+
+	day := func(d int) time.Time {
+		return time.Date(2021, 7, d, 2, 0, 0, 0, time.UTC)
+	}
+	newTestRestore := func(backupName string, complete bool) *harvesterv1.VirtualMachineRestore {
+		return &harvesterv1.VirtualMachineRestore{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testNamespace,
+				Name:      "restore-" + backupName,
+			},
+			Spec: harvesterv1.VirtualMachineRestoreSpec{
+				VirtualMachineBackupName:      backupName,
+				VirtualMachineBackupNamespace: testNamespace,
+			},
+			Status: &harvesterv1.VirtualMachineRestoreStatus{
+				Complete: pointer.BoolPtr(complete),
+			},
+		}
+	}
+	newTestRetentionVM := func(retention string) *kv1.VirtualMachine {
+		vm := newTestVM("vm", nil)
+		vm.Annotations = map[string]string{BackupRetentionAnnotation: retention}
+		return vm
+	}
+
+	type input struct {
+		backup  *harvesterv1.VirtualMachineBackup
+		objects []runtime.Object
+	}
+	var testCases = []struct {
+		name     string
+		given    input
+		expected []string
+	}{
+		{
+			name: "keep last by the VM annotation",
+			given: input{
+				backup: newTestReadyBackup("vm-3", "vm", day(3)),
+				objects: []runtime.Object{
+					newTestRetentionVM(`{"keepLast":2}`),
+					newTestReadyBackup("vm-1", "vm", day(1)),
+					newTestReadyBackup("vm-2", "vm", day(2)),
+					newTestReadyBackup("other-1", "other", day(1)),
+				},
+			},
+			expected: []string{"other-1", "vm-2", "vm-3"},
+		},
+		{
+			name: "skip the backup used by an in-progress restore",
+			given: input{
+				backup: newTestReadyBackup("vm-3", "vm", day(3)),
+				objects: []runtime.Object{
+					newTestRetentionVM(`{"keepLast":1}`),
+					newTestReadyBackup("vm-1", "vm", day(1)),
+					newTestReadyBackup("vm-2", "vm", day(2)),
+					newTestRestore("vm-1", false),
+					newTestRestore("vm-2", true),
+				},
+			},
+			expected: []string{"vm-1", "vm-3"},
+		},
+		{
+			name: "schedule policy only prunes the backups of the schedule",
+			given: input{
+				backup: func() *harvesterv1.VirtualMachineBackup {
+					backup := newTestReadyBackup("vm-3", "vm", day(3))
+					backup.Labels = map[string]string{BackupScheduleLabel: testScheduleName}
+					return backup
+				}(),
+				objects: []runtime.Object{
+					func() *harvesterv1.VirtualMachineBackupSchedule {
+						schedule := newTestSchedule(testDailyCron, day(1))
+						schedule.Spec.Retention = &harvesterv1.BackupRetentionPolicy{KeepLast: intPtr(1)}
+						return schedule
+					}(),
+					newTestRetentionVM(`{"keepLast":5}`),
+					func() *harvesterv1.VirtualMachineBackup {
+						backup := newTestReadyBackup("vm-2", "vm", day(2))
+						backup.Labels = map[string]string{BackupScheduleLabel: testScheduleName}
+						return backup
+					}(),
+					newTestReadyBackup("vm-1", "vm", day(1)),
+				},
+			},
+			expected: []string{"vm-1", "vm-3"},
+		},
+		{
+			name: "no policy keeps all",
+			given: input{
+				backup: newTestReadyBackup("vm-2", "vm", day(2)),
+				objects: []runtime.Object{
+					newTestVM("vm", nil),
+					newTestReadyBackup("vm-1", "vm", day(1)),
+				},
+			},
+			expected: []string{"vm-1", "vm-2"},
+		},
+	}
+
+	for _, tc := range testCases {
+		currentTime = func() *metav1.Time {
+			t := metav1.NewTime(day(4))
+			return &t
+		}
+
+		var clientset = fake.NewSimpleClientset(append(tc.given.objects, tc.given.backup)...)
+		var handler = &RetentionHandler{
+			vmBackups:          fakeclients.VirtualMachineBackupClient(clientset.HarvesterhciV1beta1().VirtualMachineBackups),
+			vmBackupCache:      fakeclients.VirtualMachineBackupCache(clientset.HarvesterhciV1beta1().VirtualMachineBackups),
+			vmBackupController: &fakeBackupController{},
+			restoreCache:       fakeclients.VirtualMachineRestoreCache(clientset.HarvesterhciV1beta1().VirtualMachineRestores),
+			scheduleCache:      fakeclients.VirtualMachineBackupScheduleCache(clientset.HarvesterhciV1beta1().VirtualMachineBackupSchedules),
+			vmsCache:           fakeclients.VirtualMachineCache(clientset.KubevirtV1().VirtualMachines),
+			recorder:           record.NewFakeRecorder(10),
+		}
+
+		_, err := handler.OnBackupChange(testNamespace+"/"+tc.given.backup.Name, tc.given.backup)
+		assert.Nil(t, err, "case %q", tc.name)
+
+		backups, err := handler.vmBackupCache.List(testNamespace, labels.Everything())
+		assert.Nil(t, err, "case %q", tc.name)
+		var actual []string
+		for _, backup := range backups {
+			actual = append(actual, backup.Name)
+		}
+		assert.Equal(t, tc.expected, actual, "case %q", tc.name)
+	}
+}
@@ -0,0 +1,279 @@
+package backup
+
+// Harvester VM backup schedule controller creates VirtualMachineBackups of the selected VMs periodically
+// according to the cron expression of a VirtualMachineBackupSchedule.
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/record"
+	kv1 "kubevirt.io/client-go/api/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/config"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
+	"github.com/harvester/harvester/pkg/settings"
+)
+
+const (
+	backupScheduleControllerName = "harvester-vm-backup-schedule-controller"
+
+	// BackupScheduleLabel is the label of VirtualMachineBackups created by a VirtualMachineBackupSchedule
+	BackupScheduleLabel = "backup.harvesterhci.io/schedule"
+
+	backupScheduleTimeFormat = "20060102-150405"
+
+	scheduledBackupCreatedEvent = "ScheduledBackupCreated"
+	scheduledBackupSkippedEvent = "ScheduledBackupSkipped"
+	scheduledBackupFailedEvent  = "ScheduledBackupFailed"
+)
+
+// RegisterBackupSchedule register the vmBackupSchedule controller
+func RegisterBackupSchedule(ctx context.Context, management *config.Management, opts config.Options) error {
+	schedules := management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackupSchedule()
+	vmBackups := management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackup()
+	vms := management.VirtFactory.Kubevirt().V1().VirtualMachine()
+	settings := management.HarvesterFactory.Harvesterhci().V1beta1().Setting()
+
+	scheduleHandler := &ScheduleHandler{
+		schedules:          schedules,
+		scheduleController: schedules,
+		vmBackups:          vmBackups,
+		vmBackupCache:      vmBackups.Cache(),
+		vmsCache:           vms.Cache(),
+		settingCache:       settings.Cache(),
+		recorder:           management.NewRecorder(backupScheduleControllerName, "", ""),
+	}
+
+	schedules.OnChange(ctx, backupScheduleControllerName, scheduleHandler.OnScheduleChange)
+	return nil
+}
+
+type ScheduleHandler struct {
+	schedules          ctlharvesterv1.VirtualMachineBackupScheduleClient
+	scheduleController ctlharvesterv1.VirtualMachineBackupScheduleController
+	vmBackups          ctlharvesterv1.VirtualMachineBackupClient
+	vmBackupCache      ctlharvesterv1.VirtualMachineBackupCache
+	vmsCache           ctlkubevirtv1.VirtualMachineCache
+	settingCache       ctlharvesterv1.SettingCache
+	recorder           record.EventRecorder
+}
+
+// OnScheduleChange creates the VM backups when a schedule is due and requeues the schedule until its next run
+func (h *ScheduleHandler) OnScheduleChange(key string, schedule *harvesterv1.VirtualMachineBackupSchedule) (*harvesterv1.VirtualMachineBackupSchedule, error) {
+	if schedule == nil || schedule.DeletionTimestamp != nil {
+		return nil, nil
+	}
+
+	scheduleCpy := schedule.DeepCopy()
+	if schedule.Spec.Suspend {
+		scheduleCpy.Status.NextRunTime = nil
+		return h.updateScheduleStatus(schedule, scheduleCpy)
+	}
+
+	cronSchedule, err := cron.ParseStandard(schedule.Spec.Cron)
+	if err != nil {
+		scheduleCpy.Status.NextRunTime = nil
+		// keep the original error time to avoid updating the schedule on every reconcile
+		scheduleErr := newScheduleError(fmt.Errorf("invalid cron expression %q: %w", schedule.Spec.Cron, err))
+		if lastErr := schedule.Status.LastError; lastErr == nil || lastErr.Message == nil || *lastErr.Message != *scheduleErr.Message {
+			scheduleCpy.Status.LastError = scheduleErr
+		}
+		return h.updateScheduleStatus(schedule, scheduleCpy)
+	}
+
+	now := currentTime()
+	if nextRun := getNextRunTime(schedule, cronSchedule); !nextRun.After(now.Time) {
+		scheduleCpy.Status.LastRunTime = now
+		scheduleCpy.Status.LastBackups, err = h.runSchedule(scheduleCpy, now.Time)
+		scheduleCpy.Status.LastError = newScheduleError(err)
+	}
+
+	nextRun := getNextRunTime(scheduleCpy, cronSchedule)
+	scheduleCpy.Status.NextRunTime = &metav1.Time{Time: nextRun}
+	h.scheduleController.EnqueueAfter(schedule.Namespace, schedule.Name, nextRun.Sub(now.Time))
+
+	return h.updateScheduleStatus(schedule, scheduleCpy)
+}
+
+// getNextRunTime returns the first activation time of the cron schedule after the last run,
+// or after the creation of the schedule if it has never run.
+func getNextRunTime(schedule *harvesterv1.VirtualMachineBackupSchedule, cronSchedule cron.Schedule) time.Time {
+	lastRun := schedule.CreationTimestamp.Time
+	if schedule.Status.LastRunTime != nil {
+		lastRun = schedule.Status.LastRunTime.Time
+	}
+	return cronSchedule.Next(lastRun)
+}
+
+// runSchedule creates a backup for each selected VM and returns the names of the created backups
+func (h *ScheduleHandler) runSchedule(schedule *harvesterv1.VirtualMachineBackupSchedule, now time.Time) ([]string, error) {
+	if err := h.checkBackupTargetConfigured(); err != nil {
+		h.recorder.Event(schedule, corev1.EventTypeWarning, scheduledBackupFailedEvent, err.Error())
+		return nil, err
+	}
+
+	vms, err := h.getScheduledVMs(schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		backupNames []string
+		errs        []string
+	)
+	for _, vm := range vms {
+		inProgress, err := h.isVMBackupInProgress(vm)
+		if err != nil {
+			return backupNames, err
+		}
+		if inProgress {
+			h.recorder.Eventf(schedule, corev1.EventTypeNormal, scheduledBackupSkippedEvent,
+				"Skipped backup of VM %s, the previous backup is still in progress", vm.Name)
+			continue
+		}
+
+		backup, err := h.createVMBackup(schedule, vm, now)
+		if err != nil {
+			h.recorder.Eventf(schedule, corev1.EventTypeWarning, scheduledBackupFailedEvent,
+				"Failed to create backup of VM %s: %s", vm.Name, err.Error())
+			errs = append(errs, fmt.Sprintf("%s: %s", vm.Name, err.Error()))
+			continue
+		}
+		h.recorder.Eventf(schedule, corev1.EventTypeNormal, scheduledBackupCreatedEvent, "Created backup %s of VM %s", backup.Name, vm.Name)
+		backupNames = append(backupNames, backup.Name)
+	}
+
+	if len(errs) > 0 {
+		return backupNames, fmt.Errorf("failed to create backups of VMs %v", errs)
+	}
+	return backupNames, nil
+}
+
+func (h *ScheduleHandler) checkBackupTargetConfigured() error {
+	target, err := h.settingCache.Get(settings.BackupTargetSettingName)
+	if err == nil && harvesterv1.SettingConfigured.IsTrue(target) {
+		return nil
+	}
+	return fmt.Errorf("backup target is invalid")
+}
+
+// getScheduledVMs returns the union of VMs selected by names and by the label selector, sorted by name
+func (h *ScheduleHandler) getScheduledVMs(schedule *harvesterv1.VirtualMachineBackupSchedule) ([]*kv1.VirtualMachine, error) {
+	vmsByName := map[string]*kv1.VirtualMachine{}
+
+	for _, name := range schedule.Spec.VMSelector.Names {
+		vm, err := h.vmsCache.Get(schedule.Namespace, name)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				logrus.Warnf("VM %s/%s selected by backup schedule %s is not found", schedule.Namespace, name, schedule.Name)
+				continue
+			}
+			return nil, err
+		}
+		vmsByName[vm.Name] = vm
+	}
+
+	if schedule.Spec.VMSelector.LabelSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(schedule.Spec.VMSelector.LabelSelector)
+		if err != nil {
+			return nil, err
+		}
+		vms, err := h.vmsCache.List(schedule.Namespace, selector)
+		if err != nil {
+			return nil, err
+		}
+		for _, vm := range vms {
+			vmsByName[vm.Name] = vm
+		}
+	}
+
+	result := make([]*kv1.VirtualMachine, 0, len(vmsByName))
+	for _, vm := range vmsByName {
+		if vm.DeletionTimestamp != nil {
+			continue
+		}
+		result = append(result, vm)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+func (h *ScheduleHandler) isVMBackupInProgress(vm *kv1.VirtualMachine) (bool, error) {
+	backups, err := h.vmBackupCache.List(vm.Namespace, labels.Everything())
+	if err != nil {
+		return false, err
+	}
+
+	for _, backup := range backups {
+		if backup.DeletionTimestamp != nil ||
+			backup.Spec.Source.Kind != kv1.VirtualMachineGroupVersionKind.Kind ||
+			backup.Spec.Source.Name != vm.Name {
+			continue
+		}
+		if isBackupProgressing(backup) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (h *ScheduleHandler) createVMBackup(schedule *harvesterv1.VirtualMachineBackupSchedule, vm *kv1.VirtualMachine, now time.Time) (*harvesterv1.VirtualMachineBackup, error) {
+	apiGroup := kv1.SchemeGroupVersion.Group
+	backup := &harvesterv1.VirtualMachineBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getScheduledBackupName(vm.Name, now),
+			Namespace: vm.Namespace,
+			Labels: map[string]string{
+				BackupScheduleLabel: schedule.Name,
+			},
+		},
+		Spec: harvesterv1.VirtualMachineBackupSpec{
+			Source: corev1.TypedLocalObjectReference{
+				APIGroup: &apiGroup,
+				Kind:     kv1.VirtualMachineGroupVersionKind.Kind,
+				Name:     vm.Name,
+			},
+		},
+	}
+
+	created, err := h.vmBackups.Create(backup)
+	if apierrors.IsAlreadyExists(err) {
+		return backup, nil
+	}
+	return created, err
+}
+
+func getScheduledBackupName(vmName string, t time.Time) string {
+	return fmt.Sprintf("%s-%s", vmName, t.UTC().Format(backupScheduleTimeFormat))
+}
+
+func newScheduleError(err error) *harvesterv1.Error {
+	if err == nil {
+		return nil
+	}
+	message := err.Error()
+	return &harvesterv1.Error{
+		Time:    currentTime(),
+		Message: &message,
+	}
+}
+
+func (h *ScheduleHandler) updateScheduleStatus(schedule, scheduleCpy *harvesterv1.VirtualMachineBackupSchedule) (*harvesterv1.VirtualMachineBackupSchedule, error) {
+	if reflect.DeepEqual(schedule.Status, scheduleCpy.Status) {
+		return schedule, nil
+	}
+	return h.schedules.Update(scheduleCpy)
+}
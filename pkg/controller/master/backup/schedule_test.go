@@ -0,0 +1,274 @@
+package backup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	kv1 "kubevirt.io/client-go/api/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/fake"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/settings"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+const (
+	testNamespace    = "default"
+	testScheduleName = "daily"
+	testDailyCron    = "0 2 * * *"
+)
+
+type fakeScheduleController struct {
+	ctlharvesterv1.VirtualMachineBackupScheduleController
+	enqueueAfter time.Duration
+}
+
+func (c *fakeScheduleController) EnqueueAfter(namespace, name string, duration time.Duration) {
+	c.enqueueAfter = duration
+}
+
+func newTestSchedule(cron string, created time.Time) *harvesterv1.VirtualMachineBackupSchedule {
+	return &harvesterv1.VirtualMachineBackupSchedule{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         testNamespace,
+			Name:              testScheduleName,
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: harvesterv1.VirtualMachineBackupScheduleSpec{
+			Cron: cron,
+			VMSelector: harvesterv1.VirtualMachineSelector{
+				Names: []string{"vm1", "missing"},
+				LabelSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"backup": "daily"},
+				},
+			},
+		},
+	}
+}
+
+func newTestVM(name string, vmLabels map[string]string) *kv1.VirtualMachine {
+	return &kv1.VirtualMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      name,
+			Labels:    vmLabels,
+		},
+	}
+}
+
+func newTestBackupTargetSetting(configured bool) *harvesterv1.Setting {
+	setting := &harvesterv1.Setting{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: settings.BackupTargetSettingName,
+		},
+	}
+	if configured {
+		harvesterv1.SettingConfigured.True(setting)
+	} else {
+		harvesterv1.SettingConfigured.False(setting)
+	}
+	return setting
+}
+
+func newTestInProgressBackup(vmName string) *harvesterv1.VirtualMachineBackup {
+	return &harvesterv1.VirtualMachineBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      vmName + "-manual",
+		},
+		Spec: harvesterv1.VirtualMachineBackupSpec{
+			Source: corev1.TypedLocalObjectReference{
+				Kind: kv1.VirtualMachineGroupVersionKind.Kind,
+				Name: vmName,
+			},
+		},
+		Status: &harvesterv1.VirtualMachineBackupStatus{
+			ReadyToUse: pointer.BoolPtr(false),
+		},
+	}
+}
+
+func TestScheduleHandler_OnScheduleChange(t *testing.T) {
+	originalCurrentTime := currentTime
+	t.Cleanup(func() { currentTime = originalCurrentTime })
+
+	created := time.Date(2021, 7, 1, 1, 0, 0, 0, time.UTC)
+	firstRun := time.Date(2021, 7, 1, 2, 0, 0, 0, time.UTC)
+	secondRun := time.Date(2021, 7, 2, 2, 0, 0, 0, time.UTC)
+
+	type input struct {
+		now      time.Time
+		schedule *harvesterv1.VirtualMachineBackupSchedule
+		objects  []runtime.Object
+	}
+	type output struct {
+		lastRunTime  *metav1.Time
+		nextRunTime  *metav1.Time
+		lastBackups  []string
+		lastError    bool
+		enqueueAfter time.Duration
+		backups      []string
+	}
+
+	var testCases = []struct {
+		name     string
+		given    input
+		expected output
+	}{
+		{
+			name: "schedule is not due",
+			given: input{
+				now:      created.Add(30 * time.Minute),
+				schedule: newTestSchedule(testDailyCron, created),
+				objects: []runtime.Object{
+					newTestBackupTargetSetting(true),
+					newTestVM("vm1", nil),
+				},
+			},
+			expected: output{
+				nextRunTime:  &metav1.Time{Time: firstRun},
+				enqueueAfter: 30 * time.Minute,
+				backups:      []string{},
+			},
+		},
+		{
+			name: "schedule is due",
+			given: input{
+				now:      firstRun,
+				schedule: newTestSchedule(testDailyCron, created),
+				objects: []runtime.Object{
+					newTestBackupTargetSetting(true),
+					newTestVM("vm1", nil),
+					newTestVM("vm2", map[string]string{"backup": "daily"}),
+					newTestVM("vm3", nil),
+				},
+			},
+			expected: output{
+				lastRunTime:  &metav1.Time{Time: firstRun},
+				nextRunTime:  &metav1.Time{Time: secondRun},
+				lastBackups:  []string{"vm1-20210701-020000", "vm2-20210701-020000"},
+				enqueueAfter: 24 * time.Hour,
+				backups:      []string{"vm1-20210701-020000", "vm2-20210701-020000"},
+			},
+		},
+		{
+			name: "skip VM with a backup in progress",
+			given: input{
+				now:      firstRun,
+				schedule: newTestSchedule(testDailyCron, created),
+				objects: []runtime.Object{
+					newTestBackupTargetSetting(true),
+					newTestVM("vm1", nil),
+					newTestVM("vm2", map[string]string{"backup": "daily"}),
+					newTestInProgressBackup("vm1"),
+				},
+			},
+			expected: output{
+				lastRunTime:  &metav1.Time{Time: firstRun},
+				nextRunTime:  &metav1.Time{Time: secondRun},
+				lastBackups:  []string{"vm2-20210701-020000"},
+				enqueueAfter: 24 * time.Hour,
+				backups:      []string{"vm1-manual", "vm2-20210701-020000"},
+			},
+		},
+		{
+			name: "backup target is not configured",
+			given: input{
+				now:      firstRun,
+				schedule: newTestSchedule(testDailyCron, created),
+				objects: []runtime.Object{
+					newTestBackupTargetSetting(false),
+					newTestVM("vm1", nil),
+				},
+			},
+			expected: output{
+				lastRunTime:  &metav1.Time{Time: firstRun},
+				nextRunTime:  &metav1.Time{Time: secondRun},
+				lastError:    true,
+				enqueueAfter: 24 * time.Hour,
+				backups:      []string{},
+			},
+		},
+		{
+			name: "suspended schedule",
+			given: input{
+				now: firstRun,
+				schedule: func() *harvesterv1.VirtualMachineBackupSchedule {
+					schedule := newTestSchedule(testDailyCron, created)
+					schedule.Spec.Suspend = true
+					return schedule
+				}(),
+				objects: []runtime.Object{
+					newTestBackupTargetSetting(true),
+					newTestVM("vm1", nil),
+				},
+			},
+			expected: output{
+				backups: []string{},
+			},
+		},
+		{
+			name: "invalid cron expression",
+			given: input{
+				now:      firstRun,
+				schedule: newTestSchedule("0 2 * *", created),
+				objects: []runtime.Object{
+					newTestBackupTargetSetting(true),
+					newTestVM("vm1", nil),
+				},
+			},
+			expected: output{
+				lastError: true,
+				backups:   []string{},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		now := tc.given.now
+		currentTime = func() *metav1.Time {
+			t := metav1.NewTime(now)
+			return &t
+		}
+
+		var clientset = fake.NewSimpleClientset(append(tc.given.objects, tc.given.schedule)...)
+		var controller = &fakeScheduleController{}
+		var handler = &ScheduleHandler{
+			schedules:          fakeclients.VirtualMachineBackupScheduleClient(clientset.HarvesterhciV1beta1().VirtualMachineBackupSchedules),
+			scheduleController: controller,
+			vmBackups:          fakeclients.VirtualMachineBackupClient(clientset.HarvesterhciV1beta1().VirtualMachineBackups),
+			vmBackupCache:      fakeclients.VirtualMachineBackupCache(clientset.HarvesterhciV1beta1().VirtualMachineBackups),
+			vmsCache:           fakeclients.VirtualMachineCache(clientset.KubevirtV1().VirtualMachines),
+			settingCache:       fakeclients.SettingCache(clientset.HarvesterhciV1beta1().Settings),
+			recorder:           record.NewFakeRecorder(10),
+		}
+
+		schedule, err := handler.OnScheduleChange(testNamespace+"/"+testScheduleName, tc.given.schedule)
+		assert.Nil(t, err, "case %q", tc.name)
+
+		backups, err := handler.vmBackupCache.List(testNamespace, labels.Everything())
+		assert.Nil(t, err, "case %q", tc.name)
+		var backupNames = make([]string, 0, len(backups))
+		for _, backup := range backups {
+			backupNames = append(backupNames, backup.Name)
+		}
+
+		var actual = output{
+			lastRunTime:  schedule.Status.LastRunTime,
+			nextRunTime:  schedule.Status.NextRunTime,
+			lastBackups:  schedule.Status.LastBackups,
+			lastError:    schedule.Status.LastError != nil,
+			enqueueAfter: controller.enqueueAfter,
+			backups:      backupNames,
+		}
+		assert.Equal(t, tc.expected, actual, "case %q", tc.name)
+	}
+}
@@ -0,0 +1,432 @@
+package backup
+
+// Harvester VM snapshot controller takes the longhorn snapshots of the VM volumes and keeps them in the volume replicas
+// together with the VM spec, it doesn't copy any data to the backup target.
+// A stopped VM can be reverted to a ready snapshot through the VM revertToSnapshot action, its volumes are reverted
+// in place by the controller.
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	lhv1beta1 "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
+	"github.com/longhorn/longhorn-manager/types"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	kv1 "kubevirt.io/client-go/api/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/config"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	snapshotControllerName = "harvester-vm-snapshot-controller"
+
+	// the volumes are checked again after the interval until they are attached or detached
+	volumeAttachmentInterval = 5 * time.Second
+
+	vmRevertedEvent     = "VirtualMachineReverted"
+	vmRevertFailedEvent = "VirtualMachineRevertFailed"
+)
+
+// RegisterSnapshot register the vmSnapshot controller
+func RegisterSnapshot(ctx context.Context, management *config.Management, opts config.Options) error {
+	vmSnapshots := management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineSnapshot()
+	pvc := management.CoreFactory.Core().V1().PersistentVolumeClaim()
+	vms := management.VirtFactory.Kubevirt().V1().VirtualMachine()
+	volumes := management.LonghornFactory.Longhorn().V1beta1().Volume()
+
+	vmSnapshotController := &Handler{
+		ctx:                  ctx,
+		vmSnapshots:          vmSnapshots,
+		vmSnapshotCache:      vmSnapshots.Cache(),
+		vmSnapshotController: vmSnapshots,
+		pvcCache:             pvc.Cache(),
+		vms:                  vms,
+		vmsCache:             vms.Cache(),
+		vmController:         vms,
+		volumeCache:          volumes.Cache(),
+		volumes:              volumes,
+		snapshotter:          newLonghornVolumeSnapshotter(ctx),
+		recorder:             management.NewRecorder(snapshotControllerName, "", ""),
+	}
+
+	vmSnapshots.OnChange(ctx, snapshotControllerName, vmSnapshotController.OnSnapshotChange)
+	vmSnapshots.OnRemove(ctx, snapshotControllerName, vmSnapshotController.OnSnapshotRemove)
+	vms.OnChange(ctx, snapshotControllerName, vmSnapshotController.OnVMRevert)
+	return nil
+}
+
+// OnSnapshotChange handles vm snapshot object on change, it stores the VM spec and takes the longhorn snapshots
+// of the VM volumes, and reconciles the vm snapshot status upon the volume snapshots.
+func (h *Handler) OnSnapshotChange(key string, vmSnapshot *harvesterv1.VirtualMachineSnapshot) (*harvesterv1.VirtualMachineSnapshot, error) {
+	if vmSnapshot == nil || vmSnapshot.DeletionTimestamp != nil {
+		return nil, nil
+	}
+
+	vmSnapshotCpy := vmSnapshot.DeepCopy()
+	if vmSnapshotCpy.Status == nil {
+		vmSnapshotCpy.Status = &harvesterv1.VirtualMachineSnapshotStatus{
+			ReadyToUse: pointer.BoolPtr(false),
+		}
+		updateSnapshotCondition(vmSnapshotCpy, newProgressingCondition(corev1.ConditionTrue, "Operation in progress"))
+		updateSnapshotCondition(vmSnapshotCpy, newReadyCondition(corev1.ConditionFalse, "Not ready"))
+	}
+
+	if vmSnapshotCpy.Status.SourceSpec == nil {
+		if isSnapshotError(vmSnapshotCpy) {
+			return nil, nil
+		}
+
+		sourceVM, err := h.getSnapshotSource(vmSnapshotCpy)
+		if apierrors.IsNotFound(err) {
+			return nil, h.setSnapshotError(vmSnapshot, vmSnapshotCpy, "Source does not exist")
+		} else if err != nil {
+			return nil, err
+		}
+
+		// check if the VM is running, if not make sure the volumes are mounted to the host
+		if !sourceVM.Status.Ready || !sourceVM.Status.Created {
+			if err := h.reconcileLonghornVolumes(sourceVM); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := h.updateSnapshotStatusContent(vmSnapshotCpy, sourceVM); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := h.reconcileSnapshotStatus(vmSnapshotCpy); err != nil {
+		return nil, err
+	}
+
+	if !reflect.DeepEqual(vmSnapshot.Status, vmSnapshotCpy.Status) {
+		if _, err := h.vmSnapshots.Update(vmSnapshotCpy); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func (h *Handler) getSnapshotSource(vmSnapshot *harvesterv1.VirtualMachineSnapshot) (*kv1.VirtualMachine, error) {
+	switch vmSnapshot.Spec.Source.Kind {
+	case kv1.VirtualMachineGroupVersionKind.Kind:
+		return h.vmsCache.Get(vmSnapshot.Namespace, vmSnapshot.Spec.Source.Name)
+	}
+
+	return nil, fmt.Errorf("unsupported source: %+v", vmSnapshot.Spec.Source)
+}
+
+// updateSnapshotStatusContent helps to store the snapshot source and volume contents within the VM snapshot status
+func (h *Handler) updateSnapshotStatusContent(vmSnapshot *harvesterv1.VirtualMachineSnapshot, vm *kv1.VirtualMachine) error {
+	sourceVolumes := vm.Spec.Template.Spec.Volumes
+	var volumeSnapshots = make([]harvesterv1.VolumeBackup, 0, len(sourceVolumes))
+
+	for volumeName, pvcName := range volumeToPVCMappings(sourceVolumes) {
+		pvc, err := h.getBackupPVC(vm.Namespace, pvcName)
+		if err != nil {
+			return err
+		}
+
+		volumeSnapshotName := getVMSnapshotVolumeSnapshotName(vmSnapshot.Name, pvcName)
+		volumeSnapshots = append(volumeSnapshots, harvesterv1.VolumeBackup{
+			Name:       &volumeSnapshotName,
+			VolumeName: volumeName,
+			PersistentVolumeClaim: harvesterv1.PersistentVolumeClaimSourceSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        pvc.ObjectMeta.Name,
+					Namespace:   pvc.ObjectMeta.Namespace,
+					Labels:      pvc.Labels,
+					Annotations: pvc.Annotations,
+				},
+				Spec: pvc.Spec,
+			},
+			ReadyToUse: pointer.BoolPtr(false),
+		})
+	}
+
+	vmSnapshot.Status.SourceUID = &vm.UID
+	vmSnapshot.Status.VolumeSnapshots = volumeSnapshots
+	vmSnapshot.Status.SourceSpec = &harvesterv1.VirtualMachineSourceSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        vm.ObjectMeta.Name,
+			Namespace:   vm.ObjectMeta.Namespace,
+			Annotations: vm.ObjectMeta.Annotations,
+			Labels:      vm.ObjectMeta.Labels,
+		},
+		Spec: *vm.Spec.DeepCopy(),
+	}
+	return nil
+}
+
+// reconcileSnapshotStatus takes the longhorn snapshots of the attached volumes and updates the vm snapshot readiness
+// upon them, the vm snapshot is reconciled again until all the volumes are attached.
+func (h *Handler) reconcileSnapshotStatus(vmSnapshot *harvesterv1.VirtualMachineSnapshot) error {
+	if isSnapshotReady(vmSnapshot) || isSnapshotError(vmSnapshot) {
+		return nil
+	}
+
+	var waiting bool
+	for i, volumeSnapshot := range vmSnapshot.Status.VolumeSnapshots {
+		if volumeSnapshot.Name == nil || (volumeSnapshot.ReadyToUse != nil && *volumeSnapshot.ReadyToUse) {
+			continue
+		}
+
+		volumeName := volumeSnapshot.PersistentVolumeClaim.Spec.VolumeName
+		volume, err := h.volumeCache.Get(util.LonghornSystemNamespaceName, volumeName)
+		if apierrors.IsNotFound(err) {
+			vmSnapshot.Status.VolumeSnapshots[i].Error = &harvesterv1.Error{
+				Time:    currentTime(),
+				Message: pointer.StringPtr(fmt.Sprintf("volume %s is not found", volumeName)),
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+		if volume.Status.State != types.VolumeStateAttached {
+			waiting = true
+			continue
+		}
+
+		snapshot, err := h.snapshotter.Get(volumeName, *volumeSnapshot.Name)
+		if err != nil {
+			return err
+		}
+		if snapshot == nil {
+			if err := h.snapshotter.Create(volumeName, *volumeSnapshot.Name); err != nil {
+				return err
+			}
+			h.recorder.Eventf(
+				vmSnapshot,
+				corev1.EventTypeNormal,
+				volumeSnapshotCreateEvent,
+				"Successfully created snapshot %s of volume %s",
+				*volumeSnapshot.Name,
+				volumeName,
+			)
+		}
+		vmSnapshot.Status.VolumeSnapshots[i].ReadyToUse = pointer.BoolPtr(true)
+		vmSnapshot.Status.VolumeSnapshots[i].CreationTime = currentTime()
+	}
+
+	var ready = true
+	var errorMessage = ""
+	for _, vs := range vmSnapshot.Status.VolumeSnapshots {
+		if vs.ReadyToUse == nil || !*vs.ReadyToUse {
+			ready = false
+		}
+
+		if vs.Error != nil {
+			errorMessage = "VolumeSnapshot in error state"
+			break
+		}
+	}
+
+	if errorMessage != "" {
+		vmSnapshot.Status.Error = &harvesterv1.Error{
+			Time:    currentTime(),
+			Message: &errorMessage,
+		}
+		updateSnapshotCondition(vmSnapshot, newProgressingCondition(corev1.ConditionFalse, "In error state"))
+		updateSnapshotCondition(vmSnapshot, newReadyCondition(corev1.ConditionFalse, "Error"))
+	} else if ready {
+		vmSnapshot.Status.CreationTime = currentTime()
+		updateSnapshotCondition(vmSnapshot, newProgressingCondition(corev1.ConditionFalse, "Operation complete"))
+		updateSnapshotCondition(vmSnapshot, newReadyCondition(corev1.ConditionTrue, "Operation complete"))
+	} else if waiting {
+		h.vmSnapshotController.EnqueueAfter(vmSnapshot.Namespace, vmSnapshot.Name, volumeAttachmentInterval)
+	}
+
+	vmSnapshot.Status.ReadyToUse = &ready
+	return nil
+}
+
+func (h *Handler) setSnapshotError(original, vmSnapshot *harvesterv1.VirtualMachineSnapshot, message string) error {
+	vmSnapshot.Status.Error = &harvesterv1.Error{
+		Time:    currentTime(),
+		Message: pointer.StringPtr(message),
+	}
+	updateSnapshotCondition(vmSnapshot, newProgressingCondition(corev1.ConditionFalse, message))
+	updateSnapshotCondition(vmSnapshot, newReadyCondition(corev1.ConditionFalse, "Error"))
+
+	if !reflect.DeepEqual(original.Status, vmSnapshot.Status) {
+		if _, err := h.vmSnapshots.Update(vmSnapshot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OnSnapshotRemove deletes the longhorn snapshots of the removed vm snapshot, the detached volumes are attached
+// to delete their snapshots. The snapshots of the removed volumes are removed together with the volumes.
+func (h *Handler) OnSnapshotRemove(key string, vmSnapshot *harvesterv1.VirtualMachineSnapshot) (*harvesterv1.VirtualMachineSnapshot, error) {
+	if vmSnapshot == nil || vmSnapshot.Status == nil {
+		return nil, nil
+	}
+
+	for _, volumeSnapshot := range vmSnapshot.Status.VolumeSnapshots {
+		if volumeSnapshot.Name == nil {
+			continue
+		}
+
+		volumeName := volumeSnapshot.PersistentVolumeClaim.Spec.VolumeName
+		volume, err := h.volumeCache.Get(util.LonghornSystemNamespaceName, volumeName)
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return vmSnapshot, err
+		}
+		if volume.Status.State != types.VolumeStateAttached {
+			if err := h.attachLonghornVolume(volume); err != nil {
+				return vmSnapshot, err
+			}
+			return vmSnapshot, fmt.Errorf("waiting for volume %s to be attached to delete snapshot %s", volumeName, *volumeSnapshot.Name)
+		}
+
+		snapshot, err := h.snapshotter.Get(volumeName, *volumeSnapshot.Name)
+		if err != nil {
+			return vmSnapshot, err
+		}
+		if snapshot == nil {
+			continue
+		}
+		if err := h.snapshotter.Delete(volumeName, snapshot.Name); err != nil {
+			return vmSnapshot, err
+		}
+
+		h.recorder.Eventf(
+			vmSnapshot,
+			corev1.EventTypeNormal,
+			volumeSnapshotDeleteEvent,
+			"Successfully deleted snapshot %s of volume %s",
+			snapshot.Name,
+			volumeName,
+		)
+	}
+	return vmSnapshot, nil
+}
+
+// OnVMRevert reverts the volumes of the VM annotated by the revertToSnapshot action. The volumes of the stopped VM are
+// attached with the frontend disabled, reverted to the longhorn snapshots in place, and detached once all of them
+// are reverted. The annotation is removed when the revert completes or fails.
+func (h *Handler) OnVMRevert(key string, vm *kv1.VirtualMachine) (*kv1.VirtualMachine, error) {
+	if vm == nil || vm.DeletionTimestamp != nil {
+		return vm, nil
+	}
+	snapshotName, ok := vm.Annotations[util.AnnotationRevertToSnapshot]
+	if !ok {
+		return vm, nil
+	}
+
+	vmSnapshot, err := h.vmSnapshotCache.Get(vm.Namespace, snapshotName)
+	if apierrors.IsNotFound(err) {
+		return h.finishRevert(vm, nil, fmt.Errorf("snapshot %s is not found", snapshotName))
+	} else if err != nil {
+		return vm, err
+	}
+	if !isSnapshotReady(vmSnapshot) {
+		return h.finishRevert(vm, nil, fmt.Errorf("snapshot %s is not ready to use", snapshotName))
+	}
+
+	var volumes []*lhv1beta1.Volume
+	for _, volumeSnapshot := range vmSnapshot.Status.VolumeSnapshots {
+		volumeName := volumeSnapshot.PersistentVolumeClaim.Spec.VolumeName
+		volume, err := h.volumeCache.Get(util.LonghornSystemNamespaceName, volumeName)
+		if apierrors.IsNotFound(err) {
+			return h.finishRevert(vm, volumes, fmt.Errorf("volume %s of snapshot %s is not found", volumeName, snapshotName))
+		} else if err != nil {
+			return vm, err
+		}
+		volumes = append(volumes, volume)
+	}
+
+	attached := true
+	for _, volume := range volumes {
+		if volume.Status.State == types.VolumeStateAttached && volume.Spec.DisableFrontend {
+			continue
+		}
+		attached = false
+
+		volCpy := volume.DeepCopy()
+		switch volume.Status.State {
+		case types.VolumeStateDetached:
+			volCpy.Spec.NodeID = volume.Status.OwnerID
+			volCpy.Spec.DisableFrontend = true
+		case types.VolumeStateAttached:
+			// the frontend can only be disabled when the volume is attached
+			volCpy.Spec.NodeID = ""
+		}
+		if !reflect.DeepEqual(volume, volCpy) {
+			if _, err := h.volumes.Update(volCpy); err != nil {
+				return vm, err
+			}
+		}
+	}
+	if !attached {
+		h.vmController.EnqueueAfter(vm.Namespace, vm.Name, volumeAttachmentInterval)
+		return vm, nil
+	}
+
+	for i, volumeSnapshot := range vmSnapshot.Status.VolumeSnapshots {
+		snapshot, err := h.snapshotter.Get(volumes[i].Name, *volumeSnapshot.Name)
+		if err != nil {
+			return vm, err
+		}
+		if snapshot == nil {
+			return h.finishRevert(vm, volumes, fmt.Errorf("snapshot %s of volume %s is not found", *volumeSnapshot.Name, volumes[i].Name))
+		}
+		if err := h.snapshotter.Revert(volumes[i].Name, snapshot.Name); err != nil {
+			return vm, err
+		}
+	}
+	return h.finishRevert(vm, volumes, nil)
+}
+
+// finishRevert detaches the volumes attached for the revert and removes the revert annotation of the VM
+func (h *Handler) finishRevert(vm *kv1.VirtualMachine, volumes []*lhv1beta1.Volume, revertErr error) (*kv1.VirtualMachine, error) {
+	for _, volume := range volumes {
+		if !volume.Spec.DisableFrontend {
+			continue
+		}
+		volCpy := volume.DeepCopy()
+		volCpy.Spec.NodeID = ""
+		volCpy.Spec.DisableFrontend = false
+		if _, err := h.volumes.Update(volCpy); err != nil {
+			return vm, err
+		}
+	}
+
+	snapshotName := vm.Annotations[util.AnnotationRevertToSnapshot]
+	if revertErr != nil {
+		logrus.Errorf("failed to revert VM %s/%s to snapshot %s: %v", vm.Namespace, vm.Name, snapshotName, revertErr)
+		h.recorder.Eventf(vm, corev1.EventTypeWarning, vmRevertFailedEvent, "Failed to revert to snapshot %s: %v", snapshotName, revertErr)
+	} else {
+		h.recorder.Eventf(vm, corev1.EventTypeNormal, vmRevertedEvent, "Successfully reverted to snapshot %s", snapshotName)
+	}
+
+	vmCpy := vm.DeepCopy()
+	delete(vmCpy.Annotations, util.AnnotationRevertToSnapshot)
+	return h.vms.Update(vmCpy)
+}
+
+func getVMSnapshotVolumeSnapshotName(vmSnapshotName, pvcName string) string {
+	return fmt.Sprintf("%s-snapshot-%s", vmSnapshotName, pvcName)
+}
+
+func isSnapshotReady(vmSnapshot *harvesterv1.VirtualMachineSnapshot) bool {
+	return vmSnapshot.Status != nil && vmSnapshot.Status.ReadyToUse != nil && *vmSnapshot.Status.ReadyToUse
+}
+
+func isSnapshotError(vmSnapshot *harvesterv1.VirtualMachineSnapshot) bool {
+	return vmSnapshot.Status != nil && vmSnapshot.Status.Error != nil
+}
+
+func updateSnapshotCondition(vmSnapshot *harvesterv1.VirtualMachineSnapshot, c harvesterv1.Condition) {
+	vmSnapshot.Status.Conditions = updateCondition(vmSnapshot.Status.Conditions, c, false)
+}
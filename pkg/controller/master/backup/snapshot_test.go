@@ -0,0 +1,445 @@
+package backup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	lhv1beta1 "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
+	"github.com/longhorn/longhorn-manager/types"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	kv1 "kubevirt.io/client-go/api/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/fake"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
+	ctllonghornv1 "github.com/harvester/harvester/pkg/generated/controllers/longhorn.io/v1beta1"
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/util"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+func newTestSnapshotPVC(name, volumeName string) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      name,
+			Annotations: map[string]string{
+				"pv.kubernetes.io/bind-completed": "yes",
+				ref.AnnotationSchemaOwnerKeyName:  "owners",
+				"harvesterhci.io/imageId":         "default/image",
+			},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: pointer.StringPtr("longhorn"),
+			VolumeName:       volumeName,
+		},
+	}
+}
+
+func TestUpdateSnapshotStatusContent(t *testing.T) {
+	clientset := corefake.NewSimpleClientset(
+		newTestSnapshotPVC("vm-rootdisk", "pvc-rootdisk"),
+		newTestSnapshotPVC("vm-unbound", ""),
+	)
+	h := &Handler{
+		pvcCache: fakeclients.PersistentVolumeClaimCache(clientset.CoreV1().PersistentVolumeClaims),
+	}
+
+	newVM := func(claimName string) *kv1.VirtualMachine {
+		return &kv1.VirtualMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testNamespace,
+				Name:      "vm",
+				UID:       "vm-uid",
+			},
+			Spec: kv1.VirtualMachineSpec{
+				Template: &kv1.VirtualMachineInstanceTemplateSpec{
+					Spec: kv1.VirtualMachineInstanceSpec{
+						Volumes: []kv1.Volume{
+							{
+								Name: "rootdisk",
+								VolumeSource: kv1.VolumeSource{
+									PersistentVolumeClaim: &kv1.PersistentVolumeClaimVolumeSource{
+										PersistentVolumeClaimVolumeSource: corev1.PersistentVolumeClaimVolumeSource{ClaimName: claimName},
+									},
+								},
+							},
+							{
+								Name:         "cloudinitdisk",
+								VolumeSource: kv1.VolumeSource{CloudInitNoCloud: &kv1.CloudInitNoCloudSource{}},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+	newSnapshot := func() *harvesterv1.VirtualMachineSnapshot {
+		return &harvesterv1.VirtualMachineSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testNamespace,
+				Name:      "snap",
+			},
+			Status: &harvesterv1.VirtualMachineSnapshotStatus{},
+		}
+	}
+
+	vmSnapshot := newSnapshot()
+	err := h.updateSnapshotStatusContent(vmSnapshot, newVM("vm-rootdisk"))
+	assert.Nil(t, err)
+	assert.Equal(t, "vm-uid", string(*vmSnapshot.Status.SourceUID))
+	assert.Equal(t, "vm", vmSnapshot.Status.SourceSpec.ObjectMeta.Name)
+	if assert.Len(t, vmSnapshot.Status.VolumeSnapshots, 1) {
+		volumeSnapshot := vmSnapshot.Status.VolumeSnapshots[0]
+		assert.Equal(t, "snap-snapshot-vm-rootdisk", *volumeSnapshot.Name)
+		assert.Equal(t, "rootdisk", volumeSnapshot.VolumeName)
+		assert.Equal(t, "vm-rootdisk", volumeSnapshot.PersistentVolumeClaim.ObjectMeta.Name)
+		assert.False(t, *volumeSnapshot.ReadyToUse)
+	}
+
+	vmSnapshot = newSnapshot()
+	err = h.updateSnapshotStatusContent(vmSnapshot, newVM("vm-unbound"))
+	assert.NotNil(t, err, "unbound PVC can't be snapshotted")
+	assert.Nil(t, vmSnapshot.Status.SourceSpec)
+}
+
+// fakeVolumeSnapshotter keeps the snapshots of the volumes in memory
+type fakeVolumeSnapshotter struct {
+	snapshots map[string][]string
+	reverted  map[string]string
+}
+
+func (s *fakeVolumeSnapshotter) Get(volumeName, snapshotName string) (*longhornSnapshot, error) {
+	for _, name := range s.snapshots[volumeName] {
+		if name == snapshotName {
+			return &longhornSnapshot{Name: name}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *fakeVolumeSnapshotter) Create(volumeName, snapshotName string) error {
+	s.snapshots[volumeName] = append(s.snapshots[volumeName], snapshotName)
+	return nil
+}
+
+func (s *fakeVolumeSnapshotter) Delete(volumeName, snapshotName string) error {
+	var snapshots []string
+	for _, name := range s.snapshots[volumeName] {
+		if name != snapshotName {
+			snapshots = append(snapshots, name)
+		}
+	}
+	s.snapshots[volumeName] = snapshots
+	return nil
+}
+
+func (s *fakeVolumeSnapshotter) Revert(volumeName, snapshotName string) error {
+	s.reverted[volumeName] = snapshotName
+	return nil
+}
+
+// fakeVolumeCache serves the longhorn volumes from memory
+type fakeVolumeCache struct {
+	ctllonghornv1.VolumeCache
+	volumes map[string]*lhv1beta1.Volume
+}
+
+func (c *fakeVolumeCache) Get(namespace, name string) (*lhv1beta1.Volume, error) {
+	volume, ok := c.volumes[name]
+	if !ok {
+		return nil, apierrors.NewNotFound(lhv1beta1.Resource("volumes"), name)
+	}
+	return volume, nil
+}
+
+// fakeVolumeClient updates the longhorn volumes of the fakeVolumeCache
+type fakeVolumeClient struct {
+	ctllonghornv1.VolumeClient
+	volumes map[string]*lhv1beta1.Volume
+}
+
+func (c *fakeVolumeClient) Update(volume *lhv1beta1.Volume) (*lhv1beta1.Volume, error) {
+	c.volumes[volume.Name] = volume
+	return volume, nil
+}
+
+type fakeVMController struct {
+	ctlkubevirtv1.VirtualMachineController
+	enqueueAfter time.Duration
+}
+
+func (c *fakeVMController) EnqueueAfter(namespace, name string, duration time.Duration) {
+	c.enqueueAfter = duration
+}
+
+type fakeVMSnapshotController struct {
+	ctlharvesterv1.VirtualMachineSnapshotController
+	enqueueAfter time.Duration
+}
+
+func (c *fakeVMSnapshotController) EnqueueAfter(namespace, name string, duration time.Duration) {
+	c.enqueueAfter = duration
+}
+
+func newTestVolume(name string, state types.VolumeState, nodeID string, disableFrontend bool) *lhv1beta1.Volume {
+	return &lhv1beta1.Volume{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: util.LonghornSystemNamespaceName,
+			Name:      name,
+		},
+		Spec: types.VolumeSpec{
+			NodeID:          nodeID,
+			DisableFrontend: disableFrontend,
+		},
+		Status: types.VolumeStatus{
+			State:   state,
+			OwnerID: "node1",
+		},
+	}
+}
+
+func newTestVolumeSnapshot(name, volumeName string, ready bool) harvesterv1.VolumeBackup {
+	return harvesterv1.VolumeBackup{
+		Name: pointer.StringPtr(name),
+		PersistentVolumeClaim: harvesterv1.PersistentVolumeClaimSourceSpec{
+			Spec: corev1.PersistentVolumeClaimSpec{VolumeName: volumeName},
+		},
+		ReadyToUse: pointer.BoolPtr(ready),
+	}
+}
+
+func TestHandler_reconcileSnapshotStatus(t *testing.T) {
+	originalCurrentTime := currentTime
+	t.Cleanup(func() { currentTime = originalCurrentTime })
+	currentTime = func() *metav1.Time {
+		return &metav1.Time{Time: time.Date(2021, 7, 1, 2, 0, 0, 0, time.UTC)}
+	}
+
+	type output struct {
+		ready        bool
+		err          bool
+		snapshots    map[string][]string
+		enqueueAfter time.Duration
+	}
+	var testCases = []struct {
+		name     string
+		given    []*lhv1beta1.Volume
+		expected output
+	}{
+		{
+			name: "snapshots of the attached volumes are taken",
+			given: []*lhv1beta1.Volume{
+				newTestVolume("pvc-rootdisk", types.VolumeStateAttached, "node1", false),
+				newTestVolume("pvc-datadisk", types.VolumeStateAttached, "node1", false),
+			},
+			expected: output{
+				ready: true,
+				snapshots: map[string][]string{
+					"pvc-rootdisk": {"snap-snapshot-vm-rootdisk"},
+					"pvc-datadisk": {"snap-snapshot-vm-datadisk"},
+				},
+			},
+		},
+		{
+			name: "wait for the volumes to be attached",
+			given: []*lhv1beta1.Volume{
+				newTestVolume("pvc-rootdisk", types.VolumeStateAttached, "node1", false),
+				newTestVolume("pvc-datadisk", types.VolumeStateAttaching, "node1", false),
+			},
+			expected: output{
+				snapshots: map[string][]string{
+					"pvc-rootdisk": {"snap-snapshot-vm-rootdisk"},
+				},
+				enqueueAfter: volumeAttachmentInterval,
+			},
+		},
+		{
+			name: "volume is not found",
+			given: []*lhv1beta1.Volume{
+				newTestVolume("pvc-rootdisk", types.VolumeStateAttached, "node1", false),
+			},
+			expected: output{
+				err: true,
+				snapshots: map[string][]string{
+					"pvc-rootdisk": {"snap-snapshot-vm-rootdisk"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		volumes := map[string]*lhv1beta1.Volume{}
+		for _, volume := range tc.given {
+			volumes[volume.Name] = volume
+		}
+		snapshotter := &fakeVolumeSnapshotter{snapshots: map[string][]string{}}
+		vmSnapshotController := &fakeVMSnapshotController{}
+		h := &Handler{
+			volumeCache:          &fakeVolumeCache{volumes: volumes},
+			snapshotter:          snapshotter,
+			vmSnapshotController: vmSnapshotController,
+			recorder:             record.NewFakeRecorder(10),
+		}
+		vmSnapshot := &harvesterv1.VirtualMachineSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testNamespace,
+				Name:      "snap",
+			},
+			Status: &harvesterv1.VirtualMachineSnapshotStatus{
+				VolumeSnapshots: []harvesterv1.VolumeBackup{
+					newTestVolumeSnapshot("snap-snapshot-vm-rootdisk", "pvc-rootdisk", false),
+					newTestVolumeSnapshot("snap-snapshot-vm-datadisk", "pvc-datadisk", false),
+				},
+			},
+		}
+
+		err := h.reconcileSnapshotStatus(vmSnapshot)
+		assert.Nil(t, err, "case %q", tc.name)
+		assert.Equal(t, tc.expected.ready, isSnapshotReady(vmSnapshot), "case %q", tc.name)
+		assert.Equal(t, tc.expected.err, isSnapshotError(vmSnapshot), "case %q", tc.name)
+		assert.Equal(t, tc.expected.snapshots, snapshotter.snapshots, "case %q", tc.name)
+		assert.Equal(t, tc.expected.enqueueAfter, vmSnapshotController.enqueueAfter, "case %q", tc.name)
+	}
+}
+
+func TestHandler_OnVMRevert(t *testing.T) {
+	vmSnapshot := &harvesterv1.VirtualMachineSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      "snap",
+		},
+		Status: &harvesterv1.VirtualMachineSnapshotStatus{
+			ReadyToUse: pointer.BoolPtr(true),
+			VolumeSnapshots: []harvesterv1.VolumeBackup{
+				newTestVolumeSnapshot("snap-snapshot-vm-rootdisk", "pvc-rootdisk", true),
+			},
+		},
+	}
+	newVM := func(snapshotName string) *kv1.VirtualMachine {
+		return &kv1.VirtualMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   testNamespace,
+				Name:        "vm",
+				Annotations: map[string]string{util.AnnotationRevertToSnapshot: snapshotName},
+			},
+		}
+	}
+
+	type input struct {
+		vm        *kv1.VirtualMachine
+		volume    *lhv1beta1.Volume
+		snapshots map[string][]string
+	}
+	type output struct {
+		volume       *lhv1beta1.Volume
+		reverted     map[string]string
+		reverting    bool
+		enqueueAfter time.Duration
+	}
+	var testCases = []struct {
+		name     string
+		given    input
+		expected output
+	}{
+		{
+			name: "attach the detached volume in maintenance mode",
+			given: input{
+				vm:     newVM("snap"),
+				volume: newTestVolume("pvc-rootdisk", types.VolumeStateDetached, "", false),
+			},
+			expected: output{
+				volume:       newTestVolume("pvc-rootdisk", types.VolumeStateDetached, "node1", true),
+				reverted:     map[string]string{},
+				reverting:    true,
+				enqueueAfter: volumeAttachmentInterval,
+			},
+		},
+		{
+			name: "detach the volume attached with the frontend",
+			given: input{
+				vm:     newVM("snap"),
+				volume: newTestVolume("pvc-rootdisk", types.VolumeStateAttached, "node1", false),
+			},
+			expected: output{
+				volume:       newTestVolume("pvc-rootdisk", types.VolumeStateAttached, "", false),
+				reverted:     map[string]string{},
+				reverting:    true,
+				enqueueAfter: volumeAttachmentInterval,
+			},
+		},
+		{
+			name: "revert the volume attached in maintenance mode and detach it",
+			given: input{
+				vm:        newVM("snap"),
+				volume:    newTestVolume("pvc-rootdisk", types.VolumeStateAttached, "node1", true),
+				snapshots: map[string][]string{"pvc-rootdisk": {"snap-snapshot-vm-rootdisk"}},
+			},
+			expected: output{
+				volume:   newTestVolume("pvc-rootdisk", types.VolumeStateAttached, "", false),
+				reverted: map[string]string{"pvc-rootdisk": "snap-snapshot-vm-rootdisk"},
+			},
+		},
+		{
+			name: "snapshot of the volume is not found",
+			given: input{
+				vm:     newVM("snap"),
+				volume: newTestVolume("pvc-rootdisk", types.VolumeStateAttached, "node1", true),
+			},
+			expected: output{
+				volume:   newTestVolume("pvc-rootdisk", types.VolumeStateAttached, "", false),
+				reverted: map[string]string{},
+			},
+		},
+		{
+			name: "vm snapshot is not found",
+			given: input{
+				vm:     newVM("unknown"),
+				volume: newTestVolume("pvc-rootdisk", types.VolumeStateDetached, "", false),
+			},
+			expected: output{
+				volume:   newTestVolume("pvc-rootdisk", types.VolumeStateDetached, "", false),
+				reverted: map[string]string{},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		clientset := fake.NewSimpleClientset(vmSnapshot, tc.given.vm)
+		volumes := map[string]*lhv1beta1.Volume{tc.given.volume.Name: tc.given.volume}
+		snapshots := tc.given.snapshots
+		if snapshots == nil {
+			snapshots = map[string][]string{}
+		}
+		snapshotter := &fakeVolumeSnapshotter{snapshots: snapshots, reverted: map[string]string{}}
+		vmController := &fakeVMController{}
+		h := &Handler{
+			vmSnapshotCache: fakeclients.VirtualMachineSnapshotCache(clientset.HarvesterhciV1beta1().VirtualMachineSnapshots),
+			vms:             fakeclients.VirtualMachineClient(clientset.KubevirtV1().VirtualMachines),
+			vmController:    vmController,
+			volumeCache:     &fakeVolumeCache{volumes: volumes},
+			volumes:         &fakeVolumeClient{volumes: volumes},
+			snapshotter:     snapshotter,
+			recorder:        record.NewFakeRecorder(10),
+		}
+
+		_, err := h.OnVMRevert("", tc.given.vm)
+		assert.Nil(t, err, "case %q", tc.name)
+		assert.Equal(t, tc.expected.volume, volumes[tc.given.volume.Name], "case %q", tc.name)
+		assert.Equal(t, tc.expected.reverted, snapshotter.reverted, "case %q", tc.name)
+		assert.Equal(t, tc.expected.enqueueAfter, vmController.enqueueAfter, "case %q", tc.name)
+
+		vm, err := clientset.KubevirtV1().VirtualMachines(testNamespace).Get(context.TODO(), "vm", metav1.GetOptions{})
+		assert.Nil(t, err, "case %q", tc.name)
+		_, reverting := vm.Annotations[util.AnnotationRevertToSnapshot]
+		assert.Equal(t, tc.expected.reverting, reverting, "case %q", tc.name)
+	}
+}
@@ -16,6 +16,7 @@ import (
 
 // backingImageHandler syncs upload progress from backing image to vm image status
 type backingImageHandler struct {
+	verifier          *checksumVerifier
 	vmImages          ctlharvesterv1beta1.VirtualMachineImageClient
 	vmImageCache      ctlharvesterv1beta1.VirtualMachineImageCache
 	backingImages     ctllhv1beta1.BackingImageClient
@@ -52,6 +53,12 @@ func (h *backingImageHandler) OnChanged(_ string, backingImage *lhv1beta1.Backin
 			harvesterv1beta1.ImageImported.Message(toUpdate, status.Message)
 			toUpdate.Status.Progress = status.Progress
 			toUpdate.Status.Size = backingImage.Status.Size
+			if status.State == types.BackingImageStateReady && needsVerification(toUpdate) {
+				h.verifier.verify(toUpdate, backingImage)
+			}
+		} else if isDownloading(toUpdate) || isUploading(toUpdate) {
+			// the progress is of the transfer to Harvester until the file is uploaded to the backing image
+			continue
 		} else if status.Progress != toUpdate.Status.Progress {
 			harvesterv1beta1.ImageImported.Unknown(toUpdate)
 			harvesterv1beta1.ImageImported.Reason(toUpdate, "Importing")
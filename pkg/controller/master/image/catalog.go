@@ -0,0 +1,294 @@
+package image
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	defaultCatalogSyncInterval = 24 * time.Hour
+	catalogSyncRetryInterval   = 5 * time.Minute
+	catalogFeedTimeout         = time.Minute
+)
+
+var invalidLabelValueChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// imageCatalogHandler syncs the image catalog feeds, it creates the VirtualMachineImages of the new builds matching
+// the catalog filters and deletes the unused images of the builds older than the latest ones to keep
+type imageCatalogHandler struct {
+	catalogs   ctlharvesterv1.ImageCatalogController
+	images     ctlharvesterv1.VirtualMachineImageClient
+	imageCache ctlharvesterv1.VirtualMachineImageCache
+	feedClient func() (*http.Client, error)
+}
+
+func (h *imageCatalogHandler) OnChanged(_ string, catalog *harvesterv1.ImageCatalog) (*harvesterv1.ImageCatalog, error) {
+	if catalog == nil || catalog.DeletionTimestamp != nil {
+		return catalog, nil
+	}
+
+	// the feed is synced again after the sync interval, or after the retry interval if the last sync failed
+	interval := getCatalogSyncInterval(catalog)
+	if lastSync := catalog.Status.LastSyncTime; lastSync != nil && reflect.DeepEqual(catalog.Status.AppliedSpec, &catalog.Spec) {
+		if !harvesterv1.ImageCatalogSynced.IsTrue(catalog) {
+			interval = catalogSyncRetryInterval
+		}
+		if remaining := time.Until(lastSync.Add(interval)); remaining > 0 {
+			h.catalogs.EnqueueAfter(catalog.Namespace, catalog.Name, remaining)
+			return catalog, nil
+		}
+	}
+
+	toUpdate := catalog.DeepCopy()
+	images, err := h.sync(catalog)
+	if err != nil {
+		logrus.Errorf("failed to sync image catalog %s/%s: %v", catalog.Namespace, catalog.Name, err)
+		interval = catalogSyncRetryInterval
+	} else {
+		toUpdate.Status.Images = images
+	}
+	now := metav1.Now()
+	toUpdate.Status.LastSyncTime = &now
+	toUpdate.Status.AppliedSpec = catalog.Spec.DeepCopy()
+	harvesterv1.ImageCatalogSynced.SetError(toUpdate, "", err)
+	if _, err := h.catalogs.Update(toUpdate); err != nil {
+		return catalog, err
+	}
+	h.catalogs.EnqueueAfter(catalog.Namespace, catalog.Name, interval)
+	return catalog, nil
+}
+
+// sync creates the images of the builds to keep, the other images of the catalog are deleted unless they are in use
+// or the catalog keeps all builds. It returns the names of the remaining images.
+func (h *imageCatalogHandler) sync(catalog *harvesterv1.ImageCatalog) ([]string, error) {
+	client, err := h.feedClient()
+	if err != nil {
+		return nil, err
+	}
+	builds, err := (&catalogFeedReader{client: client}).read(catalog.Spec)
+	if err != nil {
+		return nil, err
+	}
+	builds = selectCatalogBuilds(catalog.Spec, builds)
+
+	kept := map[string]bool{}
+	for _, build := range builds {
+		image, err := h.ensureImage(catalog, build)
+		if err != nil {
+			return nil, err
+		}
+		kept[image.Name] = true
+	}
+
+	images, err := h.imageCache.List(catalog.Namespace, labels.SelectorFromSet(labels.Set{
+		util.LabelImageCatalog: getCatalogLabelValue(catalog),
+	}))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, image := range images {
+		if kept[image.Name] || image.DeletionTimestamp != nil {
+			continue
+		}
+		if usage := image.Status.Usage; catalog.Spec.KeepLatest == 0 || usage != nil && isImageUsed(usage) {
+			names = append(names, image.Name)
+			continue
+		}
+		logrus.Infof("deleting image %s/%s of an outdated build of catalog %s", image.Namespace, image.Name, catalog.Name)
+		if err := h.images.Delete(image.Namespace, image.Name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+	for name := range kept {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ensureImage creates the image of the build, or updates the labels and the missing OS info of the existing one
+func (h *imageCatalogHandler) ensureImage(catalog *harvesterv1.ImageCatalog, build catalogBuild) (*harvesterv1.VirtualMachineImage, error) {
+	name := getCatalogImageName(catalog, build)
+	imageLabels := map[string]string{
+		util.LabelImageCatalog:   getCatalogLabelValue(catalog),
+		util.LabelImageOS:        toLabelValue(build.OS),
+		util.LabelImageOSRelease: toLabelValue(build.Release),
+		util.LabelImageArch:      toLabelValue(build.Arch),
+		util.LabelImageBuild:     toLabelValue(build.Version),
+	}
+
+	image, err := h.imageCache.Get(catalog.Namespace, name)
+	if errors.IsNotFound(err) {
+		return h.images.Create(&harvesterv1.VirtualMachineImage{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: catalog.Namespace,
+				Labels:    imageLabels,
+			},
+			Spec: harvesterv1.VirtualMachineImageSpec{
+				DisplayName: getCatalogImageDisplayName(build),
+				Description: build.Title,
+				SourceType:  harvesterv1.VirtualMachineImageSourceTypeDownload,
+				URL:         build.URL,
+				Checksum:    build.Checksum,
+				OSInfo:      getCatalogImageOSInfo(build),
+			},
+		})
+	} else if err != nil {
+		return nil, err
+	}
+
+	toUpdate := image.DeepCopy()
+	if toUpdate.Labels == nil {
+		toUpdate.Labels = map[string]string{}
+	}
+	for key, value := range imageLabels {
+		toUpdate.Labels[key] = value
+	}
+	if toUpdate.Spec.OSInfo == nil {
+		toUpdate.Spec.OSInfo = getCatalogImageOSInfo(build)
+	}
+	if reflect.DeepEqual(image, toUpdate) {
+		return image, nil
+	}
+	return h.images.Update(toUpdate)
+}
+
+// getCatalogImageOSInfo returns the OS info of the build, the OS version is the release if the feed doesn't tell it.
+// The catalog images are cloud images which have the virtio drivers.
+func getCatalogImageOSInfo(build catalogBuild) *harvesterv1.VirtualMachineImageOSInfo {
+	osInfo := &harvesterv1.VirtualMachineImageOSInfo{
+		Family:       harvesterv1.VirtualMachineImageOSFamilyLinux,
+		Version:      build.OSVersion,
+		Architecture: util.NormalizeArchitecture(build.Arch),
+	}
+	if strings.Contains(strings.ToLower(build.OS), harvesterv1.VirtualMachineImageOSFamilyWindows) {
+		osInfo.Family = harvesterv1.VirtualMachineImageOSFamilyWindows
+	}
+	if osInfo.Version == "" {
+		osInfo.Version = build.Release
+	}
+	return osInfo
+}
+
+// selectCatalogBuilds returns the builds matching the catalog filters, only the latest builds of each OS release
+// and architecture are returned if the catalog keeps the latest builds
+func selectCatalogBuilds(spec harvesterv1.ImageCatalogSpec, builds []catalogBuild) []catalogBuild {
+	groups := map[string][]catalogBuild{}
+	for _, build := range builds {
+		if !matchCatalogFilter(spec.OS, build.OS) || !matchCatalogFilter(spec.Releases, build.Release) ||
+			!matchCatalogFilter(spec.Arches, build.Arch) {
+			continue
+		}
+		key := strings.Join([]string{build.OS, build.Release, build.Arch}, "/")
+		groups[key] = append(groups[key], build)
+	}
+
+	var selected []catalogBuild
+	for _, group := range groups {
+		// the build versions are serials like 20211021 or 20211021.1, the latest sorts first
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].Version > group[j].Version
+		})
+		if spec.KeepLatest > 0 && len(group) > spec.KeepLatest {
+			group = group[:spec.KeepLatest]
+		}
+		selected = append(selected, group...)
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		return getCatalogImageDisplayName(selected[i]) < getCatalogImageDisplayName(selected[j])
+	})
+	return selected
+}
+
+func matchCatalogFilter(filter []string, value string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, f := range filter {
+		if strings.EqualFold(f, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// getCatalogImageName returns the image name of the build, it is derived from the build so that the images
+// are not created twice
+func getCatalogImageName(catalog *harvesterv1.ImageCatalog, build catalogBuild) string {
+	key := strings.Join([]string{build.OS, build.Release, build.Arch, build.Version}, "/")
+	return fmt.Sprintf("%s-%x", catalog.Name, sha256.Sum256([]byte(key)))[:len(catalog.Name)+11]
+}
+
+func getCatalogImageDisplayName(build catalogBuild) string {
+	parts := []string{build.OS}
+	for _, part := range []string{build.Release, build.Arch, build.Version} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// getCatalogLabelValue returns the catalog label value of the images, the names longer than a label value are
+// truncated and suffixed with their hash to stay unique
+func getCatalogLabelValue(catalog *harvesterv1.ImageCatalog) string {
+	if len(catalog.Name) <= validation.LabelValueMaxLength {
+		return catalog.Name
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(catalog.Name)))[:10]
+	prefix := strings.TrimRight(catalog.Name[:validation.LabelValueMaxLength-len(hash)-1], "-.")
+	return prefix + "-" + hash
+}
+
+// toLabelValue replaces the characters not allowed in a label value
+func toLabelValue(value string) string {
+	if len(validation.IsValidLabelValue(value)) == 0 {
+		return value
+	}
+	value = invalidLabelValueChars.ReplaceAllString(value, "-")
+	if len(value) > validation.LabelValueMaxLength {
+		value = value[:validation.LabelValueMaxLength]
+	}
+	return strings.Trim(value, "-_.")
+}
+
+func getCatalogSyncInterval(catalog *harvesterv1.ImageCatalog) time.Duration {
+	if catalog.Spec.SyncInterval == nil || catalog.Spec.SyncInterval.Duration <= 0 {
+		return defaultCatalogSyncInterval
+	}
+	return catalog.Spec.SyncInterval.Duration
+}
+
+// getCatalogFeedClient returns the client to fetch the catalog feeds through the proxy of the http-proxy setting
+func getCatalogFeedClient() (*http.Client, error) {
+	proxy, err := util.GetHTTPProxyConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy != nil {
+		transport.Proxy = util.ProxyFunc(proxy)
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   catalogFeedTimeout,
+	}, nil
+}
@@ -0,0 +1,224 @@
+package image
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+)
+
+const (
+	simpleStreamsIndexFormat    = "index:1.0"
+	simpleStreamsProductsFormat = "products:1.0"
+	simpleStreamsImageDownloads = "image-downloads"
+	simpleStreamsPathPrefix     = "streams/v1/"
+)
+
+// simpleStreamsFileTypes are the file types of the disk images in a simplestreams feed, in the order of preference
+var simpleStreamsFileTypes = []string{"disk1.img", "disk-kvm.img", "qcow2", "disk.img", "img"}
+
+// catalogBuild is a build of an OS image in a catalog feed
+type catalogBuild struct {
+	OS        string `json:"os"`
+	Release   string `json:"release"`
+	Arch      string `json:"arch"`
+	Version   string `json:"version"`
+	OSVersion string `json:"osVersion,omitempty"`
+	Title     string `json:"title,omitempty"`
+	URL       string `json:"url"`
+	Checksum  string `json:"checksum,omitempty"`
+}
+
+// catalogManifest is the YAML manifest feed listing the image builds, the relative URLs are resolved against the manifest URL
+type catalogManifest struct {
+	Images []catalogBuild `json:"images"`
+}
+
+type simpleStreamsIndex struct {
+	Format string                             `json:"format"`
+	Index  map[string]simpleStreamsIndexEntry `json:"index"`
+}
+
+type simpleStreamsIndexEntry struct {
+	DataType string `json:"datatype"`
+	Format   string `json:"format"`
+	Path     string `json:"path"`
+}
+
+type simpleStreamsProducts struct {
+	Format   string                          `json:"format"`
+	Products map[string]simpleStreamsProduct `json:"products"`
+}
+
+type simpleStreamsProduct struct {
+	OS           string                                 `json:"os"`
+	Release      string                                 `json:"release"`
+	ReleaseTitle string                                 `json:"release_title"`
+	Version      string                                 `json:"version"`
+	Arch         string                                 `json:"arch"`
+	Versions     map[string]simpleStreamsProductVersion `json:"versions"`
+}
+
+type simpleStreamsProductVersion struct {
+	Items map[string]simpleStreamsItem `json:"items"`
+}
+
+type simpleStreamsItem struct {
+	FileType string `json:"ftype"`
+	Path     string `json:"path"`
+	SHA256   string `json:"sha256"`
+}
+
+// catalogFeedReader fetches the builds of the catalog feeds
+type catalogFeedReader struct {
+	client *http.Client
+}
+
+func (r *catalogFeedReader) read(spec harvesterv1.ImageCatalogSpec) ([]catalogBuild, error) {
+	switch spec.Format {
+	case harvesterv1.ImageCatalogFormatSimpleStreams:
+		return r.readSimpleStreams(spec.URL)
+	case harvesterv1.ImageCatalogFormatManifest:
+		return r.readManifest(spec.URL)
+	default:
+		return nil, fmt.Errorf("unsupported catalog format %q", spec.Format)
+	}
+}
+
+func (r *catalogFeedReader) readManifest(manifestURL string) ([]catalogBuild, error) {
+	data, err := r.get(manifestURL)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &catalogManifest{}
+	if err := yaml.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", manifestURL, err)
+	}
+
+	builds := make([]catalogBuild, 0, len(manifest.Images))
+	for _, build := range manifest.Images {
+		if build.OS == "" || build.Version == "" || build.URL == "" {
+			return nil, fmt.Errorf("invalid image in manifest %s: os, version and url are required", manifestURL)
+		}
+		if build.URL, err = resolveURL(manifestURL, build.URL); err != nil {
+			return nil, err
+		}
+		builds = append(builds, build)
+	}
+	return builds, nil
+}
+
+// readSimpleStreams reads the image-downloads products of a simplestreams index, the paths of a simplestreams
+// mirror are relative to the parent of the streams/v1 directory
+func (r *catalogFeedReader) readSimpleStreams(indexURL string) ([]catalogBuild, error) {
+	data, err := r.get(indexURL)
+	if err != nil {
+		return nil, err
+	}
+	index := &simpleStreamsIndex{}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("failed to parse simplestreams index %s: %w", indexURL, err)
+	}
+	if index.Format != simpleStreamsIndexFormat {
+		return nil, fmt.Errorf("unsupported simplestreams index format %q", index.Format)
+	}
+
+	mirrorURL := indexURL
+	if i := strings.LastIndex(indexURL, simpleStreamsPathPrefix); i >= 0 {
+		mirrorURL = indexURL[:i]
+	}
+
+	var builds []catalogBuild
+	for _, entry := range index.Index {
+		if entry.DataType != simpleStreamsImageDownloads || entry.Format != simpleStreamsProductsFormat {
+			continue
+		}
+		productsURL, err := resolveURL(mirrorURL, entry.Path)
+		if err != nil {
+			return nil, err
+		}
+		productBuilds, err := r.readSimpleStreamsProducts(mirrorURL, productsURL)
+		if err != nil {
+			return nil, err
+		}
+		builds = append(builds, productBuilds...)
+	}
+	return builds, nil
+}
+
+func (r *catalogFeedReader) readSimpleStreamsProducts(mirrorURL, productsURL string) ([]catalogBuild, error) {
+	data, err := r.get(productsURL)
+	if err != nil {
+		return nil, err
+	}
+	products := &simpleStreamsProducts{}
+	if err := json.Unmarshal(data, products); err != nil {
+		return nil, fmt.Errorf("failed to parse simplestreams products %s: %w", productsURL, err)
+	}
+
+	var builds []catalogBuild
+	for _, product := range products.Products {
+		for version, productVersion := range product.Versions {
+			item, ok := getSimpleStreamsDiskImage(productVersion.Items)
+			if !ok {
+				continue
+			}
+			imageURL, err := resolveURL(mirrorURL, item.Path)
+			if err != nil {
+				return nil, err
+			}
+			builds = append(builds, catalogBuild{
+				OS:        product.OS,
+				Release:   product.Release,
+				Arch:      product.Arch,
+				Version:   version,
+				OSVersion: product.Version,
+				Title:     product.ReleaseTitle,
+				URL:       imageURL,
+				Checksum:  item.SHA256,
+			})
+		}
+	}
+	return builds, nil
+}
+
+func getSimpleStreamsDiskImage(items map[string]simpleStreamsItem) (simpleStreamsItem, bool) {
+	for _, fileType := range simpleStreamsFileTypes {
+		for _, item := range items {
+			if item.FileType == fileType {
+				return item, true
+			}
+		}
+	}
+	return simpleStreamsItem{}, false
+}
+
+func (r *catalogFeedReader) get(feedURL string) ([]byte, error) {
+	resp, err := r.client.Get(feedURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get %s: unexpected status %s", feedURL, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+func resolveURL(base, ref string) (string, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return baseURL.ResolveReference(refURL).String(), nil
+}
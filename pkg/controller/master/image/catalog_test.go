@@ -0,0 +1,346 @@
+package image
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/fake"
+	"github.com/harvester/harvester/pkg/util"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+const (
+	testSimpleStreamsIndex = `{
+  "format": "index:1.0",
+  "index": {
+    "com.ubuntu.cloud:released:download": {
+      "datatype": "image-downloads",
+      "format": "products:1.0",
+      "path": "streams/v1/com.ubuntu.cloud:released:download.json"
+    },
+    "com.ubuntu.cloud:released:aws": {
+      "datatype": "image-ids",
+      "format": "products:1.0",
+      "path": "streams/v1/com.ubuntu.cloud:released:aws.json"
+    }
+  }
+}`
+	testSimpleStreamsProducts = `{
+  "format": "products:1.0",
+  "products": {
+    "com.ubuntu.cloud:server:20.04:amd64": {
+      "os": "ubuntu",
+      "release": "focal",
+      "release_title": "20.04 LTS",
+      "version": "20.04",
+      "arch": "amd64",
+      "versions": {
+        "20211001": {
+          "items": {
+            "disk1.img": {"ftype": "disk1.img", "path": "server/releases/focal/release-20211001/ubuntu-20.04-server-cloudimg-amd64.img", "sha256": "aaaa"},
+            "manifest": {"ftype": "manifest", "path": "server/releases/focal/release-20211001/ubuntu-20.04-server-cloudimg-amd64.manifest"}
+          }
+        },
+        "20211021": {
+          "items": {
+            "disk1.img": {"ftype": "disk1.img", "path": "server/releases/focal/release-20211021/ubuntu-20.04-server-cloudimg-amd64.img", "sha256": "bbbb"}
+          }
+        },
+        "20210901": {
+          "items": {
+            "manifest": {"ftype": "manifest", "path": "server/releases/focal/release-20210901/ubuntu-20.04-server-cloudimg-amd64.manifest"}
+          }
+        }
+      }
+    },
+    "com.ubuntu.cloud:server:20.04:arm64": {
+      "os": "ubuntu",
+      "release": "focal",
+      "release_title": "20.04 LTS",
+      "version": "20.04",
+      "arch": "arm64",
+      "versions": {
+        "20211021": {
+          "items": {
+            "disk1.img": {"ftype": "disk1.img", "path": "server/releases/focal/release-20211021/ubuntu-20.04-server-cloudimg-arm64.img", "sha256": "cccc"}
+          }
+        }
+      }
+    }
+  }
+}`
+	testManifest = `images:
+- os: opensuse
+  release: "15.3"
+  arch: x86_64
+  version: "2.5.0"
+  url: openSUSE-Leap-15.3.x86_64-2.5.0-NoCloud.qcow2
+  checksum: dddd
+- os: rocky
+  release: "8"
+  arch: x86_64
+  version: "8.4-20210620.0"
+  url: https://download.rockylinux.org/pub/rocky/8/images/Rocky-8-GenericCloud-8.4-20210620.0.x86_64.qcow2
+`
+)
+
+func newTestCatalogServer() *httptest.Server {
+	files := map[string]string{
+		"/streams/v1/index.json":                              testSimpleStreamsIndex,
+		"/streams/v1/com.ubuntu.cloud:released:download.json": testSimpleStreamsProducts,
+		"/images/manifest.yaml":                               testManifest,
+	}
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		content, ok := files[req.URL.Path]
+		if !ok {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = rw.Write([]byte(content))
+	}))
+}
+
+func TestCatalogFeedReader(t *testing.T) {
+	server := newTestCatalogServer()
+	defer server.Close()
+
+	var testCases = []struct {
+		name     string
+		spec     harvesterv1.ImageCatalogSpec
+		expected []catalogBuild
+	}{
+		{
+			name: "simplestreams",
+			spec: harvesterv1.ImageCatalogSpec{
+				URL:    server.URL + "/streams/v1/index.json",
+				Format: harvesterv1.ImageCatalogFormatSimpleStreams,
+			},
+			expected: []catalogBuild{
+				{
+					OS: "ubuntu", Release: "focal", Arch: "amd64", Version: "20211001", OSVersion: "20.04", Title: "20.04 LTS", Checksum: "aaaa",
+					URL: server.URL + "/server/releases/focal/release-20211001/ubuntu-20.04-server-cloudimg-amd64.img",
+				},
+				{
+					OS: "ubuntu", Release: "focal", Arch: "amd64", Version: "20211021", OSVersion: "20.04", Title: "20.04 LTS", Checksum: "bbbb",
+					URL: server.URL + "/server/releases/focal/release-20211021/ubuntu-20.04-server-cloudimg-amd64.img",
+				},
+				{
+					OS: "ubuntu", Release: "focal", Arch: "arm64", Version: "20211021", OSVersion: "20.04", Title: "20.04 LTS", Checksum: "cccc",
+					URL: server.URL + "/server/releases/focal/release-20211021/ubuntu-20.04-server-cloudimg-arm64.img",
+				},
+			},
+		},
+		{
+			name: "manifest",
+			spec: harvesterv1.ImageCatalogSpec{
+				URL:    server.URL + "/images/manifest.yaml",
+				Format: harvesterv1.ImageCatalogFormatManifest,
+			},
+			expected: []catalogBuild{
+				{
+					OS: "opensuse", Release: "15.3", Arch: "x86_64", Version: "2.5.0", Checksum: "dddd",
+					URL: server.URL + "/images/openSUSE-Leap-15.3.x86_64-2.5.0-NoCloud.qcow2",
+				},
+				{
+					OS: "rocky", Release: "8", Arch: "x86_64", Version: "8.4-20210620.0",
+					URL: "https://download.rockylinux.org/pub/rocky/8/images/Rocky-8-GenericCloud-8.4-20210620.0.x86_64.qcow2",
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		actual, err := (&catalogFeedReader{client: server.Client()}).read(tc.spec)
+		assert.Nil(t, err, "case %q", tc.name)
+		assert.ElementsMatch(t, tc.expected, actual, "case %q", tc.name)
+	}
+
+	_, err := (&catalogFeedReader{client: server.Client()}).read(harvesterv1.ImageCatalogSpec{
+		URL:    server.URL + "/not-found.yaml",
+		Format: harvesterv1.ImageCatalogFormatManifest,
+	})
+	assert.NotNil(t, err)
+}
+
+func TestSelectCatalogBuilds(t *testing.T) {
+	builds := []catalogBuild{
+		{OS: "ubuntu", Release: "focal", Arch: "amd64", Version: "20211001"},
+		{OS: "ubuntu", Release: "focal", Arch: "amd64", Version: "20211021"},
+		{OS: "ubuntu", Release: "focal", Arch: "amd64", Version: "20210901"},
+		{OS: "ubuntu", Release: "focal", Arch: "arm64", Version: "20211021"},
+		{OS: "ubuntu", Release: "bionic", Arch: "amd64", Version: "20211020"},
+	}
+
+	var testCases = []struct {
+		name     string
+		spec     harvesterv1.ImageCatalogSpec
+		expected []string
+	}{
+		{
+			name: "all builds",
+			expected: []string{
+				"ubuntu-bionic-amd64-20211020",
+				"ubuntu-focal-amd64-20210901",
+				"ubuntu-focal-amd64-20211001",
+				"ubuntu-focal-amd64-20211021",
+				"ubuntu-focal-arm64-20211021",
+			},
+		},
+		{
+			name: "filter by release and arch",
+			spec: harvesterv1.ImageCatalogSpec{
+				Releases: []string{"focal"},
+				Arches:   []string{"AMD64"},
+			},
+			expected: []string{
+				"ubuntu-focal-amd64-20210901",
+				"ubuntu-focal-amd64-20211001",
+				"ubuntu-focal-amd64-20211021",
+			},
+		},
+		{
+			name: "keep the latest builds",
+			spec: harvesterv1.ImageCatalogSpec{
+				OS:         []string{"ubuntu"},
+				KeepLatest: 2,
+			},
+			expected: []string{
+				"ubuntu-bionic-amd64-20211020",
+				"ubuntu-focal-amd64-20211001",
+				"ubuntu-focal-amd64-20211021",
+				"ubuntu-focal-arm64-20211021",
+			},
+		},
+		{
+			name: "no matching os",
+			spec: harvesterv1.ImageCatalogSpec{
+				OS: []string{"rocky"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		var actual []string
+		for _, build := range selectCatalogBuilds(tc.spec, builds) {
+			actual = append(actual, getCatalogImageDisplayName(build))
+		}
+		assert.Equal(t, tc.expected, actual, "case %q", tc.name)
+	}
+}
+
+func TestImageCatalogHandler_sync(t *testing.T) {
+	server := newTestCatalogServer()
+	defer server.Close()
+
+	catalog := &harvesterv1.ImageCatalog{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "ubuntu"},
+		Spec: harvesterv1.ImageCatalogSpec{
+			URL:        server.URL + "/streams/v1/index.json",
+			Format:     harvesterv1.ImageCatalogFormatSimpleStreams,
+			Arches:     []string{"amd64"},
+			KeepLatest: 1,
+		},
+	}
+	latest := catalogBuild{OS: "ubuntu", Release: "focal", Arch: "amd64", Version: "20211021"}
+	newCatalogImage := func(build catalogBuild, usage *harvesterv1.VirtualMachineImageUsage) *harvesterv1.VirtualMachineImage {
+		return &harvesterv1.VirtualMachineImage{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: catalog.Namespace,
+				Name:      getCatalogImageName(catalog, build),
+				Labels:    map[string]string{util.LabelImageCatalog: catalog.Name},
+			},
+			Status: harvesterv1.VirtualMachineImageStatus{Usage: usage},
+		}
+	}
+	inUse := newCatalogImage(catalogBuild{OS: "ubuntu", Release: "focal", Arch: "amd64", Version: "20210801"},
+		&harvesterv1.VirtualMachineImageUsage{Volumes: []string{"default/vm-disk-0"}})
+	inTemplate := newCatalogImage(catalogBuild{OS: "ubuntu", Release: "focal", Arch: "amd64", Version: "20210901"},
+		&harvesterv1.VirtualMachineImageUsage{TemplateVersions: []string{"default/template-v1"}})
+	outdated := newCatalogImage(catalogBuild{OS: "ubuntu", Release: "focal", Arch: "amd64", Version: "20211001"}, nil)
+
+	clientset := fake.NewSimpleClientset(inUse, inTemplate, outdated)
+	h := &imageCatalogHandler{
+		images:     fakeclients.VirtualMachineImageClient(clientset.HarvesterhciV1beta1().VirtualMachineImages),
+		imageCache: fakeclients.VirtualMachineImageCache(clientset.HarvesterhciV1beta1().VirtualMachineImages),
+		feedClient: func() (*http.Client, error) {
+			return server.Client(), nil
+		},
+	}
+
+	names, err := h.sync(catalog)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{inUse.Name, inTemplate.Name, getCatalogImageName(catalog, latest)}, names)
+
+	created, err := clientset.HarvesterhciV1beta1().VirtualMachineImages("default").Get(context.TODO(), getCatalogImageName(catalog, latest), metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		util.LabelImageCatalog:   "ubuntu",
+		util.LabelImageOS:        "ubuntu",
+		util.LabelImageOSRelease: "focal",
+		util.LabelImageArch:      "amd64",
+		util.LabelImageBuild:     "20211021",
+	}, created.Labels)
+	assert.Equal(t, harvesterv1.VirtualMachineImageSpec{
+		DisplayName: "ubuntu-focal-amd64-20211021",
+		Description: "20.04 LTS",
+		SourceType:  harvesterv1.VirtualMachineImageSourceTypeDownload,
+		URL:         server.URL + "/server/releases/focal/release-20211021/ubuntu-20.04-server-cloudimg-amd64.img",
+		Checksum:    "bbbb",
+		OSInfo: &harvesterv1.VirtualMachineImageOSInfo{
+			Family:       harvesterv1.VirtualMachineImageOSFamilyLinux,
+			Version:      "20.04",
+			Architecture: harvesterv1.VirtualMachineImageArchitectureAMD64,
+		},
+	}, created.Spec)
+
+	_, err = clientset.HarvesterhciV1beta1().VirtualMachineImages("default").Get(context.TODO(), outdated.Name, metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err), "the outdated image is not deleted")
+
+	// the second sync finds the created image
+	names, err = h.sync(catalog)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{inUse.Name, inTemplate.Name, getCatalogImageName(catalog, latest)}, names)
+}
+
+func TestGetCatalogLabelValue(t *testing.T) {
+	newCatalog := func(name string) *harvesterv1.ImageCatalog {
+		return &harvesterv1.ImageCatalog{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}}
+	}
+	assert.Equal(t, "ubuntu", getCatalogLabelValue(newCatalog("ubuntu")))
+
+	long := strings.Repeat("ubuntu-", 10)
+	value := getCatalogLabelValue(newCatalog(long))
+	assert.Empty(t, validation.IsValidLabelValue(value))
+	assert.True(t, strings.HasPrefix(value, "ubuntu-ubuntu-"))
+	assert.NotEqual(t, value, getCatalogLabelValue(newCatalog(long+"x")))
+}
+
+func TestToLabelValue(t *testing.T) {
+	assert.Equal(t, "20211021", toLabelValue("20211021"))
+	assert.Equal(t, "15.3", toLabelValue("15.3"))
+	assert.Equal(t, "8.4-20210620.0", toLabelValue("8.4-20210620.0"))
+	assert.Equal(t, "Leap-15.3", toLabelValue("Leap 15.3"))
+	assert.Equal(t, "a-b", toLabelValue("/a:b/"))
+}
+
+func TestGetCatalogImageOSInfo(t *testing.T) {
+	assert.Equal(t, &harvesterv1.VirtualMachineImageOSInfo{
+		Family:       harvesterv1.VirtualMachineImageOSFamilyLinux,
+		Version:      "15.3",
+		Architecture: harvesterv1.VirtualMachineImageArchitectureAMD64,
+	}, getCatalogImageOSInfo(catalogBuild{OS: "opensuse", Release: "15.3", Arch: "x86_64", Version: "2.5.0"}))
+	assert.Equal(t, &harvesterv1.VirtualMachineImageOSInfo{
+		Family:       harvesterv1.VirtualMachineImageOSFamilyWindows,
+		Version:      "2019",
+		Architecture: harvesterv1.VirtualMachineImageArchitectureAMD64,
+	}, getCatalogImageOSInfo(catalogBuild{OS: "windows-server", Release: "2019", Arch: "amd64", Version: "17763.2237"}))
+}
@@ -0,0 +1,227 @@
+package image
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	lhv1beta1 "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	harvesterv1beta1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	ctlharvesterv1beta1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	ctllhv1beta1 "github.com/harvester/harvester/pkg/generated/controllers/longhorn.io/v1beta1"
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	// maxVerifyAttempts is the number of failed streaming verifications after which the image is marked unverified
+	maxVerifyAttempts = 5
+	// verifyRetryInterval is the delay before retrying the first failed verification, it doubles on every failure
+	verifyRetryInterval = 30 * time.Second
+)
+
+func needsVerification(vmImage *harvesterv1beta1.VirtualMachineImage) bool {
+	return vmImage.Spec.Checksum != "" && !harvesterv1beta1.ImageVerified.IsTrue(vmImage) && !harvesterv1beta1.ImageVerified.IsFalse(vmImage)
+}
+
+// checksumVerifier computes the checksums of the imported images which Longhorn doesn't compute, the backing image
+// data is streamed in the background and the progress is recorded in the message of the verified condition.
+type checksumVerifier struct {
+	ctx           context.Context
+	httpClient    http.Client
+	images        ctlharvesterv1beta1.VirtualMachineImageClient
+	imageCache    ctlharvesterv1beta1.VirtualMachineImageCache
+	backingImages ctllhv1beta1.BackingImageController
+
+	// verifying holds the cancel functions of the running verifications by image
+	verifying sync.Map
+	// failures holds the number of the failed verifications by image
+	failures sync.Map
+}
+
+// verify verifies the imported image against the checksum of the spec. Longhorn computes the sha512 checksum
+// of the backing image file along with the import, other checksums are computed by streaming the backing image data.
+func (v *checksumVerifier) verify(vmImage *harvesterv1beta1.VirtualMachineImage, backingImage *lhv1beta1.BackingImage) {
+	checksum := strings.ToLower(vmImage.Spec.Checksum)
+	if _, err := util.NewChecksumHash(checksum); err != nil {
+		setImageVerified(vmImage, false, "InvalidChecksum", err.Error())
+		return
+	}
+	if actual := strings.ToLower(backingImage.Status.Checksum); actual != "" && len(actual) == len(checksum) {
+		setChecksumResult(vmImage, actual)
+		return
+	}
+	v.start(vmImage, backingImage.Name, backingImage.Status.Size)
+}
+
+func (v *checksumVerifier) start(vmImage *harvesterv1beta1.VirtualMachineImage, backingImageName string, size int64) {
+	key := ref.Construct(vmImage.Namespace, vmImage.Name)
+	ctx, cancel := context.WithCancel(v.ctx)
+	if _, loaded := v.verifying.LoadOrStore(key, cancel); loaded {
+		cancel()
+		return
+	}
+
+	go func() {
+		defer func() {
+			v.verifying.Delete(key)
+			cancel()
+		}()
+		actual, err := v.computeChecksum(ctx, vmImage, backingImageName, size)
+		if err != nil && ctx.Err() != nil {
+			// the verification is stopped by the removal or the checksum change of the image
+			return
+		}
+		attempts := 0
+		if err != nil {
+			logrus.Errorf("failed to verify the checksum of image %s: %v", key, err)
+			attempts = v.addFailure(key)
+			if attempts < maxVerifyAttempts {
+				// the verification is started again by the resync of the backing image
+				v.backingImages.EnqueueAfter(util.LonghornSystemNamespaceName, backingImageName, getVerifyRetryDelay(attempts))
+			}
+		}
+		if updateErr := v.updateImage(vmImage, func(toUpdate *harvesterv1beta1.VirtualMachineImage) {
+			if err == nil {
+				setChecksumResult(toUpdate, actual)
+			} else if attempts < maxVerifyAttempts {
+				harvesterv1beta1.ImageVerified.Message(toUpdate, fmt.Sprintf("failed to verify the checksum, retrying: %v", err))
+			} else {
+				setImageVerified(toUpdate, false, "VerificationFailed", fmt.Sprintf("failed to verify the checksum after %d attempts: %v", attempts, err))
+			}
+		}); updateErr != nil {
+			logrus.Errorf("failed to update image %s: %v", key, updateErr)
+		}
+	}()
+}
+
+func (v *checksumVerifier) stop(vmImage *harvesterv1beta1.VirtualMachineImage) {
+	key := ref.Construct(vmImage.Namespace, vmImage.Name)
+	if cancel, ok := v.verifying.Load(key); ok {
+		cancel.(context.CancelFunc)()
+	}
+	v.failures.Delete(key)
+}
+
+// addFailure returns the number of the failed verifications of the image including the new one
+func (v *checksumVerifier) addFailure(key string) int {
+	failures := 1
+	if value, ok := v.failures.Load(key); ok {
+		failures += value.(int)
+	}
+	v.failures.Store(key, failures)
+	return failures
+}
+
+// getVerifyRetryDelay returns the exponential backoff of the verification after the failed attempts
+func getVerifyRetryDelay(attempts int) time.Duration {
+	return verifyRetryInterval << (attempts - 1)
+}
+
+func (v *checksumVerifier) computeChecksum(ctx context.Context, vmImage *harvesterv1beta1.VirtualMachineImage, backingImageName string, size int64) (string, error) {
+	hash, err := util.NewChecksumHash(strings.ToLower(vmImage.Spec.Checksum))
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, util.GetBackingImageDownloadURL(backingImageName), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download the backing image %s: %w", backingImageName, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to download the backing image %s: %s", backingImageName, string(body))
+	}
+
+	progress := &verifyProgressWriter{
+		size: size,
+		report: func(percent int64) {
+			if err := v.updateImage(vmImage, func(toUpdate *harvesterv1beta1.VirtualMachineImage) {
+				harvesterv1beta1.ImageVerified.Message(toUpdate, fmt.Sprintf("verifying the checksum, %d%% of the image is read", percent))
+			}); err != nil {
+				logrus.Errorf("failed to update the verification progress of image %s/%s: %v", vmImage.Namespace, vmImage.Name, err)
+			}
+		},
+	}
+	if _, err := io.Copy(io.MultiWriter(hash, progress), resp.Body); err != nil {
+		return "", fmt.Errorf("failed to download the backing image %s: %w", backingImageName, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (v *checksumVerifier) updateImage(vmImage *harvesterv1beta1.VirtualMachineImage, update func(toUpdate *harvesterv1beta1.VirtualMachineImage)) error {
+	for i := 0; i < 3; i++ {
+		current, err := v.imageCache.Get(vmImage.Namespace, vmImage.Name)
+		if apierrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		// the image is verified again if its checksum is changed
+		if current.DeletionTimestamp != nil || current.Spec.Checksum != vmImage.Spec.Checksum || !needsVerification(current) {
+			return nil
+		}
+		toUpdate := current.DeepCopy()
+		update(toUpdate)
+		if reflect.DeepEqual(current, toUpdate) {
+			return nil
+		}
+		if _, err = v.images.Update(toUpdate); err == nil || !apierrors.IsConflict(err) {
+			return err
+		}
+		time.Sleep(2 * time.Second)
+	}
+	return errors.New("failed to update image, max retries exceeded")
+}
+
+// verifyProgressWriter counts the bytes read for the verification and reports every ten percent of the image
+type verifyProgressWriter struct {
+	size     int64
+	read     int64
+	reported int64
+	report   func(percent int64)
+}
+
+func (w *verifyProgressWriter) Write(p []byte) (int, error) {
+	w.read += int64(len(p))
+	if w.size <= 0 {
+		return len(p), nil
+	}
+	if percent := w.read * 100 / w.size; percent >= w.reported+10 && percent < 100 {
+		w.reported = percent - percent%10
+		w.report(w.reported)
+	}
+	return len(p), nil
+}
+
+// setChecksumResult compares the checksum of the spec with the actual one
+func setChecksumResult(vmImage *harvesterv1beta1.VirtualMachineImage, actual string) {
+	checksum := strings.ToLower(vmImage.Spec.Checksum)
+	if actual != checksum {
+		setImageVerified(vmImage, false, "ChecksumMismatch", fmt.Sprintf("expected checksum %s but got %s", checksum, actual))
+		return
+	}
+	setImageVerified(vmImage, true, "ChecksumMatched", "")
+}
+
+func setImageVerified(vmImage *harvesterv1beta1.VirtualMachineImage, verified bool, reason, message string) {
+	harvesterv1beta1.ImageVerified.SetStatusBool(vmImage, verified)
+	harvesterv1beta1.ImageVerified.Reason(vmImage, reason)
+	harvesterv1beta1.ImageVerified.Message(vmImage, message)
+}
@@ -0,0 +1,87 @@
+package image
+
+import (
+	"testing"
+	"time"
+
+	lhv1beta1 "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
+	"github.com/longhorn/longhorn-manager/types"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+)
+
+const testSHA512 = "ee26b0dd4af7e749aa1a8ee3c10ae9923f618980772e473f8819a5d4940e0db27ac185f8a0e1d5f84f88bc887fd67b143732c304cc5fa9ad8e6f57f50028a8ff"
+
+func TestChecksumVerifier_verify(t *testing.T) {
+	var testCases = []struct {
+		name             string
+		checksum         string
+		backingImageSum  string
+		expectedStatus   string
+		expectedReason   string
+		expectedVerified bool
+	}{
+		{
+			name:           "invalid checksum",
+			checksum:       "abc",
+			expectedStatus: "False",
+			expectedReason: "InvalidChecksum",
+		},
+		{
+			name:            "checksum computed by longhorn matches",
+			checksum:        testSHA512,
+			backingImageSum: testSHA512,
+			expectedStatus:  "True",
+			expectedReason:  "ChecksumMatched",
+		},
+		{
+			name:            "checksum computed by longhorn mismatches",
+			checksum:        testSHA512,
+			backingImageSum: "ff" + testSHA512[2:],
+			expectedStatus:  "False",
+			expectedReason:  "ChecksumMismatch",
+		},
+	}
+
+	for _, tc := range testCases {
+		vmImage := &harvesterv1.VirtualMachineImage{
+			Spec: harvesterv1.VirtualMachineImageSpec{Checksum: tc.checksum},
+		}
+		backingImage := &lhv1beta1.BackingImage{
+			Status: types.BackingImageStatus{Checksum: tc.backingImageSum},
+		}
+		v := &checksumVerifier{}
+		v.verify(vmImage, backingImage)
+		assert.Equal(t, tc.expectedStatus, harvesterv1.ImageVerified.GetStatus(vmImage), "case %q", tc.name)
+		assert.Equal(t, tc.expectedReason, harvesterv1.ImageVerified.GetReason(vmImage), "case %q", tc.name)
+	}
+}
+
+func TestVerifyProgressWriter(t *testing.T) {
+	var reported []int64
+	w := &verifyProgressWriter{
+		size:   100,
+		report: func(percent int64) { reported = append(reported, percent) },
+	}
+	for _, n := range []int{5, 10, 3, 30, 52} {
+		_, err := w.Write(make([]byte, n))
+		assert.Nil(t, err)
+	}
+	// the progress is reported every ten percent, the completion is reported by the checksum result
+	assert.Equal(t, []int64{10, 40}, reported)
+}
+
+func TestChecksumVerifier_addFailure(t *testing.T) {
+	v := &checksumVerifier{}
+	var delays []time.Duration
+	for attempts := v.addFailure("default/image"); attempts < maxVerifyAttempts; attempts = v.addFailure("default/image") {
+		delays = append(delays, getVerifyRetryDelay(attempts))
+	}
+	// the verification is retried with the exponential backoff until the max attempts
+	assert.Equal(t, []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute}, delays)
+
+	v.stop(&harvesterv1.VirtualMachineImage{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "image"}})
+	assert.Equal(t, 1, v.addFailure("default/image"))
+}
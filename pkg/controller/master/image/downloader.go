@@ -0,0 +1,311 @@
+package image
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/longhorn/longhorn-manager/types"
+	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	lhv1beta1 "github.com/harvester/harvester/pkg/generated/controllers/longhorn.io/v1beta1"
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/util"
+	"github.com/harvester/harvester/pkg/util/diskimage"
+)
+
+const (
+	reasonDownloading = "Downloading"
+	reasonConverting  = "Converting"
+	// reasonUploading is set by the image API while receiving the chunks of a chunked upload
+	reasonUploading = "Uploading"
+)
+
+// detectRemoteFormat detects the format of the image file to download from its header and footer. The footer is only
+// requested when the server supports range requests.
+func detectRemoteFormat(source *sourceClient, url string) (diskimage.Info, error) {
+	header, size, ranged, err := getRange(source, url, fmt.Sprintf("bytes=0-%d", diskimage.HeaderSize-1), diskimage.HeaderSize)
+	if err != nil {
+		return diskimage.Info{}, err
+	}
+	info := diskimage.Detect(header, nil, size)
+	if info.Format != diskimage.FormatRaw || !ranged || size < diskimage.FooterSize {
+		return info, nil
+	}
+
+	footer, _, _, err := getRange(source, url, fmt.Sprintf("bytes=%d-%d", size-diskimage.FooterSize, size-1), diskimage.FooterSize)
+	if err != nil {
+		return diskimage.Info{}, err
+	}
+	return diskimage.Detect(header, footer, size), nil
+}
+
+// getRange returns at most limit bytes of the requested range, the size of the whole file and whether the range is
+// served. The size is -1 if the server doesn't tell it.
+func getRange(source *sourceClient, url, byteRange string, limit int64) ([]byte, int64, bool, error) {
+	req, err := source.newRequest(context.Background(), http.MethodGet, url)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	req.Header.Set("Range", byteRange)
+	resp, err := source.do(req)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	defer resp.Body.Close()
+
+	size := resp.ContentLength
+	ranged := false
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusPartialContent:
+		ranged = true
+		size = -1
+		// Content-Range: bytes 0-65535/1048576
+		contentRange := resp.Header.Get("Content-Range")
+		if i := strings.LastIndex(contentRange, "/"); i >= 0 {
+			if total, err := strconv.ParseInt(contentRange[i+1:], 10, 64); err == nil {
+				size = total
+			}
+		}
+	default:
+		return nil, 0, false, fmt.Errorf("got %d status code from %s", resp.StatusCode, url)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, 0, false, err
+	}
+	return data, size, ranged, nil
+}
+
+// imageDownloader imports the image files which Longhorn can't download itself. The image file is downloaded and
+// converted to qcow2 by qemu-img if needed, then the result is uploaded to the backing image which waits for an upload.
+type imageDownloader struct {
+	ctx                         context.Context
+	images                      ctlharvesterv1.VirtualMachineImageClient
+	imageCache                  ctlharvesterv1.VirtualMachineImageCache
+	secretCache                 ctlcorev1.SecretCache
+	backingImageDataSourceCache lhv1beta1.BackingImageDataSourceCache
+	// stagingDir stages the downloaded files to convert
+	stagingDir string
+
+	// downloading holds the cancel functions of the running downloads by image
+	downloading sync.Map
+}
+
+func isDownloading(image *harvesterv1.VirtualMachineImage) bool {
+	reason := harvesterv1.ImageImported.GetReason(image)
+	return harvesterv1.ImageImported.IsUnknown(image) && (reason == reasonDownloading || reason == reasonConverting)
+}
+
+func isUploading(image *harvesterv1.VirtualMachineImage) bool {
+	return harvesterv1.ImageImported.IsUnknown(image) && harvesterv1.ImageImported.GetReason(image) == reasonUploading
+}
+
+func (d *imageDownloader) isRunning(image *harvesterv1.VirtualMachineImage) bool {
+	_, ok := d.downloading.Load(ref.Construct(image.Namespace, image.Name))
+	return ok
+}
+
+func (d *imageDownloader) start(image *harvesterv1.VirtualMachineImage) {
+	key := ref.Construct(image.Namespace, image.Name)
+	ctx, cancel := context.WithCancel(d.ctx)
+	if _, loaded := d.downloading.LoadOrStore(key, cancel); loaded {
+		cancel()
+		return
+	}
+
+	go func() {
+		defer func() {
+			d.downloading.Delete(key)
+			cancel()
+		}()
+		if err := d.importImage(ctx, image); err != nil {
+			logrus.Errorf("failed to import image %s: %v", key, err)
+			if updateErr := d.updateImage(image, func(toUpdate *harvesterv1.VirtualMachineImage) {
+				harvesterv1.ImageImported.False(toUpdate)
+				harvesterv1.ImageImported.Reason(toUpdate, "ImportFailed")
+				harvesterv1.ImageImported.Message(toUpdate, err.Error())
+			}); updateErr != nil {
+				logrus.Errorf("failed to update image %s: %v", key, updateErr)
+			}
+		}
+	}()
+}
+
+func (d *imageDownloader) stop(image *harvesterv1.VirtualMachineImage) {
+	if cancel, ok := d.downloading.Load(ref.Construct(image.Namespace, image.Name)); ok {
+		cancel.(context.CancelFunc)()
+	}
+}
+
+func (d *imageDownloader) importImage(ctx context.Context, image *harvesterv1.VirtualMachineImage) error {
+	dir, err := util.NewImageStagingDir(d.stagingDir)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	// the checksum is of the source file, it is verified along with the download as a converted file differs
+	var checksum hash.Hash
+	if image.Spec.Checksum != "" {
+		if checksum, err = util.NewChecksumHash(image.Spec.Checksum); err != nil {
+			return err
+		}
+	}
+
+	path := filepath.Join(dir, "source")
+	if err := d.download(ctx, image, path, checksum); err != nil {
+		return err
+	}
+	info, err := diskimage.DetectFile(path)
+	if err != nil {
+		return err
+	}
+	if diskimage.NeedsConversion(info.Format) {
+		if err := d.updateImage(image, func(toUpdate *harvesterv1.VirtualMachineImage) {
+			harvesterv1.ImageImported.Reason(toUpdate, reasonConverting)
+			harvesterv1.ImageImported.Message(toUpdate, fmt.Sprintf("converting the %s image to qcow2", info.Format))
+		}); err != nil {
+			return err
+		}
+		if path, info, err = diskimage.Convert(ctx, path, dir); err != nil {
+			return err
+		}
+	}
+	if err := d.updateImage(image, func(toUpdate *harvesterv1.VirtualMachineImage) {
+		toUpdate.Status.Format = info.Format
+		toUpdate.Status.VirtualSize = info.VirtualSize
+		if checksum != nil {
+			setChecksumResult(toUpdate, hex.EncodeToString(checksum.Sum(nil)))
+		}
+	}); err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if err := d.waitForBackingImageDataSource(ctx, getBackingImageName(image)); err != nil {
+		return err
+	}
+	if err := util.UploadBackingImageData(ctx, &http.Client{}, getBackingImageName(image), image.Spec.DisplayName, f, stat.Size()); err != nil {
+		return err
+	}
+
+	// the backing image handler takes over the imported condition since now
+	return d.updateImage(image, func(toUpdate *harvesterv1.VirtualMachineImage) {
+		if isDownloading(toUpdate) {
+			harvesterv1.ImageImported.Reason(toUpdate, "Importing")
+			harvesterv1.ImageImported.Message(toUpdate, "")
+		}
+	})
+}
+
+func (d *imageDownloader) download(ctx context.Context, image *harvesterv1.VirtualMachineImage, path string, checksum hash.Hash) error {
+	source, err := getSourceClient(d.secretCache, image, 0)
+	if err != nil {
+		return err
+	}
+	req, err := source.newRequest(ctx, http.MethodGet, image.Spec.URL)
+	if err != nil {
+		return err
+	}
+	resp, err := source.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("got %d status code from %s", resp.StatusCode, image.Spec.URL)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	var w io.Writer = f
+	if checksum != nil {
+		w = io.MultiWriter(f, checksum)
+	}
+	_, err = io.Copy(w, resp.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", image.Spec.URL, err)
+	}
+	return nil
+}
+
+// waitForBackingImageDataSource waits for the data source of the backing image to accept the upload
+func (d *imageDownloader) waitForBackingImageDataSource(ctx context.Context, name string) error {
+	for {
+		ds, err := d.backingImageDataSourceCache.Get(util.LonghornSystemNamespaceName, name)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		if err == nil {
+			if ds.Status.CurrentState == types.BackingImageStateStarting {
+				return nil
+			}
+			if ds.Status.CurrentState == types.BackingImageStateFailed {
+				return errors.New(ds.Status.Message)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+func (d *imageDownloader) updateImage(image *harvesterv1.VirtualMachineImage, update func(toUpdate *harvesterv1.VirtualMachineImage)) error {
+	for i := 0; i < 3; i++ {
+		current, err := d.imageCache.Get(image.Namespace, image.Name)
+		if apierrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if current.DeletionTimestamp != nil || current.Spec.URL != image.Spec.URL {
+			return nil
+		}
+		toUpdate := current.DeepCopy()
+		update(toUpdate)
+		if reflect.DeepEqual(current, toUpdate) {
+			return nil
+		}
+		if _, err = d.images.Update(toUpdate); err == nil || !apierrors.IsConflict(err) {
+			return err
+		}
+		time.Sleep(2 * time.Second)
+	}
+	return errors.New("failed to update image, max retries exceeded")
+}
@@ -0,0 +1,63 @@
+package image
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/harvester/harvester/pkg/util/diskimage"
+)
+
+func TestDetectRemoteFormat(t *testing.T) {
+	var testCases = []struct {
+		name          string
+		file          string
+		rangeDisabled bool
+		expected      diskimage.Info
+	}{
+		{
+			name:     "qcow2",
+			file:     "disk.qcow2",
+			expected: diskimage.Info{Format: diskimage.FormatQCOW2, VirtualSize: 65536},
+		},
+		{
+			name:     "ova",
+			file:     "appliance.ova",
+			expected: diskimage.Info{Format: diskimage.FormatOVA},
+		},
+		{
+			name:     "fixed vhd",
+			file:     "disk-fixed.vhd",
+			expected: diskimage.Info{Format: diskimage.FormatVHD, VirtualSize: 4096},
+		},
+		{
+			name:          "fixed vhd without range requests",
+			file:          "disk-fixed.vhd",
+			rangeDisabled: true,
+			expected:      diskimage.Info{Format: diskimage.FormatRaw, VirtualSize: 4608},
+		},
+	}
+
+	for _, tc := range testCases {
+		data, err := ioutil.ReadFile(filepath.Join("..", "..", "..", "util", "diskimage", "testdata", tc.file))
+		assert.Nil(t, err, "case %q", tc.name)
+
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			if tc.rangeDisabled {
+				req.Header.Del("Range")
+			}
+			http.ServeContent(rw, req, tc.file, time.Time{}, bytes.NewReader(data))
+		}))
+		actual, err := detectRemoteFormat(&sourceClient{client: server.Client()}, server.URL+"/"+tc.file)
+		server.Close()
+
+		assert.Nil(t, err, "case %q", tc.name)
+		assert.Equal(t, tc.expected, actual, "case %q", tc.name)
+	}
+}
@@ -3,13 +3,14 @@ package image
 import (
 	"context"
 	"net/http"
-	"time"
 
 	"github.com/harvester/harvester/pkg/config"
 )
 
 const (
 	vmImageControllerName      = "vm-image-controller"
+	vmImageUsageControllerName = "vm-image-usage-controller"
+	imageCatalogControllerName = "image-catalog-controller"
 	backingImageControllerName = "backing-image-controller"
 )
 
@@ -18,16 +19,36 @@ func Register(ctx context.Context, management *config.Management, options config
 	images := management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineImage()
 	storageClasses := management.StorageFactory.Storage().V1().StorageClass()
 	pvcs := management.CoreFactory.Core().V1().PersistentVolumeClaim()
+	secrets := management.CoreFactory.Core().V1().Secret()
+	backingImageDataSources := management.LonghornFactory.Longhorn().V1beta1().BackingImageDataSource()
+	settings := management.HarvesterFactory.Harvesterhci().V1beta1().Setting()
+	verifier := &checksumVerifier{
+		ctx:           ctx,
+		httpClient:    http.Client{},
+		images:        images,
+		imageCache:    images.Cache(),
+		backingImages: backingImages,
+	}
 	vmImageHandler := &vmImageHandler{
-		backingImages:  backingImages,
-		storageClasses: storageClasses,
-		images:         images,
-		httpClient: http.Client{
-			Timeout: 15 * time.Second,
+		backingImages:     backingImages,
+		storageClasses:    storageClasses,
+		storageClassCache: storageClasses.Cache(),
+		images:            images,
+		pvcCache:          pvcs.Cache(),
+		secretCache:       secrets.Cache(),
+		downloader: &imageDownloader{
+			ctx:                         ctx,
+			images:                      images,
+			imageCache:                  images.Cache(),
+			secretCache:                 secrets.Cache(),
+			backingImageDataSourceCache: backingImageDataSources.Cache(),
+			stagingDir:                  options.ImageStagingDir,
 		},
-		pvcCache: pvcs.Cache(),
+		verifier:  verifier,
+		uploadDir: options.ImageUploadDir,
 	}
 	backingImageHandler := &backingImageHandler{
+		verifier:          verifier,
 		vmImages:          images,
 		vmImageCache:      images.Cache(),
 		backingImages:     backingImages,
@@ -36,6 +57,39 @@ func Register(ctx context.Context, management *config.Management, options config
 	images.OnChange(ctx, vmImageControllerName, vmImageHandler.OnChanged)
 	images.OnRemove(ctx, vmImageControllerName, vmImageHandler.OnRemove)
 
+	imageSettingHandler := &imageSettingHandler{
+		images:     images,
+		imageCache: images.Cache(),
+	}
+	settings.OnChange(ctx, vmImageControllerName, imageSettingHandler.OnChanged)
+
+	templateVersions := management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineTemplateVersion()
+	vmBackups := management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackup()
+	vmSnapshots := management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineSnapshot()
+	imageUsageHandler := &imageUsageHandler{
+		images:               images,
+		imageCache:           images.Cache(),
+		pvcCache:             pvcs.Cache(),
+		templateVersionCache: templateVersions.Cache(),
+		vmBackupCache:        vmBackups.Cache(),
+		vmSnapshotCache:      vmSnapshots.Cache(),
+	}
+	images.OnChange(ctx, vmImageUsageControllerName, imageUsageHandler.OnChanged)
+	pvcs.OnChange(ctx, vmImageUsageControllerName, imageUsageHandler.OnPVCChanged)
+	templateVersions.OnChange(ctx, vmImageUsageControllerName, imageUsageHandler.OnTemplateVersionChanged)
+	templateVersions.OnRemove(ctx, vmImageUsageControllerName, imageUsageHandler.OnTemplateVersionChanged)
+	vmBackups.OnChange(ctx, vmImageUsageControllerName, imageUsageHandler.OnVMBackupChanged)
+	vmSnapshots.OnChange(ctx, vmImageUsageControllerName, imageUsageHandler.OnVMSnapshotChanged)
+
+	imageCatalogs := management.HarvesterFactory.Harvesterhci().V1beta1().ImageCatalog()
+	imageCatalogHandler := &imageCatalogHandler{
+		catalogs:   imageCatalogs,
+		images:     images,
+		imageCache: images.Cache(),
+		feedClient: getCatalogFeedClient,
+	}
+	imageCatalogs.OnChange(ctx, imageCatalogControllerName, imageCatalogHandler.OnChanged)
+
 	backingImages.OnChange(ctx, backingImageControllerName, backingImageHandler.OnChanged)
 	return nil
 }
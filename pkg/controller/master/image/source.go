@@ -0,0 +1,99 @@
+package image
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"time"
+
+	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+// sourceClient requests the download source of a vm image with the credentials and the CA bundle of its source secret,
+// the requests go through the proxy of the http-proxy setting.
+type sourceClient struct {
+	client   *http.Client
+	username string
+	password string
+	token    string
+}
+
+func getSourceClient(secretCache ctlcorev1.SecretCache, image *harvesterv1.VirtualMachineImage, timeout time.Duration) (*sourceClient, error) {
+	var secret *corev1.Secret
+	if image.Spec.SourceSecretName != "" {
+		var err error
+		if secret, err = secretCache.Get(image.Namespace, image.Spec.SourceSecretName); err != nil {
+			return nil, err
+		}
+	}
+	proxy, err := util.GetHTTPProxyConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy != nil {
+		transport.Proxy = util.ProxyFunc(proxy)
+	}
+	c := &sourceClient{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+		},
+	}
+	if secret == nil {
+		return c, nil
+	}
+
+	if caBundle := secret.Data[harvesterv1.VirtualMachineImageSourceSecretCABundle]; len(caBundle) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(caBundle) {
+			return nil, errors.New("no valid certificate is found in the CA bundle of the source secret")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	c.username = string(secret.Data[harvesterv1.VirtualMachineImageSourceSecretUsername])
+	c.password = string(secret.Data[harvesterv1.VirtualMachineImageSourceSecretPassword])
+	c.token = string(secret.Data[harvesterv1.VirtualMachineImageSourceSecretToken])
+	return c, nil
+}
+
+func (c *sourceClient) newRequest(ctx context.Context, method, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	} else if c.username != "" || c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	return req, nil
+}
+
+func (c *sourceClient) do(req *http.Request) (*http.Response, error) {
+	return c.client.Do(req)
+}
+
+// isDownloadedByHarvester returns true if the image file is downloaded by Harvester and uploaded to the backing image,
+// which is the case when Longhorn can't download it itself because of the credentials, the CA bundle, the proxy or
+// the conversion.
+func isDownloadedByHarvester(image *harvesterv1.VirtualMachineImage, convert bool) (bool, error) {
+	if image.Spec.SourceType != harvesterv1.VirtualMachineImageSourceTypeDownload {
+		return false, nil
+	}
+	if convert || image.Spec.SourceSecretName != "" {
+		return true, nil
+	}
+	proxy, err := util.GetHTTPProxyConfig()
+	return proxy != nil, err
+}
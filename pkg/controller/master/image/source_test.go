@@ -0,0 +1,101 @@
+package image
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corefake "k8s.io/client-go/kubernetes/fake"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+func TestSourceClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if username, password, ok := req.BasicAuth(); ok && username == "user" && password == "pass" {
+			return
+		}
+		if req.Header.Get("Authorization") == "Bearer token" {
+			return
+		}
+		rw.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	var testCases = []struct {
+		name           string
+		secret         *corev1.Secret
+		expectedStatus int
+		expectedError  bool
+	}{
+		{
+			name:          "untrusted certificate",
+			expectedError: true,
+		},
+		{
+			name: "no credentials",
+			secret: &corev1.Secret{
+				Data: map[string][]byte{harvesterv1.VirtualMachineImageSourceSecretCABundle: caBundle},
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "basic auth",
+			secret: &corev1.Secret{
+				Data: map[string][]byte{
+					harvesterv1.VirtualMachineImageSourceSecretCABundle: caBundle,
+					harvesterv1.VirtualMachineImageSourceSecretUsername: []byte("user"),
+					harvesterv1.VirtualMachineImageSourceSecretPassword: []byte("pass"),
+				},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "bearer token",
+			secret: &corev1.Secret{
+				Data: map[string][]byte{
+					harvesterv1.VirtualMachineImageSourceSecretCABundle: caBundle,
+					harvesterv1.VirtualMachineImageSourceSecretToken:    []byte("token"),
+				},
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		image := &harvesterv1.VirtualMachineImage{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "image"},
+			Spec: harvesterv1.VirtualMachineImageSpec{
+				SourceType: harvesterv1.VirtualMachineImageSourceTypeDownload,
+				URL:        server.URL,
+			},
+		}
+		clientset := corefake.NewSimpleClientset()
+		if tc.secret != nil {
+			tc.secret.Namespace, tc.secret.Name = "default", "source"
+			image.Spec.SourceSecretName = "source"
+			_, err := clientset.CoreV1().Secrets("default").Create(context.TODO(), tc.secret, metav1.CreateOptions{})
+			assert.Nil(t, err, "case %q", tc.name)
+		}
+
+		source, err := getSourceClient(fakeclients.SecretCache(clientset.CoreV1().Secrets), image, 0)
+		assert.Nil(t, err, "case %q", tc.name)
+		req, err := source.newRequest(context.TODO(), http.MethodHead, image.Spec.URL)
+		assert.Nil(t, err, "case %q", tc.name)
+		resp, err := source.do(req)
+		if tc.expectedError {
+			assert.NotNil(t, err, "case %q", tc.name)
+			continue
+		}
+		assert.Nil(t, err, "case %q", tc.name)
+		assert.Equal(t, tc.expectedStatus, resp.StatusCode, "case %q", tc.name)
+		resp.Body.Close()
+	}
+}
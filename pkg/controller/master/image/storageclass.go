@@ -0,0 +1,148 @@
+package image
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/longhorn/longhorn-manager/types"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/settings"
+)
+
+const (
+	optionDataLocality = "dataLocality"
+
+	maxNumberOfReplicas = 20
+)
+
+// defaultStorageClassParameters are the parameters of the image storageclasses unless they are overridden by
+// the image-storage-class-parameters setting or the image spec
+var defaultStorageClassParameters = map[string]string{
+	types.OptionNumberOfReplicas:    "3",
+	types.OptionStaleReplicaTimeout: "30",
+	optionMigratable:                "true",
+}
+
+// ValidateStorageClassParameters validates the storageclass parameters of an image or of the
+// image-storage-class-parameters setting. The backing image parameter is managed by Harvester.
+func ValidateStorageClassParameters(parameters map[string]string) error {
+	for key, value := range parameters {
+		switch key {
+		case types.OptionNumberOfReplicas:
+			if n, err := strconv.Atoi(value); err != nil || n < 1 || n > maxNumberOfReplicas {
+				return fmt.Errorf("%s must be an integer between 1 and %d", key, maxNumberOfReplicas)
+			}
+		case types.OptionStaleReplicaTimeout:
+			if n, err := strconv.Atoi(value); err != nil || n < 1 {
+				return fmt.Errorf("%s must be a positive integer", key)
+			}
+		case optionMigratable:
+			if _, err := strconv.ParseBool(value); err != nil {
+				return fmt.Errorf("%s must be true or false", key)
+			}
+		case types.OptionNodeSelector, types.OptionDiskSelector:
+			for _, tag := range strings.Split(value, ",") {
+				if strings.TrimSpace(tag) == "" {
+					return fmt.Errorf("%s must be a comma separated list of tags", key)
+				}
+			}
+		case optionDataLocality:
+			if value != string(types.DataLocalityDisabled) && value != string(types.DataLocalityBestEffort) {
+				return fmt.Errorf("%s must be %s or %s", key, types.DataLocalityDisabled, types.DataLocalityBestEffort)
+			}
+		default:
+			return fmt.Errorf("unsupported storageclass parameter %s", key)
+		}
+	}
+	return nil
+}
+
+// getDefaultStorageClassParameters returns the default parameters merged with the image-storage-class-parameters setting
+func getDefaultStorageClassParameters() (map[string]string, error) {
+	parameters := make(map[string]string, len(defaultStorageClassParameters))
+	for key, value := range defaultStorageClassParameters {
+		parameters[key] = value
+	}
+	value := settings.ImageStorageClassParameters.Get()
+	if value == "" {
+		return parameters, nil
+	}
+
+	overrides := map[string]string{}
+	if err := json.Unmarshal([]byte(value), &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse the %s setting: %w", settings.ImageStorageClassParameters.Name, err)
+	}
+	if err := ValidateStorageClassParameters(overrides); err != nil {
+		return nil, fmt.Errorf("invalid %s setting: %w", settings.ImageStorageClassParameters.Name, err)
+	}
+	for key, value := range overrides {
+		parameters[key] = value
+	}
+	return parameters, nil
+}
+
+// getStorageClassParameters returns the parameters of the image storageclass
+func getStorageClassParameters(image *harvesterv1.VirtualMachineImage) (map[string]string, error) {
+	parameters, err := getDefaultStorageClassParameters()
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range image.Spec.StorageClassParameters {
+		parameters[key] = value
+	}
+	parameters[optionBackingImageName] = getBackingImageName(image)
+	return parameters, nil
+}
+
+// reconcileStorageClass recreates the image storageclass when its parameters differ from the desired ones,
+// the parameters of a storageclass are immutable
+func (h *vmImageHandler) reconcileStorageClass(image *harvesterv1.VirtualMachineImage) error {
+	parameters, err := getStorageClassParameters(image)
+	if err != nil {
+		return err
+	}
+	sc, err := h.storageClassCache.Get(getImageStorageClassName(image))
+	if errors.IsNotFound(err) {
+		return h.createStorageClass(image)
+	} else if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(sc.Parameters, parameters) {
+		return nil
+	}
+	if err := h.storageClasses.Delete(sc.Name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return h.createStorageClass(image)
+}
+
+// imageSettingHandler enqueues the images when the default storageclass parameters or the garbage collection days change
+type imageSettingHandler struct {
+	images     ctlharvesterv1.VirtualMachineImageController
+	imageCache ctlharvesterv1.VirtualMachineImageCache
+}
+
+func (h *imageSettingHandler) OnChanged(_ string, setting *harvesterv1.Setting) (*harvesterv1.Setting, error) {
+	if setting == nil || setting.DeletionTimestamp != nil {
+		return setting, nil
+	}
+	if setting.Name != settings.ImageStorageClassParameters.Name && setting.Name != settings.ImageGarbageCollectionDays.Name {
+		return setting, nil
+	}
+	images, err := h.imageCache.List(metav1.NamespaceAll, labels.Everything())
+	if err != nil {
+		return setting, err
+	}
+	for _, image := range images {
+		h.images.Enqueue(image.Namespace, image.Name)
+	}
+	return setting, nil
+}
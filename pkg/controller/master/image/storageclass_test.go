@@ -0,0 +1,139 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/settings"
+)
+
+func TestValidateStorageClassParameters(t *testing.T) {
+	var testCases = []struct {
+		name       string
+		parameters map[string]string
+		expectErr  bool
+	}{
+		{
+			name: "valid parameters",
+			parameters: map[string]string{
+				"numberOfReplicas":    "1",
+				"staleReplicaTimeout": "60",
+				"migratable":          "false",
+				"nodeSelector":        "storage,ssd",
+				"diskSelector":        "nvme",
+				"dataLocality":        "best-effort",
+			},
+		},
+		{
+			name:       "too many replicas",
+			parameters: map[string]string{"numberOfReplicas": "21"},
+			expectErr:  true,
+		},
+		{
+			name:       "zero replicas",
+			parameters: map[string]string{"numberOfReplicas": "0"},
+			expectErr:  true,
+		},
+		{
+			name:       "invalid migratable",
+			parameters: map[string]string{"migratable": "yes"},
+			expectErr:  true,
+		},
+		{
+			name:       "empty tag",
+			parameters: map[string]string{"nodeSelector": "storage,"},
+			expectErr:  true,
+		},
+		{
+			name:       "invalid data locality",
+			parameters: map[string]string{"dataLocality": "strict"},
+			expectErr:  true,
+		},
+		{
+			name:       "backing image is managed",
+			parameters: map[string]string{"backingImage": "default-image"},
+			expectErr:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		err := ValidateStorageClassParameters(tc.parameters)
+		assert.Equal(t, tc.expectErr, err != nil, "case %q", tc.name)
+	}
+}
+
+func TestGetStorageClassParameters(t *testing.T) {
+	defer func() {
+		_ = settings.ImageStorageClassParameters.Set("")
+	}()
+
+	var testCases = []struct {
+		name       string
+		setting    string
+		parameters map[string]string
+		expected   map[string]string
+		expectErr  bool
+	}{
+		{
+			name: "defaults",
+			expected: map[string]string{
+				"numberOfReplicas":    "3",
+				"staleReplicaTimeout": "30",
+				"migratable":          "true",
+				"backingImage":        "default-image",
+			},
+		},
+		{
+			name:    "setting overrides defaults",
+			setting: `{"numberOfReplicas":"1"}`,
+			expected: map[string]string{
+				"numberOfReplicas":    "1",
+				"staleReplicaTimeout": "30",
+				"migratable":          "true",
+				"backingImage":        "default-image",
+			},
+		},
+		{
+			name:       "image overrides setting",
+			setting:    `{"numberOfReplicas":"1"}`,
+			parameters: map[string]string{"numberOfReplicas": "2", "nodeSelector": "ssd"},
+			expected: map[string]string{
+				"numberOfReplicas":    "2",
+				"staleReplicaTimeout": "30",
+				"migratable":          "true",
+				"nodeSelector":        "ssd",
+				"backingImage":        "default-image",
+			},
+		},
+		{
+			name:      "invalid setting",
+			setting:   `{"numberOfReplicas":"none"}`,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		assert.Nil(t, settings.ImageStorageClassParameters.Set(tc.setting), "case %q", tc.name)
+		image := &harvesterv1.VirtualMachineImage{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "image"},
+			Spec:       harvesterv1.VirtualMachineImageSpec{StorageClassParameters: tc.parameters},
+		}
+		actual, err := getStorageClassParameters(image)
+		assert.Equal(t, tc.expectErr, err != nil, "case %q", tc.name)
+		assert.Equal(t, tc.expected, actual, "case %q", tc.name)
+	}
+}
+
+func TestGetImageStorageClassName(t *testing.T) {
+	image := &harvesterv1.VirtualMachineImage{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "ubuntu"},
+	}
+	assert.Equal(t, "longhorn-team-a-ubuntu", getImageStorageClassName(image))
+
+	// the storageclass of an image initialized before keeps its name
+	image.Status.StorageClassName = "longhorn-ubuntu"
+	assert.Equal(t, "longhorn-ubuntu", getImageStorageClassName(image))
+}
@@ -0,0 +1,239 @@
+package image
+
+import (
+	"reflect"
+	"sort"
+	"time"
+
+	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtv1 "kubevirt.io/client-go/api/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/indexeres"
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/settings"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+// imageUsageHandler tracks the volumes, VMs, VM template versions, VM backups and VM snapshots using the images, and
+// deletes the images unused for the days of the image-garbage-collection-days setting. The volumes of an image are
+// provisioned by its storage class, so the PVCs, VM backups and VM snapshots of the storage class use the image as well.
+type imageUsageHandler struct {
+	images               ctlharvesterv1.VirtualMachineImageController
+	imageCache           ctlharvesterv1.VirtualMachineImageCache
+	pvcCache             ctlcorev1.PersistentVolumeClaimCache
+	templateVersionCache ctlharvesterv1.VirtualMachineTemplateVersionCache
+	vmBackupCache        ctlharvesterv1.VirtualMachineBackupCache
+	vmSnapshotCache      ctlharvesterv1.VirtualMachineSnapshotCache
+}
+
+// OnPVCChanged enqueues the image of the PVC to update its usage
+func (h *imageUsageHandler) OnPVCChanged(_ string, pvc *corev1.PersistentVolumeClaim) (*corev1.PersistentVolumeClaim, error) {
+	if pvc == nil {
+		return pvc, nil
+	}
+	if pvc.Annotations[util.AnnotationImageID] != "" {
+		namespace, name := ref.Parse(pvc.Annotations[util.AnnotationImageID])
+		h.images.Enqueue(namespace, name)
+		return pvc, nil
+	}
+	storageClasses, _ := indexeres.PVCByStorageClass(pvc)
+	return pvc, h.enqueueImagesByStorageClass(storageClasses)
+}
+
+// OnVMBackupChanged enqueues the images of the storage classes of the VM backup to update their usage, the removal
+// is seen as the VM backups are only removed after the cleanup of the backup controller
+func (h *imageUsageHandler) OnVMBackupChanged(_ string, vmBackup *harvesterv1.VirtualMachineBackup) (*harvesterv1.VirtualMachineBackup, error) {
+	if vmBackup == nil {
+		return vmBackup, nil
+	}
+	storageClasses, _ := indexeres.VMBackupByStorageClass(vmBackup)
+	return vmBackup, h.enqueueImagesByStorageClass(storageClasses)
+}
+
+// OnVMSnapshotChanged enqueues the images of the storage classes of the VM snapshot to update their usage, the removal
+// is seen as the VM snapshots are only removed after the cleanup of the snapshot controller
+func (h *imageUsageHandler) OnVMSnapshotChanged(_ string, vmSnapshot *harvesterv1.VirtualMachineSnapshot) (*harvesterv1.VirtualMachineSnapshot, error) {
+	if vmSnapshot == nil {
+		return vmSnapshot, nil
+	}
+	storageClasses, _ := indexeres.VMSnapshotByStorageClass(vmSnapshot)
+	return vmSnapshot, h.enqueueImagesByStorageClass(storageClasses)
+}
+
+func (h *imageUsageHandler) enqueueImagesByStorageClass(storageClasses []string) error {
+	for _, storageClass := range storageClasses {
+		images, err := h.imageCache.GetByIndex(indexeres.ImageByStorageClassIndex, storageClass)
+		if err != nil {
+			return err
+		}
+		for _, image := range images {
+			h.images.Enqueue(image.Namespace, image.Name)
+		}
+	}
+	return nil
+}
+
+// OnTemplateVersionChanged enqueues the images of the template version to update their usage, it is also the remove
+// handler as the images of a removed template version are unknown afterwards
+func (h *imageUsageHandler) OnTemplateVersionChanged(_ string, templateVersion *harvesterv1.VirtualMachineTemplateVersion) (*harvesterv1.VirtualMachineTemplateVersion, error) {
+	if templateVersion == nil {
+		return templateVersion, nil
+	}
+	imageIDs, err := indexeres.TemplateVersionByImage(templateVersion)
+	if err != nil {
+		logrus.Warnf("failed to get the images of template version %s/%s: %v", templateVersion.Namespace, templateVersion.Name, err)
+		return templateVersion, nil
+	}
+	for _, imageID := range imageIDs {
+		namespace, name := ref.Parse(imageID)
+		h.images.Enqueue(namespace, name)
+	}
+	return templateVersion, nil
+}
+
+func (h *imageUsageHandler) OnChanged(_ string, image *harvesterv1.VirtualMachineImage) (*harvesterv1.VirtualMachineImage, error) {
+	if image == nil || image.DeletionTimestamp != nil {
+		return image, nil
+	}
+
+	usage, err := h.getUsage(image)
+	if err != nil {
+		return image, err
+	}
+	if !reflect.DeepEqual(image.Status.Usage, usage) {
+		toUpdate := image.DeepCopy()
+		toUpdate.Status.Usage = usage
+		if image, err = h.images.Update(toUpdate); err != nil {
+			return image, err
+		}
+	}
+
+	return image, h.collectGarbage(image)
+}
+
+// getUsage lists the volumes, the template versions, the VM backups and the VM snapshots of the image, the terminating
+// ones are not counted as the informers don't tell the image of a removed object
+func (h *imageUsageHandler) getUsage(image *harvesterv1.VirtualMachineImage) (*harvesterv1.VirtualMachineImageUsage, error) {
+	pvcs, err := h.pvcCache.GetByIndex(indexeres.PVCByImageIndex, ref.Construct(image.Namespace, image.Name))
+	if err != nil {
+		return nil, err
+	}
+	storageClassName := getImageStorageClassName(image)
+	storageClassPVCs, err := h.pvcCache.GetByIndex(indexeres.PVCByStorageClassIndex, storageClassName)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &harvesterv1.VirtualMachineImageUsage{}
+	volumes := map[string]bool{}
+	vms := map[string]bool{}
+	for _, pvc := range append(pvcs, storageClassPVCs...) {
+		volume := ref.Construct(pvc.Namespace, pvc.Name)
+		if pvc.DeletionTimestamp != nil || volumes[volume] {
+			continue
+		}
+		volumes[volume] = true
+		usage.Volumes = append(usage.Volumes, volume)
+		owners, err := ref.GetSchemaOwnersFromAnnotation(pvc)
+		if err != nil {
+			logrus.Warnf("failed to get the owners of pvc %s/%s: %v", pvc.Namespace, pvc.Name, err)
+			continue
+		}
+		for _, vm := range owners.List(kubevirtv1.VirtualMachineGroupVersionKind.GroupKind()) {
+			vms[vm] = true
+		}
+	}
+	for vm := range vms {
+		usage.VirtualMachines = append(usage.VirtualMachines, vm)
+	}
+
+	templateVersions, err := h.templateVersionCache.GetByIndex(indexeres.TemplateVersionByImageIndex, ref.Construct(image.Namespace, image.Name))
+	if err != nil {
+		return nil, err
+	}
+	for _, templateVersion := range templateVersions {
+		if templateVersion.DeletionTimestamp != nil {
+			continue
+		}
+		usage.TemplateVersions = append(usage.TemplateVersions, ref.Construct(templateVersion.Namespace, templateVersion.Name))
+	}
+
+	vmBackups, err := h.vmBackupCache.GetByIndex(indexeres.VMBackupByStorageClassIndex, storageClassName)
+	if err != nil {
+		return nil, err
+	}
+	for _, vmBackup := range vmBackups {
+		if vmBackup.DeletionTimestamp != nil {
+			continue
+		}
+		usage.VirtualMachineBackups = append(usage.VirtualMachineBackups, ref.Construct(vmBackup.Namespace, vmBackup.Name))
+	}
+
+	vmSnapshots, err := h.vmSnapshotCache.GetByIndex(indexeres.VMSnapshotByStorageClassIndex, storageClassName)
+	if err != nil {
+		return nil, err
+	}
+	for _, vmSnapshot := range vmSnapshots {
+		if vmSnapshot.DeletionTimestamp != nil {
+			continue
+		}
+		usage.VirtualMachineSnapshots = append(usage.VirtualMachineSnapshots, ref.Construct(vmSnapshot.Namespace, vmSnapshot.Name))
+	}
+	sort.Strings(usage.Volumes)
+	sort.Strings(usage.VirtualMachines)
+	sort.Strings(usage.TemplateVersions)
+	sort.Strings(usage.VirtualMachineBackups)
+	sort.Strings(usage.VirtualMachineSnapshots)
+
+	if previous := image.Status.Usage; previous != nil {
+		usage.LastUsedTime = previous.LastUsedTime
+		if isImageUsed(previous) && !isImageUsed(usage) {
+			now := metav1.Now()
+			usage.LastUsedTime = &now
+		}
+	}
+	if !isImageUsed(usage) && usage.LastUsedTime == nil {
+		return nil, nil
+	}
+	return usage, nil
+}
+
+func isImageUsed(usage *harvesterv1.VirtualMachineImageUsage) bool {
+	return len(usage.Volumes) > 0 || len(usage.TemplateVersions) > 0 ||
+		len(usage.VirtualMachineBackups) > 0 || len(usage.VirtualMachineSnapshots) > 0
+}
+
+// collectGarbage deletes the imported image if it is unused for the days of the image-garbage-collection-days setting,
+// the image is unused since its creation if it was never used
+func (h *imageUsageHandler) collectGarbage(image *harvesterv1.VirtualMachineImage) error {
+	days := settings.ImageGarbageCollectionDays.GetInt()
+	if days <= 0 || !harvesterv1.ImageImported.IsTrue(image) {
+		return nil
+	}
+	unusedSince := image.CreationTimestamp
+	if usage := image.Status.Usage; usage != nil {
+		if isImageUsed(usage) {
+			return nil
+		}
+		if usage.LastUsedTime != nil {
+			unusedSince = *usage.LastUsedTime
+		}
+	}
+
+	expiration := unusedSince.Add(time.Duration(days) * 24 * time.Hour)
+	if remaining := time.Until(expiration); remaining > 0 {
+		h.images.EnqueueAfter(image.Namespace, image.Name, remaining)
+		return nil
+	}
+	logrus.Infof("deleting image %s/%s unused since %s", image.Namespace, image.Name, unusedSince)
+	if err := h.images.Delete(image.Namespace, image.Name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package image
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/pointer"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/fake"
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/util"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+func TestGetUsage(t *testing.T) {
+	lastUsedTime := metav1.NewTime(time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC))
+	image := &harvesterv1.VirtualMachineImage{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "image"},
+		Status:     harvesterv1.VirtualMachineImageStatus{StorageClassName: "longhorn-image"},
+	}
+	newStorageClassPVC := func(namespace, name, storageClassName string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: pointer.StringPtr(storageClassName)},
+		}
+	}
+	newVolumeBackups := func(storageClassName string) []harvesterv1.VolumeBackup {
+		return []harvesterv1.VolumeBackup{
+			{
+				VolumeName: "disk",
+				PersistentVolumeClaim: harvesterv1.PersistentVolumeClaimSourceSpec{
+					Spec: corev1.PersistentVolumeClaimSpec{StorageClassName: pointer.StringPtr(storageClassName)},
+				},
+			},
+		}
+	}
+	newVMBackup := func(namespace, name, storageClassName string) *harvesterv1.VirtualMachineBackup {
+		return &harvesterv1.VirtualMachineBackup{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Status:     &harvesterv1.VirtualMachineBackupStatus{VolumeBackups: newVolumeBackups(storageClassName)},
+		}
+	}
+	newVMSnapshot := func(namespace, name, storageClassName string) *harvesterv1.VirtualMachineSnapshot {
+		return &harvesterv1.VirtualMachineSnapshot{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Status:     &harvesterv1.VirtualMachineSnapshotStatus{VolumeSnapshots: newVolumeBackups(storageClassName)},
+		}
+	}
+	newPVC := func(namespace, name, imageID, owners string) *corev1.PersistentVolumeClaim {
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   namespace,
+				Name:        name,
+				Annotations: map[string]string{util.AnnotationImageID: imageID},
+			},
+		}
+		if owners != "" {
+			pvc.Annotations[ref.AnnotationSchemaOwnerKeyName] = owners
+		}
+		return pvc
+	}
+	terminating := newPVC("default", "terminating", "default/image", "")
+	terminating.DeletionTimestamp = &lastUsedTime
+	newTemplateVersion := func(namespace, name, imageID, volumeImageID string) *harvesterv1.VirtualMachineTemplateVersion {
+		templateVersion := &harvesterv1.VirtualMachineTemplateVersion{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec:       harvesterv1.VirtualMachineTemplateVersionSpec{ImageID: imageID},
+		}
+		if volumeImageID != "" {
+			templateVersion.Spec.VM.ObjectMeta.Annotations = map[string]string{
+				util.AnnotationVolumeClaimTemplates: `[{"metadata":{"name":"disk","annotations":{"` + util.AnnotationImageID + `":"` + volumeImageID + `"}}}]`,
+			}
+		}
+		return templateVersion
+	}
+
+	var testCases = []struct {
+		name             string
+		pvcs             []*corev1.PersistentVolumeClaim
+		templateVersions []*harvesterv1.VirtualMachineTemplateVersion
+		vmBackups        []*harvesterv1.VirtualMachineBackup
+		vmSnapshots      []*harvesterv1.VirtualMachineSnapshot
+		previous         *harvesterv1.VirtualMachineImageUsage
+		expected         *harvesterv1.VirtualMachineImageUsage
+	}{
+		{
+			name: "never used",
+			pvcs: []*corev1.PersistentVolumeClaim{
+				newPVC("default", "other", "default/other-image", ""),
+			},
+		},
+		{
+			name: "used by volumes and vms",
+			pvcs: []*corev1.PersistentVolumeClaim{
+				newPVC("default", "vm1-disk", "default/image", `[{"schema":"kubevirt.io.virtualmachine","refs":["default/vm1"]}]`),
+				newPVC("test", "vm2-disk", "default/image", `[{"schema":"kubevirt.io.virtualmachine","refs":["test/vm2"]}]`),
+				newPVC("default", "data", "default/image", ""),
+				terminating,
+			},
+			expected: &harvesterv1.VirtualMachineImageUsage{
+				Volumes:         []string{"default/data", "default/vm1-disk", "test/vm2-disk"},
+				VirtualMachines: []string{"default/vm1", "test/vm2"},
+			},
+		},
+		{
+			name: "used by template versions",
+			templateVersions: []*harvesterv1.VirtualMachineTemplateVersion{
+				newTemplateVersion("default", "by-image-id", "default/image", ""),
+				newTemplateVersion("public", "by-volume", "", "default/image"),
+				newTemplateVersion("default", "other", "", "default/other-image"),
+			},
+			expected: &harvesterv1.VirtualMachineImageUsage{
+				TemplateVersions: []string{"default/by-image-id", "public/by-volume"},
+			},
+		},
+		{
+			name: "used by volumes of the image storage class",
+			pvcs: []*corev1.PersistentVolumeClaim{
+				newPVC("default", "vm1-disk", "default/image", ""),
+				newStorageClassPVC("default", "restored", "longhorn-image"),
+				newStorageClassPVC("default", "other", "longhorn"),
+			},
+			expected: &harvesterv1.VirtualMachineImageUsage{
+				Volumes: []string{"default/restored", "default/vm1-disk"},
+			},
+		},
+		{
+			name: "used by vm backups and vm snapshots",
+			vmBackups: []*harvesterv1.VirtualMachineBackup{
+				newVMBackup("default", "backup", "longhorn-image"),
+				newVMBackup("default", "other", "longhorn"),
+			},
+			vmSnapshots: []*harvesterv1.VirtualMachineSnapshot{
+				newVMSnapshot("test", "snapshot", "longhorn-image"),
+			},
+			expected: &harvesterv1.VirtualMachineImageUsage{
+				VirtualMachineBackups:   []string{"default/backup"},
+				VirtualMachineSnapshots: []string{"test/snapshot"},
+			},
+		},
+		{
+			name: "still unused",
+			previous: &harvesterv1.VirtualMachineImageUsage{
+				LastUsedTime: &lastUsedTime,
+			},
+			expected: &harvesterv1.VirtualMachineImageUsage{
+				LastUsedTime: &lastUsedTime,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		clientset := corefake.NewSimpleClientset()
+		for _, pvc := range tc.pvcs {
+			_, err := clientset.CoreV1().PersistentVolumeClaims(pvc.Namespace).Create(context.TODO(), pvc, metav1.CreateOptions{})
+			assert.Nil(t, err, "case %q", tc.name)
+		}
+		harvesterClientset := fake.NewSimpleClientset()
+		for _, templateVersion := range tc.templateVersions {
+			_, err := harvesterClientset.HarvesterhciV1beta1().VirtualMachineTemplateVersions(templateVersion.Namespace).Create(context.TODO(), templateVersion, metav1.CreateOptions{})
+			assert.Nil(t, err, "case %q", tc.name)
+		}
+		for _, vmBackup := range tc.vmBackups {
+			_, err := harvesterClientset.HarvesterhciV1beta1().VirtualMachineBackups(vmBackup.Namespace).Create(context.TODO(), vmBackup, metav1.CreateOptions{})
+			assert.Nil(t, err, "case %q", tc.name)
+		}
+		for _, vmSnapshot := range tc.vmSnapshots {
+			_, err := harvesterClientset.HarvesterhciV1beta1().VirtualMachineSnapshots(vmSnapshot.Namespace).Create(context.TODO(), vmSnapshot, metav1.CreateOptions{})
+			assert.Nil(t, err, "case %q", tc.name)
+		}
+		h := &imageUsageHandler{
+			pvcCache:             fakeclients.PersistentVolumeClaimCache(clientset.CoreV1().PersistentVolumeClaims),
+			templateVersionCache: fakeclients.VirtualMachineTemplateVersionCache(harvesterClientset.HarvesterhciV1beta1().VirtualMachineTemplateVersions),
+			vmBackupCache:        fakeclients.VirtualMachineBackupCache(harvesterClientset.HarvesterhciV1beta1().VirtualMachineBackups),
+			vmSnapshotCache:      fakeclients.VirtualMachineSnapshotCache(harvesterClientset.HarvesterhciV1beta1().VirtualMachineSnapshots),
+		}
+		toCheck := image.DeepCopy()
+		toCheck.Status.Usage = tc.previous
+		actual, err := h.getUsage(toCheck)
+		assert.Nil(t, err, "case %q", tc.name)
+		assert.Equal(t, tc.expected, actual, "case %q", tc.name)
+	}
+}
+
+func TestGetUsageBecomesUnused(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		previous *harvesterv1.VirtualMachineImageUsage
+	}{
+		{
+			name:     "volume removed",
+			previous: &harvesterv1.VirtualMachineImageUsage{Volumes: []string{"default/removed"}},
+		},
+		{
+			name:     "template version removed",
+			previous: &harvesterv1.VirtualMachineImageUsage{TemplateVersions: []string{"default/removed"}},
+		},
+		{
+			name:     "vm backup removed",
+			previous: &harvesterv1.VirtualMachineImageUsage{VirtualMachineBackups: []string{"default/removed"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		image := &harvesterv1.VirtualMachineImage{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "image"},
+			Status:     harvesterv1.VirtualMachineImageStatus{Usage: tc.previous},
+		}
+		harvesterClientset := fake.NewSimpleClientset()
+		h := &imageUsageHandler{
+			pvcCache:             fakeclients.PersistentVolumeClaimCache(corefake.NewSimpleClientset().CoreV1().PersistentVolumeClaims),
+			templateVersionCache: fakeclients.VirtualMachineTemplateVersionCache(harvesterClientset.HarvesterhciV1beta1().VirtualMachineTemplateVersions),
+			vmBackupCache:        fakeclients.VirtualMachineBackupCache(harvesterClientset.HarvesterhciV1beta1().VirtualMachineBackups),
+			vmSnapshotCache:      fakeclients.VirtualMachineSnapshotCache(harvesterClientset.HarvesterhciV1beta1().VirtualMachineSnapshots),
+		}
+
+		actual, err := h.getUsage(image)
+		assert.Nil(t, err, "case %q", tc.name)
+		assert.Empty(t, actual.Volumes, "case %q", tc.name)
+		assert.Empty(t, actual.TemplateVersions, "case %q", tc.name)
+		assert.Empty(t, actual.VirtualMachineBackups, "case %q", tc.name)
+		assert.NotNil(t, actual.LastUsedTime, "case %q", tc.name)
+	}
+}
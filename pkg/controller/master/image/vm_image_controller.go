@@ -1,13 +1,17 @@
 package image
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
 	"github.com/longhorn/longhorn-manager/types"
 	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
 	v1 "github.com/rancher/wrangler/pkg/generated/controllers/storage/v1"
+	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -15,10 +19,12 @@ import (
 	"k8s.io/utils/pointer"
 
 	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/builder"
 	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
 	lhv1beta1 "github.com/harvester/harvester/pkg/generated/controllers/longhorn.io/v1beta1"
 	"github.com/harvester/harvester/pkg/ref"
 	"github.com/harvester/harvester/pkg/util"
+	"github.com/harvester/harvester/pkg/util/diskimage"
 )
 
 const (
@@ -28,11 +34,15 @@ const (
 
 // vmImageHandler syncs status on vm image changes, and manage a storageclass & a backingimage per vm image
 type vmImageHandler struct {
-	httpClient     http.Client
-	storageClasses v1.StorageClassClient
-	images         ctlharvesterv1.VirtualMachineImageClient
-	backingImages  lhv1beta1.BackingImageClient
-	pvcCache       ctlcorev1.PersistentVolumeClaimCache
+	storageClasses    v1.StorageClassClient
+	storageClassCache v1.StorageClassCache
+	images            ctlharvesterv1.VirtualMachineImageClient
+	backingImages     lhv1beta1.BackingImageClient
+	pvcCache          ctlcorev1.PersistentVolumeClaimCache
+	secretCache       ctlcorev1.SecretCache
+	downloader        *imageDownloader
+	verifier          *checksumVerifier
+	uploadDir         string
 }
 
 func (h *vmImageHandler) OnChanged(_ string, image *harvesterv1.VirtualMachineImage) (*harvesterv1.VirtualMachineImage, error) {
@@ -43,13 +53,23 @@ func (h *vmImageHandler) OnChanged(_ string, image *harvesterv1.VirtualMachineIm
 		return h.initialize(image)
 	} else if image.Spec.URL != image.Status.AppliedURL {
 		// URL is changed, recreate the storageclass and backingimage
+		h.downloader.stop(image)
+		h.verifier.stop(image)
 		if err := h.backingImages.Delete(util.LonghornSystemNamespaceName, getBackingImageName(image), &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
 			return image, err
 		}
-		if err := h.storageClasses.Delete(getImageStorageClassName(image.Name), &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		if err := h.storageClasses.Delete(getImageStorageClassName(image), &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
 			return image, err
 		}
 		return h.initialize(image)
+	} else if isDownloading(image) && !h.downloader.isRunning(image) {
+		// resume the download interrupted by a restart
+		h.downloader.start(image)
+	}
+	if harvesterv1.ImageInitialized.IsTrue(image) {
+		if err := h.reconcileStorageClass(image); err != nil {
+			return image, err
+		}
 	}
 	return image, nil
 }
@@ -58,7 +78,13 @@ func (h *vmImageHandler) OnRemove(_ string, image *harvesterv1.VirtualMachineIma
 	if image == nil {
 		return nil, nil
 	}
-	scName := getImageStorageClassName(image.Name)
+	h.downloader.stop(image)
+	h.verifier.stop(image)
+	// the chunks of an unfinished chunked upload are staged on the shared upload volume
+	if err := os.Remove(util.GetImageUploadStagingPath(h.uploadDir, image)); err != nil && !os.IsNotExist(err) {
+		logrus.Errorf("failed to remove the staged upload of image %s/%s: %v", image.Namespace, image.Name, err)
+	}
+	scName := getImageStorageClassName(image)
 	if err := h.storageClasses.Delete(scName, &metav1.DeleteOptions{}); !errors.IsNotFound(err) && err != nil {
 		return image, err
 	}
@@ -70,19 +96,50 @@ func (h *vmImageHandler) OnRemove(_ string, image *harvesterv1.VirtualMachineIma
 }
 
 func (h *vmImageHandler) initialize(image *harvesterv1.VirtualMachineImage) (*harvesterv1.VirtualMachineImage, error) {
-	if err := h.createBackingImage(image); err != nil && !errors.IsAlreadyExists(err) {
+	toUpdate := image.DeepCopy()
+	toUpdate.Status.AppliedURL = toUpdate.Spec.URL
+	toUpdate.Status.StorageClassName = builder.BuildImageStorageClassName(image.Namespace, image.Name)
+
+	var source *sourceClient
+	if image.Spec.SourceType == harvesterv1.VirtualMachineImageSourceTypeDownload {
+		var err error
+		if source, err = getSourceClient(h.secretCache, image, 15*time.Second); err != nil {
+			harvesterv1.ImageInitialized.False(toUpdate)
+			harvesterv1.ImageInitialized.Message(toUpdate, err.Error())
+			return h.images.Update(toUpdate)
+		}
+	}
+
+	info, err := h.detectFormat(image, source)
+	if err != nil {
+		logrus.Warnf("failed to detect the format of image %s/%s: %v", image.Namespace, image.Name, err)
+	}
+	convert := diskimage.NeedsConversion(info.Format)
+	download, err := isDownloadedByHarvester(image, convert)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.createBackingImage(image, download); err != nil && !errors.IsAlreadyExists(err) {
 		return nil, err
 	}
-	if err := h.createStorageClass(image); err != nil && !errors.IsAlreadyExists(err) {
+	if err := h.createStorageClass(toUpdate); err != nil && !errors.IsAlreadyExists(err) {
 		return nil, err
 	}
 
-	toUpdate := image.DeepCopy()
-	toUpdate.Status.AppliedURL = toUpdate.Spec.URL
-	toUpdate.Status.StorageClassName = getImageStorageClassName(image.Name)
+	toUpdate.Status.Format = info.Format
+	toUpdate.Status.VirtualSize = info.VirtualSize
+	// the OS of an upload is guessed once the format of the uploaded file is detected
+	if toUpdate.Spec.OSInfo == nil && info.Format != "" {
+		toUpdate.Spec.OSInfo = util.GuessImageOSInfo(image, info)
+	}
 
 	if image.Spec.SourceType == harvesterv1.VirtualMachineImageSourceTypeDownload {
-		resp, err := h.httpClient.Head(image.Spec.URL)
+		req, err := source.newRequest(context.Background(), http.MethodHead, image.Spec.URL)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := source.do(req)
 		if err != nil {
 			harvesterv1.ImageInitialized.False(toUpdate)
 			harvesterv1.ImageInitialized.Message(toUpdate, err.Error())
@@ -105,13 +162,43 @@ func (h *vmImageHandler) initialize(image *harvesterv1.VirtualMachineImage) (*ha
 
 	harvesterv1.ImageImported.Unknown(toUpdate)
 	harvesterv1.ImageImported.Reason(toUpdate, "Importing")
+	harvesterv1.ImageImported.Message(toUpdate, "")
+	if download {
+		harvesterv1.ImageImported.Reason(toUpdate, reasonDownloading)
+	}
+	if toUpdate.Spec.Checksum != "" {
+		harvesterv1.ImageVerified.Unknown(toUpdate)
+		harvesterv1.ImageVerified.Reason(toUpdate, "Verifying")
+		harvesterv1.ImageVerified.Message(toUpdate, "")
+	}
 	harvesterv1.ImageInitialized.True(toUpdate)
 	harvesterv1.ImageInitialized.Reason(toUpdate, "Initialized")
 
-	return h.images.Update(toUpdate)
+	updated, err := h.images.Update(toUpdate)
+	if err == nil && download {
+		h.downloader.start(updated)
+	}
+	return updated, err
+}
+
+// detectFormat detects the format of the image source, the format of an upload is detected when it is uploaded
+func (h *vmImageHandler) detectFormat(image *harvesterv1.VirtualMachineImage, source *sourceClient) (diskimage.Info, error) {
+	switch image.Spec.SourceType {
+	case harvesterv1.VirtualMachineImageSourceTypeDownload:
+		return detectRemoteFormat(source, image.Spec.URL)
+	case harvesterv1.VirtualMachineImageSourceTypeExportVolume:
+		pvc, err := h.pvcCache.Get(image.Spec.PVCNamespace, image.Spec.PVCName)
+		if err != nil {
+			return diskimage.Info{}, err
+		}
+		return diskimage.Info{Format: diskimage.FormatRaw, VirtualSize: pvc.Spec.Resources.Requests.Storage().Value()}, nil
+	}
+	return diskimage.Info{}, nil
 }
 
-func (h *vmImageHandler) createBackingImage(image *harvesterv1.VirtualMachineImage) error {
+// createBackingImage creates the backing image of the vm image, the backing image of an image downloaded by Harvester
+// waits for the downloaded file to be uploaded.
+func (h *vmImageHandler) createBackingImage(image *harvesterv1.VirtualMachineImage, download bool) error {
 	bi := &v1beta1.BackingImage{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      getBackingImageName(image),
@@ -125,7 +212,9 @@ func (h *vmImageHandler) createBackingImage(image *harvesterv1.VirtualMachineIma
 			SourceParameters: map[string]string{},
 		},
 	}
-	if image.Spec.SourceType == harvesterv1.VirtualMachineImageSourceTypeDownload {
+	if download {
+		bi.Spec.SourceType = types.BackingImageDataSourceTypeUpload
+	} else if image.Spec.SourceType == harvesterv1.VirtualMachineImageSourceTypeDownload {
 		bi.Spec.SourceParameters[types.DataSourceTypeDownloadParameterURL] = image.Spec.URL
 	}
 
@@ -148,26 +237,30 @@ func (h *vmImageHandler) createStorageClass(image *harvesterv1.VirtualMachineIma
 	volumeBindingMode := storagev1.VolumeBindingImmediate
 	sc := &storagev1.StorageClass{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: getImageStorageClassName(image.Name),
+			Name: getImageStorageClassName(image),
 		},
 		Provisioner:          types.LonghornDriverName,
 		ReclaimPolicy:        &recliamPolicy,
 		AllowVolumeExpansion: pointer.BoolPtr(true),
 		VolumeBindingMode:    &volumeBindingMode,
-		Parameters: map[string]string{
-			types.OptionNumberOfReplicas:    "3",
-			types.OptionStaleReplicaTimeout: "30",
-			optionMigratable:                "true",
-			optionBackingImageName:          getBackingImageName(image),
-		},
 	}
+	parameters, err := getStorageClassParameters(image)
+	if err != nil {
+		return err
+	}
+	sc.Parameters = parameters
 
-	_, err := h.storageClasses.Create(sc)
+	_, err = h.storageClasses.Create(sc)
 	return err
 }
 
-func getImageStorageClassName(imageName string) string {
-	return fmt.Sprintf("longhorn-%s", imageName)
+// getImageStorageClassName returns the storageclass of the image, the name includes the image namespace
+// unless the image was initialized before, when the storageclass was named after the image name only
+func getImageStorageClassName(image *harvesterv1.VirtualMachineImage) string {
+	if image.Status.StorageClassName != "" {
+		return image.Status.StorageClassName
+	}
+	return builder.BuildImageStorageClassName(image.Namespace, image.Name)
 }
 
 func getBackingImageName(image *harvesterv1.VirtualMachineImage) string {
@@ -34,7 +34,10 @@ var registerFuncs = []registerFunc{
 	virtualmachine.Register,
 	backup.RegisterBackup,
 	backup.RegisterRestore,
+	backup.RegisterSnapshot,
 	backup.RegisterBackupTarget,
+	backup.RegisterBackupSchedule,
+	backup.RegisterBackupRetention,
 	supportbundle.Register,
 	rancher.Register,
 	upgrade.Register,
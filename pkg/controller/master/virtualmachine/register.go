@@ -12,6 +12,8 @@ const (
 	vmControllerUnsetOwnerOfPVCsControllerName         = "VMController.UnsetOwnerOfPVCs"
 	vmiControllerUnsetOwnerOfPVCsControllerName        = "VMIController.UnsetOwnerOfPVCs"
 	vmControllerSetDefaultManagementNetworkMac         = "VMController.SetDefaultManagementNetworkMacAddress"
+	vmCloneControllerName                              = "VMCloneController.OnChanged"
+	vmResizeControllerName                             = "VMResizeController.OnChanged"
 )
 
 func Register(ctx context.Context, management *config.Management, options config.Options) error {
@@ -28,6 +30,21 @@ func Register(ctx context.Context, management *config.Management, options config
 	virtualMachineClient.OnChange(ctx, vmControllerSetOwnerOfPVCsControllerName, vmCtrl.SetOwnerOfPVCs)
 	virtualMachineClient.OnRemove(ctx, vmControllerUnsetOwnerOfPVCsControllerName, vmCtrl.UnsetOwnerOfPVCs)
 
+	// registers the vm clone controller
+	var vmCloneCtrl = &VMCloneController{
+		vms:      virtualMachineClient,
+		pvcCache: pvcCache,
+	}
+	virtualMachineClient.OnChange(ctx, vmCloneControllerName, vmCloneCtrl.OnChanged)
+
+	// registers the vm resize controller
+	var virtualMachineInstanceClient = management.VirtFactory.Kubevirt().V1().VirtualMachineInstance()
+	var vmResizeCtrl = &VMResizeController{
+		vms:      virtualMachineClient,
+		vmiCache: virtualMachineInstanceClient.Cache(),
+	}
+	virtualMachineClient.OnChange(ctx, vmResizeControllerName, vmResizeCtrl.OnChanged)
+
 	// registers the vmi controller
 	var virtualMachineCache = virtualMachineClient.Cache()
 	var vmiCtrl = &VMIController{
@@ -35,7 +52,6 @@ func Register(ctx context.Context, management *config.Management, options config
 		pvcClient:           pvcClient,
 		pvcCache:            pvcCache,
 	}
-	var virtualMachineInstanceClient = management.VirtFactory.Kubevirt().V1().VirtualMachineInstance()
 	virtualMachineInstanceClient.OnRemove(ctx, vmiControllerUnsetOwnerOfPVCsControllerName, vmiCtrl.UnsetOwnerOfPVCs)
 
 	// register the vm network controller upon the VMI changes
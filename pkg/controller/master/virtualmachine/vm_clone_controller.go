@@ -0,0 +1,83 @@
+package virtualmachine
+
+import (
+	"reflect"
+	"strconv"
+	"time"
+
+	v1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/pointer"
+	kv1 "kubevirt.io/client-go/api/v1"
+
+	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	cloneCompleted        = "100"
+	cloneProgressInterval = 5 * time.Second
+)
+
+// VMCloneController tracks the progress of the VM clones created by the clone action. The progress is the percentage
+// of the volume clones which are bound, the clone is started once all of them are if it was requested.
+type VMCloneController struct {
+	vms      ctlkubevirtv1.VirtualMachineController
+	pvcCache v1.PersistentVolumeClaimCache
+}
+
+func (h *VMCloneController) OnChanged(_ string, vm *kv1.VirtualMachine) (*kv1.VirtualMachine, error) {
+	if vm == nil || vm.DeletionTimestamp != nil || vm.Spec.Template == nil {
+		return vm, nil
+	}
+	if progress, ok := vm.Annotations[util.AnnotationCloneProgress]; !ok || progress == cloneCompleted {
+		return vm, nil
+	}
+
+	var bound, total int
+	for _, pvcName := range getPVCNames(&vm.Spec.Template.Spec).List() {
+		total++
+		pvc, err := h.pvcCache.Get(vm.Namespace, pvcName)
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return vm, err
+		}
+		if pvc.Status.Phase == corev1.ClaimBound {
+			bound++
+		}
+	}
+
+	toUpdate := vm.DeepCopy()
+	setCloneProgress(toUpdate, bound, total)
+	if !reflect.DeepEqual(vm, toUpdate) {
+		updated, err := h.vms.Update(toUpdate)
+		if err != nil {
+			return vm, err
+		}
+		vm = updated
+	}
+	// the PVC changes don't trigger the VM handlers
+	if vm.Annotations[util.AnnotationCloneProgress] != cloneCompleted {
+		h.vms.EnqueueAfter(vm.Namespace, vm.Name, cloneProgressInterval)
+	}
+	return vm, nil
+}
+
+// setCloneProgress sets the clone progress of the VM, the VM is started when the clone completes if requested
+func setCloneProgress(vm *kv1.VirtualMachine, bound, total int) {
+	progress := 100
+	if total > 0 {
+		progress = bound * 100 / total
+	}
+	vm.Annotations[util.AnnotationCloneProgress] = strconv.Itoa(progress)
+	if progress < 100 {
+		return
+	}
+	if vm.Annotations[util.AnnotationCloneRunning] == "true" {
+		vm.Spec.Running = pointer.BoolPtr(true)
+		vm.Spec.RunStrategy = nil
+	}
+	delete(vm.Annotations, util.AnnotationCloneRunning)
+}
@@ -2072,3 +2072,58 @@ func MustPVCTemplatesToString(pvcs []corev1.PersistentVolumeClaim) string {
 	}
 	return result
 }
+
+func TestSetCloneProgress(t *testing.T) {
+	var testCases = []struct {
+		name            string
+		running         bool
+		bound           int
+		total           int
+		expectedRunning bool
+		expected        string
+	}{
+		{
+			name:     "cloning",
+			running:  true,
+			bound:    1,
+			total:    3,
+			expected: "33",
+		},
+		{
+			name:            "cloned and started",
+			running:         true,
+			bound:           2,
+			total:           2,
+			expectedRunning: true,
+			expected:        "100",
+		},
+		{
+			name:     "cloned and stopped",
+			bound:    2,
+			total:    2,
+			expected: "100",
+		},
+		{
+			name:     "no volumes",
+			expected: "100",
+		},
+	}
+
+	for _, tc := range testCases {
+		vm := &kubevirtapis.VirtualMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{util.AnnotationCloneProgress: "0"},
+			},
+			Spec: kubevirtapis.VirtualMachineSpec{Running: pointer.BoolPtr(false)},
+		}
+		if tc.running {
+			vm.Annotations[util.AnnotationCloneRunning] = "true"
+		}
+		setCloneProgress(vm, tc.bound, tc.total)
+		assert.Equal(t, tc.expected, vm.Annotations[util.AnnotationCloneProgress], "case %q", tc.name)
+		assert.Equal(t, tc.expectedRunning, *vm.Spec.Running, "case %q", tc.name)
+		if tc.expected == cloneCompleted {
+			assert.NotContains(t, vm.Annotations, util.AnnotationCloneRunning, "case %q", tc.name)
+		}
+	}
+}
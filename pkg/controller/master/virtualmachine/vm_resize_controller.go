@@ -0,0 +1,101 @@
+package virtualmachine
+
+import (
+	"encoding/json"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	kv1 "kubevirt.io/client-go/api/v1"
+
+	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+// PendingResize is the value of the pending resize annotation, the CPU cores and memory the VM is resized to.
+// KubeVirt applies the change when the VMI is created, so a running VM keeps the annotation until it is restarted.
+type PendingResize struct {
+	Cores  uint32 `json:"cores"`
+	Memory string `json:"memory"`
+}
+
+// VMResizeController removes the pending resize annotation of the resized VMs once the VMI runs with the CPU cores
+// and memory of the annotation, or once the VM is stopped and the change is applied on the next start. The
+// annotation is kept on the VM metadata as KubeVirt owns the VM status and drops the conditions it doesn't know.
+type VMResizeController struct {
+	vms      ctlkubevirtv1.VirtualMachineClient
+	vmiCache ctlkubevirtv1.VirtualMachineInstanceCache
+}
+
+func (h *VMResizeController) OnChanged(_ string, vm *kv1.VirtualMachine) (*kv1.VirtualMachine, error) {
+	if vm == nil || vm.DeletionTimestamp != nil || !IsRestartRequired(vm) {
+		return vm, nil
+	}
+
+	resize, err := GetPendingResize(vm)
+	if err != nil {
+		logrus.Warnf("removing the invalid pending resize of VM %s/%s: %v", vm.Namespace, vm.Name, err)
+	} else {
+		vmi, err := h.vmiCache.Get(vm.Namespace, vm.Name)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return vm, err
+		}
+		if err == nil && !vmi.IsFinal() && !IsResizeApplied(resize, vmi) {
+			return vm, nil
+		}
+	}
+
+	toUpdate := vm.DeepCopy()
+	delete(toUpdate.Annotations, util.AnnotationPendingResize)
+	return h.vms.Update(toUpdate)
+}
+
+// GetPendingResize returns the pending resize of the VM, it is nil if the VM has no change to apply on a restart
+func GetPendingResize(vm *kv1.VirtualMachine) (*PendingResize, error) {
+	value, ok := vm.Annotations[util.AnnotationPendingResize]
+	if !ok {
+		return nil, nil
+	}
+	resize := &PendingResize{}
+	if err := json.Unmarshal([]byte(value), resize); err != nil {
+		return nil, err
+	}
+	if _, err := resource.ParseQuantity(resize.Memory); err != nil {
+		return nil, err
+	}
+	return resize, nil
+}
+
+// SetPendingResize sets the pending resize annotation of the VM
+func SetPendingResize(vm *kv1.VirtualMachine, resize *PendingResize) error {
+	value, err := json.Marshal(resize)
+	if err != nil {
+		return err
+	}
+	if vm.Annotations == nil {
+		vm.Annotations = map[string]string{}
+	}
+	vm.Annotations[util.AnnotationPendingResize] = string(value)
+	return nil
+}
+
+// IsRestartRequired returns true if the VM has a change which is applied when it is restarted
+func IsRestartRequired(vm *kv1.VirtualMachine) bool {
+	_, ok := vm.Annotations[util.AnnotationPendingResize]
+	return ok
+}
+
+// IsResizeApplied returns true if the VMI runs with the CPU cores and memory of the resize
+func IsResizeApplied(resize *PendingResize, vmi *kv1.VirtualMachineInstance) bool {
+	memory, err := resource.ParseQuantity(resize.Memory)
+	if err != nil {
+		return false
+	}
+	var vmiCores uint32
+	if cpu := vmi.Spec.Domain.CPU; cpu != nil {
+		vmiCores = cpu.Cores
+	}
+	vmiMemory := vmi.Spec.Domain.Resources.Requests[corev1.ResourceMemory]
+	return resize.Cores == vmiCores && memory.Cmp(vmiMemory) == 0
+}
@@ -0,0 +1,132 @@
+package virtualmachine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kv1 "kubevirt.io/client-go/api/v1"
+
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/fake"
+	"github.com/harvester/harvester/pkg/util"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+func newResizeTestVM(pendingResize string) *kv1.VirtualMachine {
+	vm := &kv1.VirtualMachine{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+		Spec: kv1.VirtualMachineSpec{
+			Template: &kv1.VirtualMachineInstanceTemplateSpec{
+				Spec: newResizeTestVMISpec(4, "8Gi"),
+			},
+		},
+	}
+	if pendingResize != "" {
+		vm.Annotations = map[string]string{util.AnnotationPendingResize: pendingResize}
+	}
+	return vm
+}
+
+func newResizeTestVMISpec(cores uint32, memory string) kv1.VirtualMachineInstanceSpec {
+	return kv1.VirtualMachineInstanceSpec{
+		Domain: kv1.DomainSpec{
+			CPU: &kv1.CPU{Cores: cores},
+			Resources: kv1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(memory)},
+			},
+		},
+	}
+}
+
+func newResizeTestVMI(cores uint32, memory string, phase kv1.VirtualMachineInstancePhase) *kv1.VirtualMachineInstance {
+	return &kv1.VirtualMachineInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+		Spec:       newResizeTestVMISpec(cores, memory),
+		Status:     kv1.VirtualMachineInstanceStatus{Phase: phase},
+	}
+}
+
+func TestVMResizeController_OnChanged(t *testing.T) {
+	const pendingResize = `{"cores":4,"memory":"8Gi"}`
+	var testCases = []struct {
+		name            string
+		vm              *kv1.VirtualMachine
+		vmi             *kv1.VirtualMachineInstance
+		restartRequired bool
+	}{
+		{
+			name:            "running with the former resources",
+			vm:              newResizeTestVM(pendingResize),
+			vmi:             newResizeTestVMI(2, "4Gi", kv1.Running),
+			restartRequired: true,
+		},
+		{
+			name:            "restarted with the new resources",
+			vm:              newResizeTestVM(pendingResize),
+			vmi:             newResizeTestVMI(4, "8Gi", kv1.Running),
+			restartRequired: false,
+		},
+		{
+			name:            "memory of the same size",
+			vm:              newResizeTestVM(pendingResize),
+			vmi:             newResizeTestVMI(4, "8192Mi", kv1.Running),
+			restartRequired: false,
+		},
+		{
+			name:            "stopped",
+			vm:              newResizeTestVM(pendingResize),
+			restartRequired: false,
+		},
+		{
+			name:            "VMI succeeded",
+			vm:              newResizeTestVM(pendingResize),
+			vmi:             newResizeTestVMI(2, "4Gi", kv1.Succeeded),
+			restartRequired: false,
+		},
+		{
+			name:            "invalid pending resize",
+			vm:              newResizeTestVM(`{"cores":4,"memory":"a lot"}`),
+			vmi:             newResizeTestVMI(2, "4Gi", kv1.Running),
+			restartRequired: false,
+		},
+		{
+			name:            "not resized",
+			vm:              newResizeTestVM(""),
+			vmi:             newResizeTestVMI(2, "4Gi", kv1.Running),
+			restartRequired: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		var clientset = fake.NewSimpleClientset(tc.vm)
+		if tc.vmi != nil {
+			assert.Nil(t, clientset.Tracker().Add(tc.vmi), "mock resource should add into fake controller tracker")
+		}
+		var ctrl = &VMResizeController{
+			vms:      fakeclients.VirtualMachineClient(clientset.KubevirtV1().VirtualMachines),
+			vmiCache: fakeclients.VirtualMachineInstanceCache(clientset.KubevirtV1().VirtualMachineInstances),
+		}
+
+		actual, err := ctrl.OnChanged("", tc.vm)
+		assert.Nil(t, err, "case %q", tc.name)
+		assert.Equal(t, tc.restartRequired, IsRestartRequired(actual), "case %q", tc.name)
+		stored, err := clientset.KubevirtV1().VirtualMachines("default").Get(context.TODO(), "test", metav1.GetOptions{})
+		assert.Nil(t, err, "case %q", tc.name)
+		assert.Equal(t, tc.restartRequired, IsRestartRequired(stored), "case %q", tc.name)
+	}
+}
+
+func TestSetPendingResize(t *testing.T) {
+	vm := &kv1.VirtualMachine{}
+	assert.False(t, IsRestartRequired(vm))
+
+	assert.Nil(t, SetPendingResize(vm, &PendingResize{Cores: 2, Memory: "4Gi"}))
+	assert.Nil(t, SetPendingResize(vm, &PendingResize{Cores: 4, Memory: "8Gi"}))
+	assert.True(t, IsRestartRequired(vm))
+	resize, err := GetPendingResize(vm)
+	assert.Nil(t, err)
+	assert.Equal(t, &PendingResize{Cores: 4, Memory: "8Gi"}, resize, "the latest resize is pending")
+}
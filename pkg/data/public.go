@@ -4,10 +4,12 @@ import (
 	"github.com/rancher/wrangler/pkg/apply"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/harvester/harvester/pkg/util"
 )
 
 const (
-	publicNamespace = "harvester-public"
+	publicNamespace = util.PublicNamespaceName
 )
 
 func addPublicNamespace(apply apply.Apply) error {
@@ -28,6 +28,14 @@ type FakeHarvesterhciV1beta1 struct {
 	*testing.Fake
 }
 
+func (c *FakeHarvesterhciV1beta1) BackupTargets() v1beta1.BackupTargetInterface {
+	return &FakeBackupTargets{c}
+}
+
+func (c *FakeHarvesterhciV1beta1) ImageCatalogs(namespace string) v1beta1.ImageCatalogInterface {
+	return &FakeImageCatalogs{c, namespace}
+}
+
 func (c *FakeHarvesterhciV1beta1) KeyPairs(namespace string) v1beta1.KeyPairInterface {
 	return &FakeKeyPairs{c, namespace}
 }
@@ -52,6 +60,10 @@ func (c *FakeHarvesterhciV1beta1) VirtualMachineBackups(namespace string) v1beta
 	return &FakeVirtualMachineBackups{c, namespace}
 }
 
+func (c *FakeHarvesterhciV1beta1) VirtualMachineBackupSchedules(namespace string) v1beta1.VirtualMachineBackupScheduleInterface {
+	return &FakeVirtualMachineBackupSchedules{c, namespace}
+}
+
 func (c *FakeHarvesterhciV1beta1) VirtualMachineImages(namespace string) v1beta1.VirtualMachineImageInterface {
 	return &FakeVirtualMachineImages{c, namespace}
 }
@@ -60,6 +72,10 @@ func (c *FakeHarvesterhciV1beta1) VirtualMachineRestores(namespace string) v1bet
 	return &FakeVirtualMachineRestores{c, namespace}
 }
 
+func (c *FakeHarvesterhciV1beta1) VirtualMachineSnapshots(namespace string) v1beta1.VirtualMachineSnapshotInterface {
+	return &FakeVirtualMachineSnapshots{c, namespace}
+}
+
 func (c *FakeHarvesterhciV1beta1) VirtualMachineTemplates(namespace string) v1beta1.VirtualMachineTemplateInterface {
 	return &FakeVirtualMachineTemplates{c, namespace}
 }
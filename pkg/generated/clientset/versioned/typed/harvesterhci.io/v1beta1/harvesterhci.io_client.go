@@ -26,14 +26,18 @@ import (
 
 type HarvesterhciV1beta1Interface interface {
 	RESTClient() rest.Interface
+	BackupTargetsGetter
+	ImageCatalogsGetter
 	KeyPairsGetter
 	PreferencesGetter
 	SettingsGetter
 	SupportBundlesGetter
 	UpgradesGetter
 	VirtualMachineBackupsGetter
+	VirtualMachineBackupSchedulesGetter
 	VirtualMachineImagesGetter
 	VirtualMachineRestoresGetter
+	VirtualMachineSnapshotsGetter
 	VirtualMachineTemplatesGetter
 	VirtualMachineTemplateVersionsGetter
 }
@@ -43,6 +47,14 @@ type HarvesterhciV1beta1Client struct {
 	restClient rest.Interface
 }
 
+func (c *HarvesterhciV1beta1Client) BackupTargets() BackupTargetInterface {
+	return newBackupTargets(c)
+}
+
+func (c *HarvesterhciV1beta1Client) ImageCatalogs(namespace string) ImageCatalogInterface {
+	return newImageCatalogs(c, namespace)
+}
+
 func (c *HarvesterhciV1beta1Client) KeyPairs(namespace string) KeyPairInterface {
 	return newKeyPairs(c, namespace)
 }
@@ -67,6 +79,10 @@ func (c *HarvesterhciV1beta1Client) VirtualMachineBackups(namespace string) Virt
 	return newVirtualMachineBackups(c, namespace)
 }
 
+func (c *HarvesterhciV1beta1Client) VirtualMachineBackupSchedules(namespace string) VirtualMachineBackupScheduleInterface {
+	return newVirtualMachineBackupSchedules(c, namespace)
+}
+
 func (c *HarvesterhciV1beta1Client) VirtualMachineImages(namespace string) VirtualMachineImageInterface {
 	return newVirtualMachineImages(c, namespace)
 }
@@ -75,6 +91,10 @@ func (c *HarvesterhciV1beta1Client) VirtualMachineRestores(namespace string) Vir
 	return newVirtualMachineRestores(c, namespace)
 }
 
+func (c *HarvesterhciV1beta1Client) VirtualMachineSnapshots(namespace string) VirtualMachineSnapshotInterface {
+	return newVirtualMachineSnapshots(c, namespace)
+}
+
 func (c *HarvesterhciV1beta1Client) VirtualMachineTemplates(namespace string) VirtualMachineTemplateInterface {
 	return newVirtualMachineTemplates(c, namespace)
 }
@@ -30,14 +30,18 @@ func init() {
 }
 
 type Interface interface {
+	BackupTarget() BackupTargetController
+	ImageCatalog() ImageCatalogController
 	KeyPair() KeyPairController
 	Preference() PreferenceController
 	Setting() SettingController
 	SupportBundle() SupportBundleController
 	Upgrade() UpgradeController
 	VirtualMachineBackup() VirtualMachineBackupController
+	VirtualMachineBackupSchedule() VirtualMachineBackupScheduleController
 	VirtualMachineImage() VirtualMachineImageController
 	VirtualMachineRestore() VirtualMachineRestoreController
+	VirtualMachineSnapshot() VirtualMachineSnapshotController
 	VirtualMachineTemplate() VirtualMachineTemplateController
 	VirtualMachineTemplateVersion() VirtualMachineTemplateVersionController
 }
@@ -52,6 +56,12 @@ type version struct {
 	controllerFactory controller.SharedControllerFactory
 }
 
+func (c *version) BackupTarget() BackupTargetController {
+	return NewBackupTargetController(schema.GroupVersionKind{Group: "harvesterhci.io", Version: "v1beta1", Kind: "BackupTarget"}, "backuptargets", false, c.controllerFactory)
+}
+func (c *version) ImageCatalog() ImageCatalogController {
+	return NewImageCatalogController(schema.GroupVersionKind{Group: "harvesterhci.io", Version: "v1beta1", Kind: "ImageCatalog"}, "imagecatalogs", true, c.controllerFactory)
+}
 func (c *version) KeyPair() KeyPairController {
 	return NewKeyPairController(schema.GroupVersionKind{Group: "harvesterhci.io", Version: "v1beta1", Kind: "KeyPair"}, "keypairs", true, c.controllerFactory)
 }
@@ -70,12 +80,18 @@ func (c *version) Upgrade() UpgradeController {
 func (c *version) VirtualMachineBackup() VirtualMachineBackupController {
 	return NewVirtualMachineBackupController(schema.GroupVersionKind{Group: "harvesterhci.io", Version: "v1beta1", Kind: "VirtualMachineBackup"}, "virtualmachinebackups", true, c.controllerFactory)
 }
+func (c *version) VirtualMachineBackupSchedule() VirtualMachineBackupScheduleController {
+	return NewVirtualMachineBackupScheduleController(schema.GroupVersionKind{Group: "harvesterhci.io", Version: "v1beta1", Kind: "VirtualMachineBackupSchedule"}, "virtualmachinebackupschedules", true, c.controllerFactory)
+}
 func (c *version) VirtualMachineImage() VirtualMachineImageController {
 	return NewVirtualMachineImageController(schema.GroupVersionKind{Group: "harvesterhci.io", Version: "v1beta1", Kind: "VirtualMachineImage"}, "virtualmachineimages", true, c.controllerFactory)
 }
 func (c *version) VirtualMachineRestore() VirtualMachineRestoreController {
 	return NewVirtualMachineRestoreController(schema.GroupVersionKind{Group: "harvesterhci.io", Version: "v1beta1", Kind: "VirtualMachineRestore"}, "virtualmachinerestores", true, c.controllerFactory)
 }
+func (c *version) VirtualMachineSnapshot() VirtualMachineSnapshotController {
+	return NewVirtualMachineSnapshotController(schema.GroupVersionKind{Group: "harvesterhci.io", Version: "v1beta1", Kind: "VirtualMachineSnapshot"}, "virtualmachinesnapshots", true, c.controllerFactory)
+}
 func (c *version) VirtualMachineTemplate() VirtualMachineTemplateController {
 	return NewVirtualMachineTemplateController(schema.GroupVersionKind{Group: "harvesterhci.io", Version: "v1beta1", Kind: "VirtualMachineTemplate"}, "virtualmachinetemplates", true, c.controllerFactory)
 }
@@ -32,6 +32,8 @@ func init() {
 type Interface interface {
 	BackingImage() BackingImageController
 	BackingImageDataSource() BackingImageDataSourceController
+	Backup() BackupController
+	Engine() EngineController
 	Setting() SettingController
 	Volume() VolumeController
 }
@@ -52,6 +54,12 @@ func (c *version) BackingImage() BackingImageController {
 func (c *version) BackingImageDataSource() BackingImageDataSourceController {
 	return NewBackingImageDataSourceController(schema.GroupVersionKind{Group: "longhorn.io", Version: "v1beta1", Kind: "BackingImageDataSource"}, "backingimagedatasources", true, c.controllerFactory)
 }
+func (c *version) Backup() BackupController {
+	return NewBackupController(schema.GroupVersionKind{Group: "longhorn.io", Version: "v1beta1", Kind: "Backup"}, "backups", true, c.controllerFactory)
+}
+func (c *version) Engine() EngineController {
+	return NewEngineController(schema.GroupVersionKind{Group: "longhorn.io", Version: "v1beta1", Kind: "Engine"}, "engines", true, c.controllerFactory)
+}
 func (c *version) Setting() SettingController {
 	return NewSettingController(schema.GroupVersionKind{Group: "longhorn.io", Version: "v1beta1", Kind: "Setting"}, "settings", true, c.controllerFactory)
 }
@@ -32,6 +32,7 @@ var kindToTagMappings = map[string]string{
 	"VirtualMachineImage":             "Images",
 	"VirtualMachineBackup":            "Backups",
 	"VirtualMachineRestore":           "Restores",
+	"VirtualMachineSnapshot":          "Snapshots",
 	"VirtualMachineInstanceMigration": "Migrations",
 	"KeyPair":                         "SSH Keys",
 	"Setting":                         "Settings",
@@ -26,6 +26,7 @@ func AggregatedWebServices() []*restful.WebService {
 	harvesterv1beta1API := NewGroupVersionWebService(v1beta1.SchemeGroupVersion)
 	AddGenericNamespacedResourceRoutes(harvesterv1beta1API, "virtualmachinebackups", &v1beta1.VirtualMachineBackup{}, "VirtualMachineBackup", &v1beta1.VirtualMachineBackupList{})
 	AddGenericNamespacedResourceRoutes(harvesterv1beta1API, "virtualmachinerestores", &v1beta1.VirtualMachineRestore{}, "VirtualMachineRestore", &v1beta1.VirtualMachineRestoreList{})
+	AddGenericNamespacedResourceRoutes(harvesterv1beta1API, "virtualmachinesnapshots", &v1beta1.VirtualMachineSnapshot{}, "VirtualMachineSnapshot", &v1beta1.VirtualMachineSnapshotList{})
 	AddGenericNamespacedResourceRoutes(harvesterv1beta1API, "virtualmachineimages", &v1beta1.VirtualMachineImage{}, "VirtualMachineImage", &v1beta1.VirtualMachineImageList{})
 	AddGenericNamespacedResourceRoutes(harvesterv1beta1API, "virtualmachinetemplates", &v1beta1.VirtualMachineTemplate{}, "VirtualMachineTemplate", &v1beta1.VirtualMachineTemplateList{})
 	AddGenericNamespacedResourceRoutes(harvesterv1beta1API, "virtualmachinetemplateversions", &v1beta1.VirtualMachineTemplateVersion{}, "VirtualMachineTemplateVersion", &v1beta1.VirtualMachineTemplateVersionList{})
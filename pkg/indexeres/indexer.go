@@ -1,21 +1,30 @@
 package indexeres
 
 import (
+	"encoding/json"
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	kubevirtv1 "kubevirt.io/client-go/api/v1"
 
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
 	"github.com/harvester/harvester/pkg/config"
 	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/util"
 )
 
 const (
-	UserNameIndex           = "auth.harvesterhci.io/user-username-index"
-	RbByRoleAndSubjectIndex = "auth.harvesterhci.io/crb-by-role-and-subject"
-	PVCByVMIndex            = "harvesterhci.io/pvc-by-vm-index"
-	VMByNetworkIndex        = "vm.harvesterhci.io/vm-by-network"
+	UserNameIndex                 = "auth.harvesterhci.io/user-username-index"
+	RbByRoleAndSubjectIndex       = "auth.harvesterhci.io/crb-by-role-and-subject"
+	PVCByVMIndex                  = "harvesterhci.io/pvc-by-vm-index"
+	PVCByImageIndex               = "harvesterhci.io/pvc-by-image-index"
+	TemplateVersionByImageIndex   = "harvesterhci.io/templateversion-by-image-index"
+	ImageByStorageClassIndex      = "harvesterhci.io/image-by-storageclass-index"
+	PVCByStorageClassIndex        = "harvesterhci.io/pvc-by-storageclass-index"
+	VMBackupByStorageClassIndex   = "harvesterhci.io/vmbackup-by-storageclass-index"
+	VMSnapshotByStorageClassIndex = "harvesterhci.io/vmsnapshot-by-storageclass-index"
+	VMByNetworkIndex              = "vm.harvesterhci.io/vm-by-network"
 )
 
 func RegisterScaledIndexers(scaled *config.Scaled) {
@@ -28,6 +37,16 @@ func RegisterManagementIndexers(management *config.Management) {
 	crbInformer.AddIndexer(RbByRoleAndSubjectIndex, rbByRoleAndSubject)
 	pvcInformer := management.CoreFactory.Core().V1().PersistentVolumeClaim().Cache()
 	pvcInformer.AddIndexer(PVCByVMIndex, pvcByVM)
+	pvcInformer.AddIndexer(PVCByImageIndex, PVCByImage)
+	pvcInformer.AddIndexer(PVCByStorageClassIndex, PVCByStorageClass)
+	templateVersionInformer := management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineTemplateVersion().Cache()
+	templateVersionInformer.AddIndexer(TemplateVersionByImageIndex, TemplateVersionByImage)
+	imageInformer := management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineImage().Cache()
+	imageInformer.AddIndexer(ImageByStorageClassIndex, ImageByStorageClass)
+	vmBackupInformer := management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackup().Cache()
+	vmBackupInformer.AddIndexer(VMBackupByStorageClassIndex, VMBackupByStorageClass)
+	vmSnapshotInformer := management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineSnapshot().Cache()
+	vmSnapshotInformer.AddIndexer(VMSnapshotByStorageClassIndex, VMSnapshotByStorageClass)
 }
 
 func rbByRoleAndSubject(obj *rbacv1.ClusterRoleBinding) ([]string, error) {
@@ -50,6 +69,86 @@ func pvcByVM(obj *corev1.PersistentVolumeClaim) ([]string, error) {
 	return annotationSchemaOwners.List(kubevirtv1.VirtualMachineGroupVersionKind.GroupKind()), nil
 }
 
+// PVCByImage indexes the PVCs by the namespace/name of the image they are created from
+func PVCByImage(obj *corev1.PersistentVolumeClaim) ([]string, error) {
+	imageID := obj.Annotations[util.AnnotationImageID]
+	if imageID == "" {
+		return nil, nil
+	}
+	return []string{imageID}, nil
+}
+
+// TemplateVersionByImage indexes the VM template versions by the namespace/name of the images their volume claim
+// templates are created from
+func TemplateVersionByImage(obj *harvesterv1.VirtualMachineTemplateVersion) ([]string, error) {
+	images := map[string]bool{}
+	if obj.Spec.ImageID != "" {
+		images[obj.Spec.ImageID] = true
+	}
+	if annotation := obj.Spec.VM.ObjectMeta.Annotations[util.AnnotationVolumeClaimTemplates]; annotation != "" {
+		var pvcs []corev1.PersistentVolumeClaim
+		if err := json.Unmarshal([]byte(annotation), &pvcs); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal the volume claim templates of template version %s/%s: %w", obj.Namespace, obj.Name, err)
+		}
+		for _, pvc := range pvcs {
+			if imageID := pvc.Annotations[util.AnnotationImageID]; imageID != "" {
+				images[imageID] = true
+			}
+		}
+	}
+	keys := make([]string, 0, len(images))
+	for image := range images {
+		keys = append(keys, image)
+	}
+	return keys, nil
+}
+
+// ImageByStorageClass indexes the images by the name of the storage class provisioning their volumes
+func ImageByStorageClass(obj *harvesterv1.VirtualMachineImage) ([]string, error) {
+	if obj.Status.StorageClassName == "" {
+		return nil, nil
+	}
+	return []string{obj.Status.StorageClassName}, nil
+}
+
+// PVCByStorageClass indexes the PVCs by the name of their storage class
+func PVCByStorageClass(obj *corev1.PersistentVolumeClaim) ([]string, error) {
+	if obj.Spec.StorageClassName == nil || *obj.Spec.StorageClassName == "" {
+		return nil, nil
+	}
+	return []string{*obj.Spec.StorageClassName}, nil
+}
+
+// VMBackupByStorageClass indexes the VM backups by the names of the storage classes of the volumes they restore
+func VMBackupByStorageClass(obj *harvesterv1.VirtualMachineBackup) ([]string, error) {
+	if obj.Status == nil {
+		return nil, nil
+	}
+	return volumeBackupStorageClasses(obj.Status.VolumeBackups), nil
+}
+
+// VMSnapshotByStorageClass indexes the VM snapshots by the names of the storage classes of the volumes they restore
+func VMSnapshotByStorageClass(obj *harvesterv1.VirtualMachineSnapshot) ([]string, error) {
+	if obj.Status == nil {
+		return nil, nil
+	}
+	return volumeBackupStorageClasses(obj.Status.VolumeSnapshots), nil
+}
+
+func volumeBackupStorageClasses(volumeBackups []harvesterv1.VolumeBackup) []string {
+	storageClasses := map[string]bool{}
+	for _, volumeBackup := range volumeBackups {
+		if name := volumeBackup.PersistentVolumeClaim.Spec.StorageClassName; name != nil && *name != "" {
+			storageClasses[*name] = true
+		}
+	}
+	keys := make([]string, 0, len(storageClasses))
+	for name := range storageClasses {
+		keys = append(keys, name)
+	}
+	return keys
+}
+
 func VMByNetwork(obj *kubevirtv1.VirtualMachine) ([]string, error) {
 	networks := obj.Spec.Template.Spec.Networks
 	networkNameList := make([]string, 0, len(networks))
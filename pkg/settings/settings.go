@@ -22,6 +22,7 @@ var (
 	UISource                     = NewSetting("ui-source", "auto") // Options are 'auto', 'external' or 'bundled'
 	VolumeSnapshotClass          = NewSetting("volume-snapshot-class", "longhorn")
 	BackupTargetSet              = NewSetting(BackupTargetSettingName, InitBackupTargetToString())
+	BackupRetention              = NewSetting("backup-retention", "") // JSON retention policy of VM backups, e.g. {"keepLast":7}
 	UpgradableVersions           = NewSetting("upgradable-versions", "")
 	UpgradeCheckerEnabled        = NewSetting("upgrade-checker-enabled", "true")
 	UpgradeCheckerURL            = NewSetting("upgrade-checker-url", "https://harvester-upgrade-responder.rancher.io/v1/checkupgrade")
@@ -29,6 +30,13 @@ var (
 	SupportBundleImage           = NewSetting("support-bundle-image", "rancher/support-bundle-kit:v0.0.3")
 	SupportBundleImagePullPolicy = NewSetting("support-bundle-image-pull-policy", "IfNotPresent")
 	DefaultStorageClass          = NewSetting("default-storage-class", "longhorn")
+	HTTPProxy                    = NewSetting("http-proxy", "")                            // JSON proxy config of the image downloads, e.g. {"httpsProxy":"http://proxy:3128","noProxy":"localhost"}
+	ImageStorageClassParameters  = NewSetting("image-storage-class-parameters", "")        // JSON default StorageClass parameters of the images, e.g. {"numberOfReplicas":"1"}
+	ImageGarbageCollectionDays   = NewSetting("image-garbage-collection-days", "0")        // delete the images unused for the days, 0 disables the garbage collection
+	VMConsoleIdleTimeoutMinutes  = NewSetting("vm-console-idle-timeout-minutes", "30")     // close the VM console and VNC sessions without user input for the minutes, 0 disables the timeout
+	VMConsoleMaxSessions         = NewSetting("vm-console-max-sessions", "4")              // the concurrent console and VNC sessions of a VM per apiserver pod, 0 means no limit
+	VMConsoleRecording           = NewSetting("vm-console-recording", "false")             // record the serial console output of the sessions to the console recording volume
+	VMConsoleRecordingRetention  = NewSetting("vm-console-recording-retention-days", "30") // delete the console recordings older than the days, 0 keeps them
 )
 
 const (
@@ -160,3 +168,9 @@ func InitBackupTargetToString() string {
 	}
 	return string(targetStr)
 }
+
+type HTTPProxyConfig struct {
+	HTTPProxy  string `json:"httpProxy"`
+	HTTPSProxy string `json:"httpsProxy"`
+	NoProxy    string `json:"noProxy"`
+}
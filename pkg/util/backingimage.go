@@ -0,0 +1,63 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const (
+	longhornBackingImageURL = LonghornManagerEndpoint + "/v1/backingimages/%s"
+)
+
+func GetBackingImageUploadURL(backingImageName string) string {
+	return fmt.Sprintf(longhornBackingImageURL, backingImageName)
+}
+
+func GetBackingImageDownloadURL(backingImageName string) string {
+	return fmt.Sprintf(longhornBackingImageURL+"/download", backingImageName)
+}
+
+// UploadBackingImageData streams the data of the given size to the backing image waiting for an upload,
+// the data is sent the same way as the browser uploads a file.
+func UploadBackingImageData(ctx context.Context, httpClient *http.Client, backingImageName, fileName string, data io.Reader, size int64) error {
+	bodyReader, bodyWriter := io.Pipe()
+	form := multipart.NewWriter(bodyWriter)
+	go func() {
+		part, err := form.CreateFormFile("chunk", fileName)
+		if err == nil {
+			_, err = io.CopyN(part, data, size)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		_ = bodyWriter.CloseWithError(err)
+	}()
+
+	uploadReq, err := http.NewRequestWithContext(ctx, http.MethodPost, GetBackingImageUploadURL(backingImageName), bodyReader)
+	if err != nil {
+		return fmt.Errorf("failed to create the upload request: %w", err)
+	}
+	uploadReq.Header.Set("Content-Type", form.FormDataContentType())
+	uploadReq.URL.RawQuery = url.Values{
+		"action": []string{"upload"},
+		"size":   []string{strconv.FormatInt(size, 10)},
+	}.Encode()
+
+	uploadResp, err := httpClient.Do(uploadReq)
+	if err != nil {
+		return fmt.Errorf("failed to send the upload request: %w", err)
+	}
+	defer uploadResp.Body.Close()
+
+	if uploadResp.StatusCode >= http.StatusBadRequest {
+		body, _ := ioutil.ReadAll(uploadResp.Body)
+		return fmt.Errorf("upload failed: %s", string(body))
+	}
+	return nil
+}
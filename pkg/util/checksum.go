@@ -0,0 +1,24 @@
+package util
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"hash"
+)
+
+// NewChecksumHash returns the hash of the checksum, the algorithm is told by the length of the hex digest
+func NewChecksumHash(checksum string) (hash.Hash, error) {
+	if _, err := hex.DecodeString(checksum); err != nil {
+		return nil, errors.New("checksum must be a hex digest")
+	}
+	switch len(checksum) {
+	case sha256.Size * 2:
+		return sha256.New(), nil
+	case sha512.Size * 2:
+		return sha512.New(), nil
+	default:
+		return nil, errors.New("checksum must be a sha256 or sha512 hex digest")
+	}
+}
@@ -0,0 +1,49 @@
+package util
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewChecksumHash(t *testing.T) {
+	var testCases = []struct {
+		name        string
+		checksum    string
+		expectedErr bool
+		expectedLen int
+	}{
+		{
+			name:        "sha256",
+			checksum:    "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			expectedLen: sha256.Size,
+		},
+		{
+			name:        "sha512",
+			checksum:    "cf83e1357eefb8bdf1542850d66d8007d620e4050b5715dc83f4a921d36ce9ce47d0d13c5d85f2b0ff8318d2877eec2f63b931bd47417a81a538327af927da3e",
+			expectedLen: sha512.Size,
+		},
+		{
+			name:        "not hex",
+			checksum:    "z3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			expectedErr: true,
+		},
+		{
+			name:        "md5",
+			checksum:    "d41d8cd98f00b204e9800998ecf8427e",
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		h, err := NewChecksumHash(tc.checksum)
+		if tc.expectedErr {
+			assert.NotNil(t, err, "case %q", tc.name)
+			continue
+		}
+		assert.Nil(t, err, "case %q", tc.name)
+		assert.Equal(t, tc.expectedLen, h.Size(), "case %q", tc.name)
+	}
+}
@@ -10,5 +10,28 @@ const (
 	AnnotationVolumeClaimTemplates = prefix + "/volumeClaimTemplates"
 	AnnotationImageID              = prefix + "/imageId"
 
+	// the annotations of a VM clone, the source VM, the percentage of the cloned volumes,
+	// and whether the clone is started once its volumes are cloned
+	AnnotationCloneSource   = prefix + "/cloneSource"
+	AnnotationCloneProgress = prefix + "/cloneProgress"
+	AnnotationCloneRunning  = prefix + "/cloneRunning"
+
+	// the annotation of a stopped VM whose volumes are being reverted to the VM snapshot of the value
+	AnnotationRevertToSnapshot = prefix + "/revertToSnapshot"
+
+	// the annotation of a running VM holding the CPU cores and memory it is resized to when it is restarted
+	AnnotationPendingResize = prefix + "/pendingResize"
+
+	// the labels of the images created from the image catalogs
+	LabelImageCatalog   = prefix + "/imageCatalog"
+	LabelImageOS        = prefix + "/os"
+	LabelImageOSRelease = prefix + "/osRelease"
+	LabelImageArch      = prefix + "/arch"
+	LabelImageBuild     = prefix + "/imageBuild"
+
 	LonghornSystemNamespaceName = "longhorn-system"
+	PublicNamespaceName         = "harvester-public"
+
+	// LonghornManagerEndpoint is the endpoint of the longhorn manager API
+	LonghornManagerEndpoint = "http://longhorn-backend.longhorn-system:9500"
 )
@@ -0,0 +1,158 @@
+package diskimage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+)
+
+var (
+	// qemuImg is the qemu-img command which converts the image files
+	qemuImg = "qemu-img"
+
+	// qemuFormats are the qemu-img names of the formats
+	qemuFormats = map[string]string{
+		FormatRaw:   "raw",
+		FormatQCOW2: "qcow2",
+		FormatVMDK:  "vmdk",
+		FormatVHD:   "vpc",
+		FormatVHDX:  "vhdx",
+	}
+)
+
+// Convert converts the image file to a qcow2 file in the directory and returns the path of the qcow2 file
+// and the information of the source. The first disk of an OVA package is converted.
+func Convert(ctx context.Context, path, dir string) (string, Info, error) {
+	info, err := DetectFile(path)
+	if err != nil {
+		return "", Info{}, err
+	}
+
+	source, sourceInfo := path, info
+	if info.Format == FormatOVA {
+		if source, err = extractOVA(path, dir); err != nil {
+			return "", info, err
+		}
+		if sourceInfo, err = DetectFile(source); err != nil {
+			return "", info, err
+		}
+	}
+	qemuFormat, ok := qemuFormats[sourceInfo.Format]
+	if !ok {
+		return "", info, fmt.Errorf("unsupported image format %s", sourceInfo.Format)
+	}
+	if sourceInfo.Format == FormatVMDK && sourceInfo.Subformat != VMDKMonolithicSparse && sourceInfo.Subformat != VMDKStreamOptimized {
+		return "", info, fmt.Errorf("unsupported VMDK file, only the %s and %s VMDK files are supported", VMDKMonolithicSparse, VMDKStreamOptimized)
+	}
+	if err := checkStandalone(ctx, qemuFormat, source); err != nil {
+		return "", info, err
+	}
+
+	target := filepath.Join(dir, "disk.qcow2")
+	// the source format is always given, qemu-img must not probe it from the untrusted data
+	if _, err := runQemuImg(ctx, "convert", "-f", qemuFormat, "-O", "qcow2", source, target); err != nil {
+		return "", info, err
+	}
+
+	// the virtual size of some formats can't be told from the header
+	if info.VirtualSize, err = virtualSize(ctx, target); err != nil {
+		return "", info, err
+	}
+	return target, info, nil
+}
+
+// Compress converts the raw or qcow2 image file to a compressed qcow2 file in the directory and returns its path
+func Compress(ctx context.Context, path, dir string) (string, error) {
+	info, err := DetectFile(path)
+	if err != nil {
+		return "", err
+	}
+	qemuFormat := qemuFormats[FormatRaw]
+	switch info.Format {
+	case FormatQCOW2:
+		qemuFormat = qemuFormats[FormatQCOW2]
+	case FormatRaw, FormatISO:
+	default:
+		return "", fmt.Errorf("unsupported image format %s", info.Format)
+	}
+
+	// the image data is uploaded by the users, the referred host files must not be pulled into the download
+	if err := checkStandalone(ctx, qemuFormat, path); err != nil {
+		return "", err
+	}
+
+	target := filepath.Join(dir, "compressed.qcow2")
+	if _, err := runQemuImg(ctx, "convert", "-c", "-f", qemuFormat, "-O", "qcow2", path, target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// qemuImageInfo is the part of the output of qemu-img info which is checked before the conversion
+type qemuImageInfo struct {
+	VirtualSize     int64  `json:"virtual-size"`
+	BackingFilename string `json:"backing-filename"`
+	FormatSpecific  struct {
+		Data struct {
+			DataFile string `json:"data-file"`
+			Extents  []struct {
+				Filename string `json:"filename"`
+			} `json:"extents"`
+		} `json:"data"`
+	} `json:"format-specific"`
+}
+
+func imageInfo(ctx context.Context, qemuFormat, path string) (*qemuImageInfo, error) {
+	output, err := runQemuImg(ctx, "info", "-f", qemuFormat, "--output=json", path)
+	if err != nil {
+		return nil, err
+	}
+	var info qemuImageInfo
+	if err := json.Unmarshal(output, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse the output of qemu-img info: %w", err)
+	}
+	return &info, nil
+}
+
+// checkStandalone refuses the image files which refer to other files, qemu-img would read the referred files
+// on the host into the converted image
+func checkStandalone(ctx context.Context, qemuFormat, path string) error {
+	info, err := imageInfo(ctx, qemuFormat, path)
+	if err != nil {
+		return err
+	}
+	if info.BackingFilename != "" {
+		return fmt.Errorf("unsupported image file with the backing file %s", info.BackingFilename)
+	}
+	if info.FormatSpecific.Data.DataFile != "" {
+		return fmt.Errorf("unsupported image file with the data file %s", info.FormatSpecific.Data.DataFile)
+	}
+	for _, extent := range info.FormatSpecific.Data.Extents {
+		if extent.Filename != path {
+			return fmt.Errorf("unsupported image file with the external extent %s", extent.Filename)
+		}
+	}
+	return nil
+}
+
+func virtualSize(ctx context.Context, path string) (int64, error) {
+	info, err := imageInfo(ctx, qemuFormats[FormatQCOW2], path)
+	if err != nil {
+		return 0, err
+	}
+	return info.VirtualSize, nil
+}
+
+func runQemuImg(ctx context.Context, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, qemuImg, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("qemu-img %s failed: %w: %s", args[0], err, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,161 @@
+package diskimage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	FormatRaw   = "raw"
+	FormatQCOW2 = "qcow2"
+	FormatVMDK  = "vmdk"
+	FormatVHD   = "vhd"
+	FormatVHDX  = "vhdx"
+	FormatISO   = "iso"
+	FormatOVA   = "ova"
+
+	// HeaderSize is the size of the beginning of an image file which is needed to detect its format
+	HeaderSize = 64 * 1024
+	// FooterSize is the size of the end of an image file which is needed to detect a fixed VHD
+	FooterSize = 512
+
+	// VMDKMonolithicSparse and VMDKStreamOptimized are the create types of the VMDK files which hold the whole disk,
+	// the other types keep the disk data in extent files referred by the descriptor
+	VMDKMonolithicSparse = "monolithicSparse"
+	VMDKStreamOptimized  = "streamOptimized"
+
+	sectorSize = 512
+)
+
+var (
+	qcow2Magic = []byte("QFI\xfb")
+	vmdkMagic  = []byte("KDMV")
+	vhdCookie  = []byte("conectix")
+	vhdxMagic  = []byte("vhdxfile")
+	isoMagic   = []byte("CD001")
+	tarMagic   = []byte("ustar")
+
+	vmdkDescriptorMagic = []byte("# Disk DescriptorFile")
+	vmdkCreateTypeRegex = regexp.MustCompile(`(?m)^\s*createType\s*=\s*"([^"]*)"`)
+)
+
+// Info describes an image file. VirtualSize is the size of the disk presented to the guest,
+// it is 0 if it can not be told from the header. VolumeLabel is the volume identifier of an ISO file.
+// Subformat is the create type of a sparse VMDK file, it is empty for a VMDK descriptor file.
+type Info struct {
+	Format      string
+	Subformat   string
+	VirtualSize int64
+	VolumeLabel string
+}
+
+// NeedsConversion returns true if the format can't be imported by Longhorn as is,
+// Longhorn backing images only accept raw and qcow2 files.
+func NeedsConversion(format string) bool {
+	switch format {
+	case FormatVMDK, FormatVHD, FormatVHDX, FormatOVA:
+		return true
+	}
+	return false
+}
+
+// Detect tells the format of an image file of the given size from its first HeaderSize bytes and its last FooterSize
+// bytes. The footer is only needed by fixed VHD files which are otherwise taken as raw, it can be nil when the end of
+// the file is not available. Anything unrecognized is raw.
+func Detect(header, footer []byte, size int64) Info {
+	switch {
+	case bytes.HasPrefix(header, qcow2Magic) && len(header) >= 32:
+		return Info{Format: FormatQCOW2, VirtualSize: int64(binary.BigEndian.Uint64(header[24:32]))}
+	case bytes.HasPrefix(header, vmdkMagic) && len(header) >= 20:
+		return Info{
+			Format:      FormatVMDK,
+			Subformat:   vmdkCreateType(header),
+			VirtualSize: int64(binary.LittleEndian.Uint64(header[12:20])) * sectorSize,
+		}
+	case bytes.HasPrefix(header, vmdkDescriptorMagic):
+		return Info{Format: FormatVMDK}
+	case bytes.HasPrefix(header, vhdxMagic):
+		return Info{Format: FormatVHDX}
+	case bytes.HasPrefix(header, vhdCookie) && len(header) >= 56:
+		// a dynamic VHD starts with a copy of its footer
+		return Info{Format: FormatVHD, VirtualSize: int64(binary.BigEndian.Uint64(header[48:56]))}
+	case isOVA(header):
+		return Info{Format: FormatOVA}
+	case len(header) >= 0x8006 && bytes.Equal(header[0x8001:0x8006], isoMagic):
+		return Info{Format: FormatISO, VirtualSize: size, VolumeLabel: isoVolumeLabel(header)}
+	case bytes.HasPrefix(footer, vhdCookie) && len(footer) >= 56:
+		// a fixed VHD is a raw disk followed by the footer
+		return Info{Format: FormatVHD, VirtualSize: int64(binary.BigEndian.Uint64(footer[48:56]))}
+	}
+	return Info{Format: FormatRaw, VirtualSize: size}
+}
+
+// isOVA returns true if the header is a tar archive whose first file is an OVF descriptor,
+// which the OVF specification requires of an OVA package.
+func isOVA(header []byte) bool {
+	if len(header) < 262 || !bytes.Equal(header[257:262], tarMagic) {
+		return false
+	}
+	name := string(bytes.TrimRight(header[:100], "\x00"))
+	return strings.HasSuffix(strings.ToLower(name), ".ovf")
+}
+
+// vmdkCreateType returns the create type of the descriptor embedded in the sparse VMDK header,
+// it is empty if the descriptor is not within the header
+func vmdkCreateType(header []byte) string {
+	if len(header) < 44 {
+		return ""
+	}
+	offset := binary.LittleEndian.Uint64(header[28:36]) * sectorSize
+	size := binary.LittleEndian.Uint64(header[36:44]) * sectorSize
+	if offset == 0 || offset >= uint64(len(header)) || size > uint64(len(header))-offset {
+		return ""
+	}
+	match := vmdkCreateTypeRegex.FindSubmatch(header[offset : offset+size])
+	if match == nil {
+		return ""
+	}
+	return string(match[1])
+}
+
+// isoVolumeLabel returns the volume identifier of the primary volume descriptor, it is padded with spaces
+func isoVolumeLabel(header []byte) string {
+	if len(header) < 0x8048 {
+		return ""
+	}
+	return strings.TrimRight(string(header[0x8028:0x8048]), " \x00")
+}
+
+// DetectFile detects the format of the image file
+func DetectFile(path string) (Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Info{}, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return Info{}, err
+	}
+
+	header := make([]byte, HeaderSize)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return Info{}, err
+	}
+	header = header[:n]
+
+	var footer []byte
+	if stat.Size() >= FooterSize {
+		footer = make([]byte, FooterSize)
+		if _, err := f.ReadAt(footer, stat.Size()-FooterSize); err != nil {
+			return Info{}, err
+		}
+	}
+	return Detect(header, footer, stat.Size()), nil
+}
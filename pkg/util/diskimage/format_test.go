@@ -0,0 +1,218 @@
+package diskimage
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectFile(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		file     string
+		expected Info
+	}{
+		{
+			name:     "qcow2",
+			file:     "disk.qcow2",
+			expected: Info{Format: FormatQCOW2, VirtualSize: 65536},
+		},
+		{
+			name:     "sparse vmdk",
+			file:     "disk.vmdk",
+			expected: Info{Format: FormatVMDK, Subformat: VMDKMonolithicSparse, VirtualSize: 65536},
+		},
+		{
+			name:     "dynamic vhd",
+			file:     "disk.vhd",
+			expected: Info{Format: FormatVHD, VirtualSize: 65536},
+		},
+		{
+			name:     "fixed vhd",
+			file:     "disk-fixed.vhd",
+			expected: Info{Format: FormatVHD, VirtualSize: 4096},
+		},
+		{
+			name:     "vhdx",
+			file:     "disk.vhdx",
+			expected: Info{Format: FormatVHDX},
+		},
+		{
+			name:     "ova",
+			file:     "appliance.ova",
+			expected: Info{Format: FormatOVA},
+		},
+	}
+
+	for _, tc := range testCases {
+		actual, err := DetectFile(filepath.Join("testdata", tc.file))
+		assert.Nil(t, err, "case %q", tc.name)
+		assert.Equal(t, tc.expected, actual, "case %q", tc.name)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	iso := make([]byte, HeaderSize)
+	copy(iso[0x8001:], "CD001")
+	labeledISO := make([]byte, HeaderSize)
+	copy(labeledISO[0x8001:], "CD001")
+	copy(labeledISO[0x8028:], "SSS_X64FRE_EN-US_DV9              ")
+
+	var testCases = []struct {
+		name     string
+		header   []byte
+		footer   []byte
+		size     int64
+		expected Info
+	}{
+		{
+			name:     "iso",
+			header:   iso,
+			size:     1 << 20,
+			expected: Info{Format: FormatISO, VirtualSize: 1 << 20},
+		},
+		{
+			name:     "iso with volume label",
+			header:   labeledISO,
+			size:     1 << 20,
+			expected: Info{Format: FormatISO, VirtualSize: 1 << 20, VolumeLabel: "SSS_X64FRE_EN-US_DV9"},
+		},
+		{
+			name:     "raw",
+			header:   make([]byte, HeaderSize),
+			size:     1 << 20,
+			expected: Info{Format: FormatRaw, VirtualSize: 1 << 20},
+		},
+		{
+			name:     "empty file",
+			size:     0,
+			expected: Info{Format: FormatRaw},
+		},
+		{
+			name:     "tar without OVF descriptor",
+			header:   tarHeader("disk.img"),
+			size:     1024,
+			expected: Info{Format: FormatRaw, VirtualSize: 1024},
+		},
+		{
+			name:     "vmdk descriptor",
+			header:   []byte("# Disk DescriptorFile\nversion=1\n"),
+			size:     32,
+			expected: Info{Format: FormatVMDK},
+		},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, Detect(tc.header, tc.footer, tc.size), "case %q", tc.name)
+	}
+}
+
+func tarHeader(name string) []byte {
+	header := make([]byte, 512)
+	copy(header, name)
+	copy(header[257:], "ustar")
+	return header
+}
+
+func TestExtractOVA(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diskimage-")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	diskPath, err := extractOVA(filepath.Join("testdata", "appliance.ova"), dir)
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(dir, "appliance-disk1.vmdk"), diskPath)
+
+	info, err := DetectFile(diskPath)
+	assert.Nil(t, err)
+	assert.Equal(t, Info{Format: FormatVMDK, Subformat: VMDKMonolithicSparse, VirtualSize: 65536}, info)
+}
+
+func TestConvert(t *testing.T) {
+	if _, err := exec.LookPath(qemuImg); err != nil {
+		t.Skip("qemu-img is not installed")
+	}
+
+	var testCases = []struct {
+		name     string
+		file     string
+		expected Info
+	}{
+		{
+			name:     "sparse vmdk",
+			file:     "disk.vmdk",
+			expected: Info{Format: FormatVMDK, Subformat: VMDKMonolithicSparse, VirtualSize: 65536},
+		},
+		{
+			name:     "dynamic vhd",
+			file:     "disk.vhd",
+			expected: Info{Format: FormatVHD, VirtualSize: 65536},
+		},
+		{
+			name:     "ova",
+			file:     "appliance.ova",
+			expected: Info{Format: FormatOVA, VirtualSize: 65536},
+		},
+	}
+
+	for _, tc := range testCases {
+		dir, err := ioutil.TempDir("", "diskimage-")
+		assert.Nil(t, err, "case %q", tc.name)
+
+		target, info, err := Convert(context.Background(), filepath.Join("testdata", tc.file), dir)
+		assert.Nil(t, err, "case %q", tc.name)
+		assert.Equal(t, tc.expected, info, "case %q", tc.name)
+
+		converted, err := DetectFile(target)
+		assert.Nil(t, err, "case %q", tc.name)
+		assert.Equal(t, FormatQCOW2, converted.Format, "case %q", tc.name)
+
+		os.RemoveAll(dir)
+	}
+}
+
+func TestConvertVMDKDescriptor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diskimage-")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// the descriptor refers to a host file which must not be read into the image
+	descriptor := filepath.Join(dir, "disk.vmdk")
+	err = ioutil.WriteFile(descriptor, []byte("# Disk DescriptorFile\nversion=1\ncreateType=\"monolithicFlat\"\nRW 2048 FLAT \"/etc/shadow\" 0\n"), 0644)
+	assert.Nil(t, err)
+
+	_, _, err = Convert(context.Background(), descriptor, dir)
+	assert.NotNil(t, err)
+}
+
+func TestCompress(t *testing.T) {
+	if _, err := exec.LookPath(qemuImg); err != nil {
+		t.Skip("qemu-img is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "diskimage-")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	target, err := Compress(context.Background(), filepath.Join("testdata", "disk.qcow2"), dir)
+	assert.Nil(t, err)
+	compressed, err := DetectFile(target)
+	assert.Nil(t, err)
+	assert.Equal(t, Info{Format: FormatQCOW2, VirtualSize: 65536}, compressed)
+
+	_, err = Compress(context.Background(), filepath.Join("testdata", "disk.vmdk"), dir)
+	assert.NotNil(t, err)
+
+	backed := filepath.Join(dir, "backed.qcow2")
+	backing, err := filepath.Abs(filepath.Join("testdata", "disk.qcow2"))
+	assert.Nil(t, err)
+	_, err = runQemuImg(context.Background(), "create", "-f", "qcow2", "-F", "qcow2", "-b", backing, backed)
+	assert.Nil(t, err)
+	_, err = Compress(context.Background(), backed, dir)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,111 @@
+package diskimage
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ovfEnvelope is the part of an OVF descriptor which locates the disks of the package
+type ovfEnvelope struct {
+	Files []ovfFile `xml:"References>File"`
+	Disks []ovfDisk `xml:"DiskSection>Disk"`
+}
+
+type ovfFile struct {
+	ID          string `xml:"id,attr"`
+	Href        string `xml:"href,attr"`
+	Compression string `xml:"compression,attr"`
+}
+
+type ovfDisk struct {
+	DiskID  string `xml:"diskId,attr"`
+	FileRef string `xml:"fileRef,attr"`
+}
+
+// firstDiskFile returns the file of the first disk of the descriptor
+func (e *ovfEnvelope) firstDiskFile() (*ovfFile, error) {
+	if len(e.Disks) == 0 {
+		return nil, errors.New("no disk is found in the OVF descriptor")
+	}
+	for i, file := range e.Files {
+		if file.ID == e.Disks[0].FileRef {
+			return &e.Files[i], nil
+		}
+	}
+	return nil, fmt.Errorf("the file of disk %s is not found in the OVF descriptor", e.Disks[0].DiskID)
+}
+
+// extractOVA extracts the first disk of the OVA package to the directory and returns its path.
+// A VM image holds a single disk, the other disks of the package are ignored.
+func extractOVA(path, dir string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	tr := tar.NewReader(f)
+	header, err := tr.Next()
+	if err != nil {
+		return "", fmt.Errorf("failed to read the OVA package: %w", err)
+	}
+	if !strings.HasSuffix(strings.ToLower(header.Name), ".ovf") {
+		return "", fmt.Errorf("expect the OVF descriptor first in the OVA package but got %s", header.Name)
+	}
+	var envelope ovfEnvelope
+	if err := xml.NewDecoder(tr).Decode(&envelope); err != nil {
+		return "", fmt.Errorf("failed to parse the OVF descriptor: %w", err)
+	}
+	diskFile, err := envelope.firstDiskFile()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return "", fmt.Errorf("the disk file %s is not found in the OVA package", diskFile.Href)
+		} else if err != nil {
+			return "", fmt.Errorf("failed to read the OVA package: %w", err)
+		}
+		if header.Name != diskFile.Href {
+			continue
+		}
+
+		var data io.Reader = tr
+		switch diskFile.Compression {
+		case "":
+		case "gzip":
+			gz, err := gzip.NewReader(tr)
+			if err != nil {
+				return "", err
+			}
+			defer gz.Close()
+			data = gz
+		default:
+			return "", fmt.Errorf("unsupported compression %s of the disk file %s", diskFile.Compression, diskFile.Href)
+		}
+
+		diskPath := filepath.Join(dir, filepath.Base(diskFile.Href))
+		return diskPath, writeFile(diskPath, data)
+	}
+}
+
+func writeFile(path string, data io.Reader) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
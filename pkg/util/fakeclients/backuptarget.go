@@ -0,0 +1,64 @@
+package fakeclients
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	harv1type "github.com/harvester/harvester/pkg/generated/clientset/versioned/typed/harvesterhci.io/v1beta1"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+)
+
+type BackupTargetClient func() harv1type.BackupTargetInterface
+
+func (c BackupTargetClient) Create(backupTarget *harvesterv1.BackupTarget) (*harvesterv1.BackupTarget, error) {
+	return c().Create(context.TODO(), backupTarget, metav1.CreateOptions{})
+}
+func (c BackupTargetClient) Update(backupTarget *harvesterv1.BackupTarget) (*harvesterv1.BackupTarget, error) {
+	return c().Update(context.TODO(), backupTarget, metav1.UpdateOptions{})
+}
+func (c BackupTargetClient) UpdateStatus(backupTarget *harvesterv1.BackupTarget) (*harvesterv1.BackupTarget, error) {
+	return c().UpdateStatus(context.TODO(), backupTarget, metav1.UpdateOptions{})
+}
+func (c BackupTargetClient) Delete(name string, options *metav1.DeleteOptions) error {
+	return c().Delete(context.TODO(), name, *options)
+}
+func (c BackupTargetClient) Get(name string, options metav1.GetOptions) (*harvesterv1.BackupTarget, error) {
+	return c().Get(context.TODO(), name, options)
+}
+func (c BackupTargetClient) List(opts metav1.ListOptions) (*harvesterv1.BackupTargetList, error) {
+	return c().List(context.TODO(), opts)
+}
+func (c BackupTargetClient) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	return c().Watch(context.TODO(), opts)
+}
+func (c BackupTargetClient) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *harvesterv1.BackupTarget, err error) {
+	return c().Patch(context.TODO(), name, pt, data, metav1.PatchOptions{}, subresources...)
+}
+
+type BackupTargetCache func() harv1type.BackupTargetInterface
+
+func (c BackupTargetCache) Get(name string) (*harvesterv1.BackupTarget, error) {
+	return c().Get(context.TODO(), name, metav1.GetOptions{})
+}
+func (c BackupTargetCache) List(selector labels.Selector) ([]*harvesterv1.BackupTarget, error) {
+	list, err := c().List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*harvesterv1.BackupTarget, 0, len(list.Items))
+	for i := range list.Items {
+		result = append(result, &list.Items[i])
+	}
+	return result, err
+}
+func (c BackupTargetCache) AddIndexer(indexName string, indexer ctlharvesterv1.BackupTargetIndexer) {
+	panic("implement me")
+}
+func (c BackupTargetCache) GetByIndex(indexName, key string) ([]*harvesterv1.BackupTarget, error) {
+	panic("implement me")
+}
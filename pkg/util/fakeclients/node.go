@@ -23,8 +23,8 @@ func (c NodeCache) List(selector labels.Selector) ([]*v1.Node, error) {
 		return nil, err
 	}
 	result := make([]*v1.Node, 0, len(list.Items))
-	for _, node := range list.Items {
-		result = append(result, &node)
+	for i := range list.Items {
+		result = append(result, &list.Items[i])
 	}
 	return result, err
 }
@@ -0,0 +1,36 @@
+package fakeclients
+
+import (
+	"context"
+
+	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1type "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+type PodCache func(string) corev1type.PodInterface
+
+func (c PodCache) Get(namespace, name string) (*v1.Pod, error) {
+	return c(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+}
+func (c PodCache) List(namespace string, selector labels.Selector) ([]*v1.Pod, error) {
+	list, err := c(namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*v1.Pod, 0, len(list.Items))
+	for i := range list.Items {
+		result = append(result, &list.Items[i])
+	}
+	return result, err
+}
+func (c PodCache) AddIndexer(indexName string, indexer ctlcorev1.PodIndexer) {
+	panic("implement me")
+}
+func (c PodCache) GetByIndex(indexName, key string) ([]*v1.Pod, error) {
+	panic("implement me")
+}
@@ -76,6 +76,32 @@ func (c PersistentVolumeClaimCache) GetByIndex(indexName, key string) ([]*corev1
 			pvcs = append(pvcs, &pvc)
 		}
 		return pvcs, nil
+	case indexeres.PVCByImageIndex:
+		pvcList, err := c(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		var pvcs []*corev1.PersistentVolumeClaim
+		for i := range pvcList.Items {
+			keys, _ := indexeres.PVCByImage(&pvcList.Items[i])
+			if len(keys) > 0 && keys[0] == key {
+				pvcs = append(pvcs, &pvcList.Items[i])
+			}
+		}
+		return pvcs, nil
+	case indexeres.PVCByStorageClassIndex:
+		pvcList, err := c(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		var pvcs []*corev1.PersistentVolumeClaim
+		for i := range pvcList.Items {
+			keys, _ := indexeres.PVCByStorageClass(&pvcList.Items[i])
+			if len(keys) > 0 && keys[0] == key {
+				pvcs = append(pvcs, &pvcList.Items[i])
+			}
+		}
+		return pvcs, nil
 	default:
 		return nil, nil
 	}
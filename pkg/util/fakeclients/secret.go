@@ -0,0 +1,29 @@
+package fakeclients
+
+import (
+	"context"
+
+	ctlv1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+type SecretCache func(string) v1.SecretInterface
+
+func (c SecretCache) Get(namespace, name string) (*corev1.Secret, error) {
+	return c(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+func (c SecretCache) List(namespace string, selector labels.Selector) ([]*corev1.Secret, error) {
+	panic("implement me")
+}
+
+func (c SecretCache) AddIndexer(indexName string, indexer ctlv1.SecretIndexer) {
+	panic("implement me")
+}
+
+func (c SecretCache) GetByIndex(indexName, key string) ([]*corev1.Secret, error) {
+	panic("implement me")
+}
@@ -0,0 +1,87 @@
+package fakeclients
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	kubevirtv1api "kubevirt.io/client-go/api/v1"
+
+	kubevirtv1 "github.com/harvester/harvester/pkg/generated/clientset/versioned/typed/kubevirt.io/v1"
+	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
+)
+
+type VirtualMachineClient func(string) kubevirtv1.VirtualMachineInterface
+
+func (c VirtualMachineClient) Create(vm *kubevirtv1api.VirtualMachine) (*kubevirtv1api.VirtualMachine, error) {
+	return c(vm.Namespace).Create(context.TODO(), vm, metav1.CreateOptions{})
+}
+func (c VirtualMachineClient) Update(vm *kubevirtv1api.VirtualMachine) (*kubevirtv1api.VirtualMachine, error) {
+	return c(vm.Namespace).Update(context.TODO(), vm, metav1.UpdateOptions{})
+}
+func (c VirtualMachineClient) UpdateStatus(vm *kubevirtv1api.VirtualMachine) (*kubevirtv1api.VirtualMachine, error) {
+	return c(vm.Namespace).UpdateStatus(context.TODO(), vm, metav1.UpdateOptions{})
+}
+func (c VirtualMachineClient) Delete(namespace, name string, options *metav1.DeleteOptions) error {
+	return c(namespace).Delete(context.TODO(), name, *options)
+}
+func (c VirtualMachineClient) Get(namespace, name string, options metav1.GetOptions) (*kubevirtv1api.VirtualMachine, error) {
+	return c(namespace).Get(context.TODO(), name, options)
+}
+func (c VirtualMachineClient) List(namespace string, opts metav1.ListOptions) (*kubevirtv1api.VirtualMachineList, error) {
+	return c(namespace).List(context.TODO(), opts)
+}
+func (c VirtualMachineClient) Watch(namespace string, opts metav1.ListOptions) (watch.Interface, error) {
+	return c(namespace).Watch(context.TODO(), opts)
+}
+func (c VirtualMachineClient) Patch(namespace, name string, pt types.PatchType, data []byte, subresources ...string) (result *kubevirtv1api.VirtualMachine, err error) {
+	return c(namespace).Patch(context.TODO(), name, pt, data, metav1.PatchOptions{}, subresources...)
+}
+
+type VirtualMachineCache func(string) kubevirtv1.VirtualMachineInterface
+
+func (c VirtualMachineCache) Get(namespace, name string) (*kubevirtv1api.VirtualMachine, error) {
+	return c(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+}
+func (c VirtualMachineCache) List(namespace string, selector labels.Selector) ([]*kubevirtv1api.VirtualMachine, error) {
+	list, err := c(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*kubevirtv1api.VirtualMachine, 0, len(list.Items))
+	for i := range list.Items {
+		result = append(result, &list.Items[i])
+	}
+	return result, err
+}
+func (c VirtualMachineCache) AddIndexer(indexName string, indexer ctlkubevirtv1.VirtualMachineIndexer) {
+	panic("implement me")
+}
+func (c VirtualMachineCache) GetByIndex(indexName, key string) ([]*kubevirtv1api.VirtualMachine, error) {
+	panic("implement me")
+}
+
+type VirtualMachineInstanceCache func(string) kubevirtv1.VirtualMachineInstanceInterface
+
+func (c VirtualMachineInstanceCache) Get(namespace, name string) (*kubevirtv1api.VirtualMachineInstance, error) {
+	return c(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+}
+func (c VirtualMachineInstanceCache) List(namespace string, selector labels.Selector) ([]*kubevirtv1api.VirtualMachineInstance, error) {
+	list, err := c(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*kubevirtv1api.VirtualMachineInstance, 0, len(list.Items))
+	for i := range list.Items {
+		result = append(result, &list.Items[i])
+	}
+	return result, err
+}
+func (c VirtualMachineInstanceCache) AddIndexer(indexName string, indexer ctlkubevirtv1.VirtualMachineInstanceIndexer) {
+	panic("implement me")
+}
+func (c VirtualMachineInstanceCache) GetByIndex(indexName, key string) ([]*kubevirtv1api.VirtualMachineInstance, error) {
+	panic("implement me")
+}
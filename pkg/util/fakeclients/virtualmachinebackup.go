@@ -0,0 +1,81 @@
+package fakeclients
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	harv1type "github.com/harvester/harvester/pkg/generated/clientset/versioned/typed/harvesterhci.io/v1beta1"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/indexeres"
+)
+
+type VirtualMachineBackupClient func(string) harv1type.VirtualMachineBackupInterface
+
+func (c VirtualMachineBackupClient) Create(vmBackup *harvesterv1.VirtualMachineBackup) (*harvesterv1.VirtualMachineBackup, error) {
+	return c(vmBackup.Namespace).Create(context.TODO(), vmBackup, metav1.CreateOptions{})
+}
+func (c VirtualMachineBackupClient) Update(vmBackup *harvesterv1.VirtualMachineBackup) (*harvesterv1.VirtualMachineBackup, error) {
+	return c(vmBackup.Namespace).Update(context.TODO(), vmBackup, metav1.UpdateOptions{})
+}
+func (c VirtualMachineBackupClient) Delete(namespace, name string, options *metav1.DeleteOptions) error {
+	return c(namespace).Delete(context.TODO(), name, *options)
+}
+func (c VirtualMachineBackupClient) Get(namespace, name string, options metav1.GetOptions) (*harvesterv1.VirtualMachineBackup, error) {
+	return c(namespace).Get(context.TODO(), name, options)
+}
+func (c VirtualMachineBackupClient) List(namespace string, opts metav1.ListOptions) (*harvesterv1.VirtualMachineBackupList, error) {
+	return c(namespace).List(context.TODO(), opts)
+}
+func (c VirtualMachineBackupClient) Watch(namespace string, opts metav1.ListOptions) (watch.Interface, error) {
+	return c(namespace).Watch(context.TODO(), opts)
+}
+func (c VirtualMachineBackupClient) Patch(namespace, name string, pt types.PatchType, data []byte, subresources ...string) (result *harvesterv1.VirtualMachineBackup, err error) {
+	return c(namespace).Patch(context.TODO(), name, pt, data, metav1.PatchOptions{}, subresources...)
+}
+
+type VirtualMachineBackupCache func(string) harv1type.VirtualMachineBackupInterface
+
+func (c VirtualMachineBackupCache) Get(namespace, name string) (*harvesterv1.VirtualMachineBackup, error) {
+	return c(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+}
+func (c VirtualMachineBackupCache) List(namespace string, selector labels.Selector) ([]*harvesterv1.VirtualMachineBackup, error) {
+	list, err := c(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*harvesterv1.VirtualMachineBackup, 0, len(list.Items))
+	for i := range list.Items {
+		result = append(result, &list.Items[i])
+	}
+	return result, err
+}
+func (c VirtualMachineBackupCache) AddIndexer(indexName string, indexer ctlharvesterv1.VirtualMachineBackupIndexer) {
+	panic("implement me")
+}
+func (c VirtualMachineBackupCache) GetByIndex(indexName, key string) ([]*harvesterv1.VirtualMachineBackup, error) {
+	switch indexName {
+	case indexeres.VMBackupByStorageClassIndex:
+		objs, err := c.List(metav1.NamespaceAll, labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		var result []*harvesterv1.VirtualMachineBackup
+		for _, obj := range objs {
+			keys, _ := indexeres.VMBackupByStorageClass(obj)
+			for _, k := range keys {
+				if k == key {
+					result = append(result, obj)
+					break
+				}
+			}
+		}
+		return result, nil
+	default:
+		panic("implement me")
+	}
+}
@@ -0,0 +1,64 @@
+package fakeclients
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	harv1type "github.com/harvester/harvester/pkg/generated/clientset/versioned/typed/harvesterhci.io/v1beta1"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+)
+
+type VirtualMachineBackupScheduleClient func(string) harv1type.VirtualMachineBackupScheduleInterface
+
+func (c VirtualMachineBackupScheduleClient) Create(schedule *harvesterv1.VirtualMachineBackupSchedule) (*harvesterv1.VirtualMachineBackupSchedule, error) {
+	return c(schedule.Namespace).Create(context.TODO(), schedule, metav1.CreateOptions{})
+}
+func (c VirtualMachineBackupScheduleClient) Update(schedule *harvesterv1.VirtualMachineBackupSchedule) (*harvesterv1.VirtualMachineBackupSchedule, error) {
+	return c(schedule.Namespace).Update(context.TODO(), schedule, metav1.UpdateOptions{})
+}
+func (c VirtualMachineBackupScheduleClient) UpdateStatus(schedule *harvesterv1.VirtualMachineBackupSchedule) (*harvesterv1.VirtualMachineBackupSchedule, error) {
+	return c(schedule.Namespace).UpdateStatus(context.TODO(), schedule, metav1.UpdateOptions{})
+}
+func (c VirtualMachineBackupScheduleClient) Delete(namespace, name string, options *metav1.DeleteOptions) error {
+	return c(namespace).Delete(context.TODO(), name, *options)
+}
+func (c VirtualMachineBackupScheduleClient) Get(namespace, name string, options metav1.GetOptions) (*harvesterv1.VirtualMachineBackupSchedule, error) {
+	return c(namespace).Get(context.TODO(), name, options)
+}
+func (c VirtualMachineBackupScheduleClient) List(namespace string, opts metav1.ListOptions) (*harvesterv1.VirtualMachineBackupScheduleList, error) {
+	return c(namespace).List(context.TODO(), opts)
+}
+func (c VirtualMachineBackupScheduleClient) Watch(namespace string, opts metav1.ListOptions) (watch.Interface, error) {
+	return c(namespace).Watch(context.TODO(), opts)
+}
+func (c VirtualMachineBackupScheduleClient) Patch(namespace, name string, pt types.PatchType, data []byte, subresources ...string) (result *harvesterv1.VirtualMachineBackupSchedule, err error) {
+	return c(namespace).Patch(context.TODO(), name, pt, data, metav1.PatchOptions{}, subresources...)
+}
+
+type VirtualMachineBackupScheduleCache func(string) harv1type.VirtualMachineBackupScheduleInterface
+
+func (c VirtualMachineBackupScheduleCache) Get(namespace, name string) (*harvesterv1.VirtualMachineBackupSchedule, error) {
+	return c(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+}
+func (c VirtualMachineBackupScheduleCache) List(namespace string, selector labels.Selector) ([]*harvesterv1.VirtualMachineBackupSchedule, error) {
+	list, err := c(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*harvesterv1.VirtualMachineBackupSchedule, 0, len(list.Items))
+	for i := range list.Items {
+		result = append(result, &list.Items[i])
+	}
+	return result, err
+}
+func (c VirtualMachineBackupScheduleCache) AddIndexer(indexName string, indexer ctlharvesterv1.VirtualMachineBackupScheduleIndexer) {
+	panic("implement me")
+}
+func (c VirtualMachineBackupScheduleCache) GetByIndex(indexName, key string) ([]*harvesterv1.VirtualMachineBackupSchedule, error) {
+	panic("implement me")
+}
@@ -0,0 +1,55 @@
+package fakeclients
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	harv1type "github.com/harvester/harvester/pkg/generated/clientset/versioned/typed/harvesterhci.io/v1beta1"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/indexeres"
+)
+
+type VirtualMachineSnapshotCache func(string) harv1type.VirtualMachineSnapshotInterface
+
+func (c VirtualMachineSnapshotCache) Get(namespace, name string) (*harvesterv1.VirtualMachineSnapshot, error) {
+	return c(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+}
+func (c VirtualMachineSnapshotCache) List(namespace string, selector labels.Selector) ([]*harvesterv1.VirtualMachineSnapshot, error) {
+	list, err := c(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*harvesterv1.VirtualMachineSnapshot, 0, len(list.Items))
+	for i := range list.Items {
+		result = append(result, &list.Items[i])
+	}
+	return result, err
+}
+func (c VirtualMachineSnapshotCache) AddIndexer(indexName string, indexer ctlharvesterv1.VirtualMachineSnapshotIndexer) {
+	panic("implement me")
+}
+func (c VirtualMachineSnapshotCache) GetByIndex(indexName, key string) ([]*harvesterv1.VirtualMachineSnapshot, error) {
+	switch indexName {
+	case indexeres.VMSnapshotByStorageClassIndex:
+		objs, err := c.List(metav1.NamespaceAll, labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		var result []*harvesterv1.VirtualMachineSnapshot
+		for _, obj := range objs {
+			keys, _ := indexeres.VMSnapshotByStorageClass(obj)
+			for _, k := range keys {
+				if k == key {
+					result = append(result, obj)
+					break
+				}
+			}
+		}
+		return result, nil
+	default:
+		panic("implement me")
+	}
+}
@@ -0,0 +1,55 @@
+package fakeclients
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	harv1type "github.com/harvester/harvester/pkg/generated/clientset/versioned/typed/harvesterhci.io/v1beta1"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/indexeres"
+)
+
+type VirtualMachineTemplateVersionCache func(string) harv1type.VirtualMachineTemplateVersionInterface
+
+func (c VirtualMachineTemplateVersionCache) Get(namespace, name string) (*harvesterv1.VirtualMachineTemplateVersion, error) {
+	return c(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+}
+func (c VirtualMachineTemplateVersionCache) List(namespace string, selector labels.Selector) ([]*harvesterv1.VirtualMachineTemplateVersion, error) {
+	list, err := c(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*harvesterv1.VirtualMachineTemplateVersion, 0, len(list.Items))
+	for i := range list.Items {
+		result = append(result, &list.Items[i])
+	}
+	return result, err
+}
+func (c VirtualMachineTemplateVersionCache) AddIndexer(indexName string, indexer ctlharvesterv1.VirtualMachineTemplateVersionIndexer) {
+	panic("implement me")
+}
+func (c VirtualMachineTemplateVersionCache) GetByIndex(indexName, key string) ([]*harvesterv1.VirtualMachineTemplateVersion, error) {
+	switch indexName {
+	case indexeres.TemplateVersionByImageIndex:
+		templateVersions, err := c.List(metav1.NamespaceAll, labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		var result []*harvesterv1.VirtualMachineTemplateVersion
+		for _, templateVersion := range templateVersions {
+			keys, _ := indexeres.TemplateVersionByImage(templateVersion)
+			for _, k := range keys {
+				if k == key {
+					result = append(result, templateVersion)
+					break
+				}
+			}
+		}
+		return result, nil
+	default:
+		return nil, nil
+	}
+}
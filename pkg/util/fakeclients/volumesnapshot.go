@@ -0,0 +1,108 @@
+package fakeclients
+
+import (
+	"context"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/v2/pkg/apis/volumesnapshot/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+
+	snapshottype "github.com/harvester/harvester/pkg/generated/clientset/versioned/typed/snapshot.storage.k8s.io/v1beta1"
+	ctlsnapshotv1 "github.com/harvester/harvester/pkg/generated/controllers/snapshot.storage.k8s.io/v1beta1"
+)
+
+type VolumeSnapshotClient func(string) snapshottype.VolumeSnapshotInterface
+
+func (c VolumeSnapshotClient) Create(snapshot *snapshotv1.VolumeSnapshot) (*snapshotv1.VolumeSnapshot, error) {
+	return c(snapshot.Namespace).Create(context.TODO(), snapshot, metav1.CreateOptions{})
+}
+func (c VolumeSnapshotClient) Update(snapshot *snapshotv1.VolumeSnapshot) (*snapshotv1.VolumeSnapshot, error) {
+	return c(snapshot.Namespace).Update(context.TODO(), snapshot, metav1.UpdateOptions{})
+}
+func (c VolumeSnapshotClient) Delete(namespace, name string, options *metav1.DeleteOptions) error {
+	return c(namespace).Delete(context.TODO(), name, *options)
+}
+func (c VolumeSnapshotClient) Get(namespace, name string, options metav1.GetOptions) (*snapshotv1.VolumeSnapshot, error) {
+	return c(namespace).Get(context.TODO(), name, options)
+}
+func (c VolumeSnapshotClient) List(namespace string, opts metav1.ListOptions) (*snapshotv1.VolumeSnapshotList, error) {
+	return c(namespace).List(context.TODO(), opts)
+}
+func (c VolumeSnapshotClient) Watch(namespace string, opts metav1.ListOptions) (watch.Interface, error) {
+	return c(namespace).Watch(context.TODO(), opts)
+}
+func (c VolumeSnapshotClient) Patch(namespace, name string, pt types.PatchType, data []byte, subresources ...string) (result *snapshotv1.VolumeSnapshot, err error) {
+	return c(namespace).Patch(context.TODO(), name, pt, data, metav1.PatchOptions{}, subresources...)
+}
+
+type VolumeSnapshotCache func(string) snapshottype.VolumeSnapshotInterface
+
+func (c VolumeSnapshotCache) Get(namespace, name string) (*snapshotv1.VolumeSnapshot, error) {
+	return c(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+}
+func (c VolumeSnapshotCache) List(namespace string, selector labels.Selector) ([]*snapshotv1.VolumeSnapshot, error) {
+	list, err := c(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*snapshotv1.VolumeSnapshot, 0, len(list.Items))
+	for i := range list.Items {
+		result = append(result, &list.Items[i])
+	}
+	return result, err
+}
+func (c VolumeSnapshotCache) AddIndexer(indexName string, indexer ctlsnapshotv1.VolumeSnapshotIndexer) {
+	panic("implement me")
+}
+func (c VolumeSnapshotCache) GetByIndex(indexName, key string) ([]*snapshotv1.VolumeSnapshot, error) {
+	panic("implement me")
+}
+
+type VolumeSnapshotContentClient func() snapshottype.VolumeSnapshotContentInterface
+
+func (c VolumeSnapshotContentClient) Create(content *snapshotv1.VolumeSnapshotContent) (*snapshotv1.VolumeSnapshotContent, error) {
+	return c().Create(context.TODO(), content, metav1.CreateOptions{})
+}
+func (c VolumeSnapshotContentClient) Update(content *snapshotv1.VolumeSnapshotContent) (*snapshotv1.VolumeSnapshotContent, error) {
+	return c().Update(context.TODO(), content, metav1.UpdateOptions{})
+}
+func (c VolumeSnapshotContentClient) Delete(name string, options *metav1.DeleteOptions) error {
+	return c().Delete(context.TODO(), name, *options)
+}
+func (c VolumeSnapshotContentClient) Get(name string, options metav1.GetOptions) (*snapshotv1.VolumeSnapshotContent, error) {
+	return c().Get(context.TODO(), name, options)
+}
+func (c VolumeSnapshotContentClient) List(opts metav1.ListOptions) (*snapshotv1.VolumeSnapshotContentList, error) {
+	return c().List(context.TODO(), opts)
+}
+func (c VolumeSnapshotContentClient) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	return c().Watch(context.TODO(), opts)
+}
+func (c VolumeSnapshotContentClient) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *snapshotv1.VolumeSnapshotContent, err error) {
+	return c().Patch(context.TODO(), name, pt, data, metav1.PatchOptions{}, subresources...)
+}
+
+type VolumeSnapshotContentCache func() snapshottype.VolumeSnapshotContentInterface
+
+func (c VolumeSnapshotContentCache) Get(name string) (*snapshotv1.VolumeSnapshotContent, error) {
+	return c().Get(context.TODO(), name, metav1.GetOptions{})
+}
+func (c VolumeSnapshotContentCache) List(selector labels.Selector) ([]*snapshotv1.VolumeSnapshotContent, error) {
+	list, err := c().List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*snapshotv1.VolumeSnapshotContent, 0, len(list.Items))
+	for i := range list.Items {
+		result = append(result, &list.Items[i])
+	}
+	return result, err
+}
+func (c VolumeSnapshotContentCache) AddIndexer(indexName string, indexer ctlsnapshotv1.VolumeSnapshotContentIndexer) {
+	panic("implement me")
+}
+func (c VolumeSnapshotContentCache) GetByIndex(indexName, key string) ([]*snapshotv1.VolumeSnapshotContent, error) {
+	panic("implement me")
+}
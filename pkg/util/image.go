@@ -0,0 +1,10 @@
+package util
+
+import (
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+)
+
+// IsImageShared returns true if the image is usable from all namespaces
+func IsImageShared(image *harvesterv1.VirtualMachineImage) bool {
+	return image.Namespace == PublicNamespaceName || image.Spec.Shared
+}
@@ -0,0 +1,79 @@
+package util
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util/diskimage"
+)
+
+var (
+	// the volume labels of the Windows installation media look like SSS_X64FRE_EN-US_DV9 or CCCOMA_X64FRE_EN-US_DV9
+	windowsPattern = regexp.MustCompile(`windows|\bwin[-_ ]?(\d+|server|srv)|[xa]64fre`)
+	windowsVersion = regexp.MustCompile(`(?:server|srv)[-_ ]?(20\d\d)|\b(20\d\d)\b|win(?:dows)?[-_ ]?(\d+)`)
+	linuxPattern   = regexp.MustCompile(`ubuntu|debian|centos|rocky|rhel|redhat|fedora|opensuse|sles|suse|almalinux|alpine|cirros|oracle-?linux|flatcar|linux`)
+	versionPattern = regexp.MustCompile(`\d+(\.\d+)*`)
+	amd64Pattern   = regexp.MustCompile(`amd64|x86[-_]64|x64`)
+	arm64Pattern   = regexp.MustCompile(`arm64|aarch64|a64fre`)
+)
+
+// NormalizeArchitecture returns the image architecture of the architecture names used by the distributions,
+// the unknown architectures are returned as is
+func NormalizeArchitecture(arch string) string {
+	switch strings.ToLower(arch) {
+	case "amd64", "x86_64", "x86-64", "x64":
+		return harvesterv1.VirtualMachineImageArchitectureAMD64
+	case "arm64", "aarch64":
+		return harvesterv1.VirtualMachineImageArchitectureARM64
+	}
+	return arch
+}
+
+// GuessImageOSInfo guesses the operating system of the image from its display name, the file name of its URL and
+// the volume label of an ISO file. It returns nil if nothing is recognized.
+func GuessImageOSInfo(image *harvesterv1.VirtualMachineImage, info diskimage.Info) *harvesterv1.VirtualMachineImageOSInfo {
+	names := []string{image.Spec.DisplayName, info.VolumeLabel}
+	if image.Spec.URL != "" {
+		names = append(names, path.Base(image.Spec.URL))
+	}
+	text := strings.ToLower(strings.Join(names, " "))
+
+	osInfo := &harvesterv1.VirtualMachineImageOSInfo{}
+	switch {
+	case arm64Pattern.MatchString(text):
+		osInfo.Architecture = harvesterv1.VirtualMachineImageArchitectureARM64
+	case amd64Pattern.MatchString(text):
+		osInfo.Architecture = harvesterv1.VirtualMachineImageArchitectureAMD64
+	}
+	windows := windowsPattern.MatchString(text)
+	// the architectures are removed not to take them for versions
+	text = arm64Pattern.ReplaceAllString(amd64Pattern.ReplaceAllString(text, " "), " ")
+
+	if windows {
+		osInfo.Family = harvesterv1.VirtualMachineImageOSFamilyWindows
+		if match := windowsVersion.FindStringSubmatch(text); match != nil {
+			osInfo.Version = firstNonEmpty(match[1:]...)
+		}
+		// the installation media don't have the virtio drivers, the guest can't see the virtio disks without them
+		osInfo.VirtioDriversRequired = info.Format == diskimage.FormatISO
+	} else if loc := linuxPattern.FindStringIndex(text); loc != nil {
+		osInfo.Family = harvesterv1.VirtualMachineImageOSFamilyLinux
+		osInfo.Version = versionPattern.FindString(text[loc[1]:])
+	}
+
+	if osInfo.Family == "" && osInfo.Architecture == "" {
+		return nil
+	}
+	return osInfo
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
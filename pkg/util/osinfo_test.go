@@ -0,0 +1,94 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util/diskimage"
+)
+
+func TestGuessImageOSInfo(t *testing.T) {
+	var testCases = []struct {
+		name        string
+		displayName string
+		url         string
+		info        diskimage.Info
+		expected    *harvesterv1.VirtualMachineImageOSInfo
+	}{
+		{
+			name:        "ubuntu cloud image",
+			displayName: "focal",
+			url:         "https://cloud-images.ubuntu.com/releases/focal/release/ubuntu-20.04-server-cloudimg-amd64.img",
+			info:        diskimage.Info{Format: diskimage.FormatQCOW2},
+			expected: &harvesterv1.VirtualMachineImageOSInfo{
+				Family:       harvesterv1.VirtualMachineImageOSFamilyLinux,
+				Version:      "20.04",
+				Architecture: harvesterv1.VirtualMachineImageArchitectureAMD64,
+			},
+		},
+		{
+			name:        "opensuse arm64 image",
+			displayName: "openSUSE-Leap-15.3.aarch64-NoCloud.qcow2",
+			info:        diskimage.Info{Format: diskimage.FormatQCOW2},
+			expected: &harvesterv1.VirtualMachineImageOSInfo{
+				Family:       harvesterv1.VirtualMachineImageOSFamilyLinux,
+				Version:      "15.3",
+				Architecture: harvesterv1.VirtualMachineImageArchitectureARM64,
+			},
+		},
+		{
+			name:        "windows installation media",
+			displayName: "server.iso",
+			info:        diskimage.Info{Format: diskimage.FormatISO, VolumeLabel: "SSS_X64FRE_EN-US_DV9"},
+			expected: &harvesterv1.VirtualMachineImageOSInfo{
+				Family:                harvesterv1.VirtualMachineImageOSFamilyWindows,
+				Architecture:          harvesterv1.VirtualMachineImageArchitectureAMD64,
+				VirtioDriversRequired: true,
+			},
+		},
+		{
+			name:        "windows server disk",
+			displayName: "Windows Server 2019",
+			info:        diskimage.Info{Format: diskimage.FormatQCOW2},
+			expected: &harvesterv1.VirtualMachineImageOSInfo{
+				Family:  harvesterv1.VirtualMachineImageOSFamilyWindows,
+				Version: "2019",
+			},
+		},
+		{
+			name:        "windows 10 installation media",
+			displayName: "Win10_21H2_English_x64.iso",
+			info:        diskimage.Info{Format: diskimage.FormatISO},
+			expected: &harvesterv1.VirtualMachineImageOSInfo{
+				Family:                harvesterv1.VirtualMachineImageOSFamilyWindows,
+				Version:               "10",
+				Architecture:          harvesterv1.VirtualMachineImageArchitectureAMD64,
+				VirtioDriversRequired: true,
+			},
+		},
+		{
+			name:        "unknown",
+			displayName: "my-image",
+			info:        diskimage.Info{Format: diskimage.FormatRaw},
+		},
+	}
+
+	for _, tc := range testCases {
+		image := &harvesterv1.VirtualMachineImage{
+			Spec: harvesterv1.VirtualMachineImageSpec{
+				DisplayName: tc.displayName,
+				URL:         tc.url,
+			},
+		}
+		assert.Equal(t, tc.expected, GuessImageOSInfo(image, tc.info), "case %q", tc.name)
+	}
+}
+
+func TestNormalizeArchitecture(t *testing.T) {
+	assert.Equal(t, harvesterv1.VirtualMachineImageArchitectureAMD64, NormalizeArchitecture("x86_64"))
+	assert.Equal(t, harvesterv1.VirtualMachineImageArchitectureARM64, NormalizeArchitecture("aarch64"))
+	assert.Equal(t, harvesterv1.VirtualMachineImageArchitectureARM64, NormalizeArchitecture("arm64"))
+	assert.Equal(t, "ppc64el", NormalizeArchitecture("ppc64el"))
+}
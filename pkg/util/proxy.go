@@ -0,0 +1,81 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/harvester/harvester/pkg/settings"
+)
+
+// GetHTTPProxyConfig returns the proxy config of the http-proxy setting, it is nil if no proxy is configured
+func GetHTTPProxyConfig() (*settings.HTTPProxyConfig, error) {
+	return ParseHTTPProxyConfig(settings.HTTPProxy.Get())
+}
+
+// ParseHTTPProxyConfig parses and validates the JSON value of the http-proxy setting, the config is nil if no proxy
+// is configured
+func ParseHTTPProxyConfig(value string) (*settings.HTTPProxyConfig, error) {
+	if value == "" {
+		return nil, nil
+	}
+	config := &settings.HTTPProxyConfig{}
+	if err := json.Unmarshal([]byte(value), config); err != nil {
+		return nil, fmt.Errorf("invalid proxy config: %w", err)
+	}
+	for field, proxy := range map[string]string{"httpProxy": config.HTTPProxy, "httpsProxy": config.HTTPSProxy} {
+		if proxy == "" {
+			continue
+		}
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", field, err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid %s %q: an http or https URL is required", field, proxy)
+		}
+	}
+	if config.HTTPProxy == "" && config.HTTPSProxy == "" {
+		return nil, nil
+	}
+	return config, nil
+}
+
+// ProxyFunc returns the proxy function of the http transport for the proxy config
+func ProxyFunc(config *settings.HTTPProxyConfig) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		proxy := config.HTTPProxy
+		if req.URL.Scheme == "https" {
+			proxy = config.HTTPSProxy
+		}
+		if proxy == "" || isNoProxy(config.NoProxy, req.URL.Hostname()) {
+			return nil, nil
+		}
+		return url.Parse(proxy)
+	}
+}
+
+// isNoProxy returns true if the host matches the comma separated noProxy list of hosts, domains and CIDRs
+func isNoProxy(noProxy, host string) bool {
+	ip := net.ParseIP(host)
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.TrimSpace(entry)
+		switch {
+		case entry == "":
+		case entry == "*":
+			return true
+		case ip != nil && strings.Contains(entry, "/"):
+			if _, cidr, err := net.ParseCIDR(entry); err == nil && cidr.Contains(ip) {
+				return true
+			}
+		case host == strings.TrimPrefix(entry, "."):
+			return true
+		case strings.HasSuffix(host, "."+strings.TrimPrefix(entry, ".")):
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package util
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/harvester/harvester/pkg/settings"
+)
+
+func TestProxyFunc(t *testing.T) {
+	config := &settings.HTTPProxyConfig{
+		HTTPProxy:  "http://proxy:3128",
+		HTTPSProxy: "http://secure-proxy:3128",
+		NoProxy:    "localhost, .internal.example.com,10.0.0.0/8",
+	}
+
+	var testCases = []struct {
+		name     string
+		url      string
+		expected string
+	}{
+		{
+			name:     "http",
+			url:      "http://images.example.com/disk.img",
+			expected: "http://proxy:3128",
+		},
+		{
+			name:     "https",
+			url:      "https://images.example.com/disk.img",
+			expected: "http://secure-proxy:3128",
+		},
+		{
+			name: "no proxy host",
+			url:  "http://localhost:8080/disk.img",
+		},
+		{
+			name: "no proxy domain",
+			url:  "https://artifacts.internal.example.com/disk.img",
+		},
+		{
+			name: "no proxy cidr",
+			url:  "http://10.1.2.3/disk.img",
+		},
+	}
+
+	for _, tc := range testCases {
+		req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+		assert.Nil(t, err, "case %q", tc.name)
+		proxy, err := ProxyFunc(config)(req)
+		assert.Nil(t, err, "case %q", tc.name)
+		if tc.expected == "" {
+			assert.Nil(t, proxy, "case %q", tc.name)
+		} else {
+			assert.Equal(t, tc.expected, proxy.String(), "case %q", tc.name)
+		}
+	}
+}
+
+func TestParseHTTPProxyConfig(t *testing.T) {
+	var testCases = []struct {
+		name        string
+		value       string
+		expected    *settings.HTTPProxyConfig
+		expectedErr bool
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:  "no proxy",
+			value: `{"noProxy":"localhost"}`,
+		},
+		{
+			name:     "proxy",
+			value:    `{"httpsProxy":"http://proxy:3128","noProxy":"localhost"}`,
+			expected: &settings.HTTPProxyConfig{HTTPSProxy: "http://proxy:3128", NoProxy: "localhost"},
+		},
+		{
+			name:        "invalid json",
+			value:       `{"httpsProxy":`,
+			expectedErr: true,
+		},
+		{
+			name:        "proxy without scheme",
+			value:       `{"httpProxy":"proxy:3128"}`,
+			expectedErr: true,
+		},
+		{
+			name:        "proxy with unsupported scheme",
+			value:       `{"httpsProxy":"ftp://proxy:3128"}`,
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		config, err := ParseHTTPProxyConfig(tc.value)
+		if tc.expectedErr {
+			assert.NotNil(t, err, "case %q", tc.name)
+			continue
+		}
+		assert.Nil(t, err, "case %q", tc.name)
+		assert.Equal(t, tc.expected, config, "case %q", tc.name)
+	}
+}
@@ -0,0 +1,25 @@
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+)
+
+// GetImageUploadStagingPath returns the file in the upload directory staging the chunks of the chunked upload
+// of the image
+func GetImageUploadStagingPath(uploadDir string, image *harvesterv1.VirtualMachineImage) string {
+	return filepath.Join(uploadDir, fmt.Sprintf("%s-%s-%s.part", image.Namespace, image.Name, image.UID))
+}
+
+// NewImageStagingDir creates a temporary directory in the staging directory to hold the files of an image while it
+// is converted, verified or compressed, the caller removes it when done
+func NewImageStagingDir(stagingDir string) (string, error) {
+	if err := os.MkdirAll(stagingDir, 0700); err != nil {
+		return "", err
+	}
+	return ioutil.TempDir(stagingDir, "vmimage-")
+}
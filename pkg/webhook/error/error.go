@@ -43,6 +43,15 @@ func NewBadRequest(message string) AdmitError {
 	}
 }
 
+// 403
+func NewForbidden(message string) AdmitError {
+	return AdmitError{
+		code:    http.StatusForbidden,
+		message: message,
+		reason:  metav1.StatusReasonForbidden,
+	}
+}
+
 // 405
 func NewMethodNotAllowed(message string) AdmitError {
 	return AdmitError{
@@ -0,0 +1,79 @@
+package backupschedule
+
+import (
+	"fmt"
+
+	"github.com/robfig/cron/v3"
+	admissionregv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/controller/master/backup"
+	werror "github.com/harvester/harvester/pkg/webhook/error"
+	"github.com/harvester/harvester/pkg/webhook/types"
+)
+
+const (
+	fieldCron          = "spec.cron"
+	fieldVMSelector    = "spec.vmSelector"
+	fieldLabelSelector = "spec.vmSelector.labelSelector"
+	fieldRetention     = "spec.retention"
+)
+
+func NewValidator() types.Validator {
+	return &backupScheduleValidator{}
+}
+
+type backupScheduleValidator struct {
+	types.DefaultValidator
+}
+
+func (v *backupScheduleValidator) Resource() types.Resource {
+	return types.Resource{
+		Name:       v1beta1.VirtualMachineBackupScheduleResourceName,
+		Scope:      admissionregv1.NamespacedScope,
+		APIGroup:   v1beta1.SchemeGroupVersion.Group,
+		APIVersion: v1beta1.SchemeGroupVersion.Version,
+		ObjectType: &v1beta1.VirtualMachineBackupSchedule{},
+		OperationTypes: []admissionregv1.OperationType{
+			admissionregv1.Create,
+			admissionregv1.Update,
+		},
+	}
+}
+
+func (v *backupScheduleValidator) Create(request *types.Request, newObj runtime.Object) error {
+	return v.validateSchedule(newObj.(*v1beta1.VirtualMachineBackupSchedule))
+}
+
+func (v *backupScheduleValidator) Update(request *types.Request, oldObj runtime.Object, newObj runtime.Object) error {
+	return v.validateSchedule(newObj.(*v1beta1.VirtualMachineBackupSchedule))
+}
+
+func (v *backupScheduleValidator) validateSchedule(schedule *v1beta1.VirtualMachineBackupSchedule) error {
+	if schedule.Spec.Cron == "" {
+		return werror.NewInvalidError("cron expression is empty", fieldCron)
+	}
+	if _, err := cron.ParseStandard(schedule.Spec.Cron); err != nil {
+		return werror.NewInvalidError(fmt.Sprintf("invalid cron expression %q: %s", schedule.Spec.Cron, err.Error()), fieldCron)
+	}
+
+	selector := schedule.Spec.VMSelector
+	if len(selector.Names) == 0 && selector.LabelSelector == nil {
+		return werror.NewInvalidError("either VM names or a label selector is required", fieldVMSelector)
+	}
+	if selector.LabelSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(selector.LabelSelector); err != nil {
+			return werror.NewInvalidError(err.Error(), fieldLabelSelector)
+		}
+	}
+
+	if schedule.Spec.Retention != nil {
+		if err := backup.ValidateRetentionPolicy(schedule.Spec.Retention); err != nil {
+			return werror.NewInvalidError(err.Error(), fieldRetention)
+		}
+	}
+
+	return nil
+}
@@ -7,26 +7,34 @@ import (
 	v1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
 	admissionregv1 "k8s.io/api/admissionregistration/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	kv1 "kubevirt.io/client-go/api/v1"
 
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
 	ctlkv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
+	"github.com/harvester/harvester/pkg/indexeres"
 	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/util"
 	werror "github.com/harvester/harvester/pkg/webhook/error"
 	"github.com/harvester/harvester/pkg/webhook/types"
 )
 
-func NewValidator(pvcCache v1.PersistentVolumeClaimCache, vmCache ctlkv1.VirtualMachineCache) types.Validator {
+func NewValidator(pvcCache v1.PersistentVolumeClaimCache, vmCache ctlkv1.VirtualMachineCache, imageCache ctlharvesterv1.VirtualMachineImageCache) types.Validator {
+	imageCache.AddIndexer(indexeres.ImageByStorageClassIndex, indexeres.ImageByStorageClass)
 	return &pvcValidator{
-		pvcCache: pvcCache,
-		vmCache:  vmCache,
+		pvcCache:   pvcCache,
+		vmCache:    vmCache,
+		imageCache: imageCache,
 	}
 }
 
 type pvcValidator struct {
 	types.DefaultValidator
-	pvcCache v1.PersistentVolumeClaimCache
-	vmCache  ctlkv1.VirtualMachineCache
+	pvcCache   v1.PersistentVolumeClaimCache
+	vmCache    ctlkv1.VirtualMachineCache
+	imageCache ctlharvesterv1.VirtualMachineImageCache
 }
 
 func (v *pvcValidator) Resource() types.Resource {
@@ -37,12 +45,62 @@ func (v *pvcValidator) Resource() types.Resource {
 		APIVersion: corev1.SchemeGroupVersion.Version,
 		ObjectType: &corev1.PersistentVolumeClaim{},
 		OperationTypes: []admissionregv1.OperationType{
+			admissionregv1.Create,
 			admissionregv1.Delete,
 			admissionregv1.Update,
 		},
 	}
 }
 
+func (v *pvcValidator) Create(request *types.Request, newObj runtime.Object) error {
+	pvc := newObj.(*corev1.PersistentVolumeClaim)
+
+	// the annotation of a missing image is left to the provisioner, e.g. the PVCs restored from a backup may
+	// outlive their images
+	if imageID := pvc.Annotations[util.AnnotationImageID]; imageID != "" {
+		ns, name := ref.Parse(imageID)
+		image, err := v.imageCache.Get(ns, name)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return werror.NewInternalError(fmt.Sprintf("failed to get image %s: %v", imageID, err))
+		}
+		if err == nil {
+			if err := checkImage(pvc, image); err != nil {
+				return err
+			}
+		}
+	}
+
+	// the storage class of an image provisions its volumes without the image annotation as well
+	if pvc.Spec.StorageClassName != nil && *pvc.Spec.StorageClassName != "" {
+		images, err := v.imageCache.GetByIndex(indexeres.ImageByStorageClassIndex, *pvc.Spec.StorageClassName)
+		if err != nil {
+			return werror.NewInternalError(fmt.Sprintf("failed to get the images of storage class %s: %v", *pvc.Spec.StorageClassName, err))
+		}
+		for _, image := range images {
+			if err := checkImage(pvc, image); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkImage denies the volume of an image of another namespace unless it is shared, or of an image which fails
+// the checksum verification
+func checkImage(pvc *corev1.PersistentVolumeClaim, image *harvesterv1.VirtualMachineImage) error {
+	imageID := ref.Construct(image.Namespace, image.Name)
+	if image.Namespace != pvc.Namespace && !util.IsImageShared(image) {
+		message := fmt.Sprintf("can not create the volume from image %s which is not shared with namespace %s", imageID, pvc.Namespace)
+		return werror.NewInvalidError(message, "")
+	}
+	if harvesterv1.ImageVerified.IsFalse(image) {
+		message := fmt.Sprintf("can not create the volume from image %s which fails the checksum verification: %s", imageID, harvesterv1.ImageVerified.GetMessage(image))
+		return werror.NewInvalidError(message, "")
+	}
+	return nil
+}
+
 func (v *pvcValidator) Delete(request *types.Request, oldObj runtime.Object) error {
 	if request.IsGarbageCollection() {
 		return nil
@@ -1,17 +1,29 @@
 package restore
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
+	"strings"
 
+	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	ctlstoragev1 "github.com/rancher/wrangler/pkg/generated/controllers/storage/v1"
 	admissionregv1 "k8s.io/api/admissionregistration/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apiserver/pkg/authentication/user"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	kv1 "kubevirt.io/client-go/api/v1"
 
 	"github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
 	"github.com/harvester/harvester/pkg/controller/master/backup"
 	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	ctlcniv1 "github.com/harvester/harvester/pkg/generated/controllers/k8s.cni.cncf.io/v1"
 	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
 	"github.com/harvester/harvester/pkg/settings"
 	werror "github.com/harvester/harvester/pkg/webhook/error"
@@ -19,29 +31,35 @@ import (
 )
 
 const (
-	fieldTargetName               = "spec.target.name"
-	fieldVirtualMachineBackupName = "spec.virtualMachineBackupName"
-	fieldNewVM                    = "spec.newVM"
+	fieldTargetName                    = "spec.target.name"
+	fieldVirtualMachineBackupName      = "spec.virtualMachineBackupName"
+	fieldVirtualMachineBackupNamespace = "spec.virtualMachineBackupNamespace"
+	fieldNewVM                         = "spec.newVM"
+	fieldVolumes                       = "spec.volumes"
+	fieldTargetNamespace               = "spec.targetNamespace"
+	fieldStorageClassMappings          = "spec.storageClassMappings"
+	fieldNetworkMappings               = "spec.networkMappings"
 )
 
 func NewValidator(
 	vms ctlkubevirtv1.VirtualMachineCache,
-	setting ctlharvesterv1.SettingCache,
 	vmBackup ctlharvesterv1.VirtualMachineBackupCache,
+	backupTargets ctlharvesterv1.BackupTargetCache,
+	settings ctlharvesterv1.SettingCache,
+	namespaces ctlcorev1.NamespaceCache,
+	pvcs ctlcorev1.PersistentVolumeClaimCache,
+	storageClasses ctlstoragev1.StorageClassCache,
+	netAttachDefs ctlcniv1.NetworkAttachmentDefinitionCache,
+	sar authorizationv1client.SubjectAccessReviewInterface,
 ) types.Validator {
 	return &restoreValidator{
-		vms:      vms,
-		setting:  setting,
-		vmBackup: vmBackup,
+		Checker: NewChecker(vms, vmBackup, backupTargets, settings, namespaces, pvcs, storageClasses, netAttachDefs, sar),
 	}
 }
 
 type restoreValidator struct {
 	types.DefaultValidator
-
-	vms      ctlkubevirtv1.VirtualMachineCache
-	setting  ctlharvesterv1.SettingCache
-	vmBackup ctlharvesterv1.VirtualMachineBackupCache
+	*Checker
 }
 
 func (v *restoreValidator) Resource() types.Resource {
@@ -58,8 +76,58 @@ func (v *restoreValidator) Resource() types.Resource {
 }
 
 func (v *restoreValidator) Create(request *types.Request, newObj runtime.Object) error {
-	newRestore := newObj.(*v1beta1.VirtualMachineRestore)
+	userInfo := &user.DefaultInfo{
+		Name:   request.UserInfo.Username,
+		UID:    request.UserInfo.UID,
+		Groups: request.UserInfo.Groups,
+		Extra:  make(map[string][]string, len(request.UserInfo.Extra)),
+	}
+	for key, value := range request.UserInfo.Extra {
+		userInfo.Extra[key] = value
+	}
+	return v.Check(request.Context, newObj.(*v1beta1.VirtualMachineRestore), userInfo)
+}
+
+// NewChecker returns the checker of the restore validator, it's shared with the restore preflight API
+// to report the same errors before the restore is created.
+func NewChecker(
+	vms ctlkubevirtv1.VirtualMachineCache,
+	vmBackup ctlharvesterv1.VirtualMachineBackupCache,
+	backupTargets ctlharvesterv1.BackupTargetCache,
+	settings ctlharvesterv1.SettingCache,
+	namespaces ctlcorev1.NamespaceCache,
+	pvcs ctlcorev1.PersistentVolumeClaimCache,
+	storageClasses ctlstoragev1.StorageClassCache,
+	netAttachDefs ctlcniv1.NetworkAttachmentDefinitionCache,
+	sar authorizationv1client.SubjectAccessReviewInterface,
+) *Checker {
+	return &Checker{
+		vms:            vms,
+		vmBackup:       vmBackup,
+		backupTargets:  backupTargets,
+		settings:       settings,
+		namespaces:     namespaces,
+		pvcs:           pvcs,
+		storageClasses: storageClasses,
+		netAttachDefs:  netAttachDefs,
+		sar:            sar,
+	}
+}
 
+type Checker struct {
+	vms            ctlkubevirtv1.VirtualMachineCache
+	vmBackup       ctlharvesterv1.VirtualMachineBackupCache
+	backupTargets  ctlharvesterv1.BackupTargetCache
+	settings       ctlharvesterv1.SettingCache
+	namespaces     ctlcorev1.NamespaceCache
+	pvcs           ctlcorev1.PersistentVolumeClaimCache
+	storageClasses ctlstoragev1.StorageClassCache
+	netAttachDefs  ctlcniv1.NetworkAttachmentDefinitionCache
+	sar            authorizationv1client.SubjectAccessReviewInterface
+}
+
+// Check returns the first error which rejects the creation of the restore by the user
+func (v *Checker) Check(ctx context.Context, newRestore *v1beta1.VirtualMachineRestore, userInfo user.Info) error {
 	targetVM := newRestore.Spec.Target.Name
 	backupName := newRestore.Spec.VirtualMachineBackupName
 	newVM := newRestore.Spec.NewVM
@@ -70,12 +138,36 @@ func (v *restoreValidator) Create(request *types.Request, newObj runtime.Object)
 	if backupName == "" {
 		return werror.NewInvalidError("backup name is empty", fieldVirtualMachineBackupName)
 	}
+	// the restore controller reads the backup with its own permissions
+	if newRestore.Spec.VirtualMachineBackupNamespace != newRestore.Namespace {
+		return werror.NewInvalidError("the backup must be in the namespace of the restore", fieldVirtualMachineBackupNamespace)
+	}
 
 	if err := v.checkBackupTarget(newRestore); err != nil {
 		return werror.NewInvalidError(err.Error(), fieldVirtualMachineBackupName)
 	}
 
-	vm, err := v.vms.Get(newRestore.Namespace, targetVM)
+	if err := v.checkMappings(newRestore); err != nil {
+		return err
+	}
+
+	if err := CheckCreateAccess(ctx, v.sar, backup.GetRestoreTargetNamespace(newRestore), userInfo); err != nil {
+		return err
+	}
+
+	// the detached PVCs are restored aside and the source VM is left alone
+	if newRestore.Spec.Mode == v1beta1.VirtualMachineRestoreModeDetachedPVCs {
+		if newVM {
+			return werror.NewInvalidError("can't restore a new VM in the detachedPVCs mode", fieldNewVM)
+		}
+		return v.checkVolumes(newRestore)
+	}
+
+	if len(newRestore.Spec.Volumes) > 0 {
+		return werror.NewInvalidError("volumes can only be selected in the detachedPVCs mode", fieldVolumes)
+	}
+
+	vm, err := v.vms.Get(backup.GetRestoreTargetNamespace(newRestore), targetVM)
 	if err != nil {
 		if newVM && apierrors.IsNotFound(err) {
 			return nil
@@ -96,28 +188,159 @@ func (v *restoreValidator) Create(request *types.Request, newObj runtime.Object)
 	return nil
 }
 
-func (v *restoreValidator) checkBackupTarget(vmRestore *v1beta1.VirtualMachineRestore) error {
-	// get backup target
-	backupTargetSetting, err := v.setting.Get(settings.BackupTargetSettingName)
+func (v *Checker) checkBackupTarget(vmRestore *v1beta1.VirtualMachineRestore) error {
+	// get vmbackup
+	vmBackup, err := v.vmBackup.Get(vmRestore.Spec.VirtualMachineBackupNamespace, vmRestore.Spec.VirtualMachineBackupName)
 	if err != nil {
-		return fmt.Errorf("can't get backup target setting, err: %w", err)
+		return fmt.Errorf("can't get vmbackup %s/%s, err: %w", vmRestore.Spec.VirtualMachineBackupNamespace, vmRestore.Spec.VirtualMachineBackupName, err)
+	}
+
+	// get the backup target of the vmbackup, the default backup target falls back to the backup-target setting
+	// until its resource is created
+	targetName := backup.GetBackupTargetName(vmBackup)
+	backupTarget, err := v.backupTargets.Get(targetName)
+	if apierrors.IsNotFound(err) && targetName == v1beta1.DefaultBackupTargetName {
+		setting, err := v.settings.Get(settings.BackupTargetSettingName)
+		if err != nil {
+			return fmt.Errorf("can't get backup target setting, err: %w", err)
+		}
+		inTarget, err := backup.IsBackupInTargetSetting(vmBackup, setting.Value)
+		if err != nil {
+			return fmt.Errorf("can't get backup target setting, err: %w", err)
+		}
+		if !inTarget {
+			return errors.New("VM Backup is not matched with Backup Target")
+		}
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("can't get backup target %s, err: %w", targetName, err)
 	}
-	backupTarget := &settings.BackupTarget{}
-	if err := json.Unmarshal([]byte(backupTargetSetting.Value), backupTarget); err != nil {
-		return fmt.Errorf("unmarshal backup target failed, value: %s, err: %w", backupTargetSetting.Value, err)
+	if !v1beta1.BackupTargetValidated.IsTrue(backupTarget) {
+		return fmt.Errorf("backup target %s is not validated", targetName)
 	}
 
-	// get vmbackup
+	if !backup.IsBackupInTarget(vmBackup, backupTarget) {
+		return errors.New("VM Backup is not matched with Backup Target")
+	}
+
+	// the volume data can only be restored from the default backup target
+	return backup.CheckBackupTargetLocation(v.backupTargets, backupTarget)
+}
+
+// restoredResources are the resources created by the restore controller with its own permissions,
+// so the user must be allowed to create all of them in the target namespace.
+var restoredResources = []schema.GroupResource{
+	{Group: kv1.SchemeGroupVersion.Group, Resource: "virtualmachines"},
+	{Group: corev1.SchemeGroupVersion.Group, Resource: "persistentvolumeclaims"},
+	{Group: corev1.SchemeGroupVersion.Group, Resource: "secrets"},
+}
+
+// CheckCreateAccess reviews whether the user can create the restored resources in the namespace
+func CheckCreateAccess(ctx context.Context, sar authorizationv1client.SubjectAccessReviewInterface, namespace string, userInfo user.Info) error {
+	extra := make(map[string]authorizationv1.ExtraValue, len(userInfo.GetExtra()))
+	for key, value := range userInfo.GetExtra() {
+		extra[key] = value
+	}
+
+	for _, resource := range restoredResources {
+		review, err := sar.Create(ctx, &authorizationv1.SubjectAccessReview{
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace: namespace,
+					Verb:      "create",
+					Group:     resource.Group,
+					Resource:  resource.Resource,
+				},
+				User:   userInfo.GetName(),
+				Groups: userInfo.GetGroups(),
+				Extra:  extra,
+				UID:    userInfo.GetUID(),
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+		if !review.Status.Allowed {
+			return werror.NewForbidden(fmt.Sprintf("user %s can't create %s in namespace %s", userInfo.GetName(), resource.Resource, namespace))
+		}
+	}
+	return nil
+}
+
+// checkMappings checks that the target namespace and every mapped storage class and network exist
+func (v *Checker) checkMappings(vmRestore *v1beta1.VirtualMachineRestore) error {
+	targetNamespace := backup.GetRestoreTargetNamespace(vmRestore)
+	if targetNamespace != vmRestore.Namespace {
+		if !vmRestore.Spec.NewVM && vmRestore.Spec.Mode != v1beta1.VirtualMachineRestoreModeDetachedPVCs {
+			return werror.NewInvalidError("can only restore a new VM or detached PVCs into another namespace", fieldTargetNamespace)
+		}
+		if _, err := v.namespaces.Get(targetNamespace); err != nil {
+			return werror.NewInvalidError(fmt.Sprintf("can't get target namespace %s, err: %s", targetNamespace, err.Error()), fieldTargetNamespace)
+		}
+	}
+
+	for source, target := range vmRestore.Spec.StorageClassMappings {
+		if _, err := v.storageClasses.Get(target); err != nil {
+			return werror.NewInvalidError(fmt.Sprintf("can't get storage class %s mapped from %s, err: %s", target, source, err.Error()), fieldStorageClassMappings)
+		}
+	}
+
+	for source, target := range vmRestore.Spec.NetworkMappings {
+		// a Multus network name is either <namespace>/<name> or <name> in the VM namespace
+		namespace, name := targetNamespace, target
+		if parts := strings.SplitN(target, "/", 2); len(parts) == 2 {
+			namespace, name = parts[0], parts[1]
+		}
+		if _, err := v.netAttachDefs.Get(namespace, name); err != nil {
+			return werror.NewInvalidError(fmt.Sprintf("can't get network %s mapped from %s, err: %s", target, source, err.Error()), fieldNetworkMappings)
+		}
+	}
+
+	return nil
+}
+
+func (v *Checker) checkVolumes(vmRestore *v1beta1.VirtualMachineRestore) error {
 	vmBackup, err := v.vmBackup.Get(vmRestore.Spec.VirtualMachineBackupNamespace, vmRestore.Spec.VirtualMachineBackupName)
 	if err != nil {
-		return fmt.Errorf("can't get vmbackup %s/%s, err: %w", vmRestore.Spec.VirtualMachineBackupNamespace, vmRestore.Spec.VirtualMachineBackupName, err)
+		return werror.NewInvalidError(err.Error(), fieldVirtualMachineBackupName)
 	}
 
-	endpoint := vmBackup.Annotations[backup.BackupTargetAnnotation]
-	bucketName := vmBackup.Annotations[backup.BackupBucketNameAnnotation]
-	bucketRegion := vmBackup.Annotations[backup.BackupBucketRegionAnnotation]
-	if backupTarget.Endpoint != endpoint || backupTarget.BucketName != bucketName || backupTarget.BucketRegion != bucketRegion {
-		return errors.New("VM Backup is not matched with Backup Target")
+	volumeBackups := map[string]bool{}
+	for _, vb := range vmBackup.Status.VolumeBackups {
+		if vb.Name != nil {
+			volumeBackups[*vb.Name] = true
+		}
+	}
+
+	targetNamespace := backup.GetRestoreTargetNamespace(vmRestore)
+	selected := map[string]bool{}
+	pvcNames := map[string]bool{}
+	for _, volume := range vmRestore.Spec.Volumes {
+		if !volumeBackups[volume.VolumeBackupName] {
+			return werror.NewInvalidError(fmt.Sprintf("volume backup %q is not found in VM backup %s", volume.VolumeBackupName, vmBackup.Name), fieldVolumes)
+		}
+		if selected[volume.VolumeBackupName] {
+			return werror.NewInvalidError(fmt.Sprintf("volume backup %q is selected more than once", volume.VolumeBackupName), fieldVolumes)
+		}
+		selected[volume.VolumeBackupName] = true
+
+		if volume.PersistentVolumeClaimName == "" {
+			continue
+		}
+		if errs := validation.IsDNS1123Subdomain(volume.PersistentVolumeClaimName); len(errs) > 0 {
+			return werror.NewInvalidError(fmt.Sprintf("invalid PVC name %q: %s", volume.PersistentVolumeClaimName, strings.Join(errs, ", ")), fieldVolumes)
+		}
+		if pvcNames[volume.PersistentVolumeClaimName] {
+			return werror.NewInvalidError(fmt.Sprintf("PVC name %q is used more than once", volume.PersistentVolumeClaimName), fieldVolumes)
+		}
+		pvcNames[volume.PersistentVolumeClaimName] = true
+
+		// the restore must not take over an existing PVC
+		if _, err := v.pvcs.Get(targetNamespace, volume.PersistentVolumeClaimName); err == nil {
+			return werror.NewInvalidError(fmt.Sprintf("PVC %s/%s already exists", targetNamespace, volume.PersistentVolumeClaimName), fieldVolumes)
+		} else if !apierrors.IsNotFound(err) {
+			return err
+		}
 	}
 
 	return nil
@@ -0,0 +1,83 @@
+package setting
+
+import (
+	"encoding/json"
+	"fmt"
+
+	admissionregv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	imagecontroller "github.com/harvester/harvester/pkg/controller/master/image"
+	"github.com/harvester/harvester/pkg/settings"
+	"github.com/harvester/harvester/pkg/util"
+	werror "github.com/harvester/harvester/pkg/webhook/error"
+	"github.com/harvester/harvester/pkg/webhook/types"
+)
+
+const (
+	fieldValue = "value"
+)
+
+// validateSettingFuncs validates the values of the settings by name, the settings without a function accept any value
+var validateSettingFuncs = map[string]func(value string) error{
+	settings.HTTPProxy.Name:                   validateHTTPProxy,
+	settings.ImageStorageClassParameters.Name: validateImageStorageClassParameters,
+}
+
+func NewValidator() types.Validator {
+	return &settingValidator{}
+}
+
+type settingValidator struct {
+	types.DefaultValidator
+}
+
+func (v *settingValidator) Resource() types.Resource {
+	return types.Resource{
+		Name:       v1beta1.SettingResourceName,
+		Scope:      admissionregv1.ClusterScope,
+		APIGroup:   v1beta1.SchemeGroupVersion.Group,
+		APIVersion: v1beta1.SchemeGroupVersion.Version,
+		ObjectType: &v1beta1.Setting{},
+		OperationTypes: []admissionregv1.OperationType{
+			admissionregv1.Create,
+			admissionregv1.Update,
+		},
+	}
+}
+
+func (v *settingValidator) Create(request *types.Request, newObj runtime.Object) error {
+	return validateSetting(newObj.(*v1beta1.Setting))
+}
+
+func (v *settingValidator) Update(request *types.Request, oldObj runtime.Object, newObj runtime.Object) error {
+	return validateSetting(newObj.(*v1beta1.Setting))
+}
+
+func validateSetting(setting *v1beta1.Setting) error {
+	validate, ok := validateSettingFuncs[setting.Name]
+	if !ok {
+		return nil
+	}
+	if err := validate(setting.Value); err != nil {
+		return werror.NewInvalidError(err.Error(), fieldValue)
+	}
+	return nil
+}
+
+func validateHTTPProxy(value string) error {
+	_, err := util.ParseHTTPProxyConfig(value)
+	return err
+}
+
+func validateImageStorageClassParameters(value string) error {
+	if value == "" {
+		return nil
+	}
+	parameters := map[string]string{}
+	if err := json.Unmarshal([]byte(value), &parameters); err != nil {
+		return fmt.Errorf("failed to parse the storageclass parameters: %w", err)
+	}
+	return imagecontroller.ValidateStorageClassParameters(parameters)
+}
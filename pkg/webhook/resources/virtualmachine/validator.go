@@ -12,6 +12,8 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	kubevirtv1 "kubevirt.io/client-go/api/v1"
 
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/controller/master/backup"
 	"github.com/harvester/harvester/pkg/ref"
 	"github.com/harvester/harvester/pkg/util"
 	werror "github.com/harvester/harvester/pkg/webhook/error"
@@ -58,6 +60,26 @@ func (v *vmValidator) Update(request *types.Request, oldObj runtime.Object, newO
 	if err := v.checkVMSpec(vm); err != nil {
 		return err
 	}
+	if err := v.checkRevertingVM(vm); err != nil {
+		return err
+	}
+	return nil
+}
+
+// checkRevertingVM keeps the VM stopped until its volumes are reverted to the snapshot
+func (v *vmValidator) checkRevertingVM(vm *kubevirtv1.VirtualMachine) error {
+	snapshotName, ok := vm.Annotations[util.AnnotationRevertToSnapshot]
+	if !ok {
+		return nil
+	}
+	runStrategy, err := vm.RunStrategy()
+	if err != nil {
+		return werror.NewInvalidError(err.Error(), "spec.runStrategy")
+	}
+	if runStrategy != kubevirtv1.RunStrategyHalted {
+		message := fmt.Sprintf("VM %s is being reverted to snapshot %s, it can't be started until the revert is done", vm.Name, snapshotName)
+		return werror.NewConflict(message)
+	}
 	return nil
 }
 
@@ -66,12 +88,31 @@ func (v *vmValidator) checkVMSpec(vm *kubevirtv1.VirtualMachine) error {
 		message := fmt.Sprintf("the volumeClaimTemplates annotaion is invalid: %v", err)
 		return werror.NewInvalidError(message, "metadata.annotations")
 	}
+	if err := v.checkBackupRetentionAnnotation(vm); err != nil {
+		message := fmt.Sprintf("the backup retention annotation is invalid: %v", err)
+		return werror.NewInvalidError(message, "metadata.annotations")
+	}
 	if err := v.checkOccupiedPVCs(vm); err != nil {
 		return err
 	}
 	return nil
 }
 
+func (v *vmValidator) checkBackupRetentionAnnotation(vm *kubevirtv1.VirtualMachine) error {
+	if vm == nil {
+		return nil
+	}
+	retention, ok := vm.Annotations[backup.BackupRetentionAnnotation]
+	if !ok || retention == "" {
+		return nil
+	}
+	policy := &harvesterv1.BackupRetentionPolicy{}
+	if err := json.Unmarshal([]byte(retention), policy); err != nil {
+		return err
+	}
+	return backup.ValidateRetentionPolicy(policy)
+}
+
 func (v *vmValidator) checkVolumeClaimTemplatesAnnotation(vm *kubevirtv1.VirtualMachine) error {
 	if vm == nil {
 		return nil
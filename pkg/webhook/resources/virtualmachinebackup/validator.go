@@ -0,0 +1,63 @@
+package virtualmachinebackup
+
+import (
+	"fmt"
+
+	admissionregv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/controller/master/backup"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	werror "github.com/harvester/harvester/pkg/webhook/error"
+	"github.com/harvester/harvester/pkg/webhook/types"
+)
+
+const (
+	fieldBackupTarget = "spec.backupTarget"
+)
+
+func NewValidator(backupTargets ctlharvesterv1.BackupTargetCache) types.Validator {
+	return &vmBackupValidator{
+		backupTargets: backupTargets,
+	}
+}
+
+type vmBackupValidator struct {
+	types.DefaultValidator
+	backupTargets ctlharvesterv1.BackupTargetCache
+}
+
+func (v *vmBackupValidator) Resource() types.Resource {
+	return types.Resource{
+		Name:       v1beta1.VirtualMachineBackupResourceName,
+		Scope:      admissionregv1.NamespacedScope,
+		APIGroup:   v1beta1.SchemeGroupVersion.Group,
+		APIVersion: v1beta1.SchemeGroupVersion.Version,
+		ObjectType: &v1beta1.VirtualMachineBackup{},
+		OperationTypes: []admissionregv1.OperationType{
+			admissionregv1.Create,
+		},
+	}
+}
+
+// Create rejects the backups to a backup target which doesn't store the volume data in the default backup target
+func (v *vmBackupValidator) Create(request *types.Request, newObj runtime.Object) error {
+	vmBackup := newObj.(*v1beta1.VirtualMachineBackup)
+	name := backup.GetBackupTargetName(vmBackup)
+	if name == v1beta1.DefaultBackupTargetName {
+		return nil
+	}
+
+	backupTarget, err := v.backupTargets.Get(name)
+	if err != nil {
+		return werror.NewInvalidError(fmt.Sprintf("can't get backup target %s, err: %s", name, err.Error()), fieldBackupTarget)
+	}
+	if !v1beta1.BackupTargetValidated.IsTrue(backupTarget) {
+		return werror.NewInvalidError(fmt.Sprintf("backup target %s is not validated", name), fieldBackupTarget)
+	}
+	if err := backup.CheckBackupTargetLocation(v.backupTargets, backupTarget); err != nil {
+		return werror.NewInvalidError(err.Error(), fieldBackupTarget)
+	}
+	return nil
+}
@@ -13,20 +13,27 @@ import (
 	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
 
 	"github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	imagecontroller "github.com/harvester/harvester/pkg/controller/master/image"
 	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/indexeres"
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/util"
 	werror "github.com/harvester/harvester/pkg/webhook/error"
 	"github.com/harvester/harvester/pkg/webhook/types"
 )
 
 const (
 	fieldDisplayName = "spec.displayName"
+	fieldOSInfo      = "spec.osInfo"
 )
 
-func NewValidator(vmimages ctlharvesterv1.VirtualMachineImageCache, pvcCache ctlcorev1.PersistentVolumeClaimCache, ssar authorizationv1client.SelfSubjectAccessReviewInterface) types.Validator {
+func NewValidator(vmimages ctlharvesterv1.VirtualMachineImageCache, pvcCache ctlcorev1.PersistentVolumeClaimCache, ssar authorizationv1client.SelfSubjectAccessReviewInterface, sar authorizationv1client.SubjectAccessReviewInterface) types.Validator {
+	pvcCache.AddIndexer(indexeres.PVCByImageIndex, indexeres.PVCByImage)
 	return &virtualMachineImageValidator{
 		vmimages: vmimages,
 		pvcCache: pvcCache,
 		ssar:     ssar,
+		sar:      sar,
 	}
 }
 
@@ -36,6 +43,7 @@ type virtualMachineImageValidator struct {
 	vmimages ctlharvesterv1.VirtualMachineImageCache
 	pvcCache ctlcorev1.PersistentVolumeClaimCache
 	ssar     authorizationv1client.SelfSubjectAccessReviewInterface
+	sar      authorizationv1client.SubjectAccessReviewInterface
 }
 
 func (v *virtualMachineImageValidator) Resource() types.Resource {
@@ -56,6 +64,13 @@ func (v *virtualMachineImageValidator) Resource() types.Resource {
 func (v *virtualMachineImageValidator) Create(request *types.Request, newObj runtime.Object) error {
 	newImage := newObj.(*v1beta1.VirtualMachineImage)
 
+	if err := v.validate(request, newImage); err != nil {
+		return err
+	}
+	return v.checkSourceSecretAccess(request, newImage)
+}
+
+func (v *virtualMachineImageValidator) validate(request *types.Request, newImage *v1beta1.VirtualMachineImage) error {
 	if newImage.Spec.DisplayName == "" {
 		return werror.NewInvalidError("displayName is required", fieldDisplayName)
 	}
@@ -73,6 +88,24 @@ func (v *virtualMachineImageValidator) Create(request *types.Request, newObj run
 		}
 	}
 
+	if newImage.Spec.Checksum != "" {
+		if _, err := util.NewChecksumHash(newImage.Spec.Checksum); err != nil {
+			return werror.NewInvalidError(err.Error(), "spec.checksum")
+		}
+	}
+
+	if err := imagecontroller.ValidateStorageClassParameters(newImage.Spec.StorageClassParameters); err != nil {
+		return werror.NewInvalidError(err.Error(), "spec.storageClassParameters")
+	}
+
+	if err := validateOSInfo(newImage.Spec.OSInfo); err != nil {
+		return err
+	}
+
+	if newImage.Spec.SourceType != v1beta1.VirtualMachineImageSourceTypeDownload && newImage.Spec.SourceSecretName != "" {
+		return werror.NewInvalidError(`sourceSecretName should be empty when image source type is not "download"`, "spec.sourceSecretName")
+	}
+
 	if newImage.Spec.SourceType == v1beta1.VirtualMachineImageSourceTypeDownload && newImage.Spec.URL == "" {
 		return werror.NewInvalidError(`url is required when image source type is "download"`, "spec.url")
 	} else if newImage.Spec.SourceType != v1beta1.VirtualMachineImageSourceTypeDownload && newImage.Spec.URL != "" {
@@ -117,21 +150,104 @@ func (v *virtualMachineImageValidator) Create(request *types.Request, newObj run
 	return nil
 }
 
+// checkSourceSecretAccess denies the image if the user of the request can't get its source secret, the controller
+// reads the secret with its own permissions to download the image.
+func (v *virtualMachineImageValidator) checkSourceSecretAccess(request *types.Request, image *v1beta1.VirtualMachineImage) error {
+	if image.Spec.SourceSecretName == "" {
+		return nil
+	}
+
+	extra := make(map[string]authorizationv1.ExtraValue, len(request.UserInfo.Extra))
+	for key, value := range request.UserInfo.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	sar, err := v.sar.Create(request.Context, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: image.Namespace,
+				Verb:      "get",
+				Group:     "",
+				Version:   "*",
+				Resource:  "secrets",
+				Name:      image.Spec.SourceSecretName,
+			},
+			User:   request.UserInfo.Username,
+			Groups: request.UserInfo.Groups,
+			Extra:  extra,
+			UID:    request.UserInfo.UID,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return werror.NewInternalError(fmt.Sprintf("failed to check user permission, error: %s", err.Error()))
+	}
+
+	if !sar.Status.Allowed || sar.Status.Denied {
+		return werror.NewForbidden(fmt.Sprintf("user has no permission to get the secret %s/%s", image.Namespace, image.Spec.SourceSecretName))
+	}
+	return nil
+}
+
+func validateOSInfo(osInfo *v1beta1.VirtualMachineImageOSInfo) error {
+	if osInfo == nil {
+		return nil
+	}
+	switch osInfo.Family {
+	case "", v1beta1.VirtualMachineImageOSFamilyLinux, v1beta1.VirtualMachineImageOSFamilyWindows:
+	default:
+		return werror.NewInvalidError(fmt.Sprintf("unsupported OS family %q", osInfo.Family), fieldOSInfo+".family")
+	}
+	switch osInfo.Architecture {
+	case "", v1beta1.VirtualMachineImageArchitectureAMD64, v1beta1.VirtualMachineImageArchitectureARM64:
+	default:
+		return werror.NewInvalidError(fmt.Sprintf("unsupported architecture %q", osInfo.Architecture), fieldOSInfo+".architecture")
+	}
+	switch osInfo.BootMode {
+	case "", v1beta1.VirtualMachineImageBootModeBIOS, v1beta1.VirtualMachineImageBootModeUEFI:
+	default:
+		return werror.NewInvalidError(fmt.Sprintf("unsupported boot mode %q", osInfo.BootMode), fieldOSInfo+".bootMode")
+	}
+	if osInfo.Architecture == v1beta1.VirtualMachineImageArchitectureARM64 && osInfo.BootMode == v1beta1.VirtualMachineImageBootModeBIOS {
+		return werror.NewInvalidError("arm64 images can only boot in uefi mode", fieldOSInfo+".bootMode")
+	}
+	if osInfo.VirtioDriversRequired && osInfo.Family != v1beta1.VirtualMachineImageOSFamilyWindows {
+		return werror.NewInvalidError("virtio drivers are only required by windows images", fieldOSInfo+".virtioDriversRequired")
+	}
+	return nil
+}
+
 func (v *virtualMachineImageValidator) Update(request *types.Request, oldObj runtime.Object, newObj runtime.Object) error {
-	return v.Create(request, newObj)
+	oldImage := oldObj.(*v1beta1.VirtualMachineImage)
+	newImage := newObj.(*v1beta1.VirtualMachineImage)
+
+	if err := v.validate(request, newImage); err != nil {
+		return err
+	}
+	if oldImage.Spec.SourceSecretName == newImage.Spec.SourceSecretName {
+		return nil
+	}
+	return v.checkSourceSecretAccess(request, newImage)
 }
 
 func (v *virtualMachineImageValidator) Delete(request *types.Request, oldObj runtime.Object) error {
 	image := oldObj.(*v1beta1.VirtualMachineImage)
 
+	pvcs, err := v.pvcCache.GetByIndex(indexeres.PVCByImageIndex, ref.Construct(image.Namespace, image.Name))
+	if err != nil {
+		return err
+	}
+	if len(pvcs) > 0 {
+		message := fmt.Sprintf("Cannot delete image %s/%s: being used by volume %s/%s", image.Namespace, image.Spec.DisplayName, pvcs[0].Namespace, pvcs[0].Name)
+		return werror.NewInvalidError(message, "")
+	}
+
+	// the PVCs created without the image annotation still use the image through its storage class
 	if image.Status.StorageClassName == "" {
 		return nil
 	}
-	pvcs, err := v.pvcCache.List(corev1.NamespaceAll, labels.Everything())
+	pvcs, err = v.pvcCache.List(corev1.NamespaceAll, labels.Everything())
 	if err != nil {
 		return err
 	}
-
 	for _, pvc := range pvcs {
 		if pvc.Spec.StorageClassName != nil && *pvc.Spec.StorageClassName == image.Status.StorageClassName {
 			message := fmt.Sprintf("Cannot delete image %s/%s: being used by volume %s/%s", image.Namespace, image.Spec.DisplayName, pvc.Namespace, pvc.Name)